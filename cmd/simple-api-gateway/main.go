@@ -6,7 +6,10 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/aclgo/simple-api-gateway/config"
@@ -17,13 +20,32 @@ import (
 	"github.com/aclgo/simple-api-gateway/internal/delivery/http/service"
 	svcAdmin "github.com/aclgo/simple-api-gateway/internal/delivery/http/service/admin"
 	svcCaptcha "github.com/aclgo/simple-api-gateway/internal/delivery/http/service/captcha"
+	svcCart "github.com/aclgo/simple-api-gateway/internal/delivery/http/service/cart"
+	svcCatalog "github.com/aclgo/simple-api-gateway/internal/delivery/http/service/catalog"
+	svcInvoice "github.com/aclgo/simple-api-gateway/internal/delivery/http/service/invoice"
 	svcOrders "github.com/aclgo/simple-api-gateway/internal/delivery/http/service/orders"
+	svcPayment "github.com/aclgo/simple-api-gateway/internal/delivery/http/service/payment"
+	svcBoleto "github.com/aclgo/simple-api-gateway/internal/delivery/http/service/payment/boleto"
 	svcPix "github.com/aclgo/simple-api-gateway/internal/delivery/http/service/payment/pix"
+	svcWallet "github.com/aclgo/simple-api-gateway/internal/delivery/http/service/payment/wallet"
 	svcProduct "github.com/aclgo/simple-api-gateway/internal/delivery/http/service/product"
+	svcPromotion "github.com/aclgo/simple-api-gateway/internal/delivery/http/service/promotion"
+	svcSaga "github.com/aclgo/simple-api-gateway/internal/delivery/http/service/saga"
 	svcUser "github.com/aclgo/simple-api-gateway/internal/delivery/http/service/user"
+	svcWithdrawal "github.com/aclgo/simple-api-gateway/internal/delivery/http/service/withdrawal"
 	"github.com/aclgo/simple-api-gateway/internal/domain/models"
+	"github.com/aclgo/simple-api-gateway/internal/invoice"
+	"github.com/aclgo/simple-api-gateway/internal/payment"
+	"github.com/aclgo/simple-api-gateway/internal/payment/boleto"
+	"github.com/aclgo/simple-api-gateway/internal/payment/card"
+	"github.com/aclgo/simple-api-gateway/internal/payment/pix"
+	paymentRepo "github.com/aclgo/simple-api-gateway/internal/payment/repository"
 	paymentUC "github.com/aclgo/simple-api-gateway/internal/payment/usecase"
+	"github.com/aclgo/simple-api-gateway/internal/transfer"
 	"github.com/aclgo/simple-api-gateway/internal/user"
+	"github.com/aclgo/simple-api-gateway/internal/webhook"
+	"github.com/aclgo/simple-api-gateway/internal/withdrawal"
+	"github.com/aclgo/simple-api-gateway/internal/withdrawal/payout"
 	_ "github.com/lib/pq"
 	"github.com/rs/cors"
 	"google.golang.org/grpc"
@@ -31,16 +53,37 @@ import (
 
 	adminUC "github.com/aclgo/simple-api-gateway/internal/admin/usecase"
 	authUC "github.com/aclgo/simple-api-gateway/internal/auth/usecase"
+	cartRepo "github.com/aclgo/simple-api-gateway/internal/cart/repository"
+	cartUC "github.com/aclgo/simple-api-gateway/internal/cart/usecase"
+	catalogRepo "github.com/aclgo/simple-api-gateway/internal/catalog/repository"
+	catalogUC "github.com/aclgo/simple-api-gateway/internal/catalog/usecase"
+	invoiceRepo "github.com/aclgo/simple-api-gateway/internal/invoice/repository"
+	invoiceUC "github.com/aclgo/simple-api-gateway/internal/invoice/usecase"
+	ordersRepo "github.com/aclgo/simple-api-gateway/internal/orders/repository"
 	ordersUC "github.com/aclgo/simple-api-gateway/internal/orders/usecase"
+	boletoRepo "github.com/aclgo/simple-api-gateway/internal/payment/boleto/repository"
+	boletoUC "github.com/aclgo/simple-api-gateway/internal/payment/boleto/usecase"
 	cardUC "github.com/aclgo/simple-api-gateway/internal/payment/card/usecase"
 	pixRepo "github.com/aclgo/simple-api-gateway/internal/payment/pix/repository"
 	pixUC "github.com/aclgo/simple-api-gateway/internal/payment/pix/usecase"
+	settlementRepo "github.com/aclgo/simple-api-gateway/internal/payment/settlement/repository"
+	settlementUC "github.com/aclgo/simple-api-gateway/internal/payment/settlement/usecase"
 	walletUC "github.com/aclgo/simple-api-gateway/internal/payment/wallet/usecase"
 	productUC "github.com/aclgo/simple-api-gateway/internal/product/usecase"
+	promotionRepo "github.com/aclgo/simple-api-gateway/internal/promotion/repository"
+	promotionUC "github.com/aclgo/simple-api-gateway/internal/promotion/usecase"
 	subUC "github.com/aclgo/simple-api-gateway/internal/subscription/usecase"
+	transferRepo "github.com/aclgo/simple-api-gateway/internal/transfer/repository"
+	transferUC "github.com/aclgo/simple-api-gateway/internal/transfer/usecase"
 	userUC "github.com/aclgo/simple-api-gateway/internal/user/usecase"
+	webhookRepo "github.com/aclgo/simple-api-gateway/internal/webhook/repository"
+	webhookUC "github.com/aclgo/simple-api-gateway/internal/webhook/usecase"
+	withdrawalRepo "github.com/aclgo/simple-api-gateway/internal/withdrawal/repository"
+	withdrawalUC "github.com/aclgo/simple-api-gateway/internal/withdrawal/usecase"
 
+	migration "github.com/aclgo/simple-api-gateway/migrations"
 	grpcauth "github.com/aclgo/simple-api-gateway/pkg/grpc-auth"
+	"github.com/aclgo/simple-api-gateway/pkg/postgres"
 	redis "github.com/aclgo/simple-api-gateway/pkg/rredis"
 
 	"github.com/aclgo/simple-api-gateway/pkg/logger"
@@ -90,6 +133,9 @@ func main() {
 
 	ctx := context.Background()
 
+	sigCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	cfg := config.Load(".")
 
 	logger, err := logger.NewapiLogger(cfg)
@@ -148,9 +194,17 @@ func main() {
 
 	redisClient := redis.NewRedisClient(cfg)
 
+	db := postgres.NewPostgresClient(cfg)
+
+	migration.NewMigration(db, nil)
+	if err := migration.Run(); err != nil {
+		log.Fatalf("migration.Run: %v", err)
+	}
+
 	mu := sync.Mutex{}
 	user.SetConfigUserPackage(cfg.BaseApiUrl, cfg.DefaultEmailSendEmail, cfg.DefaultTimeSendEmail, cfg.DefaultServiceNameSendEmail)
 	admin.SetConfigUserPackage(cfg.BaseApiUrl, cfg.DefaultEmailSendEmail, cfg.DefaultTimeSendEmail, cfg.DefaultServiceNameSendEmail)
+	invoice.SetConfigInvoicePackage(cfg.DefaultEmailSendEmail, cfg.DefaultServiceNameSendEmail, cfg.InvoiceIssuerName, cfg.InvoiceIssuerDocument)
 
 	////////////////////////////////
 
@@ -166,35 +220,220 @@ func main() {
 	cptUC := captchaUC.NewCaptchaUC(cptRepo)
 	cptSvc := svcCaptcha.NewCaptchaService(cptUC)
 
-	pixRepository := pixRepo.NewPixRepository(time.Duration(10)*time.Minute, redisClient)
+	pixRepository := pixRepo.NewPixRepository(cfg.PixRateLimitWindow, redisClient)
+
+	attemptRepository := paymentRepo.NewAttemptRepository(db)
+	gateways := paymentUC.NewPaymentUC(balanceUserService, attemptRepository, payment.Config{
+		BreakerFailures:    cfg.PaymentBreakerFailures,
+		BreakerOpenTimeout: cfg.PaymentBreakerOpenTimeout,
+	}, logger)
+
+	paymentRoutes, err := payment.ParseRoutes(cfg.PaymentRoutes)
+	if err != nil {
+		log.Fatalf("payment.ParseRoutes: %v", err)
+	}
+
+	// fallback providers are only tried after the primary ones unless a
+	// route says otherwise
+	registerProvider := func(method string, name string, processor models.PaymentProcessor, fallback bool) {
+		route, ok := paymentRoutes[name]
+		if !ok && fallback {
+			route.Priority = 1
+		}
 
-	gateways := paymentUC.NewPaymentUC(balanceUserService, logger)
+		gateways.RegisterProvider(method, &payment.Provider{Name: name, Processor: processor, Route: route})
+	}
 
-	pixProcessor := pixUC.NewpaymentProcessorPix(cfg.PixAuthorization, pixRepository, ordersUserService, balanceUserService, clientSubscriptionService)
-	cardProcessor := cardUC.NewpaymentProcessorCard()
+	invoiceRepository := invoiceRepo.NewInvoiceRepository(db)
+	invoices := invoiceUC.NewInvoiceUC(invoiceRepository, ordersUserService, productUserService, clientUserService, mailUserService, logger)
+
+	statusHistoryRepository := ordersRepo.NewStatusHistoryRepository(db)
+	indexRepository := ordersRepo.NewIndexRepository(db)
+	statusMachine := ordersUC.NewStatusMachine(ordersUserService, statusHistoryRepository, indexRepository, logger)
+	latePaymentRepository := settlementRepo.NewLatePaymentRepository(db)
+	settler := settlementUC.NewSettlementUC(ordersUserService, balanceUserService, clientSubscriptionService, statusMachine, invoices, latePaymentRepository, logger)
+	pixProcessor := pixUC.NewpaymentProcessorPix(pix.ProviderConfig{
+		BaseURL:       cfg.PixPSPURL,
+		Authorization: cfg.PixAuthorization,
+		Key:           cfg.PixKey,
+		MerchantName:  cfg.PixMerchantName,
+		MerchantCity:  cfg.PixMerchantCity,
+		Expiration:    cfg.PixExpiration,
+		Timeout:       cfg.PixPSPTimeout,
+	}, pixRepository, ordersUserService, settler)
+	cardProcessor := cardUC.NewpaymentProcessorCard(card.ProviderConfig{
+		BaseURL: cfg.CardProviderURL,
+		APIKey:  cfg.CardProviderAPIKey,
+		Timeout: cfg.CardProviderTimeout,
+	})
+	boletoProcessor := boletoUC.NewpaymentProcessorBoleto(boleto.Config{
+		BankCode:         cfg.BoletoBankCode,
+		Agreement:        cfg.BoletoAgreement,
+		Wallet:           cfg.BoletoWallet,
+		DueDays:          cfg.BoletoDueDays,
+		SettlementWindow: cfg.BoletoSettlementWindow,
+	}, boletoRepo.NewBoletoRepository(db), ordersUserService, settler)
 	walletProcessor := walletUC.NewPaymentProcessorWallet(balanceUserService)
 
-	gateways.RegisterProvider(models.PaymentMethodPix, pixProcessor)
-	gateways.RegisterProvider(models.PaymentMethodCard, cardProcessor)
-	gateways.RegisterProvider(models.PaymentMethodInternalBalance, walletProcessor)
+	registerProvider(models.PaymentMethodPix, "pix", pixProcessor, false)
+	registerProvider(models.PaymentMethodCard, "card", cardProcessor, false)
+	registerProvider(models.PaymentMethodBoleto, "boleto", boletoProcessor, false)
+	registerProvider(models.PaymentMethodInternalBalance, "internal-balance", walletProcessor, false)
+
+	if cfg.PixFallbackPSPURL != "" {
+		pixFallbackKey := cfg.PixFallbackKey
+		if pixFallbackKey == "" {
+			pixFallbackKey = cfg.PixKey
+		}
+
+		pixFallbackProcessor := pixUC.NewpaymentProcessorPix(pix.ProviderConfig{
+			BaseURL:       cfg.PixFallbackPSPURL,
+			Authorization: cfg.PixFallbackAuthorization,
+			Key:           pixFallbackKey,
+			MerchantName:  cfg.PixMerchantName,
+			MerchantCity:  cfg.PixMerchantCity,
+			Expiration:    cfg.PixExpiration,
+			Timeout:       cfg.PixPSPTimeout,
+		}, pixRepository, ordersUserService, settler)
+
+		registerProvider(models.PaymentMethodPix, "pix-fallback", pixFallbackProcessor, true)
+	}
 
-	sagaWorkerCompensate := ordersUC.NewSagaWorker(3, time.Minute)
-	sagaWorkerCompensate.Start(ctx)
+	if cfg.CardFallbackProviderURL != "" {
+		cardFallbackProcessor := cardUC.NewpaymentProcessorCard(card.ProviderConfig{
+			BaseURL: cfg.CardFallbackProviderURL,
+			APIKey:  cfg.CardFallbackProviderAPIKey,
+			Timeout: cfg.CardProviderTimeout,
+		})
+
+		registerProvider(models.PaymentMethodCard, "card-fallback", cardFallbackProcessor, true)
+	}
+
+	sagaRepository := ordersRepo.NewSagaRepository(db)
+	deadLetterRepository := ordersRepo.NewDeadLetterRepository(db)
+	sagaWorkerCompensate := ordersUC.NewSagaWorker(sagaRepository, deadLetterRepository, ordersUC.SagaWorkerConfig{
+		Workers:     cfg.SagaWorkers,
+		MaxAttempts: cfg.SagaMaxAttempts,
+		BaseDelay:   cfg.SagaBaseDelay,
+		MaxDelay:    cfg.SagaMaxDelay,
+		MaxAge:      cfg.SagaMaxAge,
+	})
+	sagaAdmin := ordersUC.NewSagaAdminUC(deadLetterRepository, sagaWorkerCompensate)
 
 	sub := subUC.NewSubscriprionUseCase(clientSubscriptionService)
 	user := userUC.NewuserUC(clientUserService, clientSubscriptionService, mailUserService, balanceUserService, cptRepo, redisClient, logger)
 	admin := adminUC.NewadminUC(clientUserService, mailUserService, balanceUserService, cptRepo, redisClient, logger)
 	product := productUC.NewProductUC(logger, productUserService)
-	orders, err := ordersUC.NeworderUC(ordersUserService, productUserService, balanceUserService, &mu, logger, sagaWorkerCompensate, gateways, sub)
+	stockRepository := ordersRepo.NewStockRepository(cfg.StockHoldTTL, redisClient)
+	promotionRepository := promotionRepo.NewPromotionRepository(db)
+	promotion := promotionUC.NewPromotionUC(promotionRepository, logger)
+	catalogRepository := catalogRepo.NewCatalogRepository(db)
+	refundRepository := ordersRepo.NewRefundRepository(db)
+	expirationRepository := ordersRepo.NewExpirationRepository(db)
+	catalog := catalogUC.NewCatalogUC(catalogRepository, cfg.CatalogCacheTTL, logger)
+	orders, err := ordersUC.NeworderUC(ordersUserService, productUserService, balanceUserService, &mu, logger, sagaWorkerCompensate, gateways, sub, stockRepository, promotion, catalog, refundRepository, statusMachine, expirationRepository, indexRepository, invoices)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	cartRepository := cartRepo.NewCartRepository(cfg.CartTTL, redisClient)
+	cart := cartUC.NewCartUC(cartRepository, productUserService, orders, logger)
+
+	if err := sagaWorkerCompensate.Start(ctx); err != nil {
+		log.Fatalf("sagaWorkerCompensate.Start: %v", err)
+	}
+
+	expirySweeper := ordersUC.NewExpirySweeper(orders, logger, ordersUC.ExpirySweeperConfig{
+		Interval:  cfg.OrderExpirySweepInterval,
+		BatchSize: cfg.OrderExpiryBatchSize,
+	})
+	expirySweeper.Start(ctx)
+
+	indexReconciler := ordersUC.NewIndexReconciler(orders, logger, ordersUC.IndexReconcilerConfig{
+		Interval:  cfg.OrderReindexInterval,
+		BatchSize: cfg.OrderReindexBatchSize,
+	})
+	indexReconciler.Start(ctx)
+
 	userHandler := svcUser.NewuserService(user, sub, logger, cfg.BaseApiUrl)
 	adminHandler := svcAdmin.NewadminService(admin, logger)
 	productHandler := svcProduct.NewProductService(product, logger)
-	ordersHandler := svcOrders.NewOrdersService(orders, logger)
+	idempotencyRepository := ordersRepo.NewIdempotencyRepository(cfg.IdempotencyTTL, cfg.IdempotencyLeaseTTL, redisClient)
+	ordersHandler := svcOrders.NewOrdersService(orders, idempotencyRepository, logger)
+	sagaHandler := svcSaga.NewSagaService(sagaAdmin, logger)
+	cartHandler := svcCart.NewCartService(cart, logger)
+	promotionHandler := svcPromotion.NewPromotionService(promotion, logger)
+	catalogHandler := svcCatalog.NewCatalogService(catalog, logger)
+	invoiceHandler := svcInvoice.NewInvoiceService(invoices, logger)
 	paymentPixHandler := svcPix.NewpaymentServicePix(pixProcessor)
+	paymentBoletoHandler := svcBoleto.NewpaymentServiceBoleto(boletoProcessor)
+	paymentHandler := svcPayment.NewPaymentService(gateways)
+	transfers := transferUC.NewTransferUC(transfer.Config{
+		DailyLimit:              cfg.TransferDailyLimit,
+		DailyCount:              cfg.TransferDailyCount,
+		ConfirmationMinAmount:   cfg.TransferConfirmationMinAmount,
+		ConfirmationTTL:         cfg.TransferConfirmationTTL,
+		ConfirmationMaxAttempts: cfg.TransferConfirmationMaxAttempts,
+		FromSendMail:            cfg.DefaultEmailSendEmail,
+		ServiceNameSendMail:     cfg.DefaultServiceNameSendEmail,
+	}, transferRepo.NewTransferRepository(db), balanceUserService, ordersUserService, clientUserService, mailUserService,
+		sagaWorkerCompensate, indexRepository, logger)
+	walletHandler := svcWallet.NewWalletService(walletUC.NewStatementUC(balanceUserService, ordersUserService, attemptRepository, refundRepository, logger), transfers)
+
+	var payoutProvider withdrawal.PayoutProvider
+
+	switch cfg.PayoutProvider {
+	case "psp":
+		if cfg.PayoutPSPURL == "" {
+			log.Fatal("PAYOUT_PSP_URL is required with PAYOUT_PROVIDER=psp")
+		}
+
+		payoutProvider = payout.NewPSPProvider(withdrawal.PayoutConfig{
+			BaseURL:       cfg.PayoutPSPURL,
+			Authorization: cfg.PayoutPSPAuthorization,
+			Timeout:       cfg.PayoutPSPTimeout,
+		})
+	case "fake":
+		// the fake provider pays without moving money, never outside dev
+		if cfg.Server.Mode != "dev" {
+			log.Fatal("PAYOUT_PROVIDER=fake is only allowed with SERVER_MODE=dev")
+		}
+
+		payoutProvider = payout.NewFakeProvider(logger)
+	default:
+		log.Fatalf("PAYOUT_PROVIDER %q unknown, use psp or fake", cfg.PayoutProvider)
+	}
+
+	withdrawals := withdrawalUC.NewWithdrawalUC(withdrawal.Config{
+		MinAmount: cfg.WithdrawalMinAmount,
+		MaxAmount: cfg.WithdrawalMaxAmount,
+	}, withdrawalRepo.NewWithdrawalRepository(db), payoutProvider, balanceUserService, ordersUserService, statusMachine,
+		sagaWorkerCompensate, indexRepository, logger)
+	withdrawalHandler := svcWithdrawal.NewWithdrawalService(withdrawals, logger)
+
+	pixAllowedIPs, err := webhook.ParseAllowedIPs(cfg.WebhookPixAllowedIPs)
+	if err != nil {
+		log.Fatalf("webhook.ParseAllowedIPs: pix: %v", err)
+	}
+
+	boletoAllowedIPs, err := webhook.ParseAllowedIPs(cfg.WebhookBoletoAllowedIPs)
+	if err != nil {
+		log.Fatalf("webhook.ParseAllowedIPs: boleto: %v", err)
+	}
+
+	webhookRepository := webhookRepo.NewWebhookRepository(redisClient)
+	pixWebhook := webhookUC.NewWebhookUC(webhook.Config{
+		Provider:   models.PaymentMethodPix,
+		Secret:     cfg.WebhookPixSecret,
+		Tolerance:  cfg.WebhookTolerance,
+		AllowedIPs: pixAllowedIPs,
+	}, webhookRepository, logger)
+	boletoWebhook := webhookUC.NewWebhookUC(webhook.Config{
+		Provider:   models.PaymentMethodBoleto,
+		Secret:     cfg.WebhookBoletoSecret,
+		Tolerance:  cfg.WebhookTolerance,
+		AllowedIPs: boletoAllowedIPs,
+	}, webhookRepository, logger)
 	// exHandler := svcEx.NewExService()
 
 	authUC := authUC.NewAuthUC(clientUserService, clientSubscriptionService)
@@ -225,6 +464,33 @@ func main() {
 	mux.HandleFunc("DELETE /api/admin/delete/{user_id}", authUC.ValidateIsAdmin(adminHandler.Delete(ctx)))
 	mux.HandleFunc("PATCH /api/admin/register/toggle", authUC.ValidateIsAdmin(userHandler.ToggleRegistration(ctx)))
 
+	//SAGA COMPENSATIONS
+	mux.HandleFunc("GET /api/admin/saga/dead-letters", authUC.ValidateIsAdmin(sagaHandler.ListDeadLetters(ctx)))
+	mux.HandleFunc("GET /api/admin/saga/dead-letters/{dead_letter_id}", authUC.ValidateIsAdmin(sagaHandler.FindDeadLetter(ctx)))
+	mux.HandleFunc("POST /api/admin/saga/dead-letters/{dead_letter_id}/retry", authUC.ValidateIsAdmin(sagaHandler.RetryDeadLetter(ctx)))
+	mux.HandleFunc("POST /api/admin/saga/dead-letters/{dead_letter_id}/resolve", authUC.ValidateIsAdmin(sagaHandler.ResolveDeadLetter(ctx)))
+
+	mux.HandleFunc("GET /api/admin/orders", authUC.ValidateIsAdmin(ordersHandler.Search(ctx)))
+	mux.HandleFunc("GET /api/admin/orders/export", authUC.ValidateIsAdmin(ordersHandler.Export(ctx)))
+	mux.HandleFunc("GET /api/admin/payments/attempts", authUC.ValidateIsAdmin(paymentHandler.SearchAttempts(ctx)))
+	mux.HandleFunc("GET /api/admin/withdrawals", authUC.ValidateIsAdmin(withdrawalHandler.List(ctx)))
+	mux.HandleFunc("POST /api/admin/withdrawals/{withdrawal_id}/approve", authUC.ValidateIsAdmin(withdrawalHandler.Approve(ctx)))
+	mux.HandleFunc("POST /api/admin/withdrawals/{withdrawal_id}/reject", authUC.ValidateIsAdmin(withdrawalHandler.Reject(ctx)))
+	mux.HandleFunc("POST /api/admin/withdrawals/{withdrawal_id}/settle", authUC.ValidateIsAdmin(withdrawalHandler.Settle(ctx)))
+
+	mux.HandleFunc("POST /api/admin/coupons", authUC.ValidateIsAdmin(promotionHandler.CreateCoupon(ctx)))
+	mux.HandleFunc("GET /api/admin/coupons", authUC.ValidateIsAdmin(promotionHandler.ListCoupons(ctx)))
+	mux.HandleFunc("GET /api/admin/coupons/{coupon_id}", authUC.ValidateIsAdmin(promotionHandler.FindCoupon(ctx)))
+	mux.HandleFunc("PUT /api/admin/coupons/{coupon_id}", authUC.ValidateIsAdmin(promotionHandler.UpdateCoupon(ctx)))
+	mux.HandleFunc("DELETE /api/admin/coupons/{coupon_id}", authUC.ValidateIsAdmin(promotionHandler.DeleteCoupon(ctx)))
+
+	mux.HandleFunc("GET /api/plans", catalogHandler.List(ctx))
+	mux.HandleFunc("GET /api/admin/plans", authUC.ValidateIsAdmin(catalogHandler.AdminList(ctx)))
+	mux.HandleFunc("PUT /api/admin/plans/{plan_code}", authUC.ValidateIsAdmin(catalogHandler.SavePlan(ctx)))
+	mux.HandleFunc("DELETE /api/admin/plans/{plan_code}", authUC.ValidateIsAdmin(catalogHandler.DeletePlan(ctx)))
+	mux.HandleFunc("PUT /api/admin/top-ups/{amount}", authUC.ValidateIsAdmin(catalogHandler.SaveTopUp(ctx)))
+	mux.HandleFunc("DELETE /api/admin/top-ups/{amount}", authUC.ValidateIsAdmin(catalogHandler.DeleteTopUp(ctx)))
+
 	//MICROSERVICE GRPC PRODUCTS
 	mux.HandleFunc("POST /api/product/create", authUC.ValidateIsAdmin(productHandler.Create(ctx)))
 	mux.HandleFunc("GET /api/product/find/{product_id}", authUC.ValidateToken(productHandler.Find(ctx)))
@@ -237,8 +503,30 @@ func main() {
 	mux.HandleFunc("GET /api/orders/find/{order_id}", authUC.ValidateIsAdmin(ordersHandler.FindById(ctx)))
 	mux.HandleFunc("GET /api/orders/find/account", authUC.ValidateToken(ordersHandler.FindByAccount(ctx)))
 	mux.HandleFunc("GET /api/orders/find/product/{product_id}", authUC.ValidateIsAdmin(ordersHandler.FindByProduct(ctx)))
-
-	mux.HandleFunc("POST /api/webhook/pix", authUC.ValidateWebHookPix(paymentPixHandler.WebHookPix(ctx)))
+	mux.HandleFunc("POST /api/orders/{order_id}/cancel", authUC.ValidateToken(ordersHandler.Cancel(ctx)))
+	mux.HandleFunc("POST /api/orders/{order_id}/refund", authUC.ValidateIsAdmin(ordersHandler.Refund(ctx)))
+	mux.HandleFunc("GET /api/orders/{order_id}/{resource}", service.RouteByPathValue("resource", map[string]http.HandlerFunc{
+		"history":          authUC.ValidateToken(ordersHandler.History(ctx)),
+		"receipt":          authUC.ValidateToken(invoiceHandler.Receipt(ctx)),
+		"pix-qrcode":       authUC.ValidateToken(paymentPixHandler.QRCode(ctx)),
+		"boleto":           authUC.ValidateToken(paymentBoletoHandler.Find(ctx)),
+		"payment-attempts": authUC.ValidateIsAdmin(paymentHandler.OrderAttempts(ctx)),
+	}))
+
+	mux.HandleFunc("GET /api/wallet/transactions", authUC.ValidateToken(walletHandler.Transactions(ctx)))
+	mux.HandleFunc("POST /api/wallet/transfer", authUC.ValidateToken(walletHandler.Transfer(ctx)))
+	mux.HandleFunc("POST /api/wallet/transfer/{transfer_id}/confirm", authUC.ValidateToken(walletHandler.ConfirmTransfer(ctx)))
+	mux.HandleFunc("POST /api/wallet/withdrawals", authUC.ValidateToken(withdrawalHandler.Request(ctx)))
+
+	mux.HandleFunc("GET /api/cart", authUC.ValidateToken(cartHandler.Find(ctx)))
+	mux.HandleFunc("DELETE /api/cart", authUC.ValidateToken(cartHandler.Clear(ctx)))
+	mux.HandleFunc("POST /api/cart/items", authUC.ValidateToken(cartHandler.AddItem(ctx)))
+	mux.HandleFunc("PUT /api/cart/items/{product_id}", authUC.ValidateToken(cartHandler.UpdateItem(ctx)))
+	mux.HandleFunc("DELETE /api/cart/items/{product_id}", authUC.ValidateToken(cartHandler.RemoveItem(ctx)))
+	mux.HandleFunc("POST /api/cart/checkout", authUC.ValidateToken(cartHandler.Checkout(ctx)))
+
+	mux.HandleFunc("POST /api/webhook/pix", pixWebhook.Verify(paymentPixHandler.WebHookPix(ctx)))
+	mux.HandleFunc("POST /api/webhook/boleto", boletoWebhook.Verify(paymentBoletoHandler.WebHookBoleto(ctx)))
 
 	mux.HandleFunc("GET /api/captcha", cptSvc.GenCaptcha(ctx))
 
@@ -294,8 +582,39 @@ func main() {
 		MaxHeaderBytes: 8192,
 	}
 
-	logger.Infof("server running port %d", cfg.ApiPort)
-	if err := server.ListenAndServe(); err != nil {
-		log.Fatalf("mux.ListenAndServe:%v", err)
+	go func() {
+		logger.Infof("server running port %d", cfg.ApiPort)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("mux.ListenAndServe:%v", err)
+		}
+	}()
+
+	<-sigCtx.Done()
+
+	logger.Info("shutting down")
+
+	shutdownTimeout := cfg.SagaShutdownTimeout
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = 30 * time.Second
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Errorf("server.Shutdown: %v", err)
+	}
+
+	if err := indexReconciler.Stop(shutdownCtx); err != nil {
+		logger.Errorf("indexReconciler.Stop: %v", err)
+	}
+
+	// the sweeper hands work to the saga worker, so it stops first
+	if err := expirySweeper.Stop(shutdownCtx); err != nil {
+		logger.Errorf("expirySweeper.Stop: %v", err)
+	}
+
+	if err := sagaWorkerCompensate.Stop(shutdownCtx); err != nil {
+		logger.Errorf("sagaWorkerCompensate.Stop: %v", err)
 	}
 }
@@ -14,6 +14,20 @@ type Config struct {
 	UserAndAdminSetup `mapstructure:",squash"`
 	PixSetup          `mapstructure:",squash"`
 	AuthGrpc          `mapstructure:",squash"`
+	SagaSetup         `mapstructure:",squash"`
+	IdempotencySetup  `mapstructure:",squash"`
+	StockSetup        `mapstructure:",squash"`
+	CartSetup         `mapstructure:",squash"`
+	CatalogSetup      `mapstructure:",squash"`
+	ExpirySetup       `mapstructure:",squash"`
+	IndexSetup        `mapstructure:",squash"`
+	InvoiceSetup      `mapstructure:",squash"`
+	CardSetup         `mapstructure:",squash"`
+	PaymentSetup      `mapstructure:",squash"`
+	BoletoSetup       `mapstructure:",squash"`
+	WebhookSetup      `mapstructure:",squash"`
+	TransferSetup     `mapstructure:",squash"`
+	WithdrawalSetup   `mapstructure:",squash"`
 	DbDriver          string `mapstructure:"DB_DRIVER"`
 	DbUrl             string `mapstructure:"DB_URL"`
 	BaseApiUrl        string `mapstructure:"BASE_API_URL"`
@@ -43,7 +57,114 @@ type UserAndAdminSetup struct {
 }
 
 type PixSetup struct {
-	PixAuthorization string `mapstructure:"PIX_AUTHORIZATION"`
+	PixAuthorization   string        `mapstructure:"PIX_AUTHORIZATION"`
+	PixPSPURL          string        `mapstructure:"PIX_PSP_URL"`
+	PixKey             string        `mapstructure:"PIX_KEY"`
+	PixMerchantName    string        `mapstructure:"PIX_MERCHANT_NAME"`
+	PixMerchantCity    string        `mapstructure:"PIX_MERCHANT_CITY"`
+	PixExpiration      time.Duration `mapstructure:"PIX_EXPIRATION"`
+	PixPSPTimeout      time.Duration `mapstructure:"PIX_PSP_TIMEOUT"`
+	PixRateLimitWindow time.Duration `mapstructure:"PIX_RATE_LIMIT_WINDOW"`
+	// a second PSP taking pix when the first one is down
+	PixFallbackPSPURL        string `mapstructure:"PIX_FALLBACK_PSP_URL"`
+	PixFallbackAuthorization string `mapstructure:"PIX_FALLBACK_AUTHORIZATION"`
+	PixFallbackKey           string `mapstructure:"PIX_FALLBACK_KEY"`
+}
+
+type SagaSetup struct {
+	SagaWorkers         int           `mapstructure:"SAGA_WORKERS"`
+	SagaMaxAttempts     int           `mapstructure:"SAGA_MAX_ATTEMPTS"`
+	SagaBaseDelay       time.Duration `mapstructure:"SAGA_BASE_DELAY"`
+	SagaMaxDelay        time.Duration `mapstructure:"SAGA_MAX_DELAY"`
+	SagaMaxAge          time.Duration `mapstructure:"SAGA_MAX_AGE"`
+	SagaShutdownTimeout time.Duration `mapstructure:"SAGA_SHUTDOWN_TIMEOUT"`
+}
+
+type IdempotencySetup struct {
+	IdempotencyTTL      time.Duration `mapstructure:"IDEMPOTENCY_TTL"`
+	IdempotencyLeaseTTL time.Duration `mapstructure:"IDEMPOTENCY_LEASE_TTL"`
+}
+
+type CartSetup struct {
+	CartTTL time.Duration `mapstructure:"CART_TTL"`
+}
+
+type ExpirySetup struct {
+	OrderExpirySweepInterval time.Duration `mapstructure:"ORDER_EXPIRY_SWEEP_INTERVAL"`
+	OrderExpiryBatchSize     int           `mapstructure:"ORDER_EXPIRY_BATCH_SIZE"`
+}
+
+type IndexSetup struct {
+	OrderReindexInterval  time.Duration `mapstructure:"ORDER_REINDEX_INTERVAL"`
+	OrderReindexBatchSize int           `mapstructure:"ORDER_REINDEX_BATCH_SIZE"`
+}
+
+type CardSetup struct {
+	CardProviderURL     string        `mapstructure:"CARD_PROVIDER_URL"`
+	CardProviderAPIKey  string        `mapstructure:"CARD_PROVIDER_API_KEY"`
+	CardProviderTimeout time.Duration `mapstructure:"CARD_PROVIDER_TIMEOUT"`
+	// a second provider charging cards when the first one is down
+	CardFallbackProviderURL    string `mapstructure:"CARD_FALLBACK_PROVIDER_URL"`
+	CardFallbackProviderAPIKey string `mapstructure:"CARD_FALLBACK_PROVIDER_API_KEY"`
+}
+
+// PaymentSetup routes payments among the providers of a method. The routes
+// are JSON keyed by provider name, see payment.ParseRoutes.
+type PaymentSetup struct {
+	PaymentRoutes             string        `mapstructure:"PAYMENT_ROUTES"`
+	PaymentBreakerFailures    int           `mapstructure:"PAYMENT_BREAKER_FAILURES"`
+	PaymentBreakerOpenTimeout time.Duration `mapstructure:"PAYMENT_BREAKER_OPEN_TIMEOUT"`
+}
+
+type BoletoSetup struct {
+	BoletoBankCode         string        `mapstructure:"BOLETO_BANK_CODE"`
+	BoletoAgreement        string        `mapstructure:"BOLETO_AGREEMENT"`
+	BoletoWallet           string        `mapstructure:"BOLETO_WALLET"`
+	BoletoDueDays          int           `mapstructure:"BOLETO_DUE_DAYS"`
+	BoletoSettlementWindow time.Duration `mapstructure:"BOLETO_SETTLEMENT_WINDOW"`
+}
+
+// WebhookSetup holds the secret shared with each payment provider to sign
+// its callbacks. The allowed IPs are comma separated addresses or CIDRs.
+type WebhookSetup struct {
+	WebhookTolerance        time.Duration `mapstructure:"WEBHOOK_TOLERANCE"`
+	WebhookPixSecret        string        `mapstructure:"WEBHOOK_PIX_SECRET"`
+	WebhookPixAllowedIPs    string        `mapstructure:"WEBHOOK_PIX_ALLOWED_IPS"`
+	WebhookBoletoSecret     string        `mapstructure:"WEBHOOK_BOLETO_SECRET"`
+	WebhookBoletoAllowedIPs string        `mapstructure:"WEBHOOK_BOLETO_ALLOWED_IPS"`
+}
+
+type TransferSetup struct {
+	TransferDailyLimit              int64         `mapstructure:"TRANSFER_DAILY_LIMIT"`
+	TransferDailyCount              int           `mapstructure:"TRANSFER_DAILY_COUNT"`
+	TransferConfirmationMinAmount   int64         `mapstructure:"TRANSFER_CONFIRMATION_MIN_AMOUNT"`
+	TransferConfirmationTTL         time.Duration `mapstructure:"TRANSFER_CONFIRMATION_TTL"`
+	TransferConfirmationMaxAttempts int           `mapstructure:"TRANSFER_CONFIRMATION_MAX_ATTEMPTS"`
+}
+
+// WithdrawalSetup picks the payout provider: "psp" sends the payouts to
+// PAYOUT_PSP_URL and "fake", only allowed with SERVER_MODE=dev, moves no
+// money. Any other value stops the gateway at startup.
+type WithdrawalSetup struct {
+	WithdrawalMinAmount    int64         `mapstructure:"WITHDRAWAL_MIN_AMOUNT"`
+	WithdrawalMaxAmount    int64         `mapstructure:"WITHDRAWAL_MAX_AMOUNT"`
+	PayoutProvider         string        `mapstructure:"PAYOUT_PROVIDER"`
+	PayoutPSPURL           string        `mapstructure:"PAYOUT_PSP_URL"`
+	PayoutPSPAuthorization string        `mapstructure:"PAYOUT_PSP_AUTHORIZATION"`
+	PayoutPSPTimeout       time.Duration `mapstructure:"PAYOUT_PSP_TIMEOUT"`
+}
+
+type InvoiceSetup struct {
+	InvoiceIssuerName     string `mapstructure:"INVOICE_ISSUER_NAME"`
+	InvoiceIssuerDocument string `mapstructure:"INVOICE_ISSUER_DOCUMENT"`
+}
+
+type CatalogSetup struct {
+	CatalogCacheTTL time.Duration `mapstructure:"CATALOG_CACHE_TTL"`
+}
+
+type StockSetup struct {
+	StockHoldTTL time.Duration `mapstructure:"STOCK_HOLD_TTL"`
 }
 
 type AuthGrpc struct {
@@ -12,7 +12,6 @@ type Auth interface {
 	ValidateUpdate(next http.HandlerFunc) http.HandlerFunc
 	ValidateCreateAdmin(next http.HandlerFunc) http.HandlerFunc
 	ValidateIsAdmin(next http.HandlerFunc) http.HandlerFunc
-	ValidateWebHookPix(next http.HandlerFunc) http.HandlerFunc
 	ValidateTokenIsPremiun(next http.HandlerFunc)http.HandlerFunc
 }
 
@@ -58,11 +58,6 @@ func getRefreshToken(r *http.Request) string {
 	return refreshToken[7:]
 }
 
-func getPixWebHookToken(r *http.Request) string {
-	pixToken := r.Header.Get("pix-token")
-	return pixToken
-}
-
 func (a *authUC) ValidateToken(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		accessToken := getAccessToken(r)
@@ -527,16 +522,3 @@ func (a *authUC) ValidateTokenIsPremiun(next http.HandlerFunc) http.HandlerFunc
 		auth.Json(w, resp, http.StatusForbidden)
 	}
 }
-
-func (a *authUC) ValidateWebHookPix(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-
-		token := getPixWebHookToken(r)
-
-		v := context.WithValue(r.Context(), "teste", token)
-
-		r = r.WithContext(v)
-
-		next.ServeHTTP(w, r)
-	}
-}
@@ -0,0 +1,199 @@
+package cart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/orders"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrCartEmpty        = errors.New("cart empty")
+	ErrItemNotFound     = errors.New("item not in cart")
+	ErrPriceChanged     = errors.New("product price changed, review the cart before checkout")
+	ErrQuantityInvalid  = errors.New("quantity invalid")
+	ErrProductIdInvalid = errors.New("invalid uuid product")
+	ErrUserIdInvalid    = errors.New("invalid uuid account")
+)
+
+type Cart interface {
+	AddItem(ctx context.Context, params *ParamsAddItemInput) (*ParamsCartOutput, error)
+	UpdateItem(ctx context.Context, params *ParamsUpdateItemInput) (*ParamsCartOutput, error)
+	RemoveItem(ctx context.Context, params *ParamsRemoveItemInput) (*ParamsCartOutput, error)
+	Find(ctx context.Context, params *ParamsCartInput) (*ParamsCartOutput, error)
+	Clear(ctx context.Context, params *ParamsCartInput) error
+	Checkout(ctx context.Context, params *ParamsCartInput) (*orders.OrderCreateOutput, error)
+}
+
+type Repository interface {
+	// Get returns an empty cart when the user has none stored.
+	Get(ctx context.Context, userId string) (*CartData, error)
+	Save(ctx context.Context, cart *CartData) error
+	Delete(ctx context.Context, userId string) error
+}
+
+func FormatCartKeyRepository(userId string) string {
+	return fmt.Sprintf("cart:%s", userId)
+}
+
+type CartItem struct {
+	ProductId string `json:"product_id"`
+	Name      string `json:"name"`
+	UnitPrice int64  `json:"unit_price"`
+	Quantity  int64  `json:"quantity"`
+}
+
+func (i *CartItem) Validate() error {
+	return validateQuantity(i.Quantity)
+}
+
+type CartData struct {
+	UserId    string      `json:"user_id"`
+	Items     []*CartItem `json:"items"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+func NewCartData(userId string) *CartData {
+	return &CartData{
+		UserId: userId,
+		Items:  make([]*CartItem, 0),
+	}
+}
+
+func (c *CartData) Item(productId string) (*CartItem, bool) {
+	for _, item := range c.Items {
+		if item.ProductId == productId {
+			return item, true
+		}
+	}
+
+	return nil, false
+}
+
+func (c *CartData) Remove(productId string) bool {
+	for i, item := range c.Items {
+		if item.ProductId == productId {
+			c.Items = append(c.Items[:i], c.Items[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *CartData) Output() *ParamsCartOutput {
+	out := ParamsCartOutput{
+		UserId:    c.UserId,
+		Items:     make([]*CartItemOutput, 0, len(c.Items)),
+		UpdatedAt: c.UpdatedAt,
+	}
+
+	for _, item := range c.Items {
+		subtotal := item.UnitPrice * item.Quantity
+
+		out.Items = append(out.Items, &CartItemOutput{
+			ProductId: item.ProductId,
+			Name:      item.Name,
+			UnitPrice: item.UnitPrice,
+			Quantity:  item.Quantity,
+			Subtotal:  subtotal,
+		})
+
+		out.TotalItems += item.Quantity
+		out.Total += subtotal
+	}
+
+	return &out
+}
+
+func validateIds(userId, productId string) error {
+	if _, err := uuid.Parse(userId); err != nil {
+		return ErrUserIdInvalid
+	}
+
+	if _, err := uuid.Parse(productId); err != nil {
+		return ErrProductIdInvalid
+	}
+
+	return nil
+}
+
+func validateQuantity(quantity int64) error {
+	if quantity <= 0 || quantity > orders.MaxItemQuantity {
+		return ErrQuantityInvalid
+	}
+
+	return nil
+}
+
+type ParamsAddItemInput struct {
+	UserId    string `json:"-"`
+	ProductId string `json:"product_id"`
+	Quantity  int64  `json:"quantity"`
+}
+
+func (p *ParamsAddItemInput) Validate() error {
+	if p.Quantity == 0 {
+		p.Quantity = 1
+	}
+
+	if err := validateIds(p.UserId, p.ProductId); err != nil {
+		return err
+	}
+
+	return validateQuantity(p.Quantity)
+}
+
+type ParamsUpdateItemInput struct {
+	UserId    string `json:"-"`
+	ProductId string `json:"-"`
+	Quantity  int64  `json:"quantity"`
+}
+
+func (p *ParamsUpdateItemInput) Validate() error {
+	if err := validateIds(p.UserId, p.ProductId); err != nil {
+		return err
+	}
+
+	return validateQuantity(p.Quantity)
+}
+
+type ParamsRemoveItemInput struct {
+	UserId    string
+	ProductId string
+}
+
+func (p *ParamsRemoveItemInput) Validate() error {
+	return validateIds(p.UserId, p.ProductId)
+}
+
+type ParamsCartInput struct {
+	UserId string
+}
+
+func (p *ParamsCartInput) Validate() error {
+	if _, err := uuid.Parse(p.UserId); err != nil {
+		return ErrUserIdInvalid
+	}
+
+	return nil
+}
+
+type CartItemOutput struct {
+	ProductId string `json:"product_id"`
+	Name      string `json:"name"`
+	UnitPrice int64  `json:"unit_price"`
+	Quantity  int64  `json:"quantity"`
+	Subtotal  int64  `json:"subtotal"`
+}
+
+type ParamsCartOutput struct {
+	UserId     string            `json:"user_id"`
+	Items      []*CartItemOutput `json:"items"`
+	TotalItems int64             `json:"total_items"`
+	Total      int64             `json:"total"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/cart"
+	"github.com/redis/go-redis/v9"
+)
+
+const defaultCartTTL = 7 * 24 * time.Hour
+
+type cartRepository struct {
+	redis *redis.Client
+	ttl   time.Duration
+}
+
+func NewCartRepository(ttl time.Duration, rds *redis.Client) cart.Repository {
+	if ttl <= 0 {
+		ttl = defaultCartTTL
+	}
+
+	return &cartRepository{
+		redis: rds,
+		ttl:   ttl,
+	}
+}
+
+func (r *cartRepository) Get(ctx context.Context, userId string) (*cart.CartData, error) {
+	data, err := r.redis.Get(ctx, cart.FormatCartKeyRepository(userId)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return cart.NewCartData(userId), nil
+		}
+
+		return nil, fmt.Errorf("r.redis.Get: %w", err)
+	}
+
+	var c cart.CartData
+
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	return &c, nil
+}
+
+func (r *cartRepository) Save(ctx context.Context, c *cart.CartData) error {
+	c.UpdatedAt = time.Now()
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	if err := r.redis.Set(ctx, cart.FormatCartKeyRepository(c.UserId), data, r.ttl).Err(); err != nil {
+		return fmt.Errorf("r.redis.Set: %w", err)
+	}
+
+	return nil
+}
+
+func (r *cartRepository) Delete(ctx context.Context, userId string) error {
+	if err := r.redis.Del(ctx, cart.FormatCartKeyRepository(userId)).Err(); err != nil {
+		return fmt.Errorf("r.redis.Del: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aclgo/simple-api-gateway/internal/cart"
+	"github.com/aclgo/simple-api-gateway/internal/orders"
+	"github.com/aclgo/simple-api-gateway/pkg/logger"
+	protoProduct "github.com/aclgo/simple-api-gateway/proto-service/product"
+)
+
+type cartUC struct {
+	repo               cart.Repository
+	clientProductsGRPC protoProduct.ProductServiceClient
+	ordersUC           orders.Orders
+	logger             logger.Logger
+}
+
+func NewCartUC(repo cart.Repository, clientProductsGRPC protoProduct.ProductServiceClient, ordersUC orders.Orders, logger logger.Logger) cart.Cart {
+	return &cartUC{
+		repo:               repo,
+		clientProductsGRPC: clientProductsGRPC,
+		ordersUC:           ordersUC,
+		logger:             logger,
+	}
+}
+
+func (u *cartUC) findProduct(ctx context.Context, productId string) (*protoProduct.Product, error) {
+	find, err := u.clientProductsGRPC.Find(ctx, &protoProduct.ProductFindRequest{Id: productId})
+	if err != nil {
+		return nil, fmt.Errorf("u.clientProductsGRPC.Find: %w", err)
+	}
+
+	return find.Product, nil
+}
+
+func (u *cartUC) AddItem(ctx context.Context, params *cart.ParamsAddItemInput) (*cart.ParamsCartOutput, error) {
+	product, err := u.findProduct(ctx, params.ProductId)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := u.repo.Get(ctx, params.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("u.repo.Get: %w", err)
+	}
+
+	item, ok := c.Item(params.ProductId)
+	if !ok {
+		// the cart checks out as one order, so it takes no more products
+		if len(c.Items) >= orders.MaxOrderProducts {
+			return nil, orders.ErrTooManyProducts
+		}
+
+		item = &cart.CartItem{ProductId: params.ProductId}
+		c.Items = append(c.Items, item)
+	}
+
+	item.Name = product.Name
+	item.UnitPrice = product.Price
+	item.Quantity += params.Quantity
+
+	if err := item.Validate(); err != nil {
+		return nil, err
+	}
+
+	if item.Quantity > product.Quantity {
+		return nil, orders.ErrProductOutOfStock{ProductId: params.ProductId}
+	}
+
+	if err := u.repo.Save(ctx, c); err != nil {
+		return nil, fmt.Errorf("u.repo.Save: %w", err)
+	}
+
+	return c.Output(), nil
+}
+
+func (u *cartUC) UpdateItem(ctx context.Context, params *cart.ParamsUpdateItemInput) (*cart.ParamsCartOutput, error) {
+	c, err := u.repo.Get(ctx, params.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("u.repo.Get: %w", err)
+	}
+
+	item, ok := c.Item(params.ProductId)
+	if !ok {
+		return nil, cart.ErrItemNotFound
+	}
+
+	product, err := u.findProduct(ctx, params.ProductId)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Quantity > product.Quantity {
+		return nil, orders.ErrProductOutOfStock{ProductId: params.ProductId}
+	}
+
+	item.Name = product.Name
+	item.UnitPrice = product.Price
+	item.Quantity = params.Quantity
+
+	if err := u.repo.Save(ctx, c); err != nil {
+		return nil, fmt.Errorf("u.repo.Save: %w", err)
+	}
+
+	return c.Output(), nil
+}
+
+func (u *cartUC) RemoveItem(ctx context.Context, params *cart.ParamsRemoveItemInput) (*cart.ParamsCartOutput, error) {
+	c, err := u.repo.Get(ctx, params.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("u.repo.Get: %w", err)
+	}
+
+	if !c.Remove(params.ProductId) {
+		return nil, cart.ErrItemNotFound
+	}
+
+	if err := u.repo.Save(ctx, c); err != nil {
+		return nil, fmt.Errorf("u.repo.Save: %w", err)
+	}
+
+	return c.Output(), nil
+}
+
+func (u *cartUC) Find(ctx context.Context, params *cart.ParamsCartInput) (*cart.ParamsCartOutput, error) {
+	c, err := u.repo.Get(ctx, params.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("u.repo.Get: %w", err)
+	}
+
+	return c.Output(), nil
+}
+
+func (u *cartUC) Clear(ctx context.Context, params *cart.ParamsCartInput) error {
+	if err := u.repo.Delete(ctx, params.UserId); err != nil {
+		return fmt.Errorf("u.repo.Delete: %w", err)
+	}
+
+	return nil
+}
+
+// Checkout re-reads every product before buying. When a price changed since
+// the item was added the cart is updated and the checkout is refused, so the
+// user never pays a price they have not seen.
+func (u *cartUC) Checkout(ctx context.Context, params *cart.ParamsCartInput) (*orders.OrderCreateOutput, error) {
+	c, err := u.repo.Get(ctx, params.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("u.repo.Get: %w", err)
+	}
+
+	if len(c.Items) == 0 {
+		return nil, cart.ErrCartEmpty
+	}
+
+	priceChanged := false
+
+	for _, item := range c.Items {
+		product, err := u.findProduct(ctx, item.ProductId)
+		if err != nil {
+			return nil, err
+		}
+
+		if item.Quantity > product.Quantity {
+			return nil, orders.ErrProductOutOfStock{ProductId: item.ProductId}
+		}
+
+		if item.UnitPrice != product.Price {
+			priceChanged = true
+			item.UnitPrice = product.Price
+			item.Name = product.Name
+		}
+	}
+
+	if priceChanged {
+		if err := u.repo.Save(ctx, c); err != nil {
+			return nil, fmt.Errorf("u.repo.Save: %w", err)
+		}
+
+		return nil, cart.ErrPriceChanged
+	}
+
+	in := orders.ParamBuyProductInput{
+		UserId:      params.UserId,
+		ProductsIDS: make([]orders.ProductItem, 0, len(c.Items)),
+	}
+
+	for _, item := range c.Items {
+		in.ProductsIDS = append(in.ProductsIDS, orders.ProductItem{
+			Id:       item.ProductId,
+			Quantity: item.Quantity,
+		})
+	}
+
+	order, err := u.ordersUC.CreateWithSaga(ctx, &in)
+	if err != nil {
+		return nil, fmt.Errorf("u.ordersUC.CreateWithSaga: %w", err)
+	}
+
+	if err := u.repo.Delete(context.WithoutCancel(ctx), params.UserId); err != nil {
+		u.logger.Errorf("u.repo.Delete: cart of %s after order %s: %v", params.UserId, order.OrderId, err)
+	}
+
+	return order, nil
+}
@@ -0,0 +1,164 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"time"
+)
+
+type Catalog interface {
+	ListPlans(ctx context.Context, params *ParamsListCatalogInput) ([]*Plan, error)
+	SavePlan(ctx context.Context, params *ParamsSavePlanInput) (*Plan, error)
+	DeletePlan(ctx context.Context, params *ParamsPlanInput) error
+	ListTopUps(ctx context.Context, params *ParamsListCatalogInput) ([]*TopUp, error)
+	SaveTopUp(ctx context.Context, params *ParamsSaveTopUpInput) (*TopUp, error)
+	DeleteTopUp(ctx context.Context, params *ParamsTopUpInput) error
+	// FindPlan returns the plan even when inactive, for orders placed before
+	// it was disabled.
+	FindPlan(ctx context.Context, code string) (*Plan, error)
+	// PlanTerm returns the days an active plan grants and their price; see
+	// Plan.Term.
+	PlanTerm(ctx context.Context, code string, days int64) (int64, int64, error)
+	ValidateTopUp(ctx context.Context, amount int64) error
+}
+
+type Repository interface {
+	ListPlans(ctx context.Context) ([]*Plan, error)
+	SavePlan(ctx context.Context, plan *Plan) error
+	DeletePlan(ctx context.Context, code string) error
+	ListTopUps(ctx context.Context) ([]*TopUp, error)
+	SaveTopUp(ctx context.Context, topUp *TopUp) error
+	DeleteTopUp(ctx context.Context, amount int64) error
+}
+
+var (
+	ErrPlanNotFound  = errors.New("plan not found")
+	ErrPlanDays      = errors.New("plan requires between 1 and 3650 days")
+	ErrTopUpNotFound = errors.New("top-up amount not found")
+)
+
+type Plan struct {
+	Code        string    `json:"code"`
+	Name        string    `json:"name"`
+	Price       int64     `json:"price"`
+	PricePerDay int64     `json:"price_per_day"`
+	Days        int64     `json:"days"`
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// MaxPlanDays bounds the days bought at once on a plan priced per day.
+const MaxPlanDays = 3650
+
+// Term returns the days the plan grants and their price. Fixed-price plans
+// always grant their own days, whatever was asked; plans priced per day
+// grant the asked days, between 1 and MaxPlanDays.
+func (p *Plan) Term(days int64) (int64, int64, error) {
+	if p.PricePerDay == 0 {
+		return p.Days, p.Price, nil
+	}
+
+	if days <= 0 || days > MaxPlanDays {
+		return 0, 0, ErrPlanDays
+	}
+
+	return days, p.PricePerDay * days, nil
+}
+
+type TopUp struct {
+	Amount    int64     `json:"amount"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ParamsListCatalogInput struct {
+	IncludeInactive bool
+}
+
+type ParamsSavePlanInput struct {
+	Code        string `json:"-"`
+	Name        string `json:"name"`
+	Price       int64  `json:"price"`
+	PricePerDay int64  `json:"price_per_day"`
+	Days        int64  `json:"days"`
+	Active      *bool  `json:"active"`
+}
+
+func (p *ParamsSavePlanInput) Validate() error {
+	if p.Code == "" || len(p.Code) > 64 {
+		return errors.New("plan code invalid")
+	}
+
+	if p.Name == "" {
+		return errors.New("plan name empty")
+	}
+
+	if p.Price < 0 || p.PricePerDay < 0 || p.Days < 0 {
+		return errors.New("plan values must not be negative")
+	}
+
+	if (p.Price == 0) == (p.PricePerDay == 0) {
+		return errors.New("plan needs either price or price_per_day")
+	}
+
+	if p.Price > 0 && p.Days == 0 {
+		return errors.New("plan with fixed price needs days")
+	}
+
+	// so the price of MaxPlanDays days still fits in an int64
+	if p.PricePerDay > math.MaxInt64/MaxPlanDays {
+		return errors.New("plan price_per_day too high")
+	}
+
+	return nil
+}
+
+type ParamsPlanInput struct {
+	Code string
+}
+
+func (p *ParamsPlanInput) Validate() error {
+	if p.Code == "" {
+		return errors.New("plan code empty")
+	}
+
+	return nil
+}
+
+type ParamsSaveTopUpInput struct {
+	Amount int64 `json:"-"`
+	Active *bool `json:"active"`
+}
+
+func (p *ParamsSaveTopUpInput) Validate() error {
+	if p.Amount <= 0 {
+		return errors.New("top-up amount invalid")
+	}
+
+	return nil
+}
+
+type ParamsTopUpInput struct {
+	Amount    string
+	AmountInt int64
+}
+
+func (p *ParamsTopUpInput) Validate() error {
+	amount, err := strconv.ParseInt(p.Amount, 10, 64)
+	if err != nil || amount <= 0 {
+		return errors.New("top-up amount invalid")
+	}
+
+	p.AmountInt = amount
+
+	return nil
+}
+
+type ParamsCatalogOutput struct {
+	Plans  []*Plan  `json:"plans"`
+	TopUps []*TopUp `json:"top_ups"`
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/catalog"
+	"github.com/jmoiron/sqlx"
+)
+
+type catalogRepository struct {
+	db *sqlx.DB
+}
+
+func NewCatalogRepository(db *sqlx.DB) catalog.Repository {
+	return &catalogRepository{
+		db: db,
+	}
+}
+
+type planRow struct {
+	Code        string    `db:"code"`
+	Name        string    `db:"name"`
+	Price       int64     `db:"price"`
+	PricePerDay int64     `db:"price_per_day"`
+	Days        int64     `db:"days"`
+	Active      bool      `db:"active"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+type topUpRow struct {
+	Amount    int64     `db:"amount"`
+	Active    bool      `db:"active"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func (r *catalogRepository) ListPlans(ctx context.Context) ([]*catalog.Plan, error) {
+	const query = `SELECT code, name, price, price_per_day, days, active, created_at, updated_at
+	FROM plans ORDER BY price, price_per_day`
+
+	var rows []planRow
+
+	if err := r.db.SelectContext(ctx, &rows, query); err != nil {
+		return nil, fmt.Errorf("r.db.SelectContext: %w", err)
+	}
+
+	plans := make([]*catalog.Plan, 0, len(rows))
+
+	for _, row := range rows {
+		plans = append(plans, &catalog.Plan{
+			Code:        row.Code,
+			Name:        row.Name,
+			Price:       row.Price,
+			PricePerDay: row.PricePerDay,
+			Days:        row.Days,
+			Active:      row.Active,
+			CreatedAt:   row.CreatedAt,
+			UpdatedAt:   row.UpdatedAt,
+		})
+	}
+
+	return plans, nil
+}
+
+func (r *catalogRepository) SavePlan(ctx context.Context, plan *catalog.Plan) error {
+	const query = `INSERT INTO plans (code, name, price, price_per_day, days, active, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+	ON CONFLICT (code) DO UPDATE
+	SET name = $2, price = $3, price_per_day = $4, days = $5, active = $6, updated_at = $7
+	RETURNING created_at`
+
+	plan.UpdatedAt = time.Now()
+
+	err := r.db.GetContext(ctx, &plan.CreatedAt, query,
+		plan.Code,
+		plan.Name,
+		plan.Price,
+		plan.PricePerDay,
+		plan.Days,
+		plan.Active,
+		plan.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("r.db.GetContext: %w", err)
+	}
+
+	return nil
+}
+
+func (r *catalogRepository) DeletePlan(ctx context.Context, code string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM plans WHERE code = $1`, code)
+	if err != nil {
+		return fmt.Errorf("r.db.ExecContext: %w", err)
+	}
+
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return catalog.ErrPlanNotFound
+	}
+
+	return nil
+}
+
+func (r *catalogRepository) ListTopUps(ctx context.Context) ([]*catalog.TopUp, error) {
+	const query = `SELECT amount, active, created_at, updated_at FROM top_ups ORDER BY amount`
+
+	var rows []topUpRow
+
+	if err := r.db.SelectContext(ctx, &rows, query); err != nil {
+		return nil, fmt.Errorf("r.db.SelectContext: %w", err)
+	}
+
+	topUps := make([]*catalog.TopUp, 0, len(rows))
+
+	for _, row := range rows {
+		topUps = append(topUps, &catalog.TopUp{
+			Amount:    row.Amount,
+			Active:    row.Active,
+			CreatedAt: row.CreatedAt,
+			UpdatedAt: row.UpdatedAt,
+		})
+	}
+
+	return topUps, nil
+}
+
+func (r *catalogRepository) SaveTopUp(ctx context.Context, topUp *catalog.TopUp) error {
+	const query = `INSERT INTO top_ups (amount, active, created_at, updated_at)
+	VALUES ($1, $2, $3, $3)
+	ON CONFLICT (amount) DO UPDATE SET active = $2, updated_at = $3
+	RETURNING created_at`
+
+	topUp.UpdatedAt = time.Now()
+
+	if err := r.db.GetContext(ctx, &topUp.CreatedAt, query, topUp.Amount, topUp.Active, topUp.UpdatedAt); err != nil {
+		return fmt.Errorf("r.db.GetContext: %w", err)
+	}
+
+	return nil
+}
+
+func (r *catalogRepository) DeleteTopUp(ctx context.Context, amount int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM top_ups WHERE amount = $1`, amount)
+	if err != nil {
+		return fmt.Errorf("r.db.ExecContext: %w", err)
+	}
+
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return catalog.ErrTopUpNotFound
+	}
+
+	return nil
+}
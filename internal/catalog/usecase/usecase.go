@@ -0,0 +1,220 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/catalog"
+	"github.com/aclgo/simple-api-gateway/pkg/logger"
+)
+
+const defaultCacheTTL = 5 * time.Minute
+
+// catalogUC serves reads from an in-memory copy of the catalog. Writes made
+// through this instance drop the copy at once; other instances pick them up
+// when their copy expires after cacheTTL.
+type catalogUC struct {
+	repo     catalog.Repository
+	logger   logger.Logger
+	cacheTTL time.Duration
+
+	mu       sync.RWMutex
+	plans    []*catalog.Plan
+	topUps   []*catalog.TopUp
+	loadedAt time.Time
+}
+
+func NewCatalogUC(repo catalog.Repository, cacheTTL time.Duration, logger logger.Logger) catalog.Catalog {
+	if cacheTTL <= 0 {
+		cacheTTL = defaultCacheTTL
+	}
+
+	return &catalogUC{
+		repo:     repo,
+		logger:   logger,
+		cacheTTL: cacheTTL,
+	}
+}
+
+func (u *catalogUC) load(ctx context.Context) ([]*catalog.Plan, []*catalog.TopUp, error) {
+	u.mu.RLock()
+	if !u.loadedAt.IsZero() && time.Since(u.loadedAt) < u.cacheTTL {
+		plans, topUps := u.plans, u.topUps
+		u.mu.RUnlock()
+		return plans, topUps, nil
+	}
+	u.mu.RUnlock()
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	// another request may have reloaded while waiting for the lock
+	if !u.loadedAt.IsZero() && time.Since(u.loadedAt) < u.cacheTTL {
+		return u.plans, u.topUps, nil
+	}
+
+	plans, err := u.repo.ListPlans(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("u.repo.ListPlans: %w", err)
+	}
+
+	topUps, err := u.repo.ListTopUps(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("u.repo.ListTopUps: %w", err)
+	}
+
+	u.plans, u.topUps, u.loadedAt = plans, topUps, time.Now()
+
+	return plans, topUps, nil
+}
+
+func (u *catalogUC) invalidate() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.loadedAt = time.Time{}
+}
+
+func (u *catalogUC) findPlan(ctx context.Context, code string) (*catalog.Plan, error) {
+	plans, _, err := u.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, plan := range plans {
+		if plan.Code == code {
+			return plan, nil
+		}
+	}
+
+	return nil, catalog.ErrPlanNotFound
+}
+
+func (u *catalogUC) ListPlans(ctx context.Context, params *catalog.ParamsListCatalogInput) ([]*catalog.Plan, error) {
+	plans, _, err := u.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*catalog.Plan, 0, len(plans))
+	for _, plan := range plans {
+		if plan.Active || params.IncludeInactive {
+			out = append(out, plan)
+		}
+	}
+
+	return out, nil
+}
+
+func (u *catalogUC) SavePlan(ctx context.Context, params *catalog.ParamsSavePlanInput) (*catalog.Plan, error) {
+	plan := catalog.Plan{
+		Code:        params.Code,
+		Name:        params.Name,
+		Price:       params.Price,
+		PricePerDay: params.PricePerDay,
+		Days:        params.Days,
+		Active:      true,
+	}
+
+	if params.Active != nil {
+		plan.Active = *params.Active
+	} else if current, err := u.findPlan(ctx, params.Code); err == nil {
+		plan.Active = current.Active
+	}
+
+	if err := u.repo.SavePlan(ctx, &plan); err != nil {
+		return nil, fmt.Errorf("u.repo.SavePlan: %w", err)
+	}
+
+	u.invalidate()
+
+	return &plan, nil
+}
+
+func (u *catalogUC) DeletePlan(ctx context.Context, params *catalog.ParamsPlanInput) error {
+	if err := u.repo.DeletePlan(ctx, params.Code); err != nil {
+		return err
+	}
+
+	u.invalidate()
+
+	return nil
+}
+
+func (u *catalogUC) ListTopUps(ctx context.Context, params *catalog.ParamsListCatalogInput) ([]*catalog.TopUp, error) {
+	_, topUps, err := u.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*catalog.TopUp, 0, len(topUps))
+	for _, topUp := range topUps {
+		if topUp.Active || params.IncludeInactive {
+			out = append(out, topUp)
+		}
+	}
+
+	return out, nil
+}
+
+func (u *catalogUC) SaveTopUp(ctx context.Context, params *catalog.ParamsSaveTopUpInput) (*catalog.TopUp, error) {
+	topUp := catalog.TopUp{
+		Amount: params.Amount,
+		Active: true,
+	}
+
+	if params.Active != nil {
+		topUp.Active = *params.Active
+	}
+
+	if err := u.repo.SaveTopUp(ctx, &topUp); err != nil {
+		return nil, fmt.Errorf("u.repo.SaveTopUp: %w", err)
+	}
+
+	u.invalidate()
+
+	return &topUp, nil
+}
+
+func (u *catalogUC) DeleteTopUp(ctx context.Context, params *catalog.ParamsTopUpInput) error {
+	if err := u.repo.DeleteTopUp(ctx, params.AmountInt); err != nil {
+		return err
+	}
+
+	u.invalidate()
+
+	return nil
+}
+
+func (u *catalogUC) FindPlan(ctx context.Context, code string) (*catalog.Plan, error) {
+	return u.findPlan(ctx, code)
+}
+
+func (u *catalogUC) PlanTerm(ctx context.Context, code string, days int64) (int64, int64, error) {
+	plan, err := u.findPlan(ctx, code)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if !plan.Active {
+		return 0, 0, catalog.ErrPlanNotFound
+	}
+
+	return plan.Term(days)
+}
+
+func (u *catalogUC) ValidateTopUp(ctx context.Context, amount int64) error {
+	_, topUps, err := u.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, topUp := range topUps {
+		if topUp.Amount == amount && topUp.Active {
+			return nil
+		}
+	}
+
+	return catalog.ErrTopUpNotFound
+}
@@ -0,0 +1,235 @@
+package cart
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/aclgo/simple-api-gateway/internal/auth"
+	"github.com/aclgo/simple-api-gateway/internal/cart"
+	"github.com/aclgo/simple-api-gateway/internal/delivery/http/service"
+	"github.com/aclgo/simple-api-gateway/internal/orders"
+	"github.com/aclgo/simple-api-gateway/pkg/logger"
+)
+
+type cartService struct {
+	cartUC cart.Cart
+	logger logger.Logger
+}
+
+func NewCartService(cartUC cart.Cart, logger logger.Logger) *cartService {
+	return &cartService{
+		cartUC: cartUC,
+		logger: logger,
+	}
+}
+
+func parseCartError(err error) int {
+	switch {
+	case errors.Is(err, cart.ErrItemNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, cart.ErrCartEmpty),
+		errors.Is(err, cart.ErrQuantityInvalid),
+		errors.Is(err, orders.ErrTooManyProducts):
+		return http.StatusBadRequest
+	case errors.Is(err, cart.ErrPriceChanged),
+		errors.Is(err, orders.ErrOutOfStock),
+		errors.Is(err, orders.ErrStockHoldExpired):
+		return http.StatusConflict
+	}
+
+	return http.StatusInternalServerError
+}
+
+func userFromCtx(w http.ResponseWriter, r *http.Request) (string, bool) {
+	paramsTtk, ok := r.Context().Value(auth.KeyCtxParamsToken).(*auth.ParamsToken)
+	if !ok {
+		response := service.NewRestError(http.StatusText(http.StatusInternalServerError), service.ErrNoParamsInCtx.Error())
+		service.JSON(w, response, http.StatusInternalServerError)
+		return "", false
+	}
+
+	return paramsTtk.UserID, true
+}
+
+func (s *cartService) AddItem(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId, ok := userFromCtx(w, r)
+		if !ok {
+			return
+		}
+
+		var params cart.ParamsAddItemInput
+
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, response, http.StatusBadRequest)
+			return
+		}
+
+		params.UserId = userId
+
+		if err := params.Validate(); err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, response, http.StatusBadRequest)
+			return
+		}
+
+		out, err := s.cartUC.AddItem(r.Context(), &params)
+		if err != nil {
+			status := parseCartError(err)
+			response := service.NewRestError(http.StatusText(status), err.Error())
+			service.JSON(w, response, status)
+			return
+		}
+
+		service.JSON(w, out, http.StatusOK)
+	}
+}
+
+func (s *cartService) UpdateItem(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId, ok := userFromCtx(w, r)
+		if !ok {
+			return
+		}
+
+		var params cart.ParamsUpdateItemInput
+
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, response, http.StatusBadRequest)
+			return
+		}
+
+		params.UserId = userId
+		params.ProductId = r.PathValue("product_id")
+
+		if err := params.Validate(); err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, response, http.StatusBadRequest)
+			return
+		}
+
+		out, err := s.cartUC.UpdateItem(r.Context(), &params)
+		if err != nil {
+			status := parseCartError(err)
+			response := service.NewRestError(http.StatusText(status), err.Error())
+			service.JSON(w, response, status)
+			return
+		}
+
+		service.JSON(w, out, http.StatusOK)
+	}
+}
+
+func (s *cartService) RemoveItem(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId, ok := userFromCtx(w, r)
+		if !ok {
+			return
+		}
+
+		params := cart.ParamsRemoveItemInput{
+			UserId:    userId,
+			ProductId: r.PathValue("product_id"),
+		}
+
+		if err := params.Validate(); err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, response, http.StatusBadRequest)
+			return
+		}
+
+		out, err := s.cartUC.RemoveItem(r.Context(), &params)
+		if err != nil {
+			status := parseCartError(err)
+			response := service.NewRestError(http.StatusText(status), err.Error())
+			service.JSON(w, response, status)
+			return
+		}
+
+		service.JSON(w, out, http.StatusOK)
+	}
+}
+
+func (s *cartService) Find(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId, ok := userFromCtx(w, r)
+		if !ok {
+			return
+		}
+
+		params := cart.ParamsCartInput{UserId: userId}
+
+		if err := params.Validate(); err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, response, http.StatusBadRequest)
+			return
+		}
+
+		out, err := s.cartUC.Find(r.Context(), &params)
+		if err != nil {
+			status := parseCartError(err)
+			response := service.NewRestError(http.StatusText(status), err.Error())
+			service.JSON(w, response, status)
+			return
+		}
+
+		service.JSON(w, out, http.StatusOK)
+	}
+}
+
+func (s *cartService) Clear(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId, ok := userFromCtx(w, r)
+		if !ok {
+			return
+		}
+
+		params := cart.ParamsCartInput{UserId: userId}
+
+		if err := params.Validate(); err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, response, http.StatusBadRequest)
+			return
+		}
+
+		if err := s.cartUC.Clear(r.Context(), &params); err != nil {
+			status := parseCartError(err)
+			response := service.NewRestError(http.StatusText(status), err.Error())
+			service.JSON(w, response, status)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *cartService) Checkout(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId, ok := userFromCtx(w, r)
+		if !ok {
+			return
+		}
+
+		params := cart.ParamsCartInput{UserId: userId}
+
+		if err := params.Validate(); err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, response, http.StatusBadRequest)
+			return
+		}
+
+		order, err := s.cartUC.Checkout(r.Context(), &params)
+		if err != nil {
+			status := parseCartError(err)
+			response := service.NewRestError(http.StatusText(status), err.Error())
+			service.JSON(w, response, status)
+			return
+		}
+
+		service.JSON(w, order, http.StatusOK)
+	}
+}
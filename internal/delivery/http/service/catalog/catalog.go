@@ -0,0 +1,183 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/aclgo/simple-api-gateway/internal/catalog"
+	"github.com/aclgo/simple-api-gateway/internal/delivery/http/service"
+	"github.com/aclgo/simple-api-gateway/pkg/logger"
+)
+
+type catalogService struct {
+	catalogUC catalog.Catalog
+	logger    logger.Logger
+}
+
+func NewCatalogService(catalogUC catalog.Catalog, logger logger.Logger) *catalogService {
+	return &catalogService{
+		catalogUC: catalogUC,
+		logger:    logger,
+	}
+}
+
+func parseCatalogError(err error) int {
+	switch {
+	case errors.Is(err, catalog.ErrPlanNotFound),
+		errors.Is(err, catalog.ErrTopUpNotFound):
+		return http.StatusNotFound
+	}
+
+	return http.StatusInternalServerError
+}
+
+func (s *catalogService) list(ctx context.Context, w http.ResponseWriter, includeInactive bool) {
+	params := catalog.ParamsListCatalogInput{IncludeInactive: includeInactive}
+
+	plans, err := s.catalogUC.ListPlans(ctx, &params)
+	if err != nil {
+		response := service.NewRestError(http.StatusText(http.StatusInternalServerError), err.Error())
+		service.JSON(w, response, http.StatusInternalServerError)
+		return
+	}
+
+	topUps, err := s.catalogUC.ListTopUps(ctx, &params)
+	if err != nil {
+		response := service.NewRestError(http.StatusText(http.StatusInternalServerError), err.Error())
+		service.JSON(w, response, http.StatusInternalServerError)
+		return
+	}
+
+	service.JSON(w, catalog.ParamsCatalogOutput{Plans: plans, TopUps: topUps}, http.StatusOK)
+}
+
+// List is public and shows only what can be bought.
+func (s *catalogService) List(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.list(r.Context(), w, false)
+	}
+}
+
+func (s *catalogService) AdminList(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.list(r.Context(), w, true)
+	}
+}
+
+func (s *catalogService) SavePlan(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var params catalog.ParamsSavePlanInput
+
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, response, http.StatusBadRequest)
+			return
+		}
+
+		params.Code = r.PathValue("plan_code")
+
+		if err := params.Validate(); err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, response, http.StatusBadRequest)
+			return
+		}
+
+		plan, err := s.catalogUC.SavePlan(r.Context(), &params)
+		if err != nil {
+			status := parseCatalogError(err)
+			response := service.NewRestError(http.StatusText(status), err.Error())
+			service.JSON(w, response, status)
+			return
+		}
+
+		service.JSON(w, plan, http.StatusOK)
+	}
+}
+
+func (s *catalogService) DeletePlan(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		params := catalog.ParamsPlanInput{Code: r.PathValue("plan_code")}
+
+		if err := params.Validate(); err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, response, http.StatusBadRequest)
+			return
+		}
+
+		if err := s.catalogUC.DeletePlan(r.Context(), &params); err != nil {
+			status := parseCatalogError(err)
+			response := service.NewRestError(http.StatusText(status), err.Error())
+			service.JSON(w, response, status)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *catalogService) SaveTopUp(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var params catalog.ParamsSaveTopUpInput
+
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+				response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+				service.JSON(w, response, http.StatusBadRequest)
+				return
+			}
+		}
+
+		amount, err := strconv.ParseInt(r.PathValue("amount"), 10, 64)
+		if err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusBadRequest), "top-up amount invalid")
+			service.JSON(w, response, http.StatusBadRequest)
+			return
+		}
+
+		params.Amount = amount
+
+		if err := params.Validate(); err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, response, http.StatusBadRequest)
+			return
+		}
+
+		topUp, err := s.catalogUC.SaveTopUp(r.Context(), &params)
+		if err != nil {
+			status := parseCatalogError(err)
+			response := service.NewRestError(http.StatusText(status), err.Error())
+			service.JSON(w, response, status)
+			return
+		}
+
+		service.JSON(w, topUp, http.StatusOK)
+	}
+}
+
+func (s *catalogService) DeleteTopUp(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		params := catalog.ParamsTopUpInput{Amount: r.PathValue("amount")}
+
+		if err := params.Validate(); err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, response, http.StatusBadRequest)
+			return
+		}
+
+		if err := s.catalogUC.DeleteTopUp(r.Context(), &params); err != nil {
+			status := parseCatalogError(err)
+			response := service.NewRestError(http.StatusText(status), err.Error())
+			service.JSON(w, response, status)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
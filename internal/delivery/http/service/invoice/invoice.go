@@ -0,0 +1,78 @@
+package invoice
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/aclgo/simple-api-gateway/internal/auth"
+	"github.com/aclgo/simple-api-gateway/internal/delivery/http/service"
+	"github.com/aclgo/simple-api-gateway/internal/invoice"
+	"github.com/aclgo/simple-api-gateway/internal/orders"
+	"github.com/aclgo/simple-api-gateway/pkg/logger"
+)
+
+type invoiceService struct {
+	invoiceUC invoice.Invoicing
+	logger    logger.Logger
+}
+
+func NewInvoiceService(invoiceUC invoice.Invoicing, logger logger.Logger) *invoiceService {
+	return &invoiceService{
+		invoiceUC: invoiceUC,
+		logger:    logger,
+	}
+}
+
+func parseInvoiceError(err error) int {
+	switch {
+	case errors.Is(err, orders.ErrOrderNotFound),
+		errors.Is(err, invoice.ErrInvoiceNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, invoice.ErrOrderNotPaid),
+		errors.Is(err, invoice.ErrNotInvoiceable):
+		return http.StatusConflict
+	}
+
+	return http.StatusInternalServerError
+}
+
+// Receipt downloads the receipt of a paid order as PDF, or as HTML with
+// ?format=html.
+func (s *invoiceService) Receipt(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		params := invoice.ParamsReceiptInput{
+			OrderId: r.PathValue("order_id"),
+			Format:  r.URL.Query().Get("format"),
+		}
+
+		// admins see any order, customers only their own
+		paramTtk := r.Context().Value(auth.KeyCtxParamsToken).(*auth.ParamsToken)
+		if paramTtk.Role != string(auth.ADMIN) && paramTtk.Role != string(auth.SUPERADMIN) {
+			params.UserId = paramTtk.UserID
+		}
+
+		if err := params.Validate(); err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, response, http.StatusBadRequest)
+			return
+		}
+
+		receipt, err := s.invoiceUC.Receipt(r.Context(), &params)
+		if err != nil {
+			status := parseInvoiceError(err)
+			response := service.NewRestError(http.StatusText(status), err.Error())
+			service.JSON(w, response, status)
+			return
+		}
+
+		w.Header().Set("Content-Type", receipt.ContentType)
+		w.Header().Set("Content-Disposition", `inline; filename="`+receipt.Filename+`"`)
+		w.WriteHeader(http.StatusOK)
+
+		if _, err := w.Write(receipt.Content); err != nil {
+			s.logger.Errorf("w.Write: %v", err)
+		}
+	}
+}
@@ -1,98 +1,242 @@
 package orders
 
 import (
+	"bytes"
 	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"time"
 
 	"github.com/aclgo/simple-api-gateway/internal/auth"
 	"github.com/aclgo/simple-api-gateway/internal/delivery/http/service"
 	"github.com/aclgo/simple-api-gateway/internal/orders"
+	"github.com/aclgo/simple-api-gateway/internal/payment/card"
+	"github.com/aclgo/simple-api-gateway/internal/payment/pix"
+	"github.com/aclgo/simple-api-gateway/internal/promotion"
 	"github.com/aclgo/simple-api-gateway/pkg/logger"
+	"github.com/google/uuid"
 )
 
 type ordersService struct {
-	ordersUC orders.Orders
-	logger   logger.Logger
+	ordersUC    orders.Orders
+	idempotency orders.IdempotencyRepository
+	logger      logger.Logger
 }
 
-func NewOrdersService(ordersUC orders.Orders, logger logger.Logger) *ordersService {
+func NewOrdersService(ordersUC orders.Orders, idempotency orders.IdempotencyRepository, logger logger.Logger) *ordersService {
 	return &ordersService{
-		ordersUC: ordersUC,
-		logger:   logger,
+		ordersUC:    ordersUC,
+		idempotency: idempotency,
+		logger:      logger,
 	}
 }
 
 func (s *ordersService) Create(ctx context.Context) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+
+			service.JSON(w, response, http.StatusBadRequest)
+			return
+		}
+
 		var params orders.ParamsCreateOrderAction
 
-		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		if err := json.Unmarshal(body, &params); err != nil {
 			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
 
 			service.JSON(w, response, http.StatusBadRequest)
 			return
 		}
 
-		switch params.Action {
-		case string(orders.AddBalance):
-			var input orders.ParamsAddBalanceInput
-			if err := json.Unmarshal(params.Payload, &input); err != nil {
-				response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
-				service.JSON(w, response, http.StatusBadRequest)
-				return
-			}
+		idempotencyKey := r.Header.Get(orders.HeaderIdempotencyKey)
+		if idempotencyKey == "" {
+			response, status := s.create(r.Context(), &params)
+			service.JSON(w, response, status)
+			return
+		}
 
-			added, err := s.ordersUC.AddBalance(r.Context(), &input)
-			if err != nil {
-				response := service.NewRestError(http.StatusText(http.StatusInternalServerError), err.Error())
-				service.JSON(w, response, http.StatusInternalServerError)
-				return
-			}
+		if err := orders.ValidateIdempotencyKey(idempotencyKey); err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, response, http.StatusBadRequest)
+			return
+		}
 
-			service.JSON(w, added, http.StatusOK)
+		paramTtk := r.Context().Value(auth.KeyCtxParamsToken).(*auth.ParamsToken)
 
-		case string(orders.BuyProduct):
-			var input orders.ParamBuyProductInput
-			if err := json.Unmarshal(params.Payload, &input); err != nil {
-				response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
-				service.JSON(w, response, http.StatusBadRequest)
-				return
-			}
+		s.createIdempotent(w, r, orders.FormatIdempotencyKeyRepository(paramTtk.UserID, idempotencyKey), body, &params)
+	}
+}
 
-			buyed, err := s.ordersUC.CreateWithSaga(r.Context(), &input)
-			if err != nil {
-				response := service.NewRestError(http.StatusText(http.StatusInternalServerError), err.Error())
-				service.JSON(w, response, http.StatusInternalServerError)
-				return
-			}
+// createIdempotent runs create once per key. Repeated requests with the same
+// body get the stored response back, a different body is rejected with 422.
+func (s *ordersService) createIdempotent(w http.ResponseWriter, r *http.Request, key string, body []byte, params *orders.ParamsCreateOrderAction) {
+	compacted := bytes.Buffer{}
+	if err := json.Compact(&compacted, body); err != nil {
+		response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+		service.JSON(w, response, http.StatusBadRequest)
+		return
+	}
 
-			service.JSON(w, buyed, http.StatusOK)
+	record := orders.IdempotencyRecord{
+		RequestHash: orders.HashIdempotencyRequest(compacted.Bytes()),
+		LeaseToken:  uuid.NewString(),
+		Status:      orders.IdempotencyProcessing,
+		CreatedAt:   time.Now(),
+	}
 
-		case string(orders.NewSubscription):
-			var input orders.ParamsCreateOrderSubscriptionInput
-			if err := json.Unmarshal(params.Payload, &input); err != nil {
-				response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
-				service.JSON(w, response, http.StatusBadRequest)
-				return
-			}
+	stored, reserved, err := s.idempotency.Reserve(r.Context(), key, &record)
+	if err != nil {
+		s.logger.Errorf("s.idempotency.Reserve: %v", err)
+		response := service.NewRestError(http.StatusText(http.StatusInternalServerError), err.Error())
+		service.JSON(w, response, http.StatusInternalServerError)
+		return
+	}
 
-			subscription, err := s.ordersUC.CreateSubscriptionOrExtend(r.Context(), &input)
-			if err != nil {
-				response := service.NewRestError(http.StatusText(http.StatusInternalServerError), err.Error())
-				service.JSON(w, response, http.StatusInternalServerError)
-				return
-			}
+	if !reserved {
+		switch {
+		case stored.RequestHash != record.RequestHash:
+			response := service.NewRestError(http.StatusText(http.StatusUnprocessableEntity), orders.ErrIdempotencyKeyConflict.Error())
+			service.JSON(w, response, http.StatusUnprocessableEntity)
+		case stored.Status == orders.IdempotencyProcessing:
+			response := service.NewRestError(http.StatusText(http.StatusConflict), orders.ErrIdempotencyKeyInProgress.Error())
+			service.JSON(w, response, http.StatusConflict)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(stored.StatusCode)
+			w.Write(stored.Response)
+		}
 
-			service.JSON(w, subscription, http.StatusOK)
+		return
+	}
 
-		default:
-			response := service.NewRestError(http.StatusText(http.StatusBadRequest), "action not suported")
-			service.JSON(w, response, http.StatusBadRequest)
+	response, status := s.create(r.Context(), params)
+
+	// server errors are not cached so the client can retry with the same key
+	if status >= http.StatusInternalServerError {
+		if err := s.idempotency.Release(context.WithoutCancel(r.Context()), key, &record); err != nil {
+			s.logger.Errorf("s.idempotency.Release: %v", err)
+		}
+
+		service.JSON(w, response, status)
+		return
+	}
+
+	encoded, err := json.Marshal(response)
+	if err != nil {
+		service.JSON(w, response, status)
+		return
+	}
+
+	record.Status = orders.IdempotencyCompleted
+	record.StatusCode = status
+	record.Response = encoded
+
+	if err := s.idempotency.Complete(context.WithoutCancel(r.Context()), key, &record); err != nil {
+		s.logger.Errorf("s.idempotency.Complete: %v", err)
+	}
+
+	service.JSON(w, response, status)
+}
+
+func (s *ordersService) create(ctx context.Context, params *orders.ParamsCreateOrderAction) (any, int) {
+	switch params.Action {
+	case string(orders.AddBalance):
+		var input orders.ParamsAddBalanceInput
+		if err := json.Unmarshal(params.Payload, &input); err != nil {
+			return service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error()), http.StatusBadRequest
+		}
+
+		added, err := s.ordersUC.AddBalance(ctx, &input)
+		if err != nil {
+			status := parseOrderError(err)
+			return service.NewRestError(http.StatusText(status), err.Error()), status
+		}
+
+		return added, http.StatusOK
+
+	case string(orders.BuyProduct):
+		var input orders.ParamBuyProductInput
+		if err := json.Unmarshal(params.Payload, &input); err != nil {
+			return service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error()), http.StatusBadRequest
+		}
+
+		if err := input.Validate(); err != nil {
+			return service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error()), http.StatusBadRequest
+		}
+
+		buyed, err := s.ordersUC.CreateWithSaga(ctx, &input)
+		if err != nil {
+			status := parseOrderError(err)
+			return service.NewRestError(http.StatusText(status), err.Error()), status
+		}
+
+		return buyed, http.StatusOK
+
+	case string(orders.NewSubscription):
+		var input orders.ParamsCreateOrderSubscriptionInput
+		if err := json.Unmarshal(params.Payload, &input); err != nil {
+			return service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error()), http.StatusBadRequest
 		}
+
+		subscription, err := s.ordersUC.CreateSubscriptionOrExtend(ctx, &input)
+		if err != nil {
+			status := parseOrderError(err)
+			return service.NewRestError(http.StatusText(status), err.Error()), status
+		}
+
+		return subscription, http.StatusOK
+	}
+
+	return service.NewRestError(http.StatusText(http.StatusBadRequest), "action not suported"), http.StatusBadRequest
+}
+
+func parseOrderError(err error) int {
+	switch {
+	case errors.Is(err, orders.ErrOrderNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, orders.ErrOrderNotCancellable),
+		errors.Is(err, orders.ErrInvalidStatusTransition),
+		errors.Is(err, orders.ErrOrderNotRefundable),
+		errors.Is(err, orders.ErrRefundInsufficientBalance):
+		return http.StatusConflict
+	case errors.Is(err, orders.ErrRefundAmountInvalid),
+		errors.Is(err, orders.ErrRefundExceedsAmount):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, orders.ErrPlanInvalid),
+		errors.Is(err, orders.ErrAmountInvalid),
+		errors.Is(err, orders.ErrQuantityInvalid),
+		errors.Is(err, orders.ErrTooManyProducts):
+		return http.StatusBadRequest
+	case errors.Is(err, orders.ErrOutOfStock),
+		errors.Is(err, orders.ErrStockHoldExpired):
+		return http.StatusConflict
+	case errors.Is(err, pix.ErrExceddedLimitGenPix):
+		return http.StatusTooManyRequests
+	case errors.Is(err, pix.ErrPSPUnavailable),
+		errors.Is(err, card.ErrProviderUnavailable):
+		return http.StatusBadGateway
+	case errors.Is(err, promotion.ErrCouponNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, promotion.ErrCouponInactive),
+		errors.Is(err, promotion.ErrCouponNotStarted),
+		errors.Is(err, promotion.ErrCouponExpired),
+		errors.Is(err, promotion.ErrCouponExhausted),
+		errors.Is(err, promotion.ErrCouponUserLimit),
+		errors.Is(err, promotion.ErrCouponNotApplicable),
+		errors.Is(err, promotion.ErrCouponMinAmount):
+		return http.StatusUnprocessableEntity
 	}
+
+	return http.StatusInternalServerError
 }
+
 func (s *ordersService) FindById(ctx context.Context) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
@@ -125,47 +269,300 @@ func (s *ordersService) FindByAccount(ctx context.Context) http.HandlerFunc {
 
 		paramTtk := r.Context().Value(auth.KeyCtxParamsToken).(*auth.ParamsToken)
 
-		param := orders.OrderByAccountInput{
-			AccountId: paramTtk.UserID,
+		params := searchParamsFromQuery(r)
+		params.AccountId = paramTtk.UserID
+		params.ProductId = ""
+
+		s.search(w, r, params, s.ordersUC.FindByAccount)
+	}
+}
+
+func (s *ordersService) FindByProduct(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		params := searchParamsFromQuery(r)
+		params.ProductId = r.PathValue("product_id")
+		params.AccountId = ""
+
+		s.search(w, r, params, s.ordersUC.FindByProduct)
+	}
+}
+
+// Search lists every order for the admins, filtered by account or product
+// when asked.
+func (s *ordersService) Search(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		params := searchParamsFromQuery(r)
+		params.AccountId = r.URL.Query().Get("account_id")
+		params.ProductId = r.URL.Query().Get("product_id")
+
+		s.search(w, r, params, s.ordersUC.Search)
+	}
+}
+
+const (
+	exportFlushEvery   = 100
+	exportWriteTimeout = time.Minute
+)
+
+// Export streams the paid and refunded orders of a period as CSV or NDJSON.
+// Rows are flushed as they are read, so the response is never held in
+// memory. Once the first row is out the status can no longer change, so a
+// later failure only cuts the stream short.
+func (s *ordersService) Export(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		query := r.URL.Query()
+
+		params := orders.ParamsExportOrdersInput{
+			Format:  query.Get("format"),
+			From:    query.Get("from"),
+			To:      query.Get("to"),
+			Columns: query.Get("columns"),
 		}
 
-		if err := param.Validate(); err != nil {
+		if err := params.Validate(); err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, response, http.StatusBadRequest)
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			response := service.NewRestError(http.StatusText(http.StatusInternalServerError), "streaming unsupported")
+			service.JSON(w, response, http.StatusInternalServerError)
 			return
 		}
 
-		ordersByAccount, err := s.ordersUC.FindByAccount(ctx, &param)
+		// the server write timeout is meant for regular requests, an export
+		// gets a fresh deadline every time rows are flushed
+		controller := http.NewResponseController(w)
+		controller.SetWriteDeadline(time.Now().Add(exportWriteTimeout))
+
+		csvWriter := csv.NewWriter(w)
+		jsonEncoder := json.NewEncoder(w)
+
+		flush := func() error {
+			csvWriter.Flush()
+			if err := csvWriter.Error(); err != nil {
+				return err
+			}
+
+			controller.SetWriteDeadline(time.Now().Add(exportWriteTimeout))
+			flusher.Flush()
+
+			return nil
+		}
+
+		started := false
+		start := func() error {
+			started = true
+
+			filename := "orders-" + time.Now().UTC().Format("20060102150405") + "." + params.Format
+
+			w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+			if params.Format == orders.ExportFormatNDJSON {
+				w.Header().Set("Content-Type", "application/x-ndjson")
+				w.WriteHeader(http.StatusOK)
+				return nil
+			}
+
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			w.WriteHeader(http.StatusOK)
+
+			return csvWriter.Write(params.ColumnsList)
+		}
+
+		rows := 0
+
+		err := s.ordersUC.Export(r.Context(), &params, func(order *orders.ExportedOrder) error {
+			if !started {
+				if err := start(); err != nil {
+					return err
+				}
+			}
+
+			var err error
+			if params.Format == orders.ExportFormatNDJSON {
+				err = jsonEncoder.Encode(order)
+			} else {
+				err = csvWriter.Write(params.CSVRecord(order))
+			}
+
+			if err != nil {
+				return err
+			}
+
+			rows++
+			if rows%exportFlushEvery == 0 {
+				return flush()
+			}
+
+			return nil
+		})
 		if err != nil {
+			if !started {
+				status := parseOrderError(err)
+				response := service.NewRestError(http.StatusText(status), err.Error())
+				service.JSON(w, response, status)
+				return
+			}
+
+			s.logger.Errorf("s.ordersUC.Export: stopped after %d rows: %v", rows, err)
 			return
 		}
 
-		service.JSON(w, ordersByAccount, http.StatusOK)
+		if !started {
+			if err := start(); err != nil {
+				s.logger.Errorf("start: %v", err)
+				return
+			}
+		}
+
+		if err := flush(); err != nil {
+			s.logger.Errorf("flush: %v", err)
+		}
+	}
+}
+
+func (s *ordersService) search(w http.ResponseWriter, r *http.Request, params *orders.ParamsSearchOrdersInput,
+	find func(context.Context, *orders.ParamsSearchOrdersInput) (*orders.ParamsSearchOrdersOutput, error)) {
 
+	if err := params.Validate(); err != nil {
+		response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+		service.JSON(w, response, http.StatusBadRequest)
+		return
 	}
+
+	found, err := find(r.Context(), params)
+	if err != nil {
+		status := parseOrderError(err)
+		response := service.NewRestError(http.StatusText(status), err.Error())
+		service.JSON(w, response, status)
+		return
+	}
+
+	service.JSON(w, found, http.StatusOK)
 }
-func (s *ordersService) FindByProduct(ctx context.Context) http.HandlerFunc {
+
+func searchParamsFromQuery(r *http.Request) *orders.ParamsSearchOrdersInput {
+	query := r.URL.Query()
+
+	return &orders.ParamsSearchOrdersInput{
+		Status:        query.Get("status"),
+		Type:          query.Get("type"),
+		PaymentMethod: query.Get("payment_method"),
+		CreatedFrom:   query.Get("created_from"),
+		CreatedTo:     query.Get("created_to"),
+		MinAmount:     query.Get("min_amount"),
+		MaxAmount:     query.Get("max_amount"),
+		Sort:          query.Get("sort"),
+		Page:          query.Get("page"),
+		Limit:         query.Get("limit"),
+	}
+}
+
+func (s *ordersService) Cancel(ctx context.Context) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
-		id := r.PathValue("product_id")
+		var params orders.ParamsCancelOrderInput
 
-		param := orders.OrderByProductInput{
-			ProductId: id,
+		// the reason is optional, so is the body
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+				response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+				service.JSON(w, response, http.StatusBadRequest)
+				return
+			}
 		}
 
-		if err := param.Validate(); err != nil {
+		paramTtk := r.Context().Value(auth.KeyCtxParamsToken).(*auth.ParamsToken)
+
+		params.OrderId = r.PathValue("order_id")
+		params.UserId = paramTtk.UserID
+
+		if err := params.Validate(); err != nil {
 			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, response, http.StatusBadRequest)
+			return
+		}
+
+		cancelled, err := s.ordersUC.Cancel(r.Context(), &params)
+		if err != nil {
+			status := parseOrderError(err)
+			response := service.NewRestError(http.StatusText(status), err.Error())
+			service.JSON(w, response, status)
+			return
+		}
+
+		service.JSON(w, cancelled, http.StatusOK)
+	}
+}
+
+func (s *ordersService) Refund(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var params orders.ParamsRefundOrderInput
 
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
 			service.JSON(w, response, http.StatusBadRequest)
 			return
 		}
 
-		find, err := s.ordersUC.FindByProduct(ctx, &param)
+		params.OrderId = r.PathValue("order_id")
+
+		if paramsToken, ok := r.Context().Value(auth.KeyCtxParamsToken).(*auth.ParamsToken); ok {
+			params.RefundedBy = paramsToken.UserID
+		}
+
+		if err := params.Validate(); err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, response, http.StatusBadRequest)
+			return
+		}
+
+		refunded, err := s.ordersUC.Refund(r.Context(), &params)
 		if err != nil {
-			response := service.NewRestError(http.StatusText(http.StatusInternalServerError), err.Error())
-			service.JSON(w, response, http.StatusInternalServerError)
+			status := parseOrderError(err)
+			response := service.NewRestError(http.StatusText(status), err.Error())
+			service.JSON(w, response, status)
 			return
 		}
 
-		service.JSON(w, find, http.StatusOK)
+		service.JSON(w, refunded, http.StatusOK)
+	}
+}
+
+func (s *ordersService) History(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		params := orders.ParamsOrderHistoryInput{
+			OrderId: r.PathValue("order_id"),
+		}
+
+		// admins see any order, customers only their own
+		paramTtk := r.Context().Value(auth.KeyCtxParamsToken).(*auth.ParamsToken)
+		if paramTtk.Role != string(auth.ADMIN) && paramTtk.Role != string(auth.SUPERADMIN) {
+			params.UserId = paramTtk.UserID
+		}
+
+		if err := params.Validate(); err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, response, http.StatusBadRequest)
+			return
+		}
+
+		history, err := s.ordersUC.History(r.Context(), &params)
+		if err != nil {
+			status := parseOrderError(err)
+			response := service.NewRestError(http.StatusText(status), err.Error())
+			service.JSON(w, response, status)
+			return
+		}
 
+		service.JSON(w, history, http.StatusOK)
 	}
 }
@@ -0,0 +1,94 @@
+package boleto
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/aclgo/simple-api-gateway/internal/auth"
+	"github.com/aclgo/simple-api-gateway/internal/delivery/http/service"
+	"github.com/aclgo/simple-api-gateway/internal/orders"
+	"github.com/aclgo/simple-api-gateway/internal/payment/boleto"
+)
+
+type paymentServiceBoleto struct {
+	boletoUseCase boleto.UseCase
+}
+
+func NewpaymentServiceBoleto(boletoUseCase boleto.UseCase) *paymentServiceBoleto {
+	if boletoUseCase == nil {
+		log.Fatal("boleto usecase is nil")
+	}
+
+	return &paymentServiceBoleto{
+		boletoUseCase: boletoUseCase,
+	}
+}
+
+func (s *paymentServiceBoleto) WebHookBoleto(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var params boleto.ParamsBoletoWebHookInput
+
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			resp := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, resp, http.StatusBadRequest)
+			return
+		}
+
+		if err := params.Validate(); err != nil {
+			resp := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, resp, http.StatusBadRequest)
+			return
+		}
+
+		if err := s.boletoUseCase.Webhook(r.Context(), &params); err != nil {
+			status := parseBoletoError(err)
+			resp := service.NewRestError(http.StatusText(status), err.Error())
+			service.JSON(w, resp, status)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// Find returns the barcode and digitable line of a pending boleto order.
+func (s *paymentServiceBoleto) Find(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := boleto.ParamsFindBoletoInput{
+			OrderId: r.PathValue("order_id"),
+		}
+
+		// admins see any order, customers only their own
+		paramTtk := r.Context().Value(auth.KeyCtxParamsToken).(*auth.ParamsToken)
+		if paramTtk.Role != string(auth.ADMIN) && paramTtk.Role != string(auth.SUPERADMIN) {
+			params.UserId = paramTtk.UserID
+		}
+
+		out, err := s.boletoUseCase.Find(r.Context(), &params)
+		if err != nil {
+			status := parseBoletoError(err)
+			resp := service.NewRestError(http.StatusText(status), err.Error())
+			service.JSON(w, resp, status)
+			return
+		}
+
+		service.JSON(w, out, http.StatusOK)
+	}
+}
+
+func parseBoletoError(err error) int {
+	switch {
+	case errors.Is(err, orders.ErrOrderNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, boleto.ErrBoletoNotAvailable),
+		errors.Is(err, boleto.ErrPaidAfterExpiration),
+		errors.Is(err, boleto.ErrPaidAmountMismatch),
+		errors.Is(err, orders.ErrInvalidStatusTransition):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
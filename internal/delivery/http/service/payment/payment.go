@@ -1 +1,71 @@
 package payment
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aclgo/simple-api-gateway/internal/delivery/http/service"
+	"github.com/aclgo/simple-api-gateway/internal/payment"
+)
+
+type paymentService struct {
+	paymentUC payment.PaymentInterface
+}
+
+func NewPaymentService(paymentUC payment.PaymentInterface) *paymentService {
+	return &paymentService{
+		paymentUC: paymentUC,
+	}
+}
+
+// SearchAttempts lists the provider attempts matching the query string, the
+// newest first.
+func (s *paymentService) SearchAttempts(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		params := payment.ParamsSearchAttemptsInput{
+			OrderId:     query.Get("order_id"),
+			AccountId:   query.Get("account_id"),
+			ReferenceId: query.Get("reference_id"),
+			Provider:    query.Get("provider"),
+			Status:      query.Get("status"),
+			Page:        query.Get("page"),
+			Limit:       query.Get("limit"),
+		}
+
+		s.searchAttempts(w, r, &params)
+	}
+}
+
+// OrderAttempts lists the provider attempts of the order in the path.
+func (s *paymentService) OrderAttempts(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		params := payment.ParamsSearchAttemptsInput{
+			OrderId: r.PathValue("order_id"),
+			Page:    query.Get("page"),
+			Limit:   query.Get("limit"),
+		}
+
+		s.searchAttempts(w, r, &params)
+	}
+}
+
+func (s *paymentService) searchAttempts(w http.ResponseWriter, r *http.Request, params *payment.ParamsSearchAttemptsInput) {
+	if err := params.Validate(); err != nil {
+		resp := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+		service.JSON(w, resp, http.StatusBadRequest)
+		return
+	}
+
+	found, err := s.paymentUC.SearchAttempts(r.Context(), params)
+	if err != nil {
+		resp := service.NewRestError(http.StatusText(http.StatusInternalServerError), err.Error())
+		service.JSON(w, resp, http.StatusInternalServerError)
+		return
+	}
+
+	service.JSON(w, found, http.StatusOK)
+}
@@ -3,18 +3,23 @@ package pix
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"strconv"
 
+	"github.com/aclgo/simple-api-gateway/internal/auth"
 	"github.com/aclgo/simple-api-gateway/internal/delivery/http/service"
 	"github.com/aclgo/simple-api-gateway/internal/domain/models"
+	"github.com/aclgo/simple-api-gateway/internal/orders"
+	"github.com/aclgo/simple-api-gateway/internal/payment/pix"
 )
 
 type paymentServicePix struct {
-	pixUseCase  models.PixPaymentWebHook
+	pixUseCase  pix.UseCase
 }
 
-func NewpaymentServicePix(pix models.PixPaymentWebHook) *paymentServicePix{
+func NewpaymentServicePix(pix pix.UseCase) *paymentServicePix{
 	if pix == nil {
 		log.Fatal("pix usecase is nil")
 	}
@@ -41,10 +46,69 @@ func (s *paymentServicePix) WebHookPix(ctx context.Context) http.HandlerFunc {
 
 		err := s.pixUseCase.Webhook(r.Context(), &params)
 		if err != nil {
-			resp := service.NewRestError(http.StatusText(http.StatusInternalServerError), err.Error())
-			service.JSON(w,resp, http.StatusInternalServerError)
+			status := http.StatusInternalServerError
+			if errors.Is(err, orders.ErrInvalidStatusTransition) || errors.Is(err, orders.ErrOrderExpired) {
+				status = http.StatusConflict
+			}
+
+			resp := service.NewRestError(http.StatusText(status), err.Error())
+			service.JSON(w,resp, status)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// QRCode serves the QR code of a pending pix order as PNG, so the customer
+// can scan it instead of pasting the code.
+func (s *paymentServicePix) QRCode(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := pix.ParamsQRCodeInput{
+			OrderId: r.PathValue("order_id"),
+		}
+
+		if size := r.URL.Query().Get("size"); size != "" {
+			parsed, err := strconv.Atoi(size)
+			if err != nil {
+				resp := service.NewRestError(http.StatusText(http.StatusBadRequest), "size invalid")
+				service.JSON(w, resp, http.StatusBadRequest)
+				return
+			}
+
+			params.Size = parsed
+		}
+
+		// admins see any order, customers only their own
+		paramTtk := r.Context().Value(auth.KeyCtxParamsToken).(*auth.ParamsToken)
+		if paramTtk.Role != string(auth.ADMIN) && paramTtk.Role != string(auth.SUPERADMIN) {
+			params.UserId = paramTtk.UserID
+		}
+
+		if err := params.Validate(); err != nil {
+			resp := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, resp, http.StatusBadRequest)
+			return
+		}
+
+		png, err := s.pixUseCase.QRCode(r.Context(), &params)
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, orders.ErrOrderNotFound):
+				status = http.StatusNotFound
+			case errors.Is(err, pix.ErrQRCodeNotAvailable):
+				status = http.StatusConflict
+			}
+
+			resp := service.NewRestError(http.StatusText(status), err.Error())
+			service.JSON(w, resp, status)
+			return
 		}
 
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Cache-Control", "private, max-age=300")
 		w.WriteHeader(http.StatusOK)
+		w.Write(png)
 	}
 }
@@ -0,0 +1,177 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/aclgo/simple-api-gateway/internal/auth"
+	"github.com/aclgo/simple-api-gateway/internal/delivery/http/service"
+	"github.com/aclgo/simple-api-gateway/internal/payment/wallet"
+	"github.com/aclgo/simple-api-gateway/internal/transfer"
+)
+
+type walletService struct {
+	walletUC   wallet.UseCase
+	transferUC transfer.UseCase
+}
+
+func NewWalletService(walletUC wallet.UseCase, transferUC transfer.UseCase) *walletService {
+	return &walletService{
+		walletUC:   walletUC,
+		transferUC: transferUC,
+	}
+}
+
+func parseTransferError(err error) int {
+	switch {
+	case errors.Is(err, wallet.ErrWalletNotFound),
+		errors.Is(err, transfer.ErrTransferNotFound),
+		errors.Is(err, transfer.ErrRecipientNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, transfer.ErrSelfTransfer):
+		return http.StatusBadRequest
+	case errors.Is(err, transfer.ErrInsufficientBalance),
+		errors.Is(err, transfer.ErrTransferNotPending):
+		return http.StatusConflict
+	case errors.Is(err, transfer.ErrDailyLimitExceeded),
+		errors.Is(err, transfer.ErrConfirmationCodeInvalid),
+		errors.Is(err, transfer.ErrConfirmationExpired):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, transfer.ErrDailyCountExceeded),
+		errors.Is(err, transfer.ErrTooManyAttempts):
+		return http.StatusTooManyRequests
+	}
+
+	return http.StatusInternalServerError
+}
+
+func userFromCtx(w http.ResponseWriter, r *http.Request) (string, bool) {
+	paramsTtk, ok := r.Context().Value(auth.KeyCtxParamsToken).(*auth.ParamsToken)
+	if !ok {
+		resp := service.NewRestError(http.StatusText(http.StatusInternalServerError), service.ErrNoParamsInCtx.Error())
+		service.JSON(w, resp, http.StatusInternalServerError)
+		return "", false
+	}
+
+	return paramsTtk.UserID, true
+}
+
+// Transactions lists the credits and debits of the wallet of the logged in
+// user, the newest first.
+func (s *walletService) Transactions(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId, ok := userFromCtx(w, r)
+		if !ok {
+			return
+		}
+
+		query := r.URL.Query()
+
+		params := wallet.ParamsStatementInput{
+			UserId: userId,
+			Type:   query.Get("type"),
+			From:   query.Get("from"),
+			To:     query.Get("to"),
+			Page:   query.Get("page"),
+			Limit:  query.Get("limit"),
+		}
+
+		if err := params.Validate(); err != nil {
+			resp := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, resp, http.StatusBadRequest)
+			return
+		}
+
+		statement, err := s.walletUC.Statement(r.Context(), &params)
+		if err != nil {
+			code := http.StatusInternalServerError
+			if errors.Is(err, wallet.ErrWalletNotFound) {
+				code = http.StatusNotFound
+			}
+
+			resp := service.NewRestError(http.StatusText(code), err.Error())
+			service.JSON(w, resp, code)
+			return
+		}
+
+		service.JSON(w, statement, http.StatusOK)
+	}
+}
+
+// Transfer sends funds from the wallet of the logged in user to another
+// user. Transfers that need a confirmation come back awaiting it, with the
+// code sent to the sender by email.
+func (s *walletService) Transfer(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId, ok := userFromCtx(w, r)
+		if !ok {
+			return
+		}
+
+		var params transfer.ParamsTransferInput
+
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			resp := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, resp, http.StatusBadRequest)
+			return
+		}
+
+		params.UserId = userId
+
+		if err := params.Validate(); err != nil {
+			resp := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, resp, http.StatusBadRequest)
+			return
+		}
+
+		out, err := s.transferUC.Transfer(r.Context(), &params)
+		if err != nil {
+			code := parseTransferError(err)
+			resp := service.NewRestError(http.StatusText(code), err.Error())
+			service.JSON(w, resp, code)
+			return
+		}
+
+		service.JSON(w, out, http.StatusOK)
+	}
+}
+
+// ConfirmTransfer moves the funds of a transfer awaiting confirmation once
+// the code emailed to the sender is given.
+func (s *walletService) ConfirmTransfer(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId, ok := userFromCtx(w, r)
+		if !ok {
+			return
+		}
+
+		var params transfer.ParamsConfirmTransferInput
+
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			resp := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, resp, http.StatusBadRequest)
+			return
+		}
+
+		params.UserId = userId
+		params.TransferId = r.PathValue("transfer_id")
+
+		if err := params.Validate(); err != nil {
+			resp := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, resp, http.StatusBadRequest)
+			return
+		}
+
+		out, err := s.transferUC.Confirm(r.Context(), &params)
+		if err != nil {
+			code := parseTransferError(err)
+			resp := service.NewRestError(http.StatusText(code), err.Error())
+			service.JSON(w, resp, code)
+			return
+		}
+
+		service.JSON(w, out, http.StatusOK)
+	}
+}
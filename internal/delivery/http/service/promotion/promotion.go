@@ -0,0 +1,173 @@
+package promotion
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/aclgo/simple-api-gateway/internal/delivery/http/service"
+	"github.com/aclgo/simple-api-gateway/internal/promotion"
+	"github.com/aclgo/simple-api-gateway/pkg/logger"
+)
+
+type promotionService struct {
+	promotionUC promotion.Promotion
+	logger      logger.Logger
+}
+
+func NewPromotionService(promotionUC promotion.Promotion, logger logger.Logger) *promotionService {
+	return &promotionService{
+		promotionUC: promotionUC,
+		logger:      logger,
+	}
+}
+
+func parsePromotionError(err error) int {
+	switch {
+	case errors.Is(err, promotion.ErrCouponNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, promotion.ErrCouponCodeExists):
+		return http.StatusConflict
+	case errors.Is(err, promotion.ErrCouponInvalid):
+		return http.StatusBadRequest
+	}
+
+	return http.StatusInternalServerError
+}
+
+func (s *promotionService) CreateCoupon(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var params promotion.ParamsCreateCouponInput
+
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, response, http.StatusBadRequest)
+			return
+		}
+
+		if err := params.Validate(); err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, response, http.StatusBadRequest)
+			return
+		}
+
+		coupon, err := s.promotionUC.CreateCoupon(r.Context(), &params)
+		if err != nil {
+			status := parsePromotionError(err)
+			response := service.NewRestError(http.StatusText(status), err.Error())
+			service.JSON(w, response, status)
+			return
+		}
+
+		service.JSON(w, coupon, http.StatusCreated)
+	}
+}
+
+func (s *promotionService) UpdateCoupon(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var params promotion.ParamsUpdateCouponInput
+
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, response, http.StatusBadRequest)
+			return
+		}
+
+		params.CouponId = r.PathValue("coupon_id")
+
+		find := promotion.ParamsCouponInput{CouponId: params.CouponId}
+		if err := find.Validate(); err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, response, http.StatusBadRequest)
+			return
+		}
+
+		coupon, err := s.promotionUC.UpdateCoupon(r.Context(), &params)
+		if err != nil {
+			status := parsePromotionError(err)
+			response := service.NewRestError(http.StatusText(status), err.Error())
+			service.JSON(w, response, status)
+			return
+		}
+
+		service.JSON(w, coupon, http.StatusOK)
+	}
+}
+
+func (s *promotionService) FindCoupon(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		params := promotion.ParamsCouponInput{
+			CouponId: r.PathValue("coupon_id"),
+		}
+
+		if err := params.Validate(); err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, response, http.StatusBadRequest)
+			return
+		}
+
+		coupon, err := s.promotionUC.FindCoupon(r.Context(), &params)
+		if err != nil {
+			status := parsePromotionError(err)
+			response := service.NewRestError(http.StatusText(status), err.Error())
+			service.JSON(w, response, status)
+			return
+		}
+
+		service.JSON(w, coupon, http.StatusOK)
+	}
+}
+
+func (s *promotionService) ListCoupons(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		params := promotion.ParamsListCouponsInput{
+			Active: r.URL.Query().Get("active"),
+			Page:   r.URL.Query().Get("page"),
+			Limit:  r.URL.Query().Get("limit"),
+		}
+
+		if err := params.Validate(); err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, response, http.StatusBadRequest)
+			return
+		}
+
+		list, err := s.promotionUC.ListCoupons(r.Context(), &params)
+		if err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusInternalServerError), err.Error())
+			service.JSON(w, response, http.StatusInternalServerError)
+			return
+		}
+
+		service.JSON(w, list, http.StatusOK)
+	}
+}
+
+func (s *promotionService) DeleteCoupon(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		params := promotion.ParamsCouponInput{
+			CouponId: r.PathValue("coupon_id"),
+		}
+
+		if err := params.Validate(); err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, response, http.StatusBadRequest)
+			return
+		}
+
+		if err := s.promotionUC.DeleteCoupon(r.Context(), &params); err != nil {
+			status := parsePromotionError(err)
+			response := service.NewRestError(http.StatusText(status), err.Error())
+			service.JSON(w, response, status)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,19 @@
+package service
+
+import "net/http"
+
+// RouteByPathValue picks the handler named by the path value key, answering
+// 404 for unknown names. It lets routes like "/orders/{id}/history" live next
+// to older literal ones like "/orders/find/{id}", which the mux rejects as
+// conflicting when registered on their own.
+func RouteByPathValue(key string, handlers map[string]http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handler, ok := handlers[r.PathValue(key)]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		handler(w, r)
+	}
+}
@@ -0,0 +1,148 @@
+package saga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/aclgo/simple-api-gateway/internal/auth"
+	"github.com/aclgo/simple-api-gateway/internal/delivery/http/service"
+	"github.com/aclgo/simple-api-gateway/internal/orders"
+	"github.com/aclgo/simple-api-gateway/pkg/logger"
+)
+
+type sagaService struct {
+	sagaUC orders.SagaAdmin
+	logger logger.Logger
+}
+
+func NewSagaService(sagaUC orders.SagaAdmin, logger logger.Logger) *sagaService {
+	return &sagaService{
+		sagaUC: sagaUC,
+		logger: logger,
+	}
+}
+
+func parseDeadLetterError(err error) int {
+	switch {
+	case errors.Is(err, orders.ErrDeadLetterNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, orders.ErrDeadLetterResolved),
+		errors.Is(err, orders.ErrDeadLetterNotOpen):
+		return http.StatusConflict
+	}
+
+	return http.StatusInternalServerError
+}
+
+func (s *sagaService) ListDeadLetters(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		params := orders.ParamsListDeadLettersInput{
+			Status: r.URL.Query().Get("status"),
+			Page:   r.URL.Query().Get("page"),
+			Limit:  r.URL.Query().Get("limit"),
+		}
+
+		if err := params.Validate(); err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, response, http.StatusBadRequest)
+			return
+		}
+
+		list, err := s.sagaUC.ListDeadLetters(r.Context(), &params)
+		if err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusInternalServerError), err.Error())
+			service.JSON(w, response, http.StatusInternalServerError)
+			return
+		}
+
+		service.JSON(w, list, http.StatusOK)
+	}
+}
+
+func (s *sagaService) FindDeadLetter(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		params := orders.ParamsDeadLetterInput{
+			DeadLetterId: r.PathValue("dead_letter_id"),
+		}
+
+		if err := params.Validate(); err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, response, http.StatusBadRequest)
+			return
+		}
+
+		deadLetter, err := s.sagaUC.FindDeadLetter(r.Context(), &params)
+		if err != nil {
+			status := parseDeadLetterError(err)
+			response := service.NewRestError(http.StatusText(status), err.Error())
+			service.JSON(w, response, status)
+			return
+		}
+
+		service.JSON(w, deadLetter, http.StatusOK)
+	}
+}
+
+func (s *sagaService) RetryDeadLetter(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		params := orders.ParamsDeadLetterInput{
+			DeadLetterId: r.PathValue("dead_letter_id"),
+		}
+
+		if err := params.Validate(); err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, response, http.StatusBadRequest)
+			return
+		}
+
+		deadLetter, err := s.sagaUC.RetryDeadLetter(r.Context(), &params)
+		if err != nil {
+			status := parseDeadLetterError(err)
+			response := service.NewRestError(http.StatusText(status), err.Error())
+			service.JSON(w, response, status)
+			return
+		}
+
+		service.JSON(w, deadLetter, http.StatusOK)
+	}
+}
+
+func (s *sagaService) ResolveDeadLetter(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var params orders.ParamsResolveDeadLetterInput
+
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, response, http.StatusBadRequest)
+			return
+		}
+
+		params.DeadLetterId = r.PathValue("dead_letter_id")
+
+		if paramsToken, ok := r.Context().Value(auth.KeyCtxParamsToken).(*auth.ParamsToken); ok {
+			params.ResolvedBy = paramsToken.UserID
+		}
+
+		if err := params.Validate(); err != nil {
+			response := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, response, http.StatusBadRequest)
+			return
+		}
+
+		deadLetter, err := s.sagaUC.ResolveDeadLetter(r.Context(), &params)
+		if err != nil {
+			status := parseDeadLetterError(err)
+			response := service.NewRestError(http.StatusText(status), err.Error())
+			service.JSON(w, response, status)
+			return
+		}
+
+		service.JSON(w, deadLetter, http.StatusOK)
+	}
+}
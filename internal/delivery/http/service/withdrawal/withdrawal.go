@@ -0,0 +1,167 @@
+package withdrawal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/aclgo/simple-api-gateway/internal/auth"
+	"github.com/aclgo/simple-api-gateway/internal/delivery/http/service"
+	"github.com/aclgo/simple-api-gateway/internal/withdrawal"
+	"github.com/aclgo/simple-api-gateway/pkg/logger"
+)
+
+type withdrawalService struct {
+	withdrawalUC withdrawal.UseCase
+	logger       logger.Logger
+}
+
+func NewWithdrawalService(withdrawalUC withdrawal.UseCase, logger logger.Logger) *withdrawalService {
+	return &withdrawalService{
+		withdrawalUC: withdrawalUC,
+		logger:       logger,
+	}
+}
+
+func parseWithdrawalError(err error) int {
+	switch {
+	case errors.Is(err, withdrawal.ErrWithdrawalNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, withdrawal.ErrReasonRequired):
+		return http.StatusBadRequest
+	case errors.Is(err, withdrawal.ErrInsufficientBalance),
+		errors.Is(err, withdrawal.ErrWithdrawalNotPending),
+		errors.Is(err, withdrawal.ErrWithdrawalNotUnknown):
+		return http.StatusConflict
+	case errors.Is(err, withdrawal.ErrAmountOutOfRange),
+		errors.Is(err, withdrawal.ErrPayoutRejected):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, withdrawal.ErrPayoutUnavailable),
+		errors.Is(err, withdrawal.ErrPayoutOutcomeUnknown):
+		return http.StatusBadGateway
+	}
+
+	return http.StatusInternalServerError
+}
+
+// Request holds the amount out of the wallet of the logged in user and
+// queues the withdrawal for review.
+func (s *withdrawalService) Request(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		paramsTtk, ok := r.Context().Value(auth.KeyCtxParamsToken).(*auth.ParamsToken)
+		if !ok {
+			resp := service.NewRestError(http.StatusText(http.StatusInternalServerError), service.ErrNoParamsInCtx.Error())
+			service.JSON(w, resp, http.StatusInternalServerError)
+			return
+		}
+
+		var params withdrawal.ParamsRequestInput
+
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			resp := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, resp, http.StatusBadRequest)
+			return
+		}
+
+		params.UserId = paramsTtk.UserID
+
+		if err := params.Validate(); err != nil {
+			resp := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, resp, http.StatusBadRequest)
+			return
+		}
+
+		out, err := s.withdrawalUC.Request(r.Context(), &params)
+		if err != nil {
+			code := parseWithdrawalError(err)
+			resp := service.NewRestError(http.StatusText(code), err.Error())
+			service.JSON(w, resp, code)
+			return
+		}
+
+		service.JSON(w, out, http.StatusOK)
+	}
+}
+
+// List is the review queue of the admins, the oldest withdrawals first.
+func (s *withdrawalService) List(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		params := withdrawal.ParamsListInput{
+			Status: r.URL.Query().Get("status"),
+			Page:   r.URL.Query().Get("page"),
+			Limit:  r.URL.Query().Get("limit"),
+		}
+
+		if err := params.Validate(); err != nil {
+			resp := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, resp, http.StatusBadRequest)
+			return
+		}
+
+		list, err := s.withdrawalUC.List(r.Context(), &params)
+		if err != nil {
+			resp := service.NewRestError(http.StatusText(http.StatusInternalServerError), err.Error())
+			service.JSON(w, resp, http.StatusInternalServerError)
+			return
+		}
+
+		service.JSON(w, list, http.StatusOK)
+	}
+}
+
+func (s *withdrawalService) Approve(ctx context.Context) http.HandlerFunc {
+	return s.review(s.withdrawalUC.Approve)
+}
+
+func (s *withdrawalService) Reject(ctx context.Context) http.HandlerFunc {
+	return s.review(s.withdrawalUC.Reject)
+}
+
+// Settle asks the PSP how the payout of a withdrawal in payout_unknown
+// ended.
+func (s *withdrawalService) Settle(ctx context.Context) http.HandlerFunc {
+	return s.review(s.withdrawalUC.Settle)
+}
+
+// review reads the optional reason of the admin and runs the decision on
+// the withdrawal in the path.
+func (s *withdrawalService) review(decide func(context.Context, *withdrawal.ParamsReviewInput) (*withdrawal.Withdrawal, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var params withdrawal.ParamsReviewInput
+
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil && !errors.Is(err, io.EOF) {
+			resp := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, resp, http.StatusBadRequest)
+			return
+		}
+
+		params.WithdrawalId = r.PathValue("withdrawal_id")
+
+		if paramsTtk, ok := r.Context().Value(auth.KeyCtxParamsToken).(*auth.ParamsToken); ok {
+			params.AdminId = paramsTtk.UserID
+		}
+
+		if err := params.Validate(); err != nil {
+			resp := service.NewRestError(http.StatusText(http.StatusBadRequest), err.Error())
+			service.JSON(w, resp, http.StatusBadRequest)
+			return
+		}
+
+		out, err := decide(r.Context(), &params)
+		if err != nil {
+			code := parseWithdrawalError(err)
+			if code == http.StatusInternalServerError {
+				s.logger.Errorf("review withdrawal %s: %v", params.WithdrawalId, err)
+			}
+
+			resp := service.NewRestError(http.StatusText(code), err.Error())
+			service.JSON(w, resp, code)
+			return
+		}
+
+		service.JSON(w, out, http.StatusOK)
+	}
+}
@@ -37,17 +37,22 @@ type ParamPaymentProcessInput struct {
 
 type ParamPaymentProcessOutput struct {
 	Method               string     `json:"method"`
+	Provider             string     `json:"provider,omitempty"`
+	ReferenceId          string     `json:"reference_id,omitempty"`
 	Status StatusPayment `json:"status"`
+	FailureReason string `json:"failure_reason,omitempty"`
 	GatewayTransactionID string     `json:"gateway_transaction_id"`
 
 	CardToken string `json:"card_token"`
     CardExpiration string `json:"card_expiration"`
+	CardRedirectURL string `json:"card_redirect_url,omitempty"`
 	
 	PixQRCode            string     `json:"pix_qr_code,omitempty"`
 	PixExpiration        time.Time `json:"pix_expiration"`
 	
 	BoletoURL            string     `json:"boleto_url,omitempty"`
 	BoletoBarcode        string     `json:"boleto_barcode,omitempty"`
+	BoletoDigitableLine  string     `json:"boleto_digitable_line,omitempty"`
 	BoletoExpiration     time.Time `json:"boleto_expiration"`
 }
 
@@ -55,6 +60,22 @@ type PaymentProcessor interface {
 	Proccess(ctx context.Context, in *ParamPaymentProcessInput) (*ParamPaymentProcessOutput, error)
 }
 
+type ParamPaymentRefundInput struct {
+	Method               string `json:"method"`
+	// Provider names the provider that took the payment.
+	Provider             string `json:"provider"`
+	OrderId              string `json:"order_id,omitempty"`
+	AccountId            string `json:"account_id"`
+	GatewayTransactionID string `json:"gateway_transaction_id"`
+	Amount               int64  `json:"amount"`
+}
+
+// PaymentRefunder is implemented by processors able to give money back,
+// used when a saga has to undo a payment that was already captured.
+type PaymentRefunder interface {
+	Refund(ctx context.Context, in *ParamPaymentRefundInput) error
+}
+
 type PixPaymentWebHook interface {
 	Webhook(ctx context.Context, in *ParamPixWebHookInput)(error)
 }
@@ -0,0 +1,121 @@
+package invoice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Invoicing interface {
+	// Issue creates the invoice of a paid order and emails the receipt to
+	// the customer. Issuing an order twice is a no-op.
+	Issue(ctx context.Context, orderId string) error
+	Receipt(ctx context.Context, params *ParamsReceiptInput) (*Receipt, error)
+}
+
+type Repository interface {
+	// Create numbers the invoice with the next number in the sequence and
+	// stores it, or returns ErrInvoiceExists if the order already has one.
+	Create(ctx context.Context, invoice *Invoice) error
+	FindByOrder(ctx context.Context, orderId string) (*Invoice, error)
+	MarkEmailed(ctx context.Context, id string, at time.Time) error
+}
+
+var (
+	ErrInvoiceNotFound = errors.New("invoice not found")
+	ErrInvoiceExists   = errors.New("order already invoiced")
+	ErrOrderNotPaid    = errors.New("only paid orders have a receipt")
+	ErrNotInvoiceable  = errors.New("wallet transfers and withdrawals have no receipt")
+)
+
+const (
+	FormatHTML = "html"
+	FormatPDF  = "pdf"
+)
+
+type Invoice struct {
+	Id                   string     `json:"invoice_id"`
+	Number               int64      `json:"number"`
+	OrderId              string     `json:"order_id"`
+	AccountId            string     `json:"account_id"`
+	OrderType            string     `json:"order_type"`
+	PaymentMethod        string     `json:"payment_method"`
+	GatewayTransactionId string     `json:"gateway_transaction_id"`
+	Items                []Item     `json:"items"`
+	Subtotal             int64      `json:"subtotal"`
+	Discount             int64      `json:"discount"`
+	Total                int64      `json:"total"`
+	PaidAt               time.Time  `json:"paid_at"`
+	IssuedAt             time.Time  `json:"issued_at"`
+	EmailedAt            *time.Time `json:"emailed_at,omitempty"`
+}
+
+// Code is the invoice number as printed on the receipt.
+func (i *Invoice) Code() string {
+	return fmt.Sprintf("%s%08d", DefaultNumberPrefix, i.Number)
+}
+
+type Item struct {
+	Description string `json:"description"`
+	Quantity    int64  `json:"quantity"`
+	UnitPrice   int64  `json:"unit_price"`
+	Total       int64  `json:"total"`
+}
+
+// Receipt is a rendered invoice, ready to be downloaded.
+type Receipt struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
+// ParamsReceiptInput asks for the receipt of an order. UserId is empty for
+// admins, who may read any receipt.
+type ParamsReceiptInput struct {
+	OrderId string
+	UserId  string
+	Format  string
+}
+
+func (p *ParamsReceiptInput) Validate() error {
+	if _, err := uuid.Parse(p.OrderId); err != nil {
+		return errors.New("invalid uuid order")
+	}
+
+	p.Format = strings.ToLower(p.Format)
+
+	switch p.Format {
+	case "":
+		p.Format = FormatPDF
+	case FormatHTML, FormatPDF:
+	default:
+		return errors.New("format invalid")
+	}
+
+	return nil
+}
+
+// FormatAmount prints cents as reais, e.g. R$ 1.234,56.
+func FormatAmount(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+
+	whole := fmt.Sprintf("%d", cents/100)
+
+	var b strings.Builder
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(r)
+	}
+
+	return fmt.Sprintf("%sR$ %s,%02d", sign, b.String(), cents%100)
+}
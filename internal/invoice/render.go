@@ -0,0 +1,143 @@
+package invoice
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+
+	"github.com/aclgo/simple-api-gateway/pkg/pdf"
+)
+
+var receiptTemplate = template.Must(template.New("receipt").Funcs(template.FuncMap{
+	"amount": FormatAmount,
+}).Parse(`<div style="font-family: sans-serif; max-width: 600px; margin: 0 auto; color: #222;">
+    <h3 style="margin-bottom: 4px;">{{.Issuer}}</h3>
+    {{if .IssuerDocument}}<p style="margin: 0; font-size: 12px; color: #666;">{{.IssuerDocument}}</p>{{end}}
+    <p style="margin-top: 16px;">
+        <strong>Receipt {{.Invoice.Code}}</strong><br>
+        Order {{.Invoice.OrderId}}<br>
+        Paid at {{.Invoice.PaidAt.Format "02/01/2006 15:04"}} via {{.Invoice.PaymentMethod}}
+        {{if .Invoice.GatewayTransactionId}}<br>Transaction {{.Invoice.GatewayTransactionId}}{{end}}
+    </p>
+    <table style="width: 100%; border-collapse: collapse; font-size: 14px;">
+        <tr style="border-bottom: 1px solid #ccc; text-align: left;">
+            <th>Description</th><th style="text-align: right;">Qty</th>
+            <th style="text-align: right;">Unit price</th><th style="text-align: right;">Total</th>
+        </tr>
+        {{range .Invoice.Items}}
+        <tr>
+            <td>{{.Description}}</td><td style="text-align: right;">{{.Quantity}}</td>
+            <td style="text-align: right;">{{amount .UnitPrice}}</td><td style="text-align: right;">{{amount .Total}}</td>
+        </tr>
+        {{end}}
+    </table>
+    <p style="text-align: right;">
+        Subtotal {{amount .Invoice.Subtotal}}<br>
+        {{if .Invoice.Discount}}Discount -{{amount .Invoice.Discount}}<br>{{end}}
+        <strong>Total {{amount .Invoice.Total}}</strong>
+    </p>
+</div>`))
+
+// RenderHTMLBody renders the receipt as a fragment that can be embedded in
+// an email or a page.
+func RenderHTMLBody(invoice *Invoice) (string, error) {
+	var buf bytes.Buffer
+
+	err := receiptTemplate.Execute(&buf, map[string]any{
+		"Issuer":         DefaultIssuerName,
+		"IssuerDocument": DefaultIssuerDocument,
+		"Invoice":        invoice,
+	})
+	if err != nil {
+		return "", fmt.Errorf("receiptTemplate.Execute: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+func RenderHTML(invoice *Invoice) ([]byte, error) {
+	body, err := RenderHTMLBody(invoice)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>Receipt %s</title>\n</head>\n<body>\n%s\n</body>\n</html>\n",
+		template.HTMLEscapeString(invoice.Code()), body)
+
+	return buf.Bytes(), nil
+}
+
+const (
+	pdfMargin     = 50.0
+	pdfLineHeight = 16.0
+	pdfBottom     = 780.0
+)
+
+func RenderPDF(invoice *Invoice) []byte {
+	doc := pdf.New()
+	page := doc.AddPage()
+
+	right := pdf.PageWidth - pdfMargin
+	y := 70.0
+
+	line := func() {
+		y += pdfLineHeight
+		if y > pdfBottom {
+			page = doc.AddPage()
+			y = 70.0
+		}
+	}
+
+	page.Text(pdfMargin, y, 16, pdf.Bold, DefaultIssuerName)
+	if DefaultIssuerDocument != "" {
+		line()
+		page.Text(pdfMargin, y, 10, pdf.Regular, DefaultIssuerDocument)
+	}
+
+	y += 2 * pdfLineHeight
+	page.Text(pdfMargin, y, 13, pdf.Bold, "Receipt "+invoice.Code())
+	line()
+	page.Text(pdfMargin, y, 10, pdf.Regular, "Order "+invoice.OrderId)
+	line()
+	page.Text(pdfMargin, y, 10, pdf.Regular, fmt.Sprintf("Paid at %s via %s",
+		invoice.PaidAt.Format("02/01/2006 15:04"), invoice.PaymentMethod))
+	if invoice.GatewayTransactionId != "" {
+		line()
+		page.Text(pdfMargin, y, 10, pdf.Regular, "Transaction "+invoice.GatewayTransactionId)
+	}
+
+	y += 2 * pdfLineHeight
+	page.Text(pdfMargin, y, 10, pdf.Bold, "Description")
+	page.TextRight(right-200, y, 10, pdf.Bold, "Qty")
+	page.TextRight(right-100, y, 10, pdf.Bold, "Unit price")
+	page.TextRight(right, y, 10, pdf.Bold, "Total")
+	page.Line(pdfMargin, y+5, right, y+5)
+
+	for _, item := range invoice.Items {
+		line()
+		page.Text(pdfMargin, y, 10, pdf.Regular, item.Description)
+		page.TextRight(right-200, y, 10, pdf.Regular, fmt.Sprintf("%d", item.Quantity))
+		page.TextRight(right-100, y, 10, pdf.Regular, FormatAmount(item.UnitPrice))
+		page.TextRight(right, y, 10, pdf.Regular, FormatAmount(item.Total))
+	}
+
+	page.Line(pdfMargin, y+5, right, y+5)
+
+	line()
+	page.TextRight(right-100, y, 10, pdf.Regular, "Subtotal")
+	page.TextRight(right, y, 10, pdf.Regular, FormatAmount(invoice.Subtotal))
+
+	if invoice.Discount > 0 {
+		line()
+		page.TextRight(right-100, y, 10, pdf.Regular, "Discount")
+		page.TextRight(right, y, 10, pdf.Regular, "-"+FormatAmount(invoice.Discount))
+	}
+
+	line()
+	page.TextRight(right-100, y, 11, pdf.Bold, "Total")
+	page.TextRight(right, y, 11, pdf.Bold, FormatAmount(invoice.Total))
+
+	return doc.Bytes()
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/invoice"
+	"github.com/jmoiron/sqlx"
+)
+
+type invoiceRepository struct {
+	db *sqlx.DB
+}
+
+func NewInvoiceRepository(db *sqlx.DB) invoice.Repository {
+	return &invoiceRepository{
+		db: db,
+	}
+}
+
+type invoiceRow struct {
+	Id                   string       `db:"id"`
+	Number               int64        `db:"number"`
+	OrderId              string       `db:"order_id"`
+	AccountId            string       `db:"account_id"`
+	OrderType            string       `db:"order_type"`
+	PaymentMethod        string       `db:"payment_method"`
+	GatewayTransactionId string       `db:"gateway_transaction_id"`
+	Items                []byte       `db:"items"`
+	Subtotal             int64        `db:"subtotal"`
+	Discount             int64        `db:"discount"`
+	Total                int64        `db:"total"`
+	PaidAt               time.Time    `db:"paid_at"`
+	IssuedAt             time.Time    `db:"issued_at"`
+	EmailedAt            sql.NullTime `db:"emailed_at"`
+}
+
+func (r *invoiceRow) toInvoice() (*invoice.Invoice, error) {
+	out := invoice.Invoice{
+		Id:                   r.Id,
+		Number:               r.Number,
+		OrderId:              r.OrderId,
+		AccountId:            r.AccountId,
+		OrderType:            r.OrderType,
+		PaymentMethod:        r.PaymentMethod,
+		GatewayTransactionId: r.GatewayTransactionId,
+		Subtotal:             r.Subtotal,
+		Discount:             r.Discount,
+		Total:                r.Total,
+		PaidAt:               r.PaidAt,
+		IssuedAt:             r.IssuedAt,
+	}
+
+	if err := json.Unmarshal(r.Items, &out.Items); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	if r.EmailedAt.Valid {
+		emailedAt := r.EmailedAt.Time
+		out.EmailedAt = &emailedAt
+	}
+
+	return &out, nil
+}
+
+func (r *invoiceRepository) Create(ctx context.Context, inv *invoice.Invoice) error {
+	items, err := json.Marshal(inv.Items)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("r.db.BeginTxx: %w", err)
+	}
+	defer tx.Rollback()
+
+	// the counter row stays locked until commit, which also serializes
+	// concurrent attempts to invoice the same order
+	var number int64
+
+	err = tx.GetContext(ctx, &number, `UPDATE invoice_numbers SET last_number = last_number + 1 RETURNING last_number`)
+	if err != nil {
+		return fmt.Errorf("tx.GetContext: %w", err)
+	}
+
+	var exists bool
+
+	if err := tx.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM invoices WHERE order_id = $1)`, inv.OrderId); err != nil {
+		return fmt.Errorf("tx.GetContext: %w", err)
+	}
+
+	if exists {
+		return invoice.ErrInvoiceExists
+	}
+
+	const query = `INSERT INTO invoices
+	(id, number, order_id, account_id, order_type, payment_method, gateway_transaction_id,
+	items, subtotal, discount, total, paid_at, issued_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+
+	_, err = tx.ExecContext(ctx, query,
+		inv.Id,
+		number,
+		inv.OrderId,
+		inv.AccountId,
+		inv.OrderType,
+		inv.PaymentMethod,
+		inv.GatewayTransactionId,
+		items,
+		inv.Subtotal,
+		inv.Discount,
+		inv.Total,
+		inv.PaidAt,
+		inv.IssuedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("tx.ExecContext: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("tx.Commit: %w", err)
+	}
+
+	inv.Number = number
+
+	return nil
+}
+
+func (r *invoiceRepository) FindByOrder(ctx context.Context, orderId string) (*invoice.Invoice, error) {
+	const query = `SELECT id, number, order_id, account_id, order_type, payment_method, gateway_transaction_id,
+	items, subtotal, discount, total, paid_at, issued_at, emailed_at
+	FROM invoices WHERE order_id = $1`
+
+	var row invoiceRow
+
+	if err := r.db.GetContext(ctx, &row, query, orderId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, invoice.ErrInvoiceNotFound
+		}
+
+		return nil, fmt.Errorf("r.db.GetContext: %w", err)
+	}
+
+	return row.toInvoice()
+}
+
+func (r *invoiceRepository) MarkEmailed(ctx context.Context, id string, at time.Time) error {
+	if _, err := r.db.ExecContext(ctx, `UPDATE invoices SET emailed_at = $2 WHERE id = $1`, id, at); err != nil {
+		return fmt.Errorf("r.db.ExecContext: %w", err)
+	}
+
+	return nil
+}
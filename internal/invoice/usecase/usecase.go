@@ -0,0 +1,275 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/invoice"
+	"github.com/aclgo/simple-api-gateway/internal/orders"
+	"github.com/aclgo/simple-api-gateway/pkg/logger"
+	protoMail "github.com/aclgo/simple-api-gateway/proto-service/mail"
+	protoOrders "github.com/aclgo/simple-api-gateway/proto-service/orders"
+	protoProduct "github.com/aclgo/simple-api-gateway/proto-service/product"
+	protoUser "github.com/aclgo/simple-api-gateway/proto-service/user"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type invoiceUC struct {
+	repo               invoice.Repository
+	clientOrdersGRPC   protoOrders.ServiceOrderClient
+	clientProductsGRPC protoProduct.ProductServiceClient
+	clientUserGRPC     protoUser.UserServiceClient
+	clientMailGRPC     protoMail.MailServiceClient
+	logger             logger.Logger
+}
+
+func NewInvoiceUC(repo invoice.Repository, clientOrdersGRPC protoOrders.ServiceOrderClient,
+	clientProductsGRPC protoProduct.ProductServiceClient, clientUserGRPC protoUser.UserServiceClient,
+	clientMailGRPC protoMail.MailServiceClient, logger logger.Logger) invoice.Invoicing {
+	return &invoiceUC{
+		repo:               repo,
+		clientOrdersGRPC:   clientOrdersGRPC,
+		clientProductsGRPC: clientProductsGRPC,
+		clientUserGRPC:     clientUserGRPC,
+		clientMailGRPC:     clientMailGRPC,
+		logger:             logger,
+	}
+}
+
+func (u *invoiceUC) Issue(ctx context.Context, orderId string) error {
+	_, err := u.repo.FindByOrder(ctx, orderId)
+	if err == nil {
+		return nil
+	}
+
+	if !errors.Is(err, invoice.ErrInvoiceNotFound) {
+		return fmt.Errorf("u.repo.FindByOrder: %w", err)
+	}
+
+	order, err := u.findOrder(ctx, orderId)
+	if err != nil {
+		return err
+	}
+
+	_, err = u.issue(ctx, order)
+
+	return err
+}
+
+func (u *invoiceUC) issue(ctx context.Context, order *protoOrders.Orders) (*invoice.Invoice, error) {
+	if order.Status != protoOrders.OrderStatus_PAID {
+		return nil, invoice.ErrOrderNotPaid
+	}
+
+	// money moved between customers or out of the gateway, nothing was sold
+	if order.Type == protoOrders.OrderType_WALLET_TRANSFER || order.Type == protoOrders.OrderType_WITHDRAWAL {
+		return nil, invoice.ErrNotInvoiceable
+	}
+
+	inv, err := u.newInvoice(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.repo.Create(ctx, inv); err != nil {
+		if errors.Is(err, invoice.ErrInvoiceExists) {
+			return u.repo.FindByOrder(ctx, order.OrderID)
+		}
+
+		return nil, fmt.Errorf("u.repo.Create: %w", err)
+	}
+
+	// the invoice is issued either way, the receipt stays downloadable
+	if err := u.sendReceipt(ctx, inv); err != nil {
+		u.logger.Errorf("u.sendReceipt: invoice %s: %v", inv.Code(), err)
+	}
+
+	return inv, nil
+}
+
+func (u *invoiceUC) Receipt(ctx context.Context, params *invoice.ParamsReceiptInput) (*invoice.Receipt, error) {
+	order, err := u.findOrder(ctx, params.OrderId)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.UserId != "" && order.AccountID != params.UserId {
+		return nil, orders.ErrOrderNotFound
+	}
+
+	inv, err := u.repo.FindByOrder(ctx, order.OrderID)
+	if err != nil {
+		if !errors.Is(err, invoice.ErrInvoiceNotFound) {
+			return nil, fmt.Errorf("u.repo.FindByOrder: %w", err)
+		}
+
+		// orders paid before invoices existed, or whose issue failed
+		inv, err = u.issue(ctx, order)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	receipt := invoice.Receipt{
+		Filename: "receipt-" + inv.Code() + "." + params.Format,
+	}
+
+	switch params.Format {
+	case invoice.FormatHTML:
+		receipt.ContentType = "text/html; charset=utf-8"
+		receipt.Content, err = invoice.RenderHTML(inv)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		receipt.ContentType = "application/pdf"
+		receipt.Content = invoice.RenderPDF(inv)
+	}
+
+	return &receipt, nil
+}
+
+func (u *invoiceUC) findOrder(ctx context.Context, orderId string) (*protoOrders.Orders, error) {
+	find, err := u.clientOrdersGRPC.Find(ctx, &protoOrders.ParamFindOrderRequest{OrderID: orderId})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, orders.ErrOrderNotFound
+		}
+
+		return nil, fmt.Errorf("u.clientOrdersGRPC.Find: %w", err)
+	}
+
+	if find.Order == nil {
+		return nil, orders.ErrOrderNotFound
+	}
+
+	return find.Order, nil
+}
+
+func (u *invoiceUC) sendReceipt(ctx context.Context, inv *invoice.Invoice) error {
+	found, err := u.clientUserGRPC.FindById(ctx, &protoUser.FindByIdRequest{Id: inv.AccountId})
+	if err != nil {
+		return fmt.Errorf("u.clientUserGRPC.FindById: %w", err)
+	}
+
+	body, err := invoice.RenderHTMLBody(inv)
+	if err != nil {
+		return err
+	}
+
+	req := &protoMail.MailRequest{
+		From:        invoice.DefaultFromSendMail,
+		To:          found.User.Email,
+		Subject:     fmt.Sprintf(invoice.DefaultSubjectSendReceipt, inv.Code()),
+		Body:        body,
+		Template:    invoice.DefaultTemplateSendReceipt,
+		Servicename: invoice.DefaultServiceName,
+	}
+
+	if _, err := u.clientMailGRPC.SendService(ctx, req); err != nil {
+		return fmt.Errorf("u.clientMailGRPC.SendService: %w", err)
+	}
+
+	if err := u.repo.MarkEmailed(ctx, inv.Id, time.Now()); err != nil {
+		return fmt.Errorf("u.repo.MarkEmailed: %w", err)
+	}
+
+	return nil
+}
+
+// newInvoice describes what was paid for from the order metadata. The total
+// is always the amount charged; the subtotal adds the discount back.
+func (u *invoiceUC) newInvoice(ctx context.Context, order *protoOrders.Orders) (*invoice.Invoice, error) {
+	inv := invoice.Invoice{
+		Id:                   uuid.NewString(),
+		OrderId:              order.OrderID,
+		AccountId:            order.AccountID,
+		OrderType:            order.Type.String(),
+		PaymentMethod:        order.PaymentMethod.String(),
+		GatewayTransactionId: order.GatewayTransactionID,
+		Total:                order.Amount,
+		PaidAt:               order.CreatedAT.AsTime(),
+		IssuedAt:             time.Now(),
+	}
+
+	if order.UpdatedAT.GetSeconds() != 0 {
+		inv.PaidAt = order.UpdatedAT.AsTime()
+	}
+
+	var discount *orders.OrderDiscount
+
+	switch order.Type {
+	case protoOrders.OrderType_PRODUCT_PURCHASE:
+		metadata, err := orders.DecodeProductOrderMetadata(order.Metadata)
+		if err != nil {
+			return nil, err
+		}
+
+		discount = metadata.Discount
+
+		for _, product := range metadata.Products {
+			total := product.Subtotal
+			if total == 0 {
+				total = product.UnitPrice * product.Quantity
+			}
+
+			inv.Items = append(inv.Items, invoice.Item{
+				Description: u.productName(ctx, product.Id),
+				Quantity:    product.Quantity,
+				UnitPrice:   product.UnitPrice,
+				Total:       total,
+			})
+		}
+	case protoOrders.OrderType_PREMIUM_SUBSCRIPTION:
+		var metadata orders.ParamsSaveSubscriptionMetadata
+		if err := json.Unmarshal(order.Metadata, &metadata); err != nil {
+			return nil, fmt.Errorf("json.Unmarshal: %w", err)
+		}
+
+		discount = metadata.Discount
+
+		description := "Premium subscription, plan " + metadata.Plan
+		if metadata.Days > 0 {
+			description += fmt.Sprintf(" (%d days)", metadata.Days)
+		}
+
+		inv.Items = append(inv.Items, invoice.Item{Description: description, Quantity: 1})
+	case protoOrders.OrderType_BALANCE_DEPOSIT:
+		var metadata orders.ParamsSaveBalanceMetadata
+		if err := json.Unmarshal(order.Metadata, &metadata); err != nil {
+			return nil, fmt.Errorf("json.Unmarshal: %w", err)
+		}
+
+		discount = metadata.Discount
+
+		inv.Items = append(inv.Items, invoice.Item{Description: "Wallet top-up", Quantity: 1})
+	}
+
+	if discount != nil {
+		inv.Discount = discount.Discount
+	}
+
+	inv.Subtotal = inv.Total + inv.Discount
+
+	// single line orders carry no price of their own
+	if len(inv.Items) == 1 && inv.Items[0].Total == 0 {
+		inv.Items[0].UnitPrice = inv.Subtotal
+		inv.Items[0].Total = inv.Subtotal
+	}
+
+	return &inv, nil
+}
+
+func (u *invoiceUC) productName(ctx context.Context, productId string) string {
+	found, err := u.clientProductsGRPC.Find(ctx, &protoProduct.ProductFindRequest{Id: productId})
+	if err != nil || found.Product == nil {
+		return "Product " + productId
+	}
+
+	return found.Product.Name
+}
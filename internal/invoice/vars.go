@@ -0,0 +1,31 @@
+package invoice
+
+var (
+	DefaultNumberPrefix   = "INV-"
+	DefaultIssuerName     = "Simple API Gateway"
+	DefaultIssuerDocument = ""
+	DefaultFromSendMail   = ""
+	DefaultServiceName    = "gmail"
+
+	DefaultSubjectSendReceipt  = "Your receipt %s"
+	DefaultTemplateSendReceipt = `
+        <div style="font-family: sans-serif; max-width: 600px; margin: 0 auto;">
+            <h2>Thank you for your purchase!</h2>
+            <p>Your payment was confirmed. Here is your receipt:</p>
+            %s
+            <p style="margin-top: 20px; font-size: 12px; color: #666;">
+               You can download this receipt as PDF at any time from your orders.
+            </p>
+        </div>`
+)
+
+func SetConfigInvoicePackage(defaultFromSendMail string, defaultServiceSendEmailName string, issuerName string, issuerDocument string) {
+	DefaultFromSendMail = defaultFromSendMail
+	DefaultServiceName = defaultServiceSendEmailName
+
+	if issuerName != "" {
+		DefaultIssuerName = issuerName
+	}
+
+	DefaultIssuerDocument = issuerDocument
+}
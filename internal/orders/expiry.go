@@ -0,0 +1,65 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	protoOrders "github.com/aclgo/simple-api-gateway/proto-service/orders"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var ErrOrderExpired = errors.New("order payment window has expired")
+
+// OrderExpiration tracks a pending order until it is paid or its pix or
+// boleto expires, with what has to be given back when it expires.
+type OrderExpiration struct {
+	OrderId       string
+	ExpiresAt     time.Time
+	ReservationId string
+	ProductsIDS   []string
+	RedemptionId  string
+	// Attempts counts the sweeps that failed to expire the order.
+	Attempts int
+}
+
+type ExpirationRepository interface {
+	Create(ctx context.Context, expiration *OrderExpiration) error
+	// Due returns up to limit expirations that passed before now and are not
+	// waiting for a retry, the ones due the longest first.
+	Due(ctx context.Context, now time.Time, limit int) ([]*OrderExpiration, error)
+	// Postpone counts a failed attempt and keeps the expiration out of Due
+	// until next, so it does not hold back the ones behind it.
+	Postpone(ctx context.Context, orderId string, next time.Time) error
+	Delete(ctx context.Context, orderId string) error
+}
+
+// PendingExpirer cancels pending orders whose payment window has closed and
+// returns how many of the due expirations it resolved.
+type PendingExpirer interface {
+	ExpirePending(ctx context.Context, now time.Time, limit int) (int, error)
+}
+
+// PaymentExpiration returns when the pix or boleto of the order stops being
+// payable, or the zero time when it never does.
+func PaymentExpiration(order *protoOrders.Orders) time.Time {
+	switch {
+	case timestampSet(order.PixExpiration):
+		return order.PixExpiration.AsTime()
+	case timestampSet(order.BoletoExpiration):
+		return order.BoletoExpiration.AsTime()
+	}
+
+	return time.Time{}
+}
+
+// timestampSet tells unset timestamps apart from the epoch the orders
+// service may send in their place.
+func timestampSet(ts *timestamppb.Timestamp) bool {
+	return ts != nil && (ts.Seconds != 0 || ts.Nanos != 0)
+}
+
+func PaymentExpired(order *protoOrders.Orders, now time.Time) bool {
+	expiration := PaymentExpiration(order)
+	return !expiration.IsZero() && now.After(expiration)
+}
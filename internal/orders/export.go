@@ -0,0 +1,140 @@
+package orders
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	protoOrders "github.com/aclgo/simple-api-gateway/proto-service/orders"
+)
+
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
+)
+
+// ExportStatuses are the orders finance reconciles: the ones where money
+// was actually received.
+var ExportStatuses = []string{
+	protoOrders.OrderStatus_PAID.String(),
+	protoOrders.OrderStatus_REFUNDED.String(),
+}
+
+// ExportedOrder is one row of the finance export.
+type ExportedOrder struct {
+	OrderId              string    `json:"order_id"`
+	AccountId            string    `json:"account_id"`
+	Type                 string    `json:"type"`
+	Status               string    `json:"status"`
+	PaymentMethod        string    `json:"payment_method"`
+	Amount               int64     `json:"amount"`
+	Discount             int64     `json:"discount"`
+	Refunded             int64     `json:"refunded"`
+	GatewayTransactionId string    `json:"gateway_transaction_id"`
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+// ExportColumns are the CSV columns that can be asked for, by header name.
+var ExportColumns = map[string]func(order *ExportedOrder) string{
+	"order_id":               func(o *ExportedOrder) string { return o.OrderId },
+	"account_id":             func(o *ExportedOrder) string { return o.AccountId },
+	"type":                   func(o *ExportedOrder) string { return o.Type },
+	"status":                 func(o *ExportedOrder) string { return o.Status },
+	"payment_method":         func(o *ExportedOrder) string { return o.PaymentMethod },
+	"amount":                 func(o *ExportedOrder) string { return strconv.FormatInt(o.Amount, 10) },
+	"discount":               func(o *ExportedOrder) string { return strconv.FormatInt(o.Discount, 10) },
+	"refunded":               func(o *ExportedOrder) string { return strconv.FormatInt(o.Refunded, 10) },
+	"net":                    func(o *ExportedOrder) string { return strconv.FormatInt(o.Amount-o.Refunded, 10) },
+	"gateway_transaction_id": func(o *ExportedOrder) string { return o.GatewayTransactionId },
+	"created_at":             func(o *ExportedOrder) string { return formatExportTime(o.CreatedAt) },
+	"updated_at":             func(o *ExportedOrder) string { return formatExportTime(o.UpdatedAt) },
+}
+
+// DefaultExportColumns is the CSV layout used when no columns are asked for.
+var DefaultExportColumns = []string{
+	"order_id",
+	"account_id",
+	"type",
+	"status",
+	"payment_method",
+	"amount",
+	"refunded",
+	"gateway_transaction_id",
+	"created_at",
+}
+
+func formatExportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+// ParamsExportOrdersInput holds the export options as received in the query
+// string; Validate parses them into the typed fields.
+type ParamsExportOrdersInput struct {
+	Format  string
+	From    string
+	To      string
+	Columns string
+
+	FromTime    time.Time
+	ToTime      time.Time
+	ColumnsList []string
+}
+
+func (p *ParamsExportOrdersInput) Validate() error {
+	p.Format = strings.ToLower(p.Format)
+
+	switch p.Format {
+	case "":
+		p.Format = ExportFormatCSV
+	case ExportFormatCSV, ExportFormatNDJSON:
+	default:
+		return errors.New("format invalid")
+	}
+
+	var err error
+
+	if p.FromTime, err = parseSearchDate(p.From, false); err != nil {
+		return errors.New("from invalid")
+	}
+
+	if p.ToTime, err = parseSearchDate(p.To, true); err != nil {
+		return errors.New("to invalid")
+	}
+
+	if !p.FromTime.IsZero() && !p.ToTime.IsZero() && p.ToTime.Before(p.FromTime) {
+		return errors.New("to before from")
+	}
+
+	p.ColumnsList = DefaultExportColumns
+
+	if p.Columns != "" {
+		p.ColumnsList = make([]string, 0)
+
+		for _, column := range strings.Split(p.Columns, ",") {
+			column = strings.ToLower(strings.TrimSpace(column))
+			if _, ok := ExportColumns[column]; !ok {
+				return errors.New("column invalid: " + column)
+			}
+
+			p.ColumnsList = append(p.ColumnsList, column)
+		}
+	}
+
+	return nil
+}
+
+// CSVRecord lays out the order in the asked columns.
+func (p *ParamsExportOrdersInput) CSVRecord(order *ExportedOrder) []string {
+	record := make([]string, 0, len(p.ColumnsList))
+	for _, column := range p.ColumnsList {
+		record = append(record, ExportColumns[column](order))
+	}
+
+	return record
+}
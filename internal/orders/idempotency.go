@@ -0,0 +1,72 @@
+package orders
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+// MinIdempotencyLease is the shortest a key may stay reserved: the longest
+// saga, plus a minute for the calls made before it starts.
+const MinIdempotencyLease = BuyProductSagaTimeout + time.Minute
+
+var (
+	ErrIdempotencyKeyConflict   = errors.New("idempotency key already used with a different request")
+	ErrIdempotencyKeyInProgress = errors.New("request with this idempotency key is still being processed")
+	ErrIdempotencyKeyInvalid    = errors.New("idempotency key invalid")
+	ErrIdempotencyLeaseLost     = errors.New("idempotency key no longer reserved by this request")
+)
+
+type IdempotencyStatus string
+
+const (
+	IdempotencyProcessing IdempotencyStatus = "processing"
+	IdempotencyCompleted  IdempotencyStatus = "completed"
+)
+
+// IdempotencyRecord keeps the hash of the first request made with a key and,
+// once it finishes, the response that is replayed to repeated requests.
+// LeaseToken identifies the request holding the key, so it can only finish
+// its own reservation.
+type IdempotencyRecord struct {
+	RequestHash string            `json:"request_hash"`
+	LeaseToken  string            `json:"lease_token"`
+	Status      IdempotencyStatus `json:"status"`
+	StatusCode  int               `json:"status_code"`
+	Response    []byte            `json:"response"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+type IdempotencyRepository interface {
+	// Reserve stores a processing record for key when none exists, leased for
+	// as long as a request may take. When the key is already taken it returns
+	// the stored record and false.
+	Reserve(ctx context.Context, key string, record *IdempotencyRecord) (*IdempotencyRecord, bool, error)
+	// Complete stores the final response and keeps it for the full TTL.
+	// Complete and Release return ErrIdempotencyLeaseLost when the key is no
+	// longer held with the record's lease token.
+	Complete(ctx context.Context, key string, record *IdempotencyRecord) error
+	Release(ctx context.Context, key string, record *IdempotencyRecord) error
+}
+
+func FormatIdempotencyKeyRepository(accountId, key string) string {
+	return fmt.Sprintf("orders_idempotency:%s:%s", accountId, key)
+}
+
+func HashIdempotencyRequest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+func ValidateIdempotencyKey(key string) error {
+	if len(key) == 0 || len(key) > 255 {
+		return ErrIdempotencyKeyInvalid
+	}
+
+	return nil
+}
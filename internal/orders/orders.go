@@ -1,23 +1,30 @@
 package orders
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"time"
 
+	"github.com/aclgo/simple-api-gateway/internal/catalog"
 	"github.com/aclgo/simple-api-gateway/internal/domain/models"
+	"github.com/aclgo/simple-api-gateway/internal/promotion"
+	"github.com/aclgo/simple-api-gateway/internal/subscription"
 	"github.com/google/uuid"
 )
 
 type OrderCreateAction string
 
 var (
-	ErrAmountInvalid                   = errors.New("amount invalid")
-	ErrPlanInvalid                     = errors.New("plan invalid")
-	BuyProduct       OrderCreateAction = "buy-product"
-	NewSubscription  OrderCreateAction = "new-subscription"
-	AddBalance       OrderCreateAction = "add-balance"
+	ErrAmountInvalid                     = errors.New("amount invalid")
+	ErrPlanInvalid                       = errors.New("plan invalid")
+	ErrQuantityInvalid                   = fmt.Errorf("quantity product invalid, must be between 1 and %d", MaxItemQuantity)
+	ErrTooManyProducts                   = fmt.Errorf("order takes at most %d different products", MaxOrderProducts)
+	BuyProduct         OrderCreateAction = "buy-product"
+	NewSubscription    OrderCreateAction = "new-subscription"
+	AddBalance         OrderCreateAction = "add-balance"
 )
 
 type Orders interface {
@@ -26,17 +33,42 @@ type Orders interface {
 	CreateSubscriptionOrExtend(ctx context.Context,
 		params *ParamsCreateOrderSubscriptionInput) (*ParamsCreateOrderSubscriptionOutput, error)
 	FindById(context.Context, *OrderFindByIdInput) (*OrderFindByIdOutput, error)
-	FindByAccount(context.Context, *OrderByAccountInput) ([]*OrderByAccountOutput, error)
-	FindByProduct(context.Context, *OrderByProductInput) ([]*OrderByProductOutput, error)
+	FindByAccount(context.Context, *ParamsSearchOrdersInput) (*ParamsSearchOrdersOutput, error)
+	FindByProduct(context.Context, *ParamsSearchOrdersInput) (*ParamsSearchOrdersOutput, error)
+	Search(context.Context, *ParamsSearchOrdersInput) (*ParamsSearchOrdersOutput, error)
+	Export(ctx context.Context, params *ParamsExportOrdersInput, emit func(*ExportedOrder) error) error
 	AddBalance(ctx context.Context, params *ParamsAddBalanceInput) (*ParamsAddBalanceOutput, error)
+	Cancel(ctx context.Context, params *ParamsCancelOrderInput) (*ParamsCancelOrderOutput, error)
+	Refund(ctx context.Context, params *ParamsRefundOrderInput) (*ParamsRefundOrderOutput, error)
+	History(ctx context.Context, params *ParamsOrderHistoryInput) ([]*StatusChange, error)
 }
 
 type PaymentGateway interface {
 	GeneratePayment(ctx context.Context, params *models.ParamPaymentProcessInput) (*models.ParamPaymentProcessOutput, error)
+	RefundPayment(ctx context.Context, params *models.ParamPaymentRefundInput) error
+	// LinkAttempts files the attempts made for a payment under its order.
+	LinkAttempts(ctx context.Context, referenceId string, orderId string) error
+}
+
+type PromotionInterface interface {
+	ApplyCoupon(ctx context.Context, params *promotion.ParamsApplyCouponInput) (*promotion.ParamsApplyCouponOutput, error)
+	ReleaseCoupon(ctx context.Context, redemptionId string) error
+}
+
+type CatalogInterface interface {
+	FindPlan(ctx context.Context, code string) (*catalog.Plan, error)
+	PlanTerm(ctx context.Context, code string, days int64) (int64, int64, error)
+	ValidateTopUp(ctx context.Context, amount int64) error
+}
+
+type InvoiceInterface interface {
+	Issue(ctx context.Context, orderId string) error
 }
 
 type SubscriptionInterface interface {
 	ActivateSubscription(context.Context, *models.ParamsActivateSubscriptionInput) (*models.ParamsActivateSubscriptionOutput, error)
+	CancelSubscription(context.Context, *subscription.ParamsCancelSubscriptionInput) (*subscription.ParamsCancelSubscriptionOutput, error)
+	ShortenSubscription(context.Context, *subscription.ParamsShortenSubscriptionInput) (*subscription.ParamsShortenSubscriptionOutput, error)
 }
 
 type ParamPaymentProcessInput struct {
@@ -50,13 +82,144 @@ type ParamsCreateOrderAction struct {
 	Payload json.RawMessage `json:"payload"`
 }
 
+const (
+	// MaxItemQuantity caps the units of a single product in one order.
+	MaxItemQuantity = 100
+	// MaxOrderProducts caps the different products of one order, as each
+	// of them adds a step to the saga that places it.
+	MaxOrderProducts = 20
+)
+
+// ProductItem is a line of a product order. Quantity defaults to 1 and the
+// unit price is captured when the order is placed, so later price changes do
+// not alter historical orders.
 type ProductItem struct {
-	Id string `json:"product_id"`
+	Id        string `json:"product_id"`
+	Quantity  int64  `json:"quantity"`
+	UnitPrice int64  `json:"unit_price"`
+	Subtotal  int64  `json:"subtotal"`
+}
+
+// OrderDiscount records the coupon applied to an order.
+type OrderDiscount struct {
+	CouponId     string `json:"coupon_id"`
+	Code         string `json:"code"`
+	RedemptionId string `json:"redemption_id"`
+	Discount     int64  `json:"discount"`
+}
+
+type ProductOrderMetadata struct {
+	Products []ProductItem  `json:"products"`
+	Discount *OrderDiscount `json:"discount,omitempty"`
+}
+
+// DecodeProductOrderMetadata reads the metadata of a product order. Orders
+// placed before discounts existed stored a bare list of items, and the ones
+// placed before quantities existed hold one unit per line and no price.
+func DecodeProductOrderMetadata(metadata []byte) (*ProductOrderMetadata, error) {
+	out := ProductOrderMetadata{Products: make([]ProductItem, 0)}
+
+	metadata = bytes.TrimSpace(metadata)
+	if len(metadata) == 0 {
+		return &out, nil
+	}
+
+	var err error
+	if metadata[0] == '[' {
+		err = json.Unmarshal(metadata, &out.Products)
+	} else {
+		err = json.Unmarshal(metadata, &out)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	for i := range out.Products {
+		if out.Products[i].Quantity == 0 {
+			out.Products[i].Quantity = 1
+		}
+	}
+
+	return &out, nil
+}
+
+const metadataKeyPaymentProvider = "payment_provider"
+
+// WithPaymentProvider records in the metadata of an order the provider that
+// took its payment, so refunds go back through it.
+func WithPaymentProvider(metadata []byte, provider string) ([]byte, error) {
+	if provider == "" {
+		return metadata, nil
+	}
+
+	fields := make(map[string]json.RawMessage)
+
+	trimmed := bytes.TrimSpace(metadata)
+	if len(trimmed) > 0 && !bytes.Equal(trimmed, []byte("null")) {
+		// only objects have room for it
+		if trimmed[0] != '{' {
+			return metadata, nil
+		}
+
+		if err := json.Unmarshal(trimmed, &fields); err != nil {
+			return nil, fmt.Errorf("json.Unmarshal: %w", err)
+		}
+	}
+
+	encoded, err := json.Marshal(provider)
+	if err != nil {
+		return nil, fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	fields[metadataKeyPaymentProvider] = encoded
+
+	return json.Marshal(fields)
+}
+
+// PaymentProvider returns the provider recorded by WithPaymentProvider, or
+// an empty string for orders that have none.
+func PaymentProvider(metadata []byte) string {
+	var fields struct {
+		Provider string `json:"payment_provider"`
+	}
+
+	trimmed := bytes.TrimSpace(metadata)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return ""
+	}
+
+	if err := json.Unmarshal(trimmed, &fields); err != nil {
+		return ""
+	}
+
+	return fields.Provider
+}
+
+// Totals returns the items subtotal, the discount and the amount charged.
+func (m *ProductOrderMetadata) Totals() (subtotal, discount, total, totalItems int64) {
+	subtotal, totalItems = SumProductItems(m.Products)
+
+	if m.Discount != nil {
+		discount = m.Discount.Discount
+	}
+
+	return subtotal, discount, subtotal - discount, totalItems
+}
+
+func SumProductItems(items []ProductItem) (total int64, totalItems int64) {
+	for _, item := range items {
+		total += item.Subtotal
+		totalItems += item.Quantity
+	}
+
+	return total, totalItems
 }
 
 type ParamBuyProductInput struct {
 	UserId      string        `json:"user_id"`
 	ProductsIDS []ProductItem `json:"products"`
+	CouponCode  string        `json:"coupon_code"`
 }
 
 func (o *ParamBuyProductInput) Validate() error {
@@ -68,21 +231,37 @@ func (o *ParamBuyProductInput) Validate() error {
 		return errors.New("invalid uuid account")
 	}
 
+	if len(o.ProductsIDS) == 0 {
+		return errors.New("products empty")
+	}
+
 	for i := range o.ProductsIDS {
 		if _, err := uuid.Parse(o.ProductsIDS[i].Id); err != nil {
 			return errors.New("invalid uuid product")
 		}
+
+		if o.ProductsIDS[i].Quantity == 0 {
+			o.ProductsIDS[i].Quantity = 1
+		}
+
+		if o.ProductsIDS[i].Quantity < 0 || o.ProductsIDS[i].Quantity > MaxItemQuantity {
+			return ErrQuantityInvalid
+		}
 	}
 
 	return nil
 }
 
 type OrderCreateOutput struct {
-	OrderId       string        `json:"order_id"`
-	AccountId     string        `json:"account_id"`
-	PaymentMethod string        `json:"payment_method"`
-	ProductsIDS   []ProductItem `json:"products"`
-	CreatedAt     time.Time     `json:"created_at"`
+	OrderId       string         `json:"order_id"`
+	AccountId     string         `json:"account_id"`
+	PaymentMethod string         `json:"payment_method"`
+	ProductsIDS   []ProductItem  `json:"products"`
+	TotalItems    int64          `json:"total_items"`
+	Subtotal      int64          `json:"subtotal"`
+	Discount      *OrderDiscount `json:"discount,omitempty"`
+	Total         int64          `json:"total"`
+	CreatedAt     time.Time      `json:"created_at"`
 }
 
 type OrderFindByIdInput struct {
@@ -102,63 +281,33 @@ func (o *OrderFindByIdInput) Validate() error {
 }
 
 type OrderFindByIdOutput struct {
-	OrderId     string        `json:"order_id"`
-	AccountId   string        `json:"account_id"`
-	ProductsIDS []ProductItem `json:"products"`
-	CreatedAt   time.Time     `json:"created_at"`
-}
-
-type OrderByAccountInput struct {
-	AccountId string `json:"account_id"`
-}
-
-func (o *OrderByAccountInput) Validate() error {
-	if o.AccountId == "" {
-		return errors.New("account id empty")
-	}
-
-	if _, err := uuid.Parse(o.AccountId); err != nil {
-		return errors.New("invalid uuid account")
-	}
-	return nil
-}
-
-type OrderByAccountOutput struct {
-	OrderId     string        `json:"order_id"`
-	AccountId   string        `json:"account_id"`
-	ProductsIDS []ProductItem `json:"products"`
-	CreatedAt   time.Time     `json:"created_at"`
-}
-
-type OrderByProductInput struct {
-	ProductId string `json:"product_id"`
-}
-
-func (o *OrderByProductInput) Validate() error {
-	if o.ProductId == "" {
-		return errors.New("product id empty")
-	}
-
-	if _, err := uuid.Parse(o.ProductId); err != nil {
-		return errors.New("invalid uuid product")
-	}
-	return nil
+	OrderId     string         `json:"order_id"`
+	AccountId   string         `json:"account_id"`
+	Type        string         `json:"type"`
+	Status      string         `json:"status"`
+	Amount      int64          `json:"amount"`
+	ProductsIDS []ProductItem  `json:"products"`
+	TotalItems  int64          `json:"total_items"`
+	Subtotal    int64          `json:"subtotal"`
+	Discount    *OrderDiscount `json:"discount,omitempty"`
+	Total       int64          `json:"total"`
+	CreatedAt   time.Time      `json:"created_at"`
 }
 
-type OrderByProductOutput struct {
-	OrderId     string    `json:"order_id"`
-	AccountId   string    `json:"account_id"`
-	ProductsIDS []string  `json:"products"`
-	CreatedAt   time.Time `json:"created_at"`
-}
-
-type SagaWorker interface {
-	AppendTask(task *CompensationTask)
-}
-
-type CompensationTask struct {
-	Compensations []func(context.Context) error
-	OriginalErr   error
+// OrderSummaryOutput is one order in a search result.
+type OrderSummaryOutput struct {
+	OrderId       string         `json:"order_id"`
+	AccountId     string         `json:"account_id"`
+	Type          string         `json:"type"`
+	Status        string         `json:"status"`
+	PaymentMethod string         `json:"payment_method"`
+	Amount        int64          `json:"amount"`
+	ProductsIDS   []ProductItem  `json:"products"`
+	TotalItems    int64          `json:"total_items"`
+	Subtotal      int64          `json:"subtotal"`
+	Discount      *OrderDiscount `json:"discount,omitempty"`
+	Total         int64          `json:"total"`
+	CreatedAt     time.Time      `json:"created_at"`
 }
 
 type ParamsCreateOrderSubscriptionInput struct {
@@ -168,6 +317,7 @@ type ParamsCreateOrderSubscriptionInput struct {
 	Days           int64  `json:"days"`
 	CardToken      string `json:"card_token"`
 	CardExpiration string `json:"card_expiration"`
+	CouponCode     string `json:"coupon_code"`
 }
 
 func (p *ParamsCreateOrderSubscriptionInput) Validate() error {
@@ -189,12 +339,17 @@ type ParamsCreateOrderSubscriptionOutput struct {
 	OrderID              string                                   `json:"order_id"`
 	Status               string                                   `json:"status"`
 	PaymentMethod        string                                   `json:"payment_method"`
+	Amount               int64                                    `json:"amount"`
+	Discount             *OrderDiscount                           `json:"discount,omitempty"`
 	SubscriptionData     *models.ParamsActivateSubscriptionOutput `json:"subscription_data,omitempty"`
+	FailureReason        string                                   `json:"failure_reason,omitempty"`
 	GatewayTransactionID string                                   `json:"gateway_transaction_id"`
+	CardRedirectURL      string                                   `json:"card_redirect_url,omitempty"`
 	PixQRCode            string                                   `json:"pix_qr_code"`
 	PixExpiration        time.Time                                `json:"pix_expiration"`
 	BoletoURL            string                                   `json:"boleto_url"`
 	BoletoBarcode        string                                   `json:"boleto_bar_code"`
+	BoletoDigitableLine  string                                   `json:"boleto_digitable_line,omitempty"`
 	BoletoExpiration     time.Time                                `json:"boleto_expiration"`
 }
 
@@ -204,6 +359,7 @@ type ParamsAddBalanceInput struct {
 	Amount         int64  `json:"amount"`
 	CardToken      string `json:"card_token"`
 	CardExpiration string `json:"card_expiration"`
+	CouponCode     string `json:"coupon_code"`
 }
 
 func (p *ParamsAddBalanceInput) Validate() error {
@@ -211,19 +367,30 @@ func (p *ParamsAddBalanceInput) Validate() error {
 }
 
 type ParamsAddBalanceOutput struct {
-	OrderID              string    `json:"order_id"`
-	PaymentMethod        string    `json:"payment_method"`
-	Status               string    `json:"status"`
-	GatewayTransactionID string    `json:"gateway_transaction_id"`
-	PixQRCode            string    `json:"pix_qr_code"`
-	PixExpiration        time.Time `json:"pix_expiration"`
-	BoletoURL            string    `json:"boleto_url"`
-	BoletoBarcode        string    `json:"boleto_bar_code"`
-	BoletoExpiration     time.Time `json:"boleto_expiration"`
+	OrderID              string         `json:"order_id"`
+	PaymentMethod        string         `json:"payment_method"`
+	Status               string         `json:"status"`
+	Amount               int64          `json:"amount"`
+	Discount             *OrderDiscount `json:"discount,omitempty"`
+	FailureReason        string         `json:"failure_reason,omitempty"`
+	GatewayTransactionID string         `json:"gateway_transaction_id"`
+	CardRedirectURL      string         `json:"card_redirect_url,omitempty"`
+	PixQRCode            string         `json:"pix_qr_code"`
+	PixExpiration        time.Time      `json:"pix_expiration"`
+	BoletoURL            string         `json:"boleto_url"`
+	BoletoBarcode        string         `json:"boleto_bar_code"`
+	BoletoDigitableLine  string         `json:"boleto_digitable_line,omitempty"`
+	BoletoExpiration     time.Time      `json:"boleto_expiration"`
 }
 
 type ParamsSaveSubscriptionMetadata struct {
-	UserId string `json:"user_id"`
-	Plan   string `json:"plan"`
-	Days   int64  `json:"days"`
+	UserId   string         `json:"user_id"`
+	Plan     string         `json:"plan"`
+	Days     int64          `json:"days"`
+	Discount *OrderDiscount `json:"discount,omitempty"`
+}
+
+type ParamsSaveBalanceMetadata struct {
+	Amount   int64          `json:"amount"`
+	Discount *OrderDiscount `json:"discount,omitempty"`
 }
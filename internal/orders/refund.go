@@ -0,0 +1,113 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrOrderNotFound             = errors.New("order not found")
+	ErrOrderNotCancellable       = errors.New("only pending orders can be cancelled, withdrawals are rejected by an admin")
+	ErrOrderNotRefundable        = errors.New("only paid orders can be refunded, wallet transfers and withdrawals cannot")
+	ErrRefundAmountInvalid       = errors.New("refund amount invalid")
+	ErrRefundExceedsAmount       = errors.New("refund exceeds the amount left on the order")
+	ErrRefundInsufficientBalance = errors.New("wallet balance is lower than the deposit being refunded")
+)
+
+const maxReasonLength = 500
+
+type RefundRepository interface {
+	// Create stores the refund unless the refunds of the order would add up
+	// to more than orderAmount, in which case ErrRefundExceedsAmount is returned.
+	Create(ctx context.Context, refund *Refund, orderAmount int64) error
+	Delete(ctx context.Context, id string) error
+	ListByOrder(ctx context.Context, orderId string) ([]*Refund, error)
+	// OrdersByRefund maps the given refund ids to their order. Unknown ids
+	// are left out.
+	OrdersByRefund(ctx context.Context, ids []string) (map[string]string, error)
+}
+
+type Refund struct {
+	Id         string    `json:"refund_id"`
+	OrderId    string    `json:"order_id"`
+	Amount     int64     `json:"amount"`
+	Reason     string    `json:"reason"`
+	RefundedBy string    `json:"refunded_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func SumRefunds(refunds []*Refund) (total int64) {
+	for _, refund := range refunds {
+		total += refund.Amount
+	}
+
+	return total
+}
+
+type ParamsCancelOrderInput struct {
+	OrderId string `json:"-"`
+	UserId  string `json:"-"`
+	Reason  string `json:"reason"`
+}
+
+func (p *ParamsCancelOrderInput) Validate() error {
+	if _, err := uuid.Parse(p.OrderId); err != nil {
+		return errors.New("invalid uuid order")
+	}
+
+	if p.UserId == "" {
+		return errors.New("user id empty")
+	}
+
+	if len(p.Reason) > maxReasonLength {
+		return errors.New("cancel reason too long")
+	}
+
+	return nil
+}
+
+type ParamsCancelOrderOutput struct {
+	OrderId string `json:"order_id"`
+	Status  string `json:"status"`
+}
+
+// ParamsRefundOrderInput refunds Amount of a paid order. A zero amount
+// refunds whatever is left on the order.
+type ParamsRefundOrderInput struct {
+	OrderId    string `json:"-"`
+	Amount     int64  `json:"amount"`
+	Reason     string `json:"reason"`
+	RefundedBy string `json:"-"`
+}
+
+func (p *ParamsRefundOrderInput) Validate() error {
+	if _, err := uuid.Parse(p.OrderId); err != nil {
+		return errors.New("invalid uuid order")
+	}
+
+	if p.Amount < 0 {
+		return ErrRefundAmountInvalid
+	}
+
+	if p.Reason == "" {
+		return errors.New("refund reason empty")
+	}
+
+	if len(p.Reason) > maxReasonLength {
+		return errors.New("refund reason too long")
+	}
+
+	return nil
+}
+
+type ParamsRefundOrderOutput struct {
+	OrderId   string  `json:"order_id"`
+	Status    string  `json:"status"`
+	Amount    int64   `json:"amount"`
+	Refunded  int64   `json:"refunded"`
+	Remaining int64   `json:"remaining"`
+	Refund    *Refund `json:"refund"`
+}
@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/orders"
+	"github.com/jmoiron/sqlx"
+)
+
+type deadLetterRepository struct {
+	db *sqlx.DB
+}
+
+func NewDeadLetterRepository(db *sqlx.DB) orders.DeadLetterRepository {
+	return &deadLetterRepository{
+		db: db,
+	}
+}
+
+type deadLetterRow struct {
+	Id             string       `db:"id"`
+	TaskId         string       `db:"task_id"`
+	Step           string       `db:"step"`
+	Payload        []byte       `db:"payload"`
+	OriginalErr    string       `db:"original_error"`
+	LastErr        string       `db:"last_error"`
+	Status         string       `db:"status"`
+	Attempts       int          `db:"attempts"`
+	ResolvedBy     string       `db:"resolved_by"`
+	ResolutionNote string       `db:"resolution_note"`
+	ResolvedAt     sql.NullTime `db:"resolved_at"`
+	ClaimedAt      sql.NullTime `db:"claimed_at"`
+	CreatedAt      time.Time    `db:"created_at"`
+	UpdatedAt      time.Time    `db:"updated_at"`
+}
+
+func (r *deadLetterRow) toDeadLetter() *orders.DeadLetter {
+	deadLetter := orders.DeadLetter{
+		Id:             r.Id,
+		TaskId:         r.TaskId,
+		Step:           r.Step,
+		Payload:        r.Payload,
+		OriginalErr:    r.OriginalErr,
+		LastErr:        r.LastErr,
+		Status:         orders.DeadLetterStatus(r.Status),
+		Attempts:       r.Attempts,
+		ResolvedBy:     r.ResolvedBy,
+		ResolutionNote: r.ResolutionNote,
+		CreatedAt:      r.CreatedAt,
+		UpdatedAt:      r.UpdatedAt,
+	}
+
+	if r.ResolvedAt.Valid {
+		resolvedAt := r.ResolvedAt.Time
+		deadLetter.ResolvedAt = &resolvedAt
+	}
+
+	if r.ClaimedAt.Valid {
+		claimedAt := r.ClaimedAt.Time
+		deadLetter.ClaimedAt = &claimedAt
+	}
+
+	return &deadLetter
+}
+
+const deadLetterColumns = `id, task_id, step, payload, original_error, last_error, status, attempts,
+	resolved_by, resolution_note, resolved_at, claimed_at, created_at, updated_at`
+
+func (r *deadLetterRepository) Create(ctx context.Context, deadLetter *orders.DeadLetter) error {
+	const query = `INSERT INTO saga_dead_letters
+	(id, task_id, step, payload, original_error, last_error, status, attempts, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		deadLetter.Id,
+		deadLetter.TaskId,
+		deadLetter.Step,
+		[]byte(deadLetter.Payload),
+		deadLetter.OriginalErr,
+		deadLetter.LastErr,
+		deadLetter.Status,
+		deadLetter.Attempts,
+		deadLetter.CreatedAt,
+		deadLetter.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("r.db.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (r *deadLetterRepository) Update(ctx context.Context, deadLetter *orders.DeadLetter) error {
+	const query = `UPDATE saga_dead_letters
+	SET last_error = $2, status = $3, attempts = $4, resolved_by = $5, resolution_note = $6,
+	resolved_at = $7, updated_at = $8, claimed_at = NULL
+	WHERE id = $1 AND claimed_at IS NOT DISTINCT FROM $9`
+
+	deadLetter.UpdatedAt = time.Now()
+
+	result, err := r.db.ExecContext(ctx, query,
+		deadLetter.Id,
+		deadLetter.LastErr,
+		deadLetter.Status,
+		deadLetter.Attempts,
+		deadLetter.ResolvedBy,
+		deadLetter.ResolutionNote,
+		deadLetter.ResolvedAt,
+		deadLetter.UpdatedAt,
+		deadLetter.ClaimedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("r.db.ExecContext: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("result.RowsAffected: %w", err)
+	}
+
+	if affected == 0 {
+		return orders.ErrDeadLetterNotOpen
+	}
+
+	deadLetter.ClaimedAt = nil
+
+	return nil
+}
+
+func (r *deadLetterRepository) Claim(ctx context.Context, deadLetter *orders.DeadLetter) error {
+	const query = `UPDATE saga_dead_letters SET status = $1, claimed_at = NOW(), updated_at = NOW()
+	WHERE id = $2 AND status <> $3
+	AND (status = $4 OR claimed_at IS NULL OR claimed_at < NOW() - make_interval(secs => $5))
+	RETURNING claimed_at`
+
+	var claimedAt time.Time
+
+	err := r.db.GetContext(ctx, &claimedAt, query,
+		orders.DeadLetterRetrying,
+		deadLetter.Id,
+		orders.DeadLetterResolved,
+		orders.DeadLetterOpen,
+		orders.DeadLetterClaimLease.Seconds(),
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return orders.ErrDeadLetterNotOpen
+		}
+
+		return fmt.Errorf("r.db.GetContext: %w", err)
+	}
+
+	deadLetter.Status = orders.DeadLetterRetrying
+	deadLetter.ClaimedAt = &claimedAt
+
+	return nil
+}
+
+func (r *deadLetterRepository) Find(ctx context.Context, id string) (*orders.DeadLetter, error) {
+	query := `SELECT ` + deadLetterColumns + ` FROM saga_dead_letters WHERE id = $1`
+
+	var row deadLetterRow
+
+	if err := r.db.GetContext(ctx, &row, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, orders.ErrDeadLetterNotFound
+		}
+
+		return nil, fmt.Errorf("r.db.GetContext: %w", err)
+	}
+
+	return row.toDeadLetter(), nil
+}
+
+func (r *deadLetterRepository) List(ctx context.Context, params *orders.ParamsListDeadLettersInput) ([]*orders.DeadLetter, int, error) {
+	const countQuery = `SELECT count(*) FROM saga_dead_letters WHERE ($1 = '' OR status = $1)`
+
+	var total int
+
+	if err := r.db.GetContext(ctx, &total, countQuery, params.Status); err != nil {
+		return nil, 0, fmt.Errorf("r.db.GetContext: %w", err)
+	}
+
+	query := `SELECT ` + deadLetterColumns + ` FROM saga_dead_letters
+	WHERE ($1 = '' OR status = $1) ORDER BY created_at DESC LIMIT $2 OFFSET $3`
+
+	var rows []deadLetterRow
+
+	offset := (params.PageInt - 1) * params.LimitInt
+
+	if err := r.db.SelectContext(ctx, &rows, query, params.Status, params.LimitInt, offset); err != nil {
+		return nil, 0, fmt.Errorf("r.db.SelectContext: %w", err)
+	}
+
+	deadLetters := make([]*orders.DeadLetter, 0, len(rows))
+
+	for i := range rows {
+		deadLetters = append(deadLetters, rows[i].toDeadLetter())
+	}
+
+	return deadLetters, total, nil
+}
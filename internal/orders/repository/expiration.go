@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/orders"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type expirationRepository struct {
+	db *sqlx.DB
+}
+
+func NewExpirationRepository(db *sqlx.DB) orders.ExpirationRepository {
+	return &expirationRepository{
+		db: db,
+	}
+}
+
+type expirationRow struct {
+	OrderId       string         `db:"order_id"`
+	ExpiresAt     time.Time      `db:"expires_at"`
+	ReservationId string         `db:"reservation_id"`
+	ProductsIDS   pq.StringArray `db:"products"`
+	RedemptionId  string         `db:"redemption_id"`
+	Attempts      int            `db:"attempts"`
+}
+
+func (r *expirationRepository) Create(ctx context.Context, expiration *orders.OrderExpiration) error {
+	const query = `INSERT INTO order_expirations (order_id, expires_at, reservation_id, products, redemption_id, next_try_at)
+	VALUES ($1, $2, $3, $4, $5, $2)
+	ON CONFLICT (order_id) DO UPDATE SET expires_at = EXCLUDED.expires_at, next_try_at = EXCLUDED.next_try_at`
+
+	_, err := r.db.ExecContext(ctx, query,
+		expiration.OrderId,
+		expiration.ExpiresAt,
+		expiration.ReservationId,
+		pq.StringArray(expiration.ProductsIDS),
+		expiration.RedemptionId,
+	)
+	if err != nil {
+		return fmt.Errorf("r.db.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (r *expirationRepository) Due(ctx context.Context, now time.Time, limit int) ([]*orders.OrderExpiration, error) {
+	const query = `SELECT order_id, expires_at, reservation_id, products, redemption_id, attempts
+	FROM order_expirations WHERE next_try_at <= $1 ORDER BY next_try_at LIMIT $2`
+
+	var rows []expirationRow
+
+	if err := r.db.SelectContext(ctx, &rows, query, now, limit); err != nil {
+		return nil, fmt.Errorf("r.db.SelectContext: %w", err)
+	}
+
+	expirations := make([]*orders.OrderExpiration, 0, len(rows))
+
+	for _, row := range rows {
+		expirations = append(expirations, &orders.OrderExpiration{
+			OrderId:       row.OrderId,
+			ExpiresAt:     row.ExpiresAt,
+			ReservationId: row.ReservationId,
+			ProductsIDS:   row.ProductsIDS,
+			RedemptionId:  row.RedemptionId,
+			Attempts:      row.Attempts,
+		})
+	}
+
+	return expirations, nil
+}
+
+func (r *expirationRepository) Postpone(ctx context.Context, orderId string, next time.Time) error {
+	const query = `UPDATE order_expirations SET attempts = attempts + 1, next_try_at = $2 WHERE order_id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, orderId, next); err != nil {
+		return fmt.Errorf("r.db.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (r *expirationRepository) Delete(ctx context.Context, orderId string) error {
+	const query = `DELETE FROM order_expirations WHERE order_id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, orderId); err != nil {
+		return fmt.Errorf("r.db.ExecContext: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/orders"
+	"github.com/redis/go-redis/v9"
+)
+
+type idempotencyRepository struct {
+	redis    *redis.Client
+	ttl      time.Duration
+	leaseTTL time.Duration
+}
+
+const defaultIdempotencyTTL = 24 * time.Hour
+
+// luaLeaseHeld defines held, which tells whether key is still reserved with
+// the given lease token.
+const luaLeaseHeld = `
+local function held(key, token)
+	local stored = redis.call('GET', key)
+	return stored and cjson.decode(stored).lease_token == token
+end
+`
+
+var completeIdempotencyScript = redis.NewScript(luaLeaseHeld + `
+if not held(KEYS[1], ARGV[1]) then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+return 1
+`)
+
+var releaseIdempotencyScript = redis.NewScript(luaLeaseHeld + `
+if not held(KEYS[1], ARGV[1]) then
+	return 0
+end
+redis.call('DEL', KEYS[1])
+return 1
+`)
+
+// NewIdempotencyRepository keeps completed responses for ttl. A request still
+// processing only holds its key for leaseTTL, so a key whose request died
+// without Complete or Release frees up quickly. The lease is never shorter
+// than MinIdempotencyLease, or a request still running could lose its key.
+func NewIdempotencyRepository(ttl time.Duration, leaseTTL time.Duration, rds *redis.Client) orders.IdempotencyRepository {
+	if ttl <= 0 {
+		ttl = defaultIdempotencyTTL
+	}
+
+	if leaseTTL < orders.MinIdempotencyLease {
+		leaseTTL = orders.MinIdempotencyLease
+	}
+
+	return &idempotencyRepository{
+		redis:    rds,
+		ttl:      ttl,
+		leaseTTL: leaseTTL,
+	}
+}
+
+func (r *idempotencyRepository) Reserve(ctx context.Context, key string, record *orders.IdempotencyRecord) (*orders.IdempotencyRecord, bool, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, false, fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	ok, err := r.redis.SetNX(ctx, key, data, r.leaseTTL).Result()
+	if err != nil {
+		return nil, false, fmt.Errorf("r.redis.SetNX: %w", err)
+	}
+
+	if ok {
+		return record, true, nil
+	}
+
+	stored, err := r.redis.Get(ctx, key).Bytes()
+	if err != nil {
+		// the key expired between SETNX and GET, try again
+		if errors.Is(err, redis.Nil) {
+			return r.Reserve(ctx, key, record)
+		}
+
+		return nil, false, fmt.Errorf("r.redis.Get: %w", err)
+	}
+
+	var existing orders.IdempotencyRecord
+
+	if err := json.Unmarshal(stored, &existing); err != nil {
+		return nil, false, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	return &existing, false, nil
+}
+
+func (r *idempotencyRepository) Complete(ctx context.Context, key string, record *orders.IdempotencyRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	held, err := completeIdempotencyScript.Run(ctx, r.redis, []string{key}, record.LeaseToken, data, r.ttl.Milliseconds()).Int()
+	if err != nil {
+		return fmt.Errorf("completeIdempotencyScript.Run: %w", err)
+	}
+
+	if held == 0 {
+		return orders.ErrIdempotencyLeaseLost
+	}
+
+	return nil
+}
+
+func (r *idempotencyRepository) Release(ctx context.Context, key string, record *orders.IdempotencyRecord) error {
+	held, err := releaseIdempotencyScript.Run(ctx, r.redis, []string{key}, record.LeaseToken).Int()
+	if err != nil {
+		return fmt.Errorf("releaseIdempotencyScript.Run: %w", err)
+	}
+
+	if held == 0 {
+		return orders.ErrIdempotencyLeaseLost
+	}
+
+	return nil
+}
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/orders"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type indexRepository struct {
+	db *sqlx.DB
+}
+
+func NewIndexRepository(db *sqlx.DB) orders.IndexRepository {
+	return &indexRepository{
+		db: db,
+	}
+}
+
+type indexedOrderRow struct {
+	OrderId       string         `db:"order_id"`
+	AccountId     string         `db:"account_id"`
+	Type          string         `db:"type"`
+	Status        string         `db:"status"`
+	PaymentMethod string         `db:"payment_method"`
+	Amount        int64          `db:"amount"`
+	ProductsIDS   pq.StringArray `db:"product_ids"`
+	Metadata      []byte         `db:"metadata"`
+	CreatedAt     time.Time      `db:"created_at"`
+}
+
+func (r *indexedOrderRow) toIndexedOrder() *orders.IndexedOrder {
+	return &orders.IndexedOrder{
+		OrderId:       r.OrderId,
+		AccountId:     r.AccountId,
+		Type:          r.Type,
+		Status:        r.Status,
+		PaymentMethod: r.PaymentMethod,
+		Amount:        r.Amount,
+		ProductsIDS:   r.ProductsIDS,
+		Metadata:      r.Metadata,
+		CreatedAt:     r.CreatedAt,
+	}
+}
+
+var orderSorts = map[string]string{
+	orders.SortCreatedAtDesc: "created_at DESC",
+	orders.SortCreatedAtAsc:  "created_at ASC",
+	orders.SortAmountDesc:    "amount DESC, created_at DESC",
+	orders.SortAmountAsc:     "amount ASC, created_at DESC",
+}
+
+func (r *indexRepository) Upsert(ctx context.Context, order *orders.IndexedOrder) error {
+	const query = `INSERT INTO orders_index
+	(order_id, account_id, type, status, payment_method, amount, product_ids, metadata, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	ON CONFLICT (order_id) DO UPDATE SET status = EXCLUDED.status, updated_at = NOW()`
+
+	var metadata any
+	if len(order.Metadata) > 0 {
+		metadata = order.Metadata
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		order.OrderId,
+		order.AccountId,
+		order.Type,
+		order.Status,
+		order.PaymentMethod,
+		order.Amount,
+		pq.StringArray(order.ProductsIDS),
+		metadata,
+		order.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("r.db.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (r *indexRepository) UpdateStatus(ctx context.Context, orderId string, status string) error {
+	const query = `UPDATE orders_index SET status = $2, updated_at = NOW() WHERE order_id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, orderId, status); err != nil {
+		return fmt.Errorf("r.db.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (r *indexRepository) Search(ctx context.Context, params *orders.ParamsSearchOrdersInput) ([]*orders.IndexedOrder, int, error) {
+	var where whereClause
+
+	if params.AccountId != "" {
+		where.add("account_id = ?", params.AccountId)
+	}
+
+	if params.ProductId != "" {
+		where.add("product_ids @> ARRAY[?]::text[]", params.ProductId)
+	}
+
+	if params.Status != "" {
+		where.add("status = ?", params.Status)
+	}
+
+	if params.Type != "" {
+		where.add("type = ?", params.Type)
+	}
+
+	if params.PaymentMethod != "" {
+		where.add("payment_method = ?", params.PaymentMethod)
+	}
+
+	if !params.CreatedFromTime.IsZero() {
+		where.add("created_at >= ?", params.CreatedFromTime)
+	}
+
+	if !params.CreatedToTime.IsZero() {
+		where.add("created_at <= ?", params.CreatedToTime)
+	}
+
+	if params.MinAmountInt != nil {
+		where.add("amount >= ?", *params.MinAmountInt)
+	}
+
+	if params.MaxAmountInt != nil {
+		where.add("amount <= ?", *params.MaxAmountInt)
+	}
+
+	filter := where.String()
+
+	var total int
+
+	if err := r.db.GetContext(ctx, &total, `SELECT count(*) FROM orders_index`+filter, where.args...); err != nil {
+		return nil, 0, fmt.Errorf("r.db.GetContext: %w", err)
+	}
+
+	orderBy, ok := orderSorts[params.Sort]
+	if !ok {
+		orderBy = orderSorts[orders.SortCreatedAtDesc]
+	}
+
+	offset := (params.PageInt - 1) * params.LimitInt
+
+	query := `SELECT order_id, account_id, type, status, payment_method, amount, product_ids, metadata, created_at
+	FROM orders_index` + filter + ` ORDER BY ` + orderBy +
+		` LIMIT ` + strconv.Itoa(params.LimitInt) + ` OFFSET ` + strconv.Itoa(offset)
+
+	var rows []indexedOrderRow
+
+	if err := r.db.SelectContext(ctx, &rows, query, where.args...); err != nil {
+		return nil, 0, fmt.Errorf("r.db.SelectContext: %w", err)
+	}
+
+	indexed := make([]*orders.IndexedOrder, 0, len(rows))
+
+	for i := range rows {
+		indexed = append(indexed, rows[i].toIndexedOrder())
+	}
+
+	return indexed, total, nil
+}
+
+// whereClause joins filters with AND, numbering the ? placeholders in the
+// order the arguments are added.
+type whereClause struct {
+	conditions []string
+	args       []any
+}
+
+func (w *whereClause) add(condition string, args ...any) {
+	for _, arg := range args {
+		w.args = append(w.args, arg)
+		condition = strings.Replace(condition, "?", "$"+strconv.Itoa(len(w.args)), 1)
+	}
+
+	w.conditions = append(w.conditions, condition)
+}
+
+func (w *whereClause) String() string {
+	if len(w.conditions) == 0 {
+		return ""
+	}
+
+	return " WHERE " + strings.Join(w.conditions, " AND ")
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/orders"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type refundRepository struct {
+	db *sqlx.DB
+}
+
+func NewRefundRepository(db *sqlx.DB) orders.RefundRepository {
+	return &refundRepository{
+		db: db,
+	}
+}
+
+type refundRow struct {
+	Id         string    `db:"id"`
+	OrderId    string    `db:"order_id"`
+	Amount     int64     `db:"amount"`
+	Reason     string    `db:"reason"`
+	RefundedBy string    `db:"refunded_by"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+func (r *refundRow) toRefund() *orders.Refund {
+	return &orders.Refund{
+		Id:         r.Id,
+		OrderId:    r.OrderId,
+		Amount:     r.Amount,
+		Reason:     r.Reason,
+		RefundedBy: r.RefundedBy,
+		CreatedAt:  r.CreatedAt,
+	}
+}
+
+func (r *refundRepository) Create(ctx context.Context, refund *orders.Refund, orderAmount int64) (err error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("r.db.BeginTxx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// orders live in another service, so the lock is taken on the order id
+	// to serialize concurrent refunds of the same order
+	if _, err = tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, refund.OrderId); err != nil {
+		return fmt.Errorf("tx.ExecContext: %w", err)
+	}
+
+	var refunded int64
+
+	const sumQuery = `SELECT COALESCE(SUM(amount), 0) FROM order_refunds WHERE order_id = $1`
+
+	if err = tx.GetContext(ctx, &refunded, sumQuery, refund.OrderId); err != nil {
+		return fmt.Errorf("tx.GetContext: %w", err)
+	}
+
+	if refunded+refund.Amount > orderAmount {
+		return orders.ErrRefundExceedsAmount
+	}
+
+	const insertQuery = `INSERT INTO order_refunds (id, order_id, amount, reason, refunded_by, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err = tx.ExecContext(ctx, insertQuery,
+		refund.Id,
+		refund.OrderId,
+		refund.Amount,
+		refund.Reason,
+		refund.RefundedBy,
+		refund.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("tx.ExecContext: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("tx.Commit: %w", err)
+	}
+
+	return nil
+}
+
+func (r *refundRepository) Delete(ctx context.Context, id string) error {
+	const query = `DELETE FROM order_refunds WHERE id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("r.db.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (r *refundRepository) ListByOrder(ctx context.Context, orderId string) ([]*orders.Refund, error) {
+	const query = `SELECT id, order_id, amount, reason, refunded_by, created_at
+	FROM order_refunds WHERE order_id = $1 ORDER BY created_at`
+
+	var rows []refundRow
+
+	if err := r.db.SelectContext(ctx, &rows, query, orderId); err != nil {
+		return nil, fmt.Errorf("r.db.SelectContext: %w", err)
+	}
+
+	refunds := make([]*orders.Refund, 0, len(rows))
+
+	for i := range rows {
+		refunds = append(refunds, rows[i].toRefund())
+	}
+
+	return refunds, nil
+}
+
+func (r *refundRepository) OrdersByRefund(ctx context.Context, ids []string) (map[string]string, error) {
+	const query = `SELECT id, order_id FROM order_refunds WHERE id = ANY($1::uuid[])`
+
+	var rows []struct {
+		Id      string `db:"id"`
+		OrderId string `db:"order_id"`
+	}
+
+	if err := r.db.SelectContext(ctx, &rows, query, pq.StringArray(ids)); err != nil {
+		return nil, fmt.Errorf("r.db.SelectContext: %w", err)
+	}
+
+	orders := make(map[string]string, len(rows))
+	for _, row := range rows {
+		orders[row.Id] = row.OrderId
+	}
+
+	return orders, nil
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/orders"
+	"github.com/jmoiron/sqlx"
+)
+
+type sagaRepository struct {
+	db *sqlx.DB
+}
+
+func NewSagaRepository(db *sqlx.DB) orders.SagaRepository {
+	return &sagaRepository{
+		db: db,
+	}
+}
+
+type compensationTaskRow struct {
+	Id          string    `db:"id"`
+	Steps       []byte    `db:"steps"`
+	OriginalErr string    `db:"original_error"`
+	LastErr     string    `db:"last_error"`
+	Status      string    `db:"status"`
+	Attempts    int       `db:"attempts"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+func (r *compensationTaskRow) toTask() (*orders.CompensationTask, error) {
+	task := orders.CompensationTask{
+		Id:          r.Id,
+		OriginalErr: r.OriginalErr,
+		LastErr:     r.LastErr,
+		Status:      orders.CompensationStatus(r.Status),
+		Attempts:    r.Attempts,
+		CreatedAt:   r.CreatedAt,
+		UpdatedAt:   r.UpdatedAt,
+	}
+
+	if err := json.Unmarshal(r.Steps, &task.Steps); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	return &task, nil
+}
+
+func (r *sagaRepository) Create(ctx context.Context, task *orders.CompensationTask) error {
+	const query = `INSERT INTO saga_compensation_tasks
+	(id, steps, original_error, last_error, status, attempts, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	steps, err := json.Marshal(task.Steps)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	_, err = r.db.ExecContext(ctx, query,
+		task.Id,
+		steps,
+		task.OriginalErr,
+		task.LastErr,
+		task.Status,
+		task.Attempts,
+		task.CreatedAt,
+		task.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("r.db.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (r *sagaRepository) Update(ctx context.Context, task *orders.CompensationTask) error {
+	const query = `UPDATE saga_compensation_tasks
+	SET steps = $2, last_error = $3, status = $4, attempts = $5, updated_at = $6
+	WHERE id = $1`
+
+	steps, err := json.Marshal(task.Steps)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	task.UpdatedAt = time.Now()
+
+	_, err = r.db.ExecContext(ctx, query,
+		task.Id,
+		steps,
+		task.LastErr,
+		task.Status,
+		task.Attempts,
+		task.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("r.db.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (r *sagaRepository) FindUnfinished(ctx context.Context) ([]*orders.CompensationTask, error) {
+	const query = `SELECT id, steps, original_error, last_error, status, attempts, created_at, updated_at
+	FROM saga_compensation_tasks WHERE status IN ($1, $2) ORDER BY created_at`
+
+	var rows []compensationTaskRow
+
+	err := r.db.SelectContext(ctx, &rows, query, orders.CompensationPending, orders.CompensationRetrying)
+	if err != nil {
+		return nil, fmt.Errorf("r.db.SelectContext: %w", err)
+	}
+
+	tasks := make([]*orders.CompensationTask, 0, len(rows))
+
+	for i := range rows {
+		task, err := rows[i].toTask()
+		if err != nil {
+			return nil, err
+		}
+
+		tasks = append(tasks, task)
+	}
+
+	return tasks, nil
+}
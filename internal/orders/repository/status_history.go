@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/orders"
+	"github.com/jmoiron/sqlx"
+)
+
+type statusHistoryRepository struct {
+	db *sqlx.DB
+}
+
+func NewStatusHistoryRepository(db *sqlx.DB) orders.StatusHistoryRepository {
+	return &statusHistoryRepository{
+		db: db,
+	}
+}
+
+type statusChangeRow struct {
+	Id        string    `db:"id"`
+	OrderId   string    `db:"order_id"`
+	From      string    `db:"from_status"`
+	To        string    `db:"to_status"`
+	Actor     string    `db:"actor"`
+	Reason    string    `db:"reason"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func (r *statusChangeRow) toStatusChange() *orders.StatusChange {
+	return &orders.StatusChange{
+		Id:        r.Id,
+		OrderId:   r.OrderId,
+		From:      r.From,
+		To:        r.To,
+		Actor:     r.Actor,
+		Reason:    r.Reason,
+		CreatedAt: r.CreatedAt,
+	}
+}
+
+func (r *statusHistoryRepository) Record(ctx context.Context, change *orders.StatusChange, apply func(ctx context.Context) error) (err error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("r.db.BeginTxx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// held until the transaction ends, so other gateway instances wait for
+	// this change before reading the order status
+	if _, err = tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, "order_status:"+change.OrderId); err != nil {
+		return fmt.Errorf("tx.ExecContext: %w", err)
+	}
+
+	if err = apply(ctx); err != nil {
+		return err
+	}
+
+	const query = `INSERT INTO order_status_history (id, order_id, from_status, to_status, actor, reason, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err = tx.ExecContext(ctx, query,
+		change.Id,
+		change.OrderId,
+		change.From,
+		change.To,
+		change.Actor,
+		change.Reason,
+		change.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("tx.ExecContext: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("tx.Commit: %w", err)
+	}
+
+	return nil
+}
+
+func (r *statusHistoryRepository) ListByOrder(ctx context.Context, orderId string) ([]*orders.StatusChange, error) {
+	const query = `SELECT id, order_id, from_status, to_status, actor, reason, created_at
+	FROM order_status_history WHERE order_id = $1 ORDER BY created_at`
+
+	var rows []statusChangeRow
+
+	if err := r.db.SelectContext(ctx, &rows, query, orderId); err != nil {
+		return nil, fmt.Errorf("r.db.SelectContext: %w", err)
+	}
+
+	changes := make([]*orders.StatusChange, 0, len(rows))
+
+	for i := range rows {
+		changes = append(changes, rows[i].toStatusChange())
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/orders"
+	"github.com/redis/go-redis/v9"
+)
+
+// Every product uses three keys: the available counter, a sorted set of
+// reservation ids scored by expiry and a hash with the units of each hold.
+// Expired holds are returned to the counter before any new reservation.
+//
+// The counter expires, so restocks in the catalog are picked up: a missing
+// counter is seeded from the catalog quantity minus the units on hold. After
+// a commit the counter is kept until the catalog has caught up with the sale.
+const luaReclaimExpired = `
+local function reclaim(stock, holds, quantities, now)
+	local expired = redis.call('ZRANGEBYSCORE', holds, '-inf', now)
+	for _, rid in ipairs(expired) do
+		local qty = redis.call('HGET', quantities, rid)
+		if qty then
+			redis.call('INCRBY', stock, qty)
+			redis.call('HDEL', quantities, rid)
+		end
+		redis.call('ZREM', holds, rid)
+	end
+end
+`
+
+var reserveStockScript = redis.NewScript(luaReclaimExpired + `
+local now = tonumber(ARGV[1])
+local expires = tonumber(ARGV[2])
+local rid = ARGV[3]
+local counterTTL = ARGV[4]
+local n = #KEYS / 3
+
+for i = 1, n do
+	local stock, holds, quantities = KEYS[i*3-2], KEYS[i*3-1], KEYS[i*3]
+	reclaim(stock, holds, quantities, now)
+	if redis.call('EXISTS', stock) == 0 then
+		local seed = tonumber(ARGV[4+i*2])
+		for _, qty in ipairs(redis.call('HVALS', quantities)) do
+			seed = seed - tonumber(qty)
+		end
+		redis.call('SET', stock, math.max(seed, 0), 'PX', counterTTL)
+	elseif redis.call('PTTL', stock) == -1 then
+		redis.call('PEXPIRE', stock, counterTTL)
+	end
+	if tonumber(redis.call('GET', stock)) < tonumber(ARGV[3+i*2]) then
+		return i
+	end
+end
+
+for i = 1, n do
+	local stock, holds, quantities = KEYS[i*3-2], KEYS[i*3-1], KEYS[i*3]
+	local want = tonumber(ARGV[3+i*2])
+	redis.call('DECRBY', stock, want)
+	redis.call('HINCRBY', quantities, rid, want)
+	redis.call('ZADD', holds, expires, rid)
+end
+
+return 0
+`)
+
+// luaHoldAlive returns the index of the first product whose hold is missing
+// or past its expiry, or 0 when the hold is in place for all of them.
+const luaHoldAlive = `
+local function expired(rid, now)
+	for i = 1, #KEYS / 3 do
+		local score = redis.call('ZSCORE', KEYS[i*3-1], rid)
+		if not score or tonumber(score) <= now then
+			return i
+		end
+	end
+	return 0
+end
+`
+
+var confirmStockScript = redis.NewScript(luaHoldAlive + `
+local rid = ARGV[1]
+local lapsed = expired(rid, tonumber(ARGV[2]))
+if lapsed ~= 0 then
+	return lapsed
+end
+for i = 1, #KEYS / 3 do
+	redis.call('ZADD', KEYS[i*3-1], 'XX', ARGV[3], rid)
+end
+return 0
+`)
+
+var releaseStockScript = redis.NewScript(`
+local rid = ARGV[1]
+for i = 1, #KEYS / 3 do
+	local stock, holds, quantities = KEYS[i*3-2], KEYS[i*3-1], KEYS[i*3]
+	local qty = redis.call('HGET', quantities, rid)
+	if qty then
+		redis.call('INCRBY', stock, qty)
+		redis.call('HDEL', quantities, rid)
+	end
+	redis.call('ZREM', holds, rid)
+end
+return 1
+`)
+
+// commitStockScript drops the hold, keeping its units off the counter. The
+// counter is kept for at least the commit grace, so it is not seeded again
+// from a catalog that has not seen the sale yet.
+var commitStockScript = redis.NewScript(luaHoldAlive + `
+local rid = ARGV[1]
+local grace = tonumber(ARGV[3])
+local lapsed = expired(rid, tonumber(ARGV[2]))
+if lapsed ~= 0 then
+	return lapsed
+end
+for i = 1, #KEYS / 3 do
+	local stock, holds, quantities = KEYS[i*3-2], KEYS[i*3-1], KEYS[i*3]
+	redis.call('HDEL', quantities, rid)
+	redis.call('ZREM', holds, rid)
+	local ttl = redis.call('PTTL', stock)
+	if ttl >= 0 and ttl < grace then
+		redis.call('PEXPIRE', stock, grace)
+	end
+end
+return 0
+`)
+
+type stockRepository struct {
+	redis   *redis.Client
+	holdTTL time.Duration
+}
+
+const (
+	defaultStockHoldTTL = 10 * time.Minute
+	// stockCounterTTL is how long a restock in the catalog can go unseen.
+	stockCounterTTL = time.Minute
+	// stockCommitGrace covers the catalog update that follows a commit.
+	stockCommitGrace = 30 * time.Second
+)
+
+func NewStockRepository(holdTTL time.Duration, rds *redis.Client) orders.StockReservation {
+	if holdTTL <= 0 {
+		holdTTL = defaultStockHoldTTL
+	}
+
+	return &stockRepository{
+		redis:   rds,
+		holdTTL: holdTTL,
+	}
+}
+
+func stockKeys(productsIds []string) []string {
+	keys := make([]string, 0, len(productsIds)*3)
+
+	for _, id := range productsIds {
+		keys = append(keys,
+			orders.FormatStockKeyRepository(id),
+			orders.FormatStockHoldsKeyRepository(id),
+			orders.FormatStockHoldQuantityKeyRepository(id),
+		)
+	}
+
+	return keys
+}
+
+func (r *stockRepository) Reserve(ctx context.Context, reservationId string, items []*orders.StockItem) error {
+	now := time.Now()
+
+	productsIds := make([]string, 0, len(items))
+	args := []any{now.UnixMilli(), now.Add(r.holdTTL).UnixMilli(), reservationId, stockCounterTTL.Milliseconds()}
+
+	for _, item := range items {
+		productsIds = append(productsIds, item.ProductId)
+		args = append(args, item.Quantity, item.Available)
+	}
+
+	short, err := reserveStockScript.Run(ctx, r.redis, stockKeys(productsIds), args...).Int()
+	if err != nil {
+		return fmt.Errorf("reserveStockScript.Run: %w", err)
+	}
+
+	if short != 0 {
+		return orders.ErrProductOutOfStock{ProductId: productsIds[short-1]}
+	}
+
+	return nil
+}
+
+func (r *stockRepository) Confirm(ctx context.Context, reservationId string, productsIds []string) error {
+	now := time.Now()
+
+	lapsed, err := confirmStockScript.Run(ctx, r.redis, stockKeys(productsIds),
+		reservationId, now.UnixMilli(), now.Add(r.holdTTL).UnixMilli()).Int()
+	if err != nil {
+		return fmt.Errorf("confirmStockScript.Run: %w", err)
+	}
+
+	if lapsed != 0 {
+		return fmt.Errorf("%w: product %s", orders.ErrStockHoldExpired, productsIds[lapsed-1])
+	}
+
+	return nil
+}
+
+func (r *stockRepository) Commit(ctx context.Context, reservationId string, productsIds []string) error {
+	lapsed, err := commitStockScript.Run(ctx, r.redis, stockKeys(productsIds),
+		reservationId, time.Now().UnixMilli(), stockCommitGrace.Milliseconds()).Int()
+	if err != nil {
+		return fmt.Errorf("commitStockScript.Run: %w", err)
+	}
+
+	if lapsed != 0 {
+		return fmt.Errorf("%w: product %s", orders.ErrStockHoldExpired, productsIds[lapsed-1])
+	}
+
+	return nil
+}
+
+func (r *stockRepository) Release(ctx context.Context, reservationId string, productsIds []string) error {
+	if err := releaseStockScript.Run(ctx, r.redis, stockKeys(productsIds), reservationId).Err(); err != nil {
+		return fmt.Errorf("releaseStockScript.Run: %w", err)
+	}
+
+	return nil
+}
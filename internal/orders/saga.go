@@ -0,0 +1,316 @@
+package orders
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SagaWorker interface {
+	AppendTask(ctx context.Context, task *CompensationTask) error
+	RegisterCompensation(step string, fn CompensationFunc)
+}
+
+type SagaRepository interface {
+	Create(ctx context.Context, task *CompensationTask) error
+	Update(ctx context.Context, task *CompensationTask) error
+	FindUnfinished(ctx context.Context) ([]*CompensationTask, error)
+}
+
+type DeadLetterRepository interface {
+	Create(ctx context.Context, deadLetter *DeadLetter) error
+	// Update saves a retried or resolved dead letter and ends its claim. It
+	// returns ErrDeadLetterNotOpen if the dead letter was claimed again since
+	// deadLetter.ClaimedAt.
+	Update(ctx context.Context, deadLetter *DeadLetter) error
+	// Claim moves an open dead letter, or one whose claim is older than
+	// DeadLetterClaimLease, to retrying and sets deadLetter.ClaimedAt, so
+	// only one retry runs at a time. It returns ErrDeadLetterNotOpen if the
+	// dead letter is resolved or held by a live claim.
+	Claim(ctx context.Context, deadLetter *DeadLetter) error
+	Find(ctx context.Context, id string) (*DeadLetter, error)
+	List(ctx context.Context, params *ParamsListDeadLettersInput) ([]*DeadLetter, int, error)
+}
+
+type SagaAdmin interface {
+	ListDeadLetters(ctx context.Context, params *ParamsListDeadLettersInput) (*ParamsListDeadLettersOutput, error)
+	FindDeadLetter(ctx context.Context, params *ParamsDeadLetterInput) (*DeadLetter, error)
+	RetryDeadLetter(ctx context.Context, params *ParamsDeadLetterInput) (*DeadLetter, error)
+	ResolveDeadLetter(ctx context.Context, params *ParamsResolveDeadLetterInput) (*DeadLetter, error)
+}
+
+// CompensationFunc undoes one saga step using the payload stored with it,
+// so tasks can be replayed after a restart without the original closures.
+type CompensationFunc func(ctx context.Context, payload json.RawMessage) error
+
+type CompensationStatus string
+
+type DeadLetterStatus string
+
+var (
+	CompensationPending  CompensationStatus = "pending"
+	CompensationRetrying CompensationStatus = "retrying"
+	CompensationDone     CompensationStatus = "done"
+	CompensationDead     CompensationStatus = "dead"
+
+	StepCreditWallet          = "credit-wallet"
+	StepRevertProductsOrdered = "revert-products-ordered"
+	StepRefundPayment         = "refund-payment"
+	StepUpdateOrderStatus     = "update-order-status"
+	StepReleaseStock          = "release-stock"
+	StepReleaseCoupon         = "release-coupon"
+	StepCancelSubscription    = "cancel-subscription"
+	StepShortenSubscription   = "shorten-subscription"
+	StepDecreaseQuantity      = "decrease-quantity"
+
+	DeadLetterOpen     DeadLetterStatus = "open"
+	DeadLetterRetrying DeadLetterStatus = "retrying"
+	DeadLetterResolved DeadLetterStatus = "resolved"
+
+	ErrCompensationNotRegistered = errors.New("compensation not registered")
+	ErrCompensationExpired       = errors.New("compensation task exceeded max age")
+	ErrDeadLetterNotFound        = errors.New("dead letter not found")
+	ErrDeadLetterResolved        = errors.New("dead letter already resolved")
+	ErrDeadLetterNotOpen         = errors.New("dead letter is being retried or already resolved")
+)
+
+// DeadLetterClaimLease is how long a retry holds its dead letter. A claim
+// older than that was left behind by a retry that never finished, and the
+// dead letter can be retried or resolved again.
+const DeadLetterClaimLease = 5 * time.Minute
+
+type CompensationStep struct {
+	Name    string          `json:"name"`
+	Payload json.RawMessage `json:"payload"`
+	Done    bool            `json:"done"`
+}
+
+type CompensationTask struct {
+	Id          string              `json:"task_id"`
+	Steps       []*CompensationStep `json:"steps"`
+	OriginalErr string              `json:"original_error"`
+	LastErr     string              `json:"last_error"`
+	Status      CompensationStatus  `json:"status"`
+	Attempts    int                 `json:"attempts"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+}
+
+func NewCompensationTask(originalErr error) *CompensationTask {
+	now := time.Now()
+
+	task := CompensationTask{
+		Id:        uuid.NewString(),
+		Steps:     make([]*CompensationStep, 0),
+		Status:    CompensationPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if originalErr != nil {
+		task.OriginalErr = originalErr.Error()
+	}
+
+	return &task
+}
+
+func (t *CompensationTask) AddStep(name string, payload any) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	t.Steps = append(t.Steps, &CompensationStep{
+		Name:    name,
+		Payload: raw,
+	})
+
+	return nil
+}
+
+type ParamsCompensateCreditWallet struct {
+	WalletID    string `json:"wallet_id"`
+	Amount      int64  `json:"amount"`
+	ReferenceID string `json:"reference_id"`
+}
+
+// ParamsCompensateDecreaseQuantity takes sold units off the catalog.
+// Reference keeps a retried step from decreasing twice.
+type ParamsCompensateDecreaseQuantity struct {
+	ProductId string `json:"product_id"`
+	Quantity  int64  `json:"quantity"`
+	Reference string `json:"reference"`
+}
+
+type ParamsCompensateRevertProducts struct {
+	ProductsIDS []string `json:"products"`
+}
+
+type ParamsCompensateRefundPayment struct {
+	Method               string `json:"method"`
+	Provider             string `json:"provider,omitempty"`
+	AccountId            string `json:"account_id"`
+	GatewayTransactionID string `json:"gateway_transaction_id"`
+	Amount               int64  `json:"amount"`
+}
+
+type ParamsCompensateOrderStatus struct {
+	OrderId string `json:"order_id"`
+	Status  string `json:"status"`
+	Actor   string `json:"actor,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+type ParamsCompensateReleaseCoupon struct {
+	RedemptionId string `json:"redemption_id"`
+}
+
+type ParamsCompensateCancelSubscription struct {
+	UserId string `json:"user_id"`
+}
+
+// ParamsCompensateShortenSubscription takes back the days of a partially
+// refunded subscription. RefundId keeps a retried step from shortening twice.
+type ParamsCompensateShortenSubscription struct {
+	UserId   string `json:"user_id"`
+	Days     int64  `json:"days"`
+	RefundId string `json:"refund_id"`
+}
+
+type ParamsCompensateReleaseStock struct {
+	ReservationId string   `json:"reservation_id"`
+	ProductsIDS   []string `json:"products"`
+}
+
+// DeadLetter keeps a compensation step that exhausted its retries, with
+// everything support needs to replay it or refund the customer by hand.
+type DeadLetter struct {
+	Id             string           `json:"dead_letter_id"`
+	TaskId         string           `json:"task_id"`
+	Step           string           `json:"step"`
+	Payload        json.RawMessage  `json:"payload"`
+	OriginalErr    string           `json:"original_error"`
+	LastErr        string           `json:"last_error"`
+	Status         DeadLetterStatus `json:"status"`
+	Attempts       int              `json:"attempts"`
+	ResolvedBy     string           `json:"resolved_by,omitempty"`
+	ResolutionNote string           `json:"resolution_note,omitempty"`
+	ResolvedAt     *time.Time       `json:"resolved_at,omitempty"`
+	ClaimedAt      *time.Time       `json:"claimed_at,omitempty"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+}
+
+// Claimed tells whether a retry still holds the dead letter. Dead letters
+// left retrying without a claim time predate the lease and are not held.
+func (d *DeadLetter) Claimed(now time.Time) bool {
+	return d.Status == DeadLetterRetrying && d.ClaimedAt != nil && now.Sub(*d.ClaimedAt) < DeadLetterClaimLease
+}
+
+func NewDeadLetter(task *CompensationTask, step *CompensationStep, lastErr error) *DeadLetter {
+	now := time.Now()
+
+	deadLetter := DeadLetter{
+		Id:          uuid.NewString(),
+		TaskId:      task.Id,
+		Step:        step.Name,
+		Payload:     step.Payload,
+		OriginalErr: task.OriginalErr,
+		Status:      DeadLetterOpen,
+		Attempts:    task.Attempts,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if lastErr != nil {
+		deadLetter.LastErr = lastErr.Error()
+	}
+
+	return &deadLetter
+}
+
+type ParamsListDeadLettersInput struct {
+	Status   string `json:"status"`
+	Page     string `json:"page"`
+	Limit    string `json:"limit"`
+	PageInt  int
+	LimitInt int
+}
+
+func (p *ParamsListDeadLettersInput) Validate() error {
+	switch DeadLetterStatus(p.Status) {
+	case "", DeadLetterOpen, DeadLetterRetrying, DeadLetterResolved:
+	default:
+		return errors.New("status invalid")
+	}
+
+	p.PageInt = 1
+	p.LimitInt = 20
+
+	if p.Page != "" {
+		page, err := strconv.Atoi(p.Page)
+		if err != nil || page <= 0 {
+			return errors.New("page invalid")
+		}
+
+		p.PageInt = page
+	}
+
+	if p.Limit != "" {
+		limit, err := strconv.Atoi(p.Limit)
+		if err != nil || limit <= 0 || limit > 100 {
+			return errors.New("limit invalid")
+		}
+
+		p.LimitInt = limit
+	}
+
+	return nil
+}
+
+type ParamsListDeadLettersOutput struct {
+	DeadLetters []*DeadLetter `json:"dead_letters"`
+	Page        int           `json:"page"`
+	Limit       int           `json:"limit"`
+	TotalItens  int           `json:"total_itens"`
+	TotalPages  int           `json:"total_pages"`
+}
+
+type ParamsDeadLetterInput struct {
+	DeadLetterId string `json:"dead_letter_id"`
+}
+
+func (p *ParamsDeadLetterInput) Validate() error {
+	if p.DeadLetterId == "" {
+		return errors.New("dead letter id empty")
+	}
+
+	if _, err := uuid.Parse(p.DeadLetterId); err != nil {
+		return errors.New("invalid uuid dead letter")
+	}
+
+	return nil
+}
+
+type ParamsResolveDeadLetterInput struct {
+	DeadLetterId string `json:"dead_letter_id"`
+	ResolvedBy   string `json:"resolved_by"`
+	Note         string `json:"note"`
+}
+
+func (p *ParamsResolveDeadLetterInput) Validate() error {
+	if _, err := uuid.Parse(p.DeadLetterId); err != nil {
+		return errors.New("invalid uuid dead letter")
+	}
+
+	if p.Note == "" {
+		return errors.New("resolution note empty")
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package orders
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// SagaState is shared by every step of a saga run so later steps can read
+// what earlier ones produced (payment result, created order, ...).
+type SagaState struct {
+	mu     sync.RWMutex
+	values map[string]any
+}
+
+func (s *SagaState) Set(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+}
+
+func (s *SagaState) Get(key string) (any, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	value, ok := s.values[key]
+	return value, ok
+}
+
+func SagaValue[T any](state *SagaState, key string) (T, bool) {
+	var zero T
+
+	value, ok := state.Get(key)
+	if !ok {
+		return zero, false
+	}
+
+	typed, ok := value.(T)
+	return typed, ok
+}
+
+type SagaAction func(ctx context.Context, state *SagaState) error
+
+const (
+	StepTimeout        = 10 * time.Second
+	PaymentStepTimeout = 30 * time.Second
+
+	// BuyProductSagaTimeout bounds the longest saga, buying MaxOrderProducts
+	// products: one step per product on top of the six the purchase always
+	// runs. The other sagas run fewer steps, with a single payment among them.
+	BuyProductSagaTimeout = (6 + MaxOrderProducts) * StepTimeout
+)
+
+// SagaStep is one unit of work of a saga. Compensation names a function
+// registered on the SagaWorker; CompensationPayload builds its payload once
+// Action succeeds and may return nil when there is nothing to undo.
+type SagaStep struct {
+	Name                string
+	Timeout             time.Duration
+	Action              SagaAction
+	Compensation        string
+	CompensationPayload func(state *SagaState) any
+}
+
+type SagaError struct {
+	Saga string
+	Step string
+	Err  error
+}
+
+func (e *SagaError) Error() string {
+	return fmt.Sprintf("saga %s: step %s: %v", e.Saga, e.Step, e.Err)
+}
+
+func (e *SagaError) Unwrap() error {
+	return e.Err
+}
+
+type Saga struct {
+	name   string
+	worker SagaWorker
+	steps  []*SagaStep
+	state  *SagaState
+}
+
+func NewSaga(name string, worker SagaWorker) *Saga {
+	return &Saga{
+		name:   name,
+		worker: worker,
+		steps:  make([]*SagaStep, 0),
+		state:  &SagaState{values: make(map[string]any)},
+	}
+}
+
+func (s *Saga) AddStep(step *SagaStep) *Saga {
+	s.steps = append(s.steps, step)
+	return s
+}
+
+func (s *Saga) State() *SagaState {
+	return s.state
+}
+
+// Execute runs the steps in order. When one fails, the compensations of the
+// steps already completed are handed to the SagaWorker, which persists them
+// and undoes them in reverse order.
+func (s *Saga) Execute(ctx context.Context) error {
+	task := NewCompensationTask(nil)
+
+	for _, step := range s.steps {
+		if err := s.run(ctx, step); err != nil {
+			return s.rollback(ctx, task, &SagaError{Saga: s.name, Step: step.Name, Err: err})
+		}
+
+		if step.Compensation == "" || step.CompensationPayload == nil {
+			continue
+		}
+
+		payload := step.CompensationPayload(s.state)
+		if payload == nil {
+			continue
+		}
+
+		if err := task.AddStep(step.Compensation, payload); err != nil {
+			return s.rollback(ctx, task, &SagaError{Saga: s.name, Step: step.Name, Err: err})
+		}
+	}
+
+	return nil
+}
+
+func (s *Saga) run(ctx context.Context, step *SagaStep) error {
+	if step.Timeout <= 0 {
+		return step.Action(ctx, s.state)
+	}
+
+	stepCtx, cancel := context.WithTimeout(ctx, step.Timeout)
+	defer cancel()
+
+	return step.Action(stepCtx, s.state)
+}
+
+func (s *Saga) rollback(ctx context.Context, task *CompensationTask, sagaErr *SagaError) error {
+	if len(task.Steps) == 0 {
+		return sagaErr
+	}
+
+	task.OriginalErr = sagaErr.Error()
+
+	if err := s.worker.AppendTask(context.WithoutCancel(ctx), task); err != nil {
+		log.Printf("[CRITICAL ALARM] saga %s: failed to schedule compensations: %v | erro original: %v", s.name, err, sagaErr)
+	}
+
+	return sagaErr
+}
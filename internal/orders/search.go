@@ -0,0 +1,273 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	protoOrders "github.com/aclgo/simple-api-gateway/proto-service/orders"
+	"github.com/google/uuid"
+)
+
+const (
+	SortCreatedAtDesc = "-created_at"
+	SortCreatedAtAsc  = "created_at"
+	SortAmountDesc    = "-amount"
+	SortAmountAsc     = "amount"
+)
+
+// IndexRepository keeps a searchable copy of the orders, since the orders
+// service can only list them by account or product. Orders are added as the
+// gateway places them; the ones placed before the index existed, or whose
+// upsert failed, are filled in by the OrderReindexer.
+type IndexRepository interface {
+	Upsert(ctx context.Context, order *IndexedOrder) error
+	UpdateStatus(ctx context.Context, orderId string, status string) error
+	Search(ctx context.Context, params *ParamsSearchOrdersInput) ([]*IndexedOrder, int, error)
+}
+
+type IndexedOrder struct {
+	OrderId       string
+	AccountId     string
+	Type          string
+	Status        string
+	PaymentMethod string
+	Amount        int64
+	ProductsIDS   []string
+	Metadata      []byte
+	CreatedAt     time.Time
+}
+
+func NewIndexedOrder(order *protoOrders.Orders) *IndexedOrder {
+	indexed := IndexedOrder{
+		OrderId:       order.OrderID,
+		AccountId:     order.AccountID,
+		Type:          order.Type.String(),
+		Status:        order.Status.String(),
+		PaymentMethod: order.PaymentMethod.String(),
+		Amount:        order.Amount,
+		ProductsIDS:   make([]string, 0),
+		Metadata:      order.Metadata,
+		CreatedAt:     order.CreatedAT.AsTime(),
+	}
+
+	if order.Type == protoOrders.OrderType_PRODUCT_PURCHASE {
+		if metadata, err := DecodeProductOrderMetadata(order.Metadata); err == nil {
+			for _, product := range metadata.Products {
+				indexed.ProductsIDS = append(indexed.ProductsIDS, product.Id)
+			}
+		}
+	}
+
+	return &indexed
+}
+
+// IndexNewOrder adds an order just placed to the index. The order already
+// exists, so callers only log a failure; the OrderReindexer adds it later.
+func IndexNewOrder(ctx context.Context, index IndexRepository, order *protoOrders.Orders) error {
+	if err := index.Upsert(context.WithoutCancel(ctx), NewIndexedOrder(order)); err != nil {
+		return fmt.Errorf("index.Upsert: order %s: %w", order.OrderID, err)
+	}
+
+	return nil
+}
+
+// OrderReindexer copies the orders created or changed since the given time
+// from the orders service into the index, limit orders per call to the
+// service, and returns how many it copied. A zero since copies every order.
+type OrderReindexer interface {
+	ReindexOrders(ctx context.Context, since time.Time, limit int) (int, error)
+}
+
+// ParamsSearchOrdersInput holds the filters as received in the query string;
+// Validate parses them into the typed fields.
+type ParamsSearchOrdersInput struct {
+	AccountId     string
+	ProductId     string
+	Status        string
+	Type          string
+	PaymentMethod string
+	CreatedFrom   string
+	CreatedTo     string
+	MinAmount     string
+	MaxAmount     string
+	Sort          string
+	Page          string
+	Limit         string
+
+	CreatedFromTime time.Time
+	CreatedToTime   time.Time
+	MinAmountInt    *int64
+	MaxAmountInt    *int64
+	PageInt         int
+	LimitInt        int
+}
+
+func (p *ParamsSearchOrdersInput) Validate() error {
+	if p.AccountId != "" {
+		if _, err := uuid.Parse(p.AccountId); err != nil {
+			return errors.New("invalid uuid account")
+		}
+	}
+
+	if p.ProductId != "" {
+		if _, err := uuid.Parse(p.ProductId); err != nil {
+			return errors.New("invalid uuid product")
+		}
+	}
+
+	p.Status = strings.ToUpper(p.Status)
+	if _, ok := protoOrders.OrderStatus_value[p.Status]; p.Status != "" && !ok {
+		return errors.New("status invalid")
+	}
+
+	p.Type = strings.ToUpper(p.Type)
+	if _, ok := protoOrders.OrderType_value[p.Type]; p.Type != "" && !ok {
+		return errors.New("type invalid")
+	}
+
+	p.PaymentMethod = strings.ToUpper(p.PaymentMethod)
+	if _, ok := protoOrders.PaymentMethod_value[p.PaymentMethod]; p.PaymentMethod != "" && !ok {
+		return errors.New("payment method invalid")
+	}
+
+	var err error
+
+	if p.CreatedFromTime, err = parseSearchDate(p.CreatedFrom, false); err != nil {
+		return errors.New("created_from invalid")
+	}
+
+	if p.CreatedToTime, err = parseSearchDate(p.CreatedTo, true); err != nil {
+		return errors.New("created_to invalid")
+	}
+
+	if !p.CreatedFromTime.IsZero() && !p.CreatedToTime.IsZero() && p.CreatedToTime.Before(p.CreatedFromTime) {
+		return errors.New("created_to before created_from")
+	}
+
+	if p.MinAmountInt, err = parseSearchAmount(p.MinAmount); err != nil {
+		return errors.New("min_amount invalid")
+	}
+
+	if p.MaxAmountInt, err = parseSearchAmount(p.MaxAmount); err != nil {
+		return errors.New("max_amount invalid")
+	}
+
+	if p.MinAmountInt != nil && p.MaxAmountInt != nil && *p.MaxAmountInt < *p.MinAmountInt {
+		return errors.New("max_amount lower than min_amount")
+	}
+
+	switch p.Sort {
+	case "":
+		p.Sort = SortCreatedAtDesc
+	case SortCreatedAtDesc, SortCreatedAtAsc, SortAmountDesc, SortAmountAsc:
+	default:
+		return errors.New("sort invalid")
+	}
+
+	p.PageInt = 1
+	p.LimitInt = 20
+
+	if p.Page != "" {
+		page, err := strconv.Atoi(p.Page)
+		if err != nil || page <= 0 {
+			return errors.New("page invalid")
+		}
+
+		p.PageInt = page
+	}
+
+	if p.Limit != "" {
+		limit, err := strconv.Atoi(p.Limit)
+		if err != nil || limit <= 0 || limit > 100 {
+			return errors.New("limit invalid")
+		}
+
+		p.LimitInt = limit
+	}
+
+	return nil
+}
+
+// parseSearchDate accepts RFC 3339 timestamps or plain dates. A plain date
+// used as the upper bound covers the whole day.
+func parseSearchDate(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	return t, nil
+}
+
+func parseSearchAmount(value string) (*int64, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	amount, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || amount < 0 {
+		return nil, errors.New("amount invalid")
+	}
+
+	return &amount, nil
+}
+
+// Match tells whether the order passes every filter but the account and
+// product ones, which the orders service already applies.
+func (p *ParamsSearchOrdersInput) Match(order *protoOrders.Orders) bool {
+	if p.Status != "" && order.Status.String() != p.Status {
+		return false
+	}
+
+	if p.Type != "" && order.Type.String() != p.Type {
+		return false
+	}
+
+	if p.PaymentMethod != "" && order.PaymentMethod.String() != p.PaymentMethod {
+		return false
+	}
+
+	createdAt := order.CreatedAT.AsTime()
+
+	if !p.CreatedFromTime.IsZero() && createdAt.Before(p.CreatedFromTime) {
+		return false
+	}
+
+	if !p.CreatedToTime.IsZero() && createdAt.After(p.CreatedToTime) {
+		return false
+	}
+
+	if p.MinAmountInt != nil && order.Amount < *p.MinAmountInt {
+		return false
+	}
+
+	if p.MaxAmountInt != nil && order.Amount > *p.MaxAmountInt {
+		return false
+	}
+
+	return true
+}
+
+type ParamsSearchOrdersOutput struct {
+	Orders     []*OrderSummaryOutput `json:"orders"`
+	Page       int                   `json:"page"`
+	Limit      int                   `json:"limit"`
+	TotalItens int                   `json:"total_itens"`
+	TotalPages int                   `json:"total_pages"`
+}
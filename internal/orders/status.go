@@ -0,0 +1,110 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	protoOrders "github.com/aclgo/simple-api-gateway/proto-service/orders"
+	"github.com/google/uuid"
+)
+
+const (
+	ActorSystem        = "system"
+	ActorPixWebhook    = "webhook:pix"
+	ActorBoletoWebhook = "webhook:boleto"
+)
+
+var ErrInvalidStatusTransition = errors.New("invalid order status transition")
+
+// ErrStatusTransition is returned when an order cannot move from its current
+// status to the requested one.
+type ErrStatusTransition struct {
+	From protoOrders.OrderStatus
+	To   protoOrders.OrderStatus
+}
+
+func (e *ErrStatusTransition) Error() string {
+	return fmt.Sprintf("order status cannot change from %s to %s", e.From, e.To)
+}
+
+func (e *ErrStatusTransition) Unwrap() error {
+	return ErrInvalidStatusTransition
+}
+
+// statusTransitions lists where each status may go. FAILED, CANCELLED and
+// REFUNDED are final. A partially refunded order stays PAID.
+var statusTransitions = map[protoOrders.OrderStatus][]protoOrders.OrderStatus{
+	protoOrders.OrderStatus_ORDER_STATUS_UNSPECIFIED: {
+		protoOrders.OrderStatus_PENDING,
+		protoOrders.OrderStatus_PAID,
+		protoOrders.OrderStatus_FAILED,
+	},
+	protoOrders.OrderStatus_PENDING: {
+		protoOrders.OrderStatus_PAID,
+		protoOrders.OrderStatus_FAILED,
+		protoOrders.OrderStatus_CANCELLED,
+	},
+	protoOrders.OrderStatus_PAID: {
+		protoOrders.OrderStatus_REFUNDED,
+	},
+}
+
+func ValidateStatusTransition(from, to protoOrders.OrderStatus) error {
+	for _, next := range statusTransitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+
+	return &ErrStatusTransition{From: from, To: to}
+}
+
+type StatusMachine interface {
+	// Transition moves the order to params.To when that is legal from its
+	// current status and records the change. apply, when not nil, runs right
+	// before the status is saved while the order is locked, so effects tied to
+	// the new status happen once; if it fails the status is left unchanged.
+	Transition(ctx context.Context, params *ParamsStatusTransitionInput,
+		apply func(ctx context.Context, order *protoOrders.Orders) error) (*StatusChange, error)
+	History(ctx context.Context, params *ParamsOrderHistoryInput) ([]*StatusChange, error)
+}
+
+type StatusHistoryRepository interface {
+	// Record locks the order, runs apply and stores the change if it
+	// succeeds. Concurrent calls for the same order run one at a time.
+	Record(ctx context.Context, change *StatusChange, apply func(ctx context.Context) error) error
+	ListByOrder(ctx context.Context, orderId string) ([]*StatusChange, error)
+}
+
+type StatusChange struct {
+	Id        string    `json:"id"`
+	OrderId   string    `json:"order_id"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Actor     string    `json:"actor"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ParamsStatusTransitionInput struct {
+	OrderId string
+	To      protoOrders.OrderStatus
+	Actor   string
+	Reason  string
+}
+
+type ParamsOrderHistoryInput struct {
+	OrderId string
+	// UserId restricts the history to orders of that account; admins leave it empty.
+	UserId string
+}
+
+func (p *ParamsOrderHistoryInput) Validate() error {
+	if _, err := uuid.Parse(p.OrderId); err != nil {
+		return errors.New("invalid uuid order")
+	}
+
+	return nil
+}
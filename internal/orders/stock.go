@@ -0,0 +1,68 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrOutOfStock = errors.New("out of stock")
+	// ErrStockHoldExpired is returned for a hold that lapsed: its units went
+	// back to the stock and may have been sold to someone else.
+	ErrStockHoldExpired = errors.New("stock hold expired")
+)
+
+// StockReservation holds product units while a purchase is in flight. A hold
+// expires on its own when neither Commit nor Release is called in time, so a
+// crashed request does not keep the units locked forever.
+type StockReservation interface {
+	// Reserve takes the requested units from every item or from none of them.
+	Reserve(ctx context.Context, reservationId string, items []*StockItem) error
+	// Confirm checks the hold is still in place and gives it a full hold TTL
+	// again, so it cannot lapse before Commit. It returns ErrStockHoldExpired
+	// otherwise.
+	Confirm(ctx context.Context, reservationId string, productsIds []string) error
+	// Commit turns the hold into a sale: the units stay taken. It returns
+	// ErrStockHoldExpired and leaves the stock alone if the hold lapsed.
+	Commit(ctx context.Context, reservationId string, productsIds []string) error
+	// Release gives the held units back.
+	Release(ctx context.Context, reservationId string, productsIds []string) error
+}
+
+type StockItem struct {
+	ProductId string
+	Quantity  int64
+	// Available is the quantity in the catalog. The stock counter is seeded
+	// from it, minus the units on hold, whenever the counter has expired.
+	Available int64
+}
+
+type ErrProductOutOfStock struct {
+	ProductId string
+}
+
+func (e ErrProductOutOfStock) Error() string {
+	return fmt.Sprintf("%v: product %s", ErrOutOfStock, e.ProductId)
+}
+
+func (e ErrProductOutOfStock) Unwrap() error {
+	return ErrOutOfStock
+}
+
+// stockHashTag puts every stock key in the same Redis Cluster slot, since a
+// reservation takes the units of all its products in one script. It trades
+// spreading the stock keys over the cluster for all-or-nothing holds.
+const stockHashTag = "{stock}"
+
+func FormatStockKeyRepository(productId string) string {
+	return fmt.Sprintf("%s:product_stock:%s", stockHashTag, productId)
+}
+
+func FormatStockHoldsKeyRepository(productId string) string {
+	return fmt.Sprintf("%s:product_stock_holds:%s", stockHashTag, productId)
+}
+
+func FormatStockHoldQuantityKeyRepository(productId string) string {
+	return fmt.Sprintf("%s:product_stock_hold_quantity:%s", stockHashTag, productId)
+}
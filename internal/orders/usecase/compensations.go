@@ -0,0 +1,214 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/aclgo/simple-api-gateway/internal/domain/models"
+	"github.com/aclgo/simple-api-gateway/internal/orders"
+	"github.com/aclgo/simple-api-gateway/internal/subscription"
+	protoBalance "github.com/aclgo/simple-api-gateway/proto-service/balance"
+	protoOrders "github.com/aclgo/simple-api-gateway/proto-service/orders"
+	protoProduct "github.com/aclgo/simple-api-gateway/proto-service/product"
+)
+
+func (u *orderUC) registerCompensations() {
+	u.compensations = map[string]orders.CompensationFunc{
+		orders.StepCreditWallet:          u.compensateCreditWallet,
+		orders.StepRevertProductsOrdered: u.compensateRevertProductsOrdered,
+		orders.StepRefundPayment:         u.compensateRefundPayment,
+		orders.StepUpdateOrderStatus:     u.compensateUpdateOrderStatus,
+		orders.StepReleaseStock:          u.compensateReleaseStock,
+		orders.StepReleaseCoupon:         u.compensateReleaseCoupon,
+		orders.StepCancelSubscription:    u.compensateCancelSubscription,
+		orders.StepShortenSubscription:   u.compensateShortenSubscription,
+		orders.StepDecreaseQuantity:      u.compensateDecreaseQuantity,
+	}
+
+	for step, fn := range u.compensations {
+		u.workerSaga.RegisterCompensation(step, fn)
+	}
+}
+
+func (u *orderUC) compensateCreditWallet(ctx context.Context, payload json.RawMessage) error {
+	var params orders.ParamsCompensateCreditWallet
+
+	if err := json.Unmarshal(payload, &params); err != nil {
+		return fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	_, err := u.clientBalanceGPRC.Credit(ctx, &protoBalance.ParamCreditWalletRequest{
+		WalletID:    params.WalletID,
+		Amount:      params.Amount,
+		ReferenceID: params.ReferenceID,
+	})
+	if err != nil {
+		return fmt.Errorf("u.clientBalanceGPRC.Credit: %w", err)
+	}
+
+	return nil
+}
+
+func (u *orderUC) compensateRevertProductsOrdered(ctx context.Context, payload json.RawMessage) error {
+	var params orders.ParamsCompensateRevertProducts
+
+	if err := json.Unmarshal(payload, &params); err != nil {
+		return fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	for _, pId := range params.ProductsIDS {
+		_, err := u.clientProductsGRPC.Update(ctx, &protoProduct.ProductUpdateRequest{
+			Id:         pId,
+			HasOrdered: false,
+		})
+		if err != nil {
+			return fmt.Errorf("u.clientProductsGRPC.Update: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (u *orderUC) compensateDecreaseQuantity(ctx context.Context, payload json.RawMessage) error {
+	var params orders.ParamsCompensateDecreaseQuantity
+
+	if err := json.Unmarshal(payload, &params); err != nil {
+		return fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	_, err := u.clientProductsGRPC.DecreaseQuantity(ctx, &protoProduct.ProductDecreaseQuantityRequest{
+		Id:        params.ProductId,
+		Quantity:  params.Quantity,
+		Reference: params.Reference,
+	})
+	if err != nil {
+		return fmt.Errorf("u.clientProductsGRPC.DecreaseQuantity: %w", err)
+	}
+
+	return nil
+}
+
+func (u *orderUC) compensateRefundPayment(ctx context.Context, payload json.RawMessage) error {
+	var params orders.ParamsCompensateRefundPayment
+
+	if err := json.Unmarshal(payload, &params); err != nil {
+		return fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	refund := models.ParamPaymentRefundInput{
+		Method:               params.Method,
+		Provider:             params.Provider,
+		AccountId:            params.AccountId,
+		GatewayTransactionID: params.GatewayTransactionID,
+		Amount:               params.Amount,
+	}
+
+	if err := u.gateway.RefundPayment(ctx, &refund); err != nil {
+		return fmt.Errorf("u.gateway.RefundPayment: %w", err)
+	}
+
+	return nil
+}
+
+func (u *orderUC) compensateUpdateOrderStatus(ctx context.Context, payload json.RawMessage) error {
+	var params orders.ParamsCompensateOrderStatus
+
+	if err := json.Unmarshal(payload, &params); err != nil {
+		return fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	status, ok := protoOrders.OrderStatus_value[params.Status]
+	if !ok {
+		return fmt.Errorf("unknown order status %q", params.Status)
+	}
+
+	transition := orders.ParamsStatusTransitionInput{
+		OrderId: params.OrderId,
+		To:      protoOrders.OrderStatus(status),
+		Actor:   params.Actor,
+		Reason:  params.Reason,
+	}
+
+	if transition.Actor == "" {
+		transition.Actor = orders.ActorSystem
+	}
+
+	_, err := u.status.Transition(ctx, &transition, nil)
+	if err != nil {
+		// a retry after the status was saved finds the order already there
+		var transitionErr *orders.ErrStatusTransition
+		if errors.As(err, &transitionErr) && transitionErr.From == transition.To {
+			return nil
+		}
+
+		return fmt.Errorf("u.status.Transition: %w", err)
+	}
+
+	return nil
+}
+
+func (u *orderUC) compensateReleaseStock(ctx context.Context, payload json.RawMessage) error {
+	var params orders.ParamsCompensateReleaseStock
+
+	if err := json.Unmarshal(payload, &params); err != nil {
+		return fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	if err := u.stock.Release(ctx, params.ReservationId, params.ProductsIDS); err != nil {
+		return fmt.Errorf("u.stock.Release: %w", err)
+	}
+
+	return nil
+}
+
+func (u *orderUC) compensateReleaseCoupon(ctx context.Context, payload json.RawMessage) error {
+	var params orders.ParamsCompensateReleaseCoupon
+
+	if err := json.Unmarshal(payload, &params); err != nil {
+		return fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	if err := u.promotion.ReleaseCoupon(ctx, params.RedemptionId); err != nil {
+		return fmt.Errorf("u.promotion.ReleaseCoupon: %w", err)
+	}
+
+	return nil
+}
+
+func (u *orderUC) compensateCancelSubscription(ctx context.Context, payload json.RawMessage) error {
+	var params orders.ParamsCompensateCancelSubscription
+
+	if err := json.Unmarshal(payload, &params); err != nil {
+		return fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	_, err := u.subscription.CancelSubscription(ctx, &subscription.ParamsCancelSubscriptionInput{UserId: params.UserId})
+	if err != nil {
+		return fmt.Errorf("u.subscription.CancelSubscription: %w", err)
+	}
+
+	return nil
+}
+
+// compensateShortenSubscription takes days off the subscription through the
+// dedicated call of the user service, which cannot extend it.
+func (u *orderUC) compensateShortenSubscription(ctx context.Context, payload json.RawMessage) error {
+	var params orders.ParamsCompensateShortenSubscription
+
+	if err := json.Unmarshal(payload, &params); err != nil {
+		return fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	_, err := u.subscription.ShortenSubscription(ctx, &subscription.ParamsShortenSubscriptionInput{
+		UserId:    params.UserId,
+		Days:      params.Days,
+		Reference: params.RefundId,
+	})
+	if err != nil {
+		return fmt.Errorf("u.subscription.ShortenSubscription: %w", err)
+	}
+
+	return nil
+}
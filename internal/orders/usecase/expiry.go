@@ -0,0 +1,207 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/orders"
+	"github.com/aclgo/simple-api-gateway/pkg/logger"
+	protoOrders "github.com/aclgo/simple-api-gateway/proto-service/orders"
+)
+
+// ExpirePending cancels the pending orders whose payment window closed before
+// now and gives back their stock holds and coupon uses. Orders that were paid
+// or cancelled in the meantime are just forgotten.
+func (u *orderUC) ExpirePending(ctx context.Context, now time.Time, limit int) (int, error) {
+	due, err := u.expirations.Due(ctx, now, limit)
+	if err != nil {
+		return 0, fmt.Errorf("u.expirations.Due: %w", err)
+	}
+
+	resolved := 0
+
+	for _, expiration := range due {
+		if err := u.expireOrder(ctx, expiration); err != nil {
+			u.logger.Errorf("expire order %s: %v", expiration.OrderId, err)
+
+			// tried again later, so it does not starve the ones due after it
+			next := now.Add(expiryRetryDelay(expiration.Attempts))
+			if err := u.expirations.Postpone(ctx, expiration.OrderId, next); err != nil {
+				u.logger.Errorf("u.expirations.Postpone: order %s: %v", expiration.OrderId, err)
+			}
+
+			continue
+		}
+
+		if err := u.expirations.Delete(ctx, expiration.OrderId); err != nil {
+			u.logger.Errorf("u.expirations.Delete: order %s: %v", expiration.OrderId, err)
+			continue
+		}
+
+		resolved++
+	}
+
+	return resolved, nil
+}
+
+func (u *orderUC) expireOrder(ctx context.Context, expiration *orders.OrderExpiration) error {
+	transition := orders.ParamsStatusTransitionInput{
+		OrderId: expiration.OrderId,
+		To:      protoOrders.OrderStatus_CANCELLED,
+		Actor:   orders.ActorSystem,
+		Reason:  "payment expired",
+	}
+
+	_, err := u.status.Transition(ctx, &transition, nil)
+	if err != nil {
+		if errors.Is(err, orders.ErrInvalidStatusTransition) || errors.Is(err, orders.ErrOrderNotFound) {
+			return nil
+		}
+
+		return fmt.Errorf("u.status.Transition: %w", err)
+	}
+
+	followUps := make([]followUp, 0, 2)
+
+	if expiration.ReservationId != "" {
+		followUps = append(followUps, followUp{
+			step: orders.StepReleaseStock,
+			payload: &orders.ParamsCompensateReleaseStock{
+				ReservationId: expiration.ReservationId,
+				ProductsIDS:   expiration.ProductsIDS,
+			},
+		})
+	}
+
+	if expiration.RedemptionId != "" {
+		followUps = append(followUps, followUp{
+			step:    orders.StepReleaseCoupon,
+			payload: &orders.ParamsCompensateReleaseCoupon{RedemptionId: expiration.RedemptionId},
+		})
+	}
+
+	u.runFollowUps(ctx, "expire order "+expiration.OrderId, followUps)
+
+	return nil
+}
+
+const (
+	expiryBaseRetryDelay = time.Minute
+	expiryMaxRetryDelay  = time.Hour
+)
+
+// expiryRetryDelay doubles the wait after every failed attempt, up to an hour.
+func expiryRetryDelay(attempts int) time.Duration {
+	delay := expiryBaseRetryDelay
+
+	for i := 0; i < attempts && delay < expiryMaxRetryDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, expiryMaxRetryDelay)
+}
+
+type ExpirySweeperConfig struct {
+	Interval  time.Duration
+	BatchSize int
+}
+
+// ExpirySweeper periodically cancels pending orders that were not paid in
+// time. Running it on several instances is safe: status changes are
+// serialized per order, so an order is only cancelled once.
+type ExpirySweeper struct {
+	expirer  orders.PendingExpirer
+	logger   logger.Logger
+	cfg      ExpirySweeperConfig
+	quit     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+func NewExpirySweeper(expirer orders.PendingExpirer, logger logger.Logger, cfg ExpirySweeperConfig) *ExpirySweeper {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Minute
+	}
+
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+
+	return &ExpirySweeper{
+		expirer: expirer,
+		logger:  logger,
+		cfg:     cfg,
+		quit:    make(chan struct{}),
+	}
+}
+
+func (s *ExpirySweeper) Start(ctx context.Context) {
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.cfg.Interval)
+		defer ticker.Stop()
+
+		for {
+			s.sweep(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.quit:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop waits for the sweep in progress, if any, to finish.
+func (s *ExpirySweeper) Stop(ctx context.Context) error {
+	s.stopOnce.Do(func() {
+		close(s.quit)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// sweep drains every expiration due now, one batch at a time.
+func (s *ExpirySweeper) sweep(ctx context.Context) {
+	now := time.Now()
+
+	for {
+		select {
+		case <-s.quit:
+			return
+		default:
+		}
+
+		resolved, err := s.expirer.ExpirePending(ctx, now, s.cfg.BatchSize)
+		if err != nil {
+			s.logger.Errorf("s.expirer.ExpirePending: %v", err)
+			return
+		}
+
+		// a short batch means nothing is left or the rest failed and was
+		// postponed
+		if resolved < s.cfg.BatchSize {
+			return
+		}
+	}
+}
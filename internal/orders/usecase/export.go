@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aclgo/simple-api-gateway/internal/orders"
+	protoOrders "github.com/aclgo/simple-api-gateway/proto-service/orders"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const exportBatchSize = 200
+
+// Export pages through the orders service and emits every paid or refunded
+// order created in the period, oldest first. Nothing is held in memory
+// besides the current page.
+func (u *orderUC) Export(ctx context.Context, params *orders.ParamsExportOrdersInput, emit func(*orders.ExportedOrder) error) error {
+	request := protoOrders.ParamListOrdersRequest{Limit: exportBatchSize}
+
+	for _, status := range orders.ExportStatuses {
+		request.Statuses = append(request.Statuses, protoOrders.OrderStatus(protoOrders.OrderStatus_value[status]))
+	}
+
+	if !params.FromTime.IsZero() {
+		request.CreatedFrom = timestamppb.New(params.FromTime)
+	}
+
+	if !params.ToTime.IsZero() {
+		request.CreatedTo = timestamppb.New(params.ToTime)
+	}
+
+	for {
+		page, err := u.clientOrdersGRPC.ListOrders(ctx, &request)
+		if err != nil {
+			return fmt.Errorf("u.clientOrdersGRPC.ListOrders: %w", err)
+		}
+
+		for _, order := range page.Orders {
+			exported, err := u.exportOrder(ctx, order)
+			if err != nil {
+				return err
+			}
+
+			if err := emit(exported); err != nil {
+				return err
+			}
+		}
+
+		if len(page.Orders) < exportBatchSize {
+			return nil
+		}
+
+		last := page.Orders[len(page.Orders)-1]
+		request.AfterCreatedAt = last.CreatedAT
+		request.AfterOrderId = last.OrderID
+	}
+}
+
+func (u *orderUC) exportOrder(ctx context.Context, order *protoOrders.Orders) (*orders.ExportedOrder, error) {
+	exported := orders.ExportedOrder{
+		OrderId:              order.OrderID,
+		AccountId:            order.AccountID,
+		Type:                 order.Type.String(),
+		Status:               order.Status.String(),
+		PaymentMethod:        order.PaymentMethod.String(),
+		Amount:               order.Amount,
+		GatewayTransactionId: order.GatewayTransactionID,
+		CreatedAt:            order.CreatedAT.AsTime(),
+	}
+
+	if order.UpdatedAT.GetSeconds() != 0 {
+		exported.UpdatedAt = order.UpdatedAT.AsTime()
+	}
+
+	if discount, err := orderDiscount(order); err == nil && discount != nil {
+		exported.Discount = discount.Discount
+	}
+
+	refunds, err := u.refunds.ListByOrder(ctx, order.OrderID)
+	if err != nil {
+		return nil, fmt.Errorf("u.refunds.ListByOrder: %w", err)
+	}
+
+	exported.Refunded = orders.SumRefunds(refunds)
+
+	// orders rolled back by a saga are refunded in full without a refund record
+	if exported.Refunded == 0 && order.Status == protoOrders.OrderStatus_REFUNDED {
+		exported.Refunded = order.Amount
+	}
+
+	return &exported, nil
+}
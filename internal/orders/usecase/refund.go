@@ -0,0 +1,437 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/domain/models"
+	"github.com/aclgo/simple-api-gateway/internal/orders"
+	protoBalance "github.com/aclgo/simple-api-gateway/proto-service/balance"
+	protoOrders "github.com/aclgo/simple-api-gateway/proto-service/orders"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const sagaRefundOrder = "refund-order"
+
+func (u *orderUC) findOrder(ctx context.Context, orderId string) (*protoOrders.Orders, error) {
+	return findOrder(ctx, u.clientOrdersGRPC, orderId)
+}
+
+func findOrder(ctx context.Context, client protoOrders.ServiceOrderClient, orderId string) (*protoOrders.Orders, error) {
+	find, err := client.Find(ctx, &protoOrders.ParamFindOrderRequest{OrderID: orderId})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, orders.ErrOrderNotFound
+		}
+
+		return nil, fmt.Errorf("client.Find: %w", err)
+	}
+
+	if find.Order == nil {
+		return nil, orders.ErrOrderNotFound
+	}
+
+	return find.Order, nil
+}
+
+// Cancel drops a pending order of the customer. Nothing was charged yet, so
+// only the coupon redemption has to be given back.
+func (u *orderUC) Cancel(ctx context.Context, params *orders.ParamsCancelOrderInput) (*orders.ParamsCancelOrderOutput, error) {
+	order, err := u.findOrder(ctx, params.OrderId)
+	if err != nil {
+		return nil, err
+	}
+
+	// customers do not get to know whether other people's orders exist
+	if order.AccountID != params.UserId {
+		return nil, orders.ErrOrderNotFound
+	}
+
+	// the funds of a withdrawal are held until an admin reviews it,
+	// cancelling the order alone would keep them
+	if order.Status != protoOrders.OrderStatus_PENDING || order.Type == protoOrders.OrderType_WITHDRAWAL {
+		return nil, orders.ErrOrderNotCancellable
+	}
+
+	transition := orders.ParamsStatusTransitionInput{
+		OrderId: order.OrderID,
+		To:      protoOrders.OrderStatus_CANCELLED,
+		Actor:   params.UserId,
+		Reason:  params.Reason,
+	}
+
+	if transition.Reason == "" {
+		transition.Reason = "cancelled by customer"
+	}
+
+	if _, err := u.status.Transition(ctx, &transition, nil); err != nil {
+		return nil, fmt.Errorf("u.status.Transition: %w", err)
+	}
+
+	// the sweeper would only find the order cancelled already
+	if err := u.expirations.Delete(context.WithoutCancel(ctx), order.OrderID); err != nil {
+		u.logger.Errorf("u.expirations.Delete: order %s: %v", order.OrderID, err)
+	}
+
+	discount, err := orderDiscount(order)
+	if err != nil {
+		u.logger.Errorf("cancel order %s: %v", order.OrderID, err)
+	}
+
+	if discount != nil {
+		u.runFollowUps(ctx, "cancel order "+order.OrderID, []followUp{
+			{step: orders.StepReleaseCoupon, payload: &orders.ParamsCompensateReleaseCoupon{RedemptionId: discount.RedemptionId}},
+		})
+	}
+
+	out := orders.ParamsCancelOrderOutput{
+		OrderId: order.OrderID,
+		Status:  protoOrders.OrderStatus_CANCELLED.String(),
+	}
+
+	return &out, nil
+}
+
+// Refund gives back part or all of a paid order. The money moves inside a
+// saga; what the order granted (products, subscription days) is reverted
+// afterwards and retried by the saga worker when it fails, since the money is
+// already back with the customer by then.
+func (u *orderUC) Refund(ctx context.Context, params *orders.ParamsRefundOrderInput) (*orders.ParamsRefundOrderOutput, error) {
+	order, err := u.findOrder(ctx, params.OrderId)
+	if err != nil {
+		return nil, err
+	}
+
+	// the recipient may have spent the money already, transfers are undone
+	// by a transfer back; a paid withdrawal already left the gateway
+	if order.Status != protoOrders.OrderStatus_PAID || order.Type == protoOrders.OrderType_WALLET_TRANSFER ||
+		order.Type == protoOrders.OrderType_WITHDRAWAL {
+		return nil, orders.ErrOrderNotRefundable
+	}
+
+	refunds, err := u.refunds.ListByOrder(ctx, order.OrderID)
+	if err != nil {
+		return nil, fmt.Errorf("u.refunds.ListByOrder: %w", err)
+	}
+
+	refunded := orders.SumRefunds(refunds)
+	remaining := order.Amount - refunded
+
+	amount := params.Amount
+	if amount == 0 {
+		amount = remaining
+	}
+
+	if amount <= 0 {
+		return nil, orders.ErrRefundAmountInvalid
+	}
+
+	if amount > remaining {
+		return nil, orders.ErrRefundExceedsAmount
+	}
+
+	refund := orders.Refund{
+		Id:         uuid.NewString(),
+		OrderId:    order.OrderID,
+		Amount:     amount,
+		Reason:     params.Reason,
+		RefundedBy: params.RefundedBy,
+		CreatedAt:  time.Now(),
+	}
+
+	if err := u.refunds.Create(ctx, &refund, order.Amount); err != nil {
+		return nil, fmt.Errorf("u.refunds.Create: %w", err)
+	}
+
+	full := refunded+amount == order.Amount
+
+	saga := orders.NewSaga(sagaRefundOrder, u.workerSaga)
+
+	followUps, err := u.refundSteps(ctx, saga, order, &refund, full)
+	if err == nil {
+		err = saga.Execute(ctx)
+	}
+
+	if err != nil {
+		if err := u.refunds.Delete(context.WithoutCancel(ctx), refund.Id); err != nil {
+			u.logger.Errorf("u.refunds.Delete: refund %s: %v", refund.Id, err)
+		}
+
+		return nil, err
+	}
+
+	orderStatus := order.Status
+	if full {
+		orderStatus = protoOrders.OrderStatus_REFUNDED
+
+		followUps = append(followUps, followUp{
+			step: orders.StepUpdateOrderStatus,
+			payload: &orders.ParamsCompensateOrderStatus{
+				OrderId: order.OrderID,
+				Status:  orderStatus.String(),
+				Actor:   params.RefundedBy,
+				Reason:  params.Reason,
+			},
+		})
+	}
+
+	u.runFollowUps(ctx, "refund order "+order.OrderID, followUps)
+
+	out := orders.ParamsRefundOrderOutput{
+		OrderId:   order.OrderID,
+		Status:    orderStatus.String(),
+		Amount:    order.Amount,
+		Refunded:  refunded + amount,
+		Remaining: remaining - amount,
+		Refund:    &refund,
+	}
+
+	return &out, nil
+}
+
+// refundSteps adds to the saga the steps that move money and returns the
+// effects to revert once they succeed, which depend on the order type.
+func (u *orderUC) refundSteps(ctx context.Context, saga *orders.Saga, order *protoOrders.Orders, refund *orders.Refund, full bool) ([]followUp, error) {
+	amount := refund.Amount
+	followUps := make([]followUp, 0)
+
+	switch order.Type {
+	case protoOrders.OrderType_BALANCE_DEPOSIT:
+		saga.AddStep(u.debitDepositStep(order.AccountID, refund.Id, amount))
+
+	case protoOrders.OrderType_PRODUCT_PURCHASE:
+		if !full {
+			break
+		}
+
+		metadata, err := orders.DecodeProductOrderMetadata(order.Metadata)
+		if err != nil {
+			return nil, err
+		}
+
+		ids := make([]string, 0, len(metadata.Products))
+		for _, product := range metadata.Products {
+			ids = append(ids, product.Id)
+		}
+
+		followUps = append(followUps, followUp{
+			step:    orders.StepRevertProductsOrdered,
+			payload: &orders.ParamsCompensateRevertProducts{ProductsIDS: ids},
+		})
+
+	case protoOrders.OrderType_PREMIUM_SUBSCRIPTION:
+		if full {
+			followUps = append(followUps, followUp{
+				step:    orders.StepCancelSubscription,
+				payload: &orders.ParamsCompensateCancelSubscription{UserId: order.AccountID},
+			})
+
+			break
+		}
+
+		var metadata orders.ParamsSaveSubscriptionMetadata
+		if err := json.Unmarshal(order.Metadata, &metadata); err != nil {
+			return nil, fmt.Errorf("json.Unmarshal: %w", err)
+		}
+
+		days := metadata.Days
+		if days == 0 {
+			plan, err := u.catalog.FindPlan(ctx, metadata.Plan)
+			if err != nil {
+				return nil, fmt.Errorf("u.catalog.FindPlan: %w", err)
+			}
+
+			days = plan.Days
+		}
+
+		if shorten := days * amount / order.Amount; shorten > 0 {
+			followUps = append(followUps, followUp{
+				step: orders.StepShortenSubscription,
+				payload: &orders.ParamsCompensateShortenSubscription{
+					UserId:   order.AccountID,
+					Days:     shorten,
+					RefundId: refund.Id,
+				},
+			})
+		}
+	}
+
+	saga.AddStep(u.returnPaymentStep(order, refund.Id, amount))
+
+	return followUps, nil
+}
+
+// debitDepositStep takes back from the wallet the balance a deposit added.
+// The debit is made under the refund id so the wallet statement can tell
+// which order it belongs to.
+func (u *orderUC) debitDepositStep(accountId string, refundId string, amount int64) *orders.SagaStep {
+	var walletId string
+
+	return &orders.SagaStep{
+		Name:    "debit-deposit",
+		Timeout: defaultStepTimeout,
+		Action: func(ctx context.Context, state *orders.SagaState) error {
+			wallet, err := u.clientBalanceGPRC.GetWalletByAccount(ctx, &protoBalance.ParamGetWalletByAccountRequest{AccountID: accountId})
+			if err != nil {
+				return fmt.Errorf("u.clientBalanceGPRC.GetWalletByAccount: %w", err)
+			}
+
+			if wallet.Balance < amount {
+				return orders.ErrRefundInsufficientBalance
+			}
+
+			walletId = wallet.WalletID
+
+			_, err = u.clientBalanceGPRC.Debit(ctx, &protoBalance.ParamDebitWalletRequest{
+				WalletID:    walletId,
+				Amount:      amount,
+				ReferenceID: refundId,
+			})
+			if err != nil {
+				return fmt.Errorf("u.clientBalanceGPRC.Debit: %w", err)
+			}
+
+			return nil
+		},
+		Compensation: orders.StepCreditWallet,
+		CompensationPayload: func(state *orders.SagaState) any {
+			return &orders.ParamsCompensateCreditWallet{
+				WalletID:    walletId,
+				Amount:      amount,
+				ReferenceID: uuid.NewString(),
+			}
+		},
+	}
+}
+
+// returnPaymentStep sends the money back the way it came: to the wallet for
+// orders paid with balance, through the gateway otherwise.
+func (u *orderUC) returnPaymentStep(order *protoOrders.Orders, refundId string, amount int64) *orders.SagaStep {
+	return &orders.SagaStep{
+		Name:    "return-payment",
+		Timeout: paymentStepTimeout,
+		Action: func(ctx context.Context, state *orders.SagaState) error {
+			if order.PaymentMethod == protoOrders.PaymentMethod_INTERNAL_BALANCE {
+				wallet, err := u.clientBalanceGPRC.GetWalletByAccount(ctx, &protoBalance.ParamGetWalletByAccountRequest{AccountID: order.AccountID})
+				if err != nil {
+					return fmt.Errorf("u.clientBalanceGPRC.GetWalletByAccount: %w", err)
+				}
+
+				_, err = u.clientBalanceGPRC.Credit(ctx, &protoBalance.ParamCreditWalletRequest{
+					WalletID:    wallet.WalletID,
+					Amount:      amount,
+					ReferenceID: refundId,
+				})
+				if err != nil {
+					return fmt.Errorf("u.clientBalanceGPRC.Credit: %w", err)
+				}
+
+				return nil
+			}
+
+			refund := models.ParamPaymentRefundInput{
+				Method:               paymentMethodFromOrder(order.PaymentMethod),
+				Provider:             orders.PaymentProvider(order.Metadata),
+				OrderId:              order.OrderID,
+				AccountId:            order.AccountID,
+				GatewayTransactionID: order.GatewayTransactionID,
+				Amount:               amount,
+			}
+
+			if err := u.gateway.RefundPayment(ctx, &refund); err != nil {
+				return fmt.Errorf("u.gateway.RefundPayment: %w", err)
+			}
+
+			return nil
+		},
+	}
+}
+
+type followUp struct {
+	step    string
+	payload any
+}
+
+// runFollowUps applies effects that can no longer fail the request. The ones
+// that error are handed to the saga worker, which retries them like
+// compensations and dead-letters them when they keep failing.
+func (u *orderUC) runFollowUps(ctx context.Context, origin string, followUps []followUp) {
+	ctx = context.WithoutCancel(ctx)
+
+	task := orders.NewCompensationTask(nil)
+
+	var lastErr error
+
+	// the worker runs steps last to first, so failures are prepended to keep
+	// their original order
+	for _, f := range followUps {
+		payload, err := json.Marshal(f.payload)
+		if err == nil {
+			err = u.compensations[f.step](ctx, payload)
+		}
+
+		if err == nil {
+			continue
+		}
+
+		u.logger.Errorf("%s: %s: %v", origin, f.step, err)
+		lastErr = err
+
+		task.Steps = append([]*orders.CompensationStep{{Name: f.step, Payload: payload}}, task.Steps...)
+	}
+
+	if lastErr == nil {
+		return
+	}
+
+	task.OriginalErr = fmt.Sprintf("%s: %v", origin, lastErr)
+
+	if err := u.workerSaga.AppendTask(ctx, task); err != nil {
+		u.logger.Errorf("[CRITICAL ALARM] %s: failed to schedule follow-ups: %v", origin, err)
+	}
+}
+
+// orderDiscount reads the coupon applied to an order from its metadata.
+func orderDiscount(order *protoOrders.Orders) (*orders.OrderDiscount, error) {
+	if order.Type == protoOrders.OrderType_PRODUCT_PURCHASE {
+		metadata, err := orders.DecodeProductOrderMetadata(order.Metadata)
+		if err != nil {
+			return nil, err
+		}
+
+		return metadata.Discount, nil
+	}
+
+	if len(order.Metadata) == 0 {
+		return nil, nil
+	}
+
+	var metadata struct {
+		Discount *orders.OrderDiscount `json:"discount"`
+	}
+
+	if err := json.Unmarshal(order.Metadata, &metadata); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	return metadata.Discount, nil
+}
+
+func paymentMethodFromOrder(method protoOrders.PaymentMethod) string {
+	switch method {
+	case protoOrders.PaymentMethod_PIX:
+		return models.PaymentMethodPix
+	case protoOrders.PaymentMethod_CREDIT_CARD:
+		return models.PaymentMethodCard
+	case protoOrders.PaymentMethod_BOLETO:
+		return models.PaymentMethodBoleto
+	case protoOrders.PaymentMethod_INTERNAL_BALANCE:
+		return models.PaymentMethodInternalBalance
+	}
+
+	return ""
+}
@@ -0,0 +1,160 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/orders"
+	"github.com/aclgo/simple-api-gateway/pkg/logger"
+	protoOrders "github.com/aclgo/simple-api-gateway/proto-service/orders"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ReindexOrders pages through the orders service, oldest order first, and
+// upserts every order created or changed since the given time.
+func (u *orderUC) ReindexOrders(ctx context.Context, since time.Time, limit int) (int, error) {
+	request := protoOrders.ParamListOrdersRequest{Limit: int32(limit)}
+
+	if !since.IsZero() {
+		request.UpdatedFrom = timestamppb.New(since)
+	}
+
+	indexed := 0
+
+	for {
+		page, err := u.clientOrdersGRPC.ListOrders(ctx, &request)
+		if err != nil {
+			return indexed, fmt.Errorf("u.clientOrdersGRPC.ListOrders: %w", err)
+		}
+
+		for _, order := range page.Orders {
+			if err := u.index.Upsert(ctx, orders.NewIndexedOrder(order)); err != nil {
+				return indexed, fmt.Errorf("u.index.Upsert: order %s: %w", order.OrderID, err)
+			}
+
+			indexed++
+		}
+
+		if len(page.Orders) < limit {
+			return indexed, nil
+		}
+
+		last := page.Orders[len(page.Orders)-1]
+		request.AfterCreatedAt = last.CreatedAT
+		request.AfterOrderId = last.OrderID
+	}
+}
+
+// reindexOverlap is taken back from the start of the previous pass, so
+// orders changed while it ran, or stamped by a clock running behind ours,
+// are not missed.
+const reindexOverlap = 5 * time.Minute
+
+type IndexReconcilerConfig struct {
+	Interval  time.Duration
+	BatchSize int
+}
+
+// IndexReconciler keeps the orders index in line with the orders service.
+// Its first pass copies every order, which backfills the ones placed before
+// the index existed; the next ones copy the orders changed since the last
+// pass that went through, so failed upserts and status changes made outside
+// the gateway are picked up. Running it on several instances is safe, as
+// upserts are idempotent.
+type IndexReconciler struct {
+	reindexer orders.OrderReindexer
+	logger    logger.Logger
+	cfg       IndexReconcilerConfig
+	since     time.Time
+	quit      chan struct{}
+	stopOnce  sync.Once
+	wg        sync.WaitGroup
+}
+
+func NewIndexReconciler(reindexer orders.OrderReindexer, logger logger.Logger, cfg IndexReconcilerConfig) *IndexReconciler {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 10 * time.Minute
+	}
+
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 200
+	}
+
+	return &IndexReconciler{
+		reindexer: reindexer,
+		logger:    logger,
+		cfg:       cfg,
+		quit:      make(chan struct{}),
+	}
+}
+
+func (r *IndexReconciler) Start(ctx context.Context) {
+	r.wg.Add(1)
+
+	go func() {
+		defer r.wg.Done()
+
+		// a backfill can take long, so Stop cancels the pass in progress
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		go func() {
+			select {
+			case <-r.quit:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		ticker := time.NewTicker(r.cfg.Interval)
+		defer ticker.Stop()
+
+		for {
+			r.reconcile(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop cancels the pass in progress, if any, and waits for it to return.
+func (r *IndexReconciler) Stop(ctx context.Context) error {
+	r.stopOnce.Do(func() {
+		close(r.quit)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// reconcile only moves since forward when the pass went through, so a
+// failed one is retried in full on the next tick.
+func (r *IndexReconciler) reconcile(ctx context.Context) {
+	started := time.Now()
+
+	if _, err := r.reindexer.ReindexOrders(ctx, r.since, r.cfg.BatchSize); err != nil {
+		if ctx.Err() == nil {
+			r.logger.Errorf("r.reindexer.ReindexOrders: %v", err)
+		}
+
+		return
+	}
+
+	r.since = started.Add(-reindexOverlap)
+}
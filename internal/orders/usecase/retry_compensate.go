@@ -4,31 +4,63 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"math/rand/v2"
 	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/orders"
 )
 
-func compensateWithRetry(ctx context.Context, maxAttempts int, delay time.Duration, fn func(context.Context)error)error{
+type retryPolicy struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	deadline    time.Time
+}
+
+// backoff doubles the base delay on every attempt, caps it at maxDelay and
+// keeps a random half of it so concurrent workers don't retry in lockstep.
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	delay := p.baseDelay << (attempt - 1)
+	if delay <= 0 || delay > p.maxDelay {
+		delay = p.maxDelay
+	}
+
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+
+	return half + rand.N(half)
+}
+
+func compensateWithRetry(ctx context.Context, policy retryPolicy, fn func(context.Context) error) error {
 	var err error
-	for attempt := 1; attempt <= maxAttempts; attempt++{
+	for attempt := 1; attempt <= policy.maxAttempts; attempt++ {
 		err = fn(ctx)
-		if err == nil{
+		if err == nil {
 			return nil
 		}
 
-		log.Printf("compensação falhou na tentativa %d/%d. erro: %v. retentando em %v...", attempt, maxAttempts, err, delay)
-
-		if attempt == maxAttempts{
+		if attempt == policy.maxAttempts {
 			break
 		}
 
-		timer := time.NewTicker(delay)
-		defer timer.Stop()
+		delay := policy.backoff(attempt)
+
+		if !policy.deadline.IsZero() && time.Now().Add(delay).After(policy.deadline) {
+			return fmt.Errorf("%w: último erro: %w", orders.ErrCompensationExpired, err)
+		}
+
+		log.Printf("compensação falhou na tentativa %d/%d. erro: %v. retentando em %v...", attempt, policy.maxAttempts, err, delay)
+
+		timer := time.NewTimer(delay)
 		select {
-		case  <-ctx.Done():
+		case <-ctx.Done():
+			timer.Stop()
 			return fmt.Errorf("contexto cancelado durante o retry: %w", ctx.Err())
 		case <-timer.C:
-		}	
+		}
 	}
 
-	return fmt.Errorf("esgotadas as %d tentativas. Último erro: %w", maxAttempts, err)
-}
\ No newline at end of file
+	return fmt.Errorf("esgotadas as %d tentativas. Último erro: %w", policy.maxAttempts, err)
+}
@@ -0,0 +1,128 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/orders"
+)
+
+type sagaAdminUC struct {
+	deadLetters orders.DeadLetterRepository
+	worker      *SagaWorker
+}
+
+func NewSagaAdminUC(deadLetters orders.DeadLetterRepository, worker *SagaWorker) orders.SagaAdmin {
+	return &sagaAdminUC{
+		deadLetters: deadLetters,
+		worker:      worker,
+	}
+}
+
+func (u *sagaAdminUC) ListDeadLetters(ctx context.Context, params *orders.ParamsListDeadLettersInput) (*orders.ParamsListDeadLettersOutput, error) {
+	deadLetters, total, err := u.deadLetters.List(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("u.deadLetters.List: %w", err)
+	}
+
+	out := orders.ParamsListDeadLettersOutput{
+		DeadLetters: deadLetters,
+		Page:        params.PageInt,
+		Limit:       params.LimitInt,
+		TotalItens:  total,
+		TotalPages:  int(math.Ceil(float64(total) / float64(params.LimitInt))),
+	}
+
+	return &out, nil
+}
+
+func (u *sagaAdminUC) FindDeadLetter(ctx context.Context, params *orders.ParamsDeadLetterInput) (*orders.DeadLetter, error) {
+	return u.deadLetters.Find(ctx, params.DeadLetterId)
+}
+
+// RetryDeadLetter claims the dead letter before running its compensation, so
+// two admins retrying at once do not apply it twice. A failed retry opens it
+// again. The compensation gets no longer than the claim lease, so the claim
+// is not taken over while it still runs.
+func (u *sagaAdminUC) RetryDeadLetter(ctx context.Context, params *orders.ParamsDeadLetterInput) (*orders.DeadLetter, error) {
+	deadLetter, err := u.deadLetters.Find(ctx, params.DeadLetterId)
+	if err != nil {
+		return nil, err
+	}
+
+	if deadLetter.Status == orders.DeadLetterResolved {
+		return nil, orders.ErrDeadLetterResolved
+	}
+
+	if err := u.deadLetters.Claim(ctx, deadLetter); err != nil {
+		return nil, err
+	}
+
+	deadLetter.Attempts++
+
+	compensateCtx, cancel := context.WithTimeout(ctx, orders.DeadLetterClaimLease)
+	errCompensate := u.worker.Compensate(compensateCtx, deadLetter.Step, deadLetter.Payload)
+	cancel()
+
+	if errCompensate != nil {
+		deadLetter.Status = orders.DeadLetterOpen
+		deadLetter.LastErr = errCompensate.Error()
+	} else {
+		now := time.Now()
+		deadLetter.Status = orders.DeadLetterResolved
+		deadLetter.ResolutionNote = "compensation retried successfully"
+		deadLetter.ResolvedAt = &now
+	}
+
+	// the claim must not be left behind because the admin went away
+	if err := u.deadLetters.Update(context.WithoutCancel(ctx), deadLetter); err != nil {
+		if errors.Is(err, orders.ErrDeadLetterNotOpen) {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("u.deadLetters.Update: %w", err)
+	}
+
+	if errCompensate != nil {
+		return deadLetter, fmt.Errorf("u.worker.Compensate: %w", errCompensate)
+	}
+
+	return deadLetter, nil
+}
+
+func (u *sagaAdminUC) ResolveDeadLetter(ctx context.Context, params *orders.ParamsResolveDeadLetterInput) (*orders.DeadLetter, error) {
+	deadLetter, err := u.deadLetters.Find(ctx, params.DeadLetterId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	if deadLetter.Status == orders.DeadLetterResolved {
+		return nil, orders.ErrDeadLetterResolved
+	}
+
+	// a retry left behind by a crash no longer holds it
+	if deadLetter.Claimed(now) {
+		return nil, orders.ErrDeadLetterNotOpen
+	}
+
+	deadLetter.Status = orders.DeadLetterResolved
+	deadLetter.ResolvedBy = params.ResolvedBy
+	deadLetter.ResolutionNote = params.Note
+	deadLetter.ResolvedAt = &now
+
+	// fails if a retry claimed it since it was read
+	if err := u.deadLetters.Update(ctx, deadLetter); err != nil {
+		if errors.Is(err, orders.ErrDeadLetterNotOpen) {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("u.deadLetters.Update: %w", err)
+	}
+
+	return deadLetter, nil
+}
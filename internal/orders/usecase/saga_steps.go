@@ -0,0 +1,398 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aclgo/simple-api-gateway/internal/domain/models"
+	"github.com/aclgo/simple-api-gateway/internal/orders"
+	"github.com/aclgo/simple-api-gateway/internal/promotion"
+	protoOrders "github.com/aclgo/simple-api-gateway/proto-service/orders"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultStepTimeout = orders.StepTimeout
+	paymentStepTimeout = orders.PaymentStepTimeout
+
+	sagaKeyPayment      = "payment"
+	sagaKeyOrder        = "order"
+	sagaKeySubscription = "subscription"
+	sagaKeyReservation  = "reservation"
+	sagaKeyDiscount     = "discount"
+)
+
+// couponStep redeems the coupon of the order, if any, and leaves the discount
+// in the state for the steps that charge the customer. The redemption is
+// released on rollback so it does not count towards the usage limits.
+func (u *orderUC) couponStep(params *promotion.ParamsApplyCouponInput) *orders.SagaStep {
+	return &orders.SagaStep{
+		Name:    "apply-coupon",
+		Timeout: defaultStepTimeout,
+		Action: func(ctx context.Context, state *orders.SagaState) error {
+			if params.Code == "" {
+				return nil
+			}
+
+			applied, err := u.promotion.ApplyCoupon(ctx, params)
+			if err != nil {
+				return fmt.Errorf("u.promotion.ApplyCoupon: %w", err)
+			}
+
+			state.Set(sagaKeyDiscount, &orders.OrderDiscount{
+				CouponId:     applied.CouponId,
+				Code:         applied.Code,
+				RedemptionId: applied.RedemptionId,
+				Discount:     applied.Discount,
+			})
+
+			return nil
+		},
+		Compensation: orders.StepReleaseCoupon,
+		CompensationPayload: func(state *orders.SagaState) any {
+			discount := discountFrom(state)
+			if discount == nil {
+				return nil
+			}
+
+			return &orders.ParamsCompensateReleaseCoupon{RedemptionId: discount.RedemptionId}
+		},
+	}
+}
+
+func discountFrom(state *orders.SagaState) *orders.OrderDiscount {
+	discount, _ := orders.SagaValue[*orders.OrderDiscount](state, sagaKeyDiscount)
+	return discount
+}
+
+// chargedAmount is what the customer pays once the coupon is applied.
+func chargedAmount(state *orders.SagaState, amount int64) int64 {
+	if discount := discountFrom(state); discount != nil {
+		return amount - discount.Discount
+	}
+
+	return amount
+}
+
+// paymentStep charges the customer through the gateway. A captured payment
+// is refunded if a later step fails.
+func (u *orderUC) paymentStep(params *models.ParamPaymentProcessInput) *orders.SagaStep {
+	return &orders.SagaStep{
+		Name:    "generate-payment",
+		Timeout: paymentStepTimeout,
+		Action: func(ctx context.Context, state *orders.SagaState) error {
+			charge := *params
+			charge.Amount = chargedAmount(state, params.Amount)
+
+			payment, err := u.gateway.GeneratePayment(ctx, &charge)
+			if err != nil {
+				if payment == nil {
+					return fmt.Errorf("u.gateway.GeneratePayment: %w", err)
+				}
+
+				u.logger.Errorf("u.gateway.GeneratePayment: reference %s: %v", payment.ReferenceId, err)
+			}
+
+			state.Set(sagaKeyPayment, payment)
+
+			return nil
+		},
+		Compensation: orders.StepRefundPayment,
+		CompensationPayload: func(state *orders.SagaState) any {
+			payment, _ := orders.SagaValue[*models.ParamPaymentProcessOutput](state, sagaKeyPayment)
+			if payment.Status != models.PaymentPaid {
+				return nil
+			}
+
+			return &orders.ParamsCompensateRefundPayment{
+				Method:               payment.Method,
+				Provider:             payment.Provider,
+				AccountId:            params.AccountId,
+				GatewayTransactionID: payment.GatewayTransactionID,
+				Amount:               chargedAmount(state, params.Amount),
+			}
+		},
+	}
+}
+
+// createPaymentOrderStep records the order for the payment made by
+// paymentStep. metadata builds the order metadata once the discount is known.
+// A paid order is flagged as refunded if a later step fails. A declined or
+// cancelled payment still gets its order, so the customer sees why, and gives
+// back the coupon it redeemed.
+func (u *orderUC) createPaymentOrderStep(orderType protoOrders.OrderType, accountId string, amount int64,
+	metadata func(discount *orders.OrderDiscount) any) *orders.SagaStep {
+	return &orders.SagaStep{
+		Name:    "create-order",
+		Timeout: defaultStepTimeout,
+		Action: func(ctx context.Context, state *orders.SagaState) error {
+			payment, _ := orders.SagaValue[*models.ParamPaymentProcessOutput](state, sagaKeyPayment)
+
+			metadataJson, err := json.Marshal(metadata(discountFrom(state)))
+			if err != nil {
+				return fmt.Errorf("json.Marshal: %w", err)
+			}
+
+			metadataJson, err = orders.WithPaymentProvider(metadataJson, payment.Provider)
+			if err != nil {
+				return fmt.Errorf("orders.WithPaymentProvider: %w", err)
+			}
+
+			var pixExp *timestamppb.Timestamp
+			if !payment.PixExpiration.IsZero() {
+				pixExp = timestamppb.New(payment.PixExpiration)
+			}
+
+			var boletoExp *timestamppb.Timestamp
+			if !payment.BoletoExpiration.IsZero() {
+				boletoExp = timestamppb.New(payment.BoletoExpiration)
+			}
+
+			paramsNewOrder := protoOrders.ParamCreateOrderRequest{
+				AccountID:            accountId,
+				Type:                 orderType,
+				Status:               orderStatusFromPayment(payment.Status),
+				Amount:               chargedAmount(state, amount),
+				PaymentMethod:        orderPaymentMethod(payment.Method),
+				Metadata:             metadataJson,
+				GatewayTransactionID: payment.GatewayTransactionID,
+				PixQRCode:            payment.PixQRCode,
+				PixExpiration:        pixExp,
+				CardToken:            payment.CardToken,
+				CardExpiration:       payment.CardExpiration,
+				BoletoURL:            payment.BoletoURL,
+				BoletoBarcode:        payment.BoletoBarcode,
+				BoletoExpiration:     boletoExp,
+			}
+
+			newOrder, err := u.clientOrdersGRPC.Create(ctx, &paramsNewOrder)
+			if err != nil {
+				return fmt.Errorf("u.clientOrdersGRPC.Create: %w", err)
+			}
+
+			state.Set(sagaKeyOrder, newOrder.Order)
+			u.indexOrder(ctx, newOrder.Order)
+			u.linkPaymentAttempts(ctx, payment.ReferenceId, newOrder.Order.OrderID)
+
+			switch newOrder.Order.Status {
+			case protoOrders.OrderStatus_PENDING:
+				u.scheduleExpiry(ctx, newOrder.Order, state)
+			case protoOrders.OrderStatus_FAILED, protoOrders.OrderStatus_CANCELLED:
+				u.releaseUnpaidCoupon(ctx, newOrder.Order, state)
+			}
+
+			return nil
+		},
+		Compensation: orders.StepUpdateOrderStatus,
+		CompensationPayload: func(state *orders.SagaState) any {
+			order, _ := orders.SagaValue[*protoOrders.Orders](state, sagaKeyOrder)
+			if order.Status != protoOrders.OrderStatus_PAID {
+				return nil
+			}
+
+			return &orders.ParamsCompensateOrderStatus{
+				OrderId: order.OrderID,
+				Status:  protoOrders.OrderStatus_REFUNDED.String(),
+				Reason:  "order rolled back",
+			}
+		},
+	}
+}
+
+// linkPaymentAttempts files the attempts of the payment under the order. The
+// order already exists, so a failure here is only logged.
+func (u *orderUC) linkPaymentAttempts(ctx context.Context, referenceId string, orderId string) {
+	if err := u.gateway.LinkAttempts(context.WithoutCancel(ctx), referenceId, orderId); err != nil {
+		u.logger.Errorf("u.gateway.LinkAttempts: order %s: %v", orderId, err)
+	}
+}
+
+// releaseUnpaidCoupon gives back the redemption of an order whose payment
+// did not go through. The saga completes in that case, so the rollback of
+// couponStep never runs; a failure is retried by the saga worker.
+func (u *orderUC) releaseUnpaidCoupon(ctx context.Context, order *protoOrders.Orders, state *orders.SagaState) {
+	discount := discountFrom(state)
+	if discount == nil {
+		return
+	}
+
+	u.runFollowUps(ctx, "unpaid order "+order.OrderID, []followUp{
+		{step: orders.StepReleaseCoupon, payload: &orders.ParamsCompensateReleaseCoupon{RedemptionId: discount.RedemptionId}},
+	})
+}
+
+// scheduleExpiry has the sweeper cancel the order if its pix or boleto is not
+// paid in time. The order already exists, so a failure here is only logged.
+func (u *orderUC) scheduleExpiry(ctx context.Context, order *protoOrders.Orders, state *orders.SagaState) {
+	expiresAt := orders.PaymentExpiration(order)
+	if expiresAt.IsZero() {
+		return
+	}
+
+	expiration := orders.OrderExpiration{
+		OrderId:   order.OrderID,
+		ExpiresAt: expiresAt,
+	}
+
+	if reservation, ok := orders.SagaValue[*orders.ParamsCompensateReleaseStock](state, sagaKeyReservation); ok {
+		expiration.ReservationId = reservation.ReservationId
+		expiration.ProductsIDS = reservation.ProductsIDS
+	}
+
+	if discount := discountFrom(state); discount != nil {
+		expiration.RedemptionId = discount.RedemptionId
+	}
+
+	if err := u.expirations.Create(ctx, &expiration); err != nil {
+		u.logger.Errorf("u.expirations.Create: order %s: %v", order.OrderID, err)
+	}
+}
+
+func orderStatusFromPayment(status models.StatusPayment) protoOrders.OrderStatus {
+	switch status {
+	case models.PaymentPaid:
+		return protoOrders.OrderStatus_PAID
+	case models.PaymentFailed:
+		return protoOrders.OrderStatus_FAILED
+	case models.PaymentPending:
+		return protoOrders.OrderStatus_PENDING
+	case models.PaymentCancelled:
+		return protoOrders.OrderStatus_CANCELLED
+	case models.PaymentRefunded:
+		return protoOrders.OrderStatus_REFUNDED
+	case models.PaymentUnspecified:
+		return protoOrders.OrderStatus_ORDER_STATUS_UNSPECIFIED
+	}
+
+	return protoOrders.OrderStatus_PENDING
+}
+
+func orderPaymentMethod(method string) protoOrders.PaymentMethod {
+	switch method {
+	case models.PaymentMethodPix:
+		return protoOrders.PaymentMethod_PIX
+	case models.PaymentMethodCard:
+		return protoOrders.PaymentMethod_CREDIT_CARD
+	case models.PaymentMethodBoleto:
+		return protoOrders.PaymentMethod_BOLETO
+	case models.PaymentMethodInternalBalance:
+		return protoOrders.PaymentMethod_INTERNAL_BALANCE
+	}
+
+	return protoOrders.PaymentMethod_PAYMENT_METHOD_UNSPECIFIED
+}
+
+// reserveStockStep holds the units being bought so concurrent purchases
+// cannot sell the same unit twice. The hold is released on rollback.
+func (u *orderUC) reserveStockStep(reservationId string, items []*orders.StockItem) *orders.SagaStep {
+	return &orders.SagaStep{
+		Name:    "reserve-stock",
+		Timeout: defaultStepTimeout,
+		Action: func(ctx context.Context, state *orders.SagaState) error {
+			if err := u.stock.Reserve(ctx, reservationId, items); err != nil {
+				return fmt.Errorf("u.stock.Reserve: %w", err)
+			}
+
+			state.Set(sagaKeyReservation, &orders.ParamsCompensateReleaseStock{
+				ReservationId: reservationId,
+				ProductsIDS:   stockProductsIds(items),
+			})
+
+			return nil
+		},
+		Compensation: orders.StepReleaseStock,
+		CompensationPayload: func(state *orders.SagaState) any {
+			return &orders.ParamsCompensateReleaseStock{
+				ReservationId: reservationId,
+				ProductsIDS:   stockProductsIds(items),
+			}
+		},
+	}
+}
+
+// confirmStockStep runs right before the order is created. A hold that
+// lapsed fails the saga, since its units may have been sold again; one still
+// in place is kept from lapsing until commitStockStep.
+func (u *orderUC) confirmStockStep(reservationId string, items []*orders.StockItem) *orders.SagaStep {
+	return &orders.SagaStep{
+		Name:    "confirm-stock",
+		Timeout: defaultStepTimeout,
+		Action: func(ctx context.Context, state *orders.SagaState) error {
+			if err := u.stock.Confirm(ctx, reservationId, stockProductsIds(items)); err != nil {
+				return fmt.Errorf("u.stock.Confirm: %w", err)
+			}
+
+			return nil
+		},
+	}
+}
+
+// commitStockStep runs once the order exists, so it never fails the saga: a
+// commit error is only logged.
+func (u *orderUC) commitStockStep(reservationId string, items []*orders.StockItem) *orders.SagaStep {
+	return &orders.SagaStep{
+		Name:    "commit-stock",
+		Timeout: defaultStepTimeout,
+		Action: func(ctx context.Context, state *orders.SagaState) error {
+			u.commitStock(ctx, reservationId, items)
+
+			return nil
+		},
+	}
+}
+
+// commitStock takes the sold units off the catalog as a decrement, so
+// concurrent sales of the same product add up instead of overwriting each
+// other. The reservation id keeps a retried decrement from counting twice,
+// and the ones that fail are retried by the saga worker.
+func (u *orderUC) commitStock(ctx context.Context, reservationId string, items []*orders.StockItem) {
+	if err := u.stock.Commit(ctx, reservationId, stockProductsIds(items)); err != nil {
+		u.logger.Errorf("u.stock.Commit: reservation %s: %v", reservationId, err)
+		return
+	}
+
+	followUps := make([]followUp, 0, len(items))
+
+	for _, item := range items {
+		followUps = append(followUps, followUp{
+			step: orders.StepDecreaseQuantity,
+			payload: &orders.ParamsCompensateDecreaseQuantity{
+				ProductId: item.ProductId,
+				Quantity:  item.Quantity,
+				Reference: reservationId,
+			},
+		})
+	}
+
+	u.runFollowUps(ctx, "commit stock "+reservationId, followUps)
+}
+
+func (u *orderUC) releaseStock(ctx context.Context, reservationId string, items []*orders.StockItem) {
+	if err := u.stock.Release(context.WithoutCancel(ctx), reservationId, stockProductsIds(items)); err != nil {
+		u.logger.Errorf("u.stock.Release: reservation %s: %v", reservationId, err)
+	}
+}
+
+func stockProductsIds(items []*orders.StockItem) []string {
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ProductId)
+	}
+
+	return ids
+}
+
+// issueInvoice sends the receipt of an order paid on the spot. Pending
+// orders are invoiced when their payment is confirmed. The order is paid
+// already, so a failure is only logged; the receipt can still be issued on
+// download.
+func (u *orderUC) issueInvoice(ctx context.Context, order *protoOrders.Orders) {
+	if order.Status != protoOrders.OrderStatus_PAID {
+		return
+	}
+
+	if err := u.invoices.Issue(context.WithoutCancel(ctx), order.OrderID); err != nil {
+		u.logger.Errorf("u.invoices.Issue: order %s: %v", order.OrderID, err)
+	}
+}
@@ -2,6 +2,8 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"log"
 	"sync"
 	"time"
@@ -9,67 +11,299 @@ import (
 	"github.com/aclgo/simple-api-gateway/internal/orders"
 )
 
+type SagaWorkerConfig struct {
+	Workers     int
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	MaxAge      time.Duration
+}
 
 type SagaWorker struct {
-	taskArray []*orders.CompensationTask
-	maxAttempts int
-	delay time .Duration
-	mu sync.Mutex
+	repo          orders.SagaRepository
+	deadLetters   orders.DeadLetterRepository
+	taskArray     []*orders.CompensationTask
+	compensations map[string]orders.CompensationFunc
+	cfg           SagaWorkerConfig
+	mu            sync.Mutex
+	wake          chan struct{}
+	quit          chan struct{}
+	stopOnce      sync.Once
+	wg            sync.WaitGroup
+	cancelWork    context.CancelFunc
 }
 
-func NewSagaWorker(maxAttempts int, delay time.Duration)*SagaWorker{
+func NewSagaWorker(repo orders.SagaRepository, deadLetters orders.DeadLetterRepository, cfg SagaWorkerConfig) *SagaWorker {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 3
+	}
+
+	if cfg.BaseDelay <= 0 {
+		cfg.BaseDelay = time.Second
+	}
+
+	if cfg.MaxDelay < cfg.BaseDelay {
+		cfg.MaxDelay = cfg.BaseDelay
+	}
+
 	return &SagaWorker{
-		taskArray: make([]*orders.CompensationTask, 0),
+		repo:          repo,
+		deadLetters:   deadLetters,
+		taskArray:     make([]*orders.CompensationTask, 0),
+		compensations: make(map[string]orders.CompensationFunc),
+		cfg:           cfg,
+		wake:          make(chan struct{}, cfg.Workers),
+		quit:          make(chan struct{}),
 	}
 }
 
-func(s *SagaWorker)Start(ctx context.Context){
+// Start replays every task left pending or retrying by a previous run and
+// then starts the consumers. Compensations must be registered first.
+func (s *SagaWorker) Start(ctx context.Context) error {
+	if err := s.replay(ctx); err != nil {
+		return err
+	}
+
+	// in-flight compensations outlive ctx so Stop can let them finish
+	workCtx, cancelWork := context.WithCancel(context.WithoutCancel(ctx))
+	s.cancelWork = cancelWork
+
+	for i := 0; i < s.cfg.Workers; i++ {
+		s.wg.Add(1)
+		go s.consume(ctx, workCtx)
+	}
 
-	ticker := time.NewTicker(5 *time.Second)
-	defer ticker.Stop()
+	s.notify()
 
+	return nil
+}
+
+// Stop stops taking new tasks and waits for the in-flight ones to finish.
+// When ctx expires first the remaining work is cancelled; those tasks stay
+// retrying in the database and are replayed on the next start.
+func (s *SagaWorker) Stop(ctx context.Context) error {
+	s.stopOnce.Do(func() {
+		close(s.quit)
+	})
+
+	done := make(chan struct{})
 	go func() {
-		for{
-			select{
-			case <-ctx.Done():
-				return
-			case <-ticker.C:
-				s.proccessNext(ctx)
-			}
-		}
+		s.wg.Wait()
+		close(done)
 	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		if s.cancelWork != nil {
+			s.cancelWork()
+		}
+		<-done
+		return ctx.Err()
+	}
 }
 
-func (s *SagaWorker)AppendTask(task *orders.CompensationTask){
+func (s *SagaWorker) RegisterCompensation(step string, fn orders.CompensationFunc) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.compensations[step] = fn
+}
+
+func (s *SagaWorker) AppendTask(ctx context.Context, task *orders.CompensationTask) error {
+	if err := s.repo.Create(ctx, task); err != nil {
+		return fmt.Errorf("s.repo.Create: %w", err)
+	}
+
+	s.mu.Lock()
 	s.taskArray = append(s.taskArray, task)
+	s.mu.Unlock()
+
+	s.notify()
+
+	return nil
 }
 
-func(s *SagaWorker)proccessNext(ctx context.Context){
+func (s *SagaWorker) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *SagaWorker) replay(ctx context.Context) error {
+	tasks, err := s.repo.FindUnfinished(ctx)
+	if err != nil {
+		return fmt.Errorf("s.repo.FindUnfinished: %w", err)
+	}
+
 	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.taskArray = append(s.taskArray, tasks...)
 
-	if len(s.taskArray) == 0  {
-		s.mu.Unlock()
-		return
+	if len(tasks) > 0 {
+		log.Printf("saga worker: replaying %d unfinished compensation task(s)", len(tasks))
+	}
+
+	return nil
+}
+
+func (s *SagaWorker) consume(ctx context.Context, workCtx context.Context) {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.quit:
+			return
+		default:
+		}
+
+		task, ok := s.next()
+		if !ok {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.quit:
+				return
+			case <-s.wake:
+			}
+
+			continue
+		}
+
+		s.processTask(workCtx, task)
+	}
+}
+
+func (s *SagaWorker) next() (*orders.CompensationTask, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.taskArray) == 0 {
+		return nil, false
 	}
 
 	task := s.taskArray[0]
 
 	s.taskArray = s.taskArray[1:]
 
-	s.mu.Unlock()
+	return task, true
+}
+
+func (s *SagaWorker) compensation(step string) (orders.CompensationFunc, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn, ok := s.compensations[step]
+	return fn, ok
+}
+
+// Compensate runs a single registered compensation once, outside the queue.
+// It is used to retry dead letters on demand.
+func (s *SagaWorker) Compensate(ctx context.Context, step string, payload json.RawMessage) error {
+	compFn, ok := s.compensation(step)
+	if !ok {
+		return fmt.Errorf("%w: %s", orders.ErrCompensationNotRegistered, step)
+	}
+
+	return compFn(ctx, payload)
+}
+
+func (s *SagaWorker) deadLetter(ctx context.Context, task *orders.CompensationTask, step *orders.CompensationStep, err error) {
+	log.Printf("[CRITICAL ALARM] worker falhou permanentemente na compensação! task: %s | step: %s | erro original: %v | erro compensação: %v", task.Id, step.Name, task.OriginalErr, err)
+
+	if errDL := s.deadLetters.Create(ctx, orders.NewDeadLetter(task, step, err)); errDL != nil {
+		log.Printf("[CRITICAL ALARM] failed to store dead letter for task %s step %s: %v", task.Id, step.Name, errDL)
+	}
+}
+
+func (s *SagaWorker) save(ctx context.Context, task *orders.CompensationTask) {
+	if err := s.repo.Update(ctx, task); err != nil {
+		log.Printf("saga worker: failed to persist task %s: %v", task.Id, err)
+	}
+}
+
+func (s *SagaWorker) policy(task *orders.CompensationTask) retryPolicy {
+	policy := retryPolicy{
+		maxAttempts: s.cfg.MaxAttempts,
+		baseDelay:   s.cfg.BaseDelay,
+		maxDelay:    s.cfg.MaxDelay,
+	}
 
-	s.processTask(ctx, task)
+	if s.cfg.MaxAge > 0 {
+		policy.deadline = task.CreatedAt.Add(s.cfg.MaxAge)
+	}
+
+	return policy
 }
 
-func(s *SagaWorker)processTask(ctx context.Context, task *orders.CompensationTask){
-	for i := len(task.Compensations)-1;i>=0;i--{
-		compFn := task.Compensations[i]
+func (s *SagaWorker) processTask(ctx context.Context, task *orders.CompensationTask) {
+	task.Status = orders.CompensationRetrying
+	s.save(ctx, task)
+
+	policy := s.policy(task)
+	failed := false
+
+	for i := len(task.Steps) - 1; i >= 0; i-- {
+		step := task.Steps[i]
+		if step.Done {
+			continue
+		}
+
+		if !policy.deadline.IsZero() && time.Now().After(policy.deadline) {
+			failed = true
+			task.LastErr = orders.ErrCompensationExpired.Error()
+			s.deadLetter(ctx, task, step, orders.ErrCompensationExpired)
+			continue
+		}
+
+		compFn, ok := s.compensation(step.Name)
+		if !ok {
+			failed = true
+			err := fmt.Errorf("%w: %s", orders.ErrCompensationNotRegistered, step.Name)
+			task.LastErr = err.Error()
+			s.deadLetter(ctx, task, step, err)
+			continue
+		}
+
+		retryErr := compensateWithRetry(ctx, policy, func(ctx context.Context) error {
+			task.Attempts++
+
+			err := compFn(ctx, step.Payload)
+			if err != nil {
+				task.LastErr = err.Error()
+				s.save(ctx, task)
+			}
+
+			return err
+		})
 
-		retryErr := compensateWithRetry(ctx, s.maxAttempts,s.delay,compFn)
 		if retryErr != nil {
-			log.Printf("[CRITICAL ALARM] worker falhou permanentemente na compensação! erro original: %v | erro compensação: %v", task.OriginalErr, retryErr)
+			// shutting down mid-retry leaves the task as retrying so the next start replays it
+			if ctx.Err() != nil {
+				return
+			}
+
+			failed = true
+			task.LastErr = retryErr.Error()
+			s.deadLetter(ctx, task, step, retryErr)
+			continue
 		}
+
+		step.Done = true
+		s.save(ctx, task)
+	}
+
+	if failed {
+		task.Status = orders.CompensationDead
+	} else {
+		task.Status = orders.CompensationDone
 	}
-}
\ No newline at end of file
+
+	s.save(ctx, task)
+}
@@ -0,0 +1,156 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/aclgo/simple-api-gateway/internal/orders"
+	protoOrders "github.com/aclgo/simple-api-gateway/proto-service/orders"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// FindByAccount pages through the orders of params.AccountId. The orders
+// service returns them all at once, so every order of the account is loaded
+// and the filters and paging are applied in memory; accounts with many
+// orders are better served by Search, which pages in the index.
+func (u *orderUC) FindByAccount(ctx context.Context, params *orders.ParamsSearchOrdersInput) (*orders.ParamsSearchOrdersOutput, error) {
+	found, err := u.clientOrdersGRPC.FindOrderByAccount(ctx, &protoOrders.ParamFindOrderByAccountRequest{
+		AccountID: params.AccountId,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("u.clientOrdersGRPC.FindOrderByAccount: %w", err)
+	}
+
+	return pageOrders(found.Orders, params)
+}
+
+// FindByProduct is FindByAccount for the orders of params.ProductId, with
+// the same cost: every order of the product is loaded to build one page.
+func (u *orderUC) FindByProduct(ctx context.Context, params *orders.ParamsSearchOrdersInput) (*orders.ParamsSearchOrdersOutput, error) {
+	found, err := u.clientOrdersGRPC.FindOrderByProduct(ctx, &protoOrders.ParamFindOrderByProductRequest{
+		ProductID: params.ProductId,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("u.clientOrdersGRPC.FindOrderByProduct: %w", err)
+	}
+
+	return pageOrders(found.Orders, params)
+}
+
+// Search looks through every order in the index, for admins.
+func (u *orderUC) Search(ctx context.Context, params *orders.ParamsSearchOrdersInput) (*orders.ParamsSearchOrdersOutput, error) {
+	indexed, total, err := u.index.Search(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("u.index.Search: %w", err)
+	}
+
+	out := newSearchOutput(params, total)
+
+	for _, order := range indexed {
+		summary, err := newOrderSummaryOutput(indexedToProto(order))
+		if err != nil {
+			return nil, err
+		}
+
+		out.Orders = append(out.Orders, summary)
+	}
+
+	return out, nil
+}
+
+func pageOrders(all []*protoOrders.Orders, params *orders.ParamsSearchOrdersInput) (*orders.ParamsSearchOrdersOutput, error) {
+	matched := make([]*protoOrders.Orders, 0, len(all))
+	for _, order := range all {
+		if params.Match(order) {
+			matched = append(matched, order)
+		}
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		a, b := matched[i], matched[j]
+
+		switch params.Sort {
+		case orders.SortCreatedAtAsc:
+			return a.CreatedAT.AsTime().Before(b.CreatedAT.AsTime())
+		case orders.SortAmountDesc:
+			return a.Amount > b.Amount
+		case orders.SortAmountAsc:
+			return a.Amount < b.Amount
+		}
+
+		return a.CreatedAT.AsTime().After(b.CreatedAT.AsTime())
+	})
+
+	out := newSearchOutput(params, len(matched))
+
+	start := min((params.PageInt-1)*params.LimitInt, len(matched))
+	end := min(start+params.LimitInt, len(matched))
+
+	for _, order := range matched[start:end] {
+		summary, err := newOrderSummaryOutput(order)
+		if err != nil {
+			return nil, err
+		}
+
+		out.Orders = append(out.Orders, summary)
+	}
+
+	return out, nil
+}
+
+func newSearchOutput(params *orders.ParamsSearchOrdersInput, total int) *orders.ParamsSearchOrdersOutput {
+	return &orders.ParamsSearchOrdersOutput{
+		Orders:     make([]*orders.OrderSummaryOutput, 0, params.LimitInt),
+		Page:       params.PageInt,
+		Limit:      params.LimitInt,
+		TotalItens: total,
+		TotalPages: int(math.Ceil(float64(total) / float64(params.LimitInt))),
+	}
+}
+
+func newOrderSummaryOutput(order *protoOrders.Orders) (*orders.OrderSummaryOutput, error) {
+	out := orders.OrderSummaryOutput{
+		OrderId:       order.OrderID,
+		AccountId:     order.AccountID,
+		Type:          order.Type.String(),
+		Status:        order.Status.String(),
+		PaymentMethod: order.PaymentMethod.String(),
+		Amount:        order.Amount,
+		ProductsIDS:   make([]orders.ProductItem, 0),
+		CreatedAt:     order.CreatedAT.AsTime(),
+	}
+
+	if order.Type == protoOrders.OrderType_PRODUCT_PURCHASE {
+		metadata, err := orders.DecodeProductOrderMetadata(order.Metadata)
+		if err != nil {
+			return nil, err
+		}
+
+		out.ProductsIDS = metadata.Products
+		out.Discount = metadata.Discount
+		out.Subtotal, _, out.Total, out.TotalItems = metadata.Totals()
+	}
+
+	return &out, nil
+}
+
+func (u *orderUC) indexOrder(ctx context.Context, order *protoOrders.Orders) {
+	if err := orders.IndexNewOrder(ctx, u.index, order); err != nil {
+		u.logger.Errorf("orders.IndexNewOrder: %v", err)
+	}
+}
+
+func indexedToProto(order *orders.IndexedOrder) *protoOrders.Orders {
+	return &protoOrders.Orders{
+		OrderID:       order.OrderId,
+		AccountID:     order.AccountId,
+		Type:          protoOrders.OrderType(protoOrders.OrderType_value[order.Type]),
+		Status:        protoOrders.OrderStatus(protoOrders.OrderStatus_value[order.Status]),
+		PaymentMethod: protoOrders.PaymentMethod(protoOrders.PaymentMethod_value[order.PaymentMethod]),
+		Amount:        order.Amount,
+		Metadata:      order.Metadata,
+		CreatedAT:     timestamppb.New(order.CreatedAt),
+	}
+}
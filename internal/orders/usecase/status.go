@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/orders"
+	"github.com/aclgo/simple-api-gateway/pkg/logger"
+	protoOrders "github.com/aclgo/simple-api-gateway/proto-service/orders"
+	"github.com/google/uuid"
+)
+
+type statusMachine struct {
+	clientOrdersGRPC protoOrders.ServiceOrderClient
+	history          orders.StatusHistoryRepository
+	index            orders.IndexRepository
+	logger           logger.Logger
+}
+
+func NewStatusMachine(clientOrdersGRPC protoOrders.ServiceOrderClient, history orders.StatusHistoryRepository,
+	index orders.IndexRepository, logger logger.Logger) orders.StatusMachine {
+	return &statusMachine{
+		clientOrdersGRPC: clientOrdersGRPC,
+		history:          history,
+		index:            index,
+		logger:           logger,
+	}
+}
+
+func (m *statusMachine) Transition(ctx context.Context, params *orders.ParamsStatusTransitionInput,
+	apply func(ctx context.Context, order *protoOrders.Orders) error) (*orders.StatusChange, error) {
+
+	change := orders.StatusChange{
+		Id:        uuid.NewString(),
+		OrderId:   params.OrderId,
+		To:        params.To.String(),
+		Actor:     params.Actor,
+		Reason:    params.Reason,
+		CreatedAt: time.Now(),
+	}
+
+	err := m.history.Record(ctx, &change, func(ctx context.Context) error {
+		// read under the lock so the check sees the latest status
+		order, err := findOrder(ctx, m.clientOrdersGRPC, params.OrderId)
+		if err != nil {
+			return err
+		}
+
+		if err := orders.ValidateStatusTransition(order.Status, params.To); err != nil {
+			return err
+		}
+
+		change.From = order.Status.String()
+
+		if apply != nil {
+			if err := apply(ctx, order); err != nil {
+				return err
+			}
+		}
+
+		_, err = m.clientOrdersGRPC.UpdateOrderStatus(ctx, &protoOrders.ParamUpdateOrderStatusRequest{
+			OrderId: params.OrderId,
+			Status:  params.To,
+		})
+		if err != nil {
+			return fmt.Errorf("m.clientOrdersGRPC.UpdateOrderStatus: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.index.UpdateStatus(context.WithoutCancel(ctx), change.OrderId, change.To); err != nil {
+		m.logger.Errorf("m.index.UpdateStatus: order %s: %v", change.OrderId, err)
+	}
+
+	return &change, nil
+}
+
+func (m *statusMachine) History(ctx context.Context, params *orders.ParamsOrderHistoryInput) ([]*orders.StatusChange, error) {
+	if params.UserId != "" {
+		order, err := findOrder(ctx, m.clientOrdersGRPC, params.OrderId)
+		if err != nil {
+			return nil, err
+		}
+
+		if order.AccountID != params.UserId {
+			return nil, orders.ErrOrderNotFound
+		}
+	}
+
+	changes, err := m.history.ListByOrder(ctx, params.OrderId)
+	if err != nil {
+		return nil, fmt.Errorf("m.history.ListByOrder: %w", err)
+	}
+
+	return changes, nil
+}
+
+func (u *orderUC) History(ctx context.Context, params *orders.ParamsOrderHistoryInput) ([]*orders.StatusChange, error) {
+	return u.status.History(ctx, params)
+}
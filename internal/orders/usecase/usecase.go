@@ -8,16 +8,15 @@ import (
 	"sync"
 	"time"
 
-	"log"
-
+	"github.com/aclgo/simple-api-gateway/internal/catalog"
 	"github.com/aclgo/simple-api-gateway/internal/domain/models"
 	"github.com/aclgo/simple-api-gateway/internal/orders"
+	"github.com/aclgo/simple-api-gateway/internal/promotion"
 	"github.com/aclgo/simple-api-gateway/pkg/logger"
 	protoBalance "github.com/aclgo/simple-api-gateway/proto-service/balance"
 	protoOrders "github.com/aclgo/simple-api-gateway/proto-service/orders"
 	protoProduct "github.com/aclgo/simple-api-gateway/proto-service/product"
 	"github.com/google/uuid"
-	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type orderUC struct {
@@ -29,6 +28,15 @@ type orderUC struct {
 	workerSaga         orders.SagaWorker
 	gateway            orders.PaymentGateway
 	subscription       orders.SubscriptionInterface
+	stock              orders.StockReservation
+	promotion          orders.PromotionInterface
+	catalog            orders.CatalogInterface
+	refunds            orders.RefundRepository
+	status             orders.StatusMachine
+	expirations        orders.ExpirationRepository
+	index              orders.IndexRepository
+	invoices           orders.InvoiceInterface
+	compensations      map[string]orders.CompensationFunc
 }
 
 func NeworderUC(
@@ -40,12 +48,57 @@ func NeworderUC(
 	workerSaga orders.SagaWorker,
 	gateway orders.PaymentGateway,
 	subscription orders.SubscriptionInterface,
+	stock orders.StockReservation,
+	promotion orders.PromotionInterface,
+	catalog orders.CatalogInterface,
+	refunds orders.RefundRepository,
+	status orders.StatusMachine,
+	expirations orders.ExpirationRepository,
+	index orders.IndexRepository,
+	invoices orders.InvoiceInterface,
 ) (*orderUC, error) {
 
 	if gateway == nil {
 		return nil, errors.New("not configured orders gateways payment")
 	}
-	return &orderUC{
+
+	if workerSaga == nil {
+		return nil, errors.New("not configured orders saga worker")
+	}
+
+	if stock == nil {
+		return nil, errors.New("not configured orders stock reservation")
+	}
+
+	if promotion == nil {
+		return nil, errors.New("not configured orders promotion")
+	}
+
+	if catalog == nil {
+		return nil, errors.New("not configured orders catalog")
+	}
+
+	if refunds == nil {
+		return nil, errors.New("not configured orders refunds")
+	}
+
+	if status == nil {
+		return nil, errors.New("not configured orders status machine")
+	}
+
+	if expirations == nil {
+		return nil, errors.New("not configured orders expirations")
+	}
+
+	if index == nil {
+		return nil, errors.New("not configured orders index")
+	}
+
+	if invoices == nil {
+		return nil, errors.New("not configured orders invoices")
+	}
+
+	uc := &orderUC{
 		clientOrdersGRPC:   clientOrdersGRPC,
 		clientBalanceGPRC:  clientBalanceGRPC,
 		clientProductsGRPC: clientProductsGRPC,
@@ -54,24 +107,27 @@ func NeworderUC(
 		workerSaga:         workerSaga,
 		gateway:            gateway,
 		subscription:       subscription,
-	}, nil
+		stock:              stock,
+		promotion:          promotion,
+		catalog:            catalog,
+		refunds:            refunds,
+		status:             status,
+		expirations:        expirations,
+		index:              index,
+		invoices:           invoices,
+	}
+
+	uc.registerCompensations()
+
+	return uc, nil
 }
 
 // version create order v1 simple
 func (u *orderUC) Create(ctx context.Context, in *orders.ParamBuyProductInput) (*orders.OrderCreateOutput, error) {
 
-	var amountProducts int64
-
-	for i := range in.ProductsIDS {
-		paramProductProto := protoProduct.ProductFindRequest{
-			Id: in.ProductsIDS[i].Id,
-		}
-
-		product, err := u.clientProductsGRPC.Find(ctx, &paramProductProto)
-		if err != nil {
-			return nil, fmt.Errorf("u.clientProductsGRPC.Find: %w", err)
-		}
-		amountProducts = amountProducts + product.Product.Price
+	items, stockItems, amountProducts, err := u.priceProducts(ctx, in.ProductsIDS)
+	if err != nil {
+		return nil, err
 	}
 
 	paramProtoFindAccount := protoBalance.ParamGetWalletByAccountRequest{
@@ -89,6 +145,10 @@ func (u *orderUC) Create(ctx context.Context, in *orders.ParamBuyProductInput) (
 
 	refrenceId := uuid.NewString()
 
+	if err := u.stock.Reserve(ctx, refrenceId, stockItems); err != nil {
+		return nil, fmt.Errorf("u.stock.Reserve: %w", err)
+	}
+
 	paramProtoDebit := protoBalance.ParamDebitWalletRequest{
 		WalletID:    wallet.WalletID,
 		Amount:      amountProducts,
@@ -97,32 +157,62 @@ func (u *orderUC) Create(ctx context.Context, in *orders.ParamBuyProductInput) (
 
 	_, err = u.clientBalanceGPRC.Debit(ctx, &paramProtoDebit)
 	if err != nil {
+		u.releaseStock(ctx, refrenceId, stockItems)
 		return nil, fmt.Errorf("u.clientBalanceGPRC.Debit: %w", err)
 	}
 
-	for i := range in.ProductsIDS {
+	for i := range items {
 		paramProductProto := protoProduct.ProductUpdateRequest{
-			Id:         in.ProductsIDS[i].Id,
+			Id:         items[i].Id,
 			HasOrdered: true,
 		}
 
 		_, err := u.clientProductsGRPC.Update(ctx, &paramProductProto)
 		if err != nil {
-			return nil, fmt.Errorf("u.clientProductsGRPC.Find: %w", err)
+			// the debit went through, so the credit must not be lost
+			u.runFollowUps(ctx, "create order "+refrenceId, []followUp{{
+				step: orders.StepCreditWallet,
+				payload: &orders.ParamsCompensateCreditWallet{
+					WalletID:    wallet.WalletID,
+					Amount:      amountProducts,
+					ReferenceID: "refund-" + refrenceId,
+				},
+			}})
+
+			u.releaseStock(ctx, refrenceId, stockItems)
+
+			return nil, fmt.Errorf("u.clientProductsGRPC.Update: %w", err)
+		}
+	}
+
+	if err := u.stock.Confirm(ctx, refrenceId, stockProductsIds(stockItems)); err != nil {
+		_, errCredit := u.clientBalanceGPRC.Credit(ctx, &protoBalance.ParamCreditWalletRequest{
+			WalletID:    wallet.WalletID,
+			Amount:      amountProducts,
+			ReferenceID: uuid.NewString(),
+		})
+		if errCredit != nil {
+			u.logger.Errorf("u.clientBalanceGPRC.Credit: reference %s: %v", refrenceId, errCredit)
 		}
+
+		u.releaseStock(ctx, refrenceId, stockItems)
+
+		return nil, fmt.Errorf("u.stock.Confirm: %w", err)
 	}
 
-	metadata, err := json.Marshal(in.ProductsIDS)
+	metadata, err := json.Marshal(orders.ProductOrderMetadata{Products: items})
 	if err != nil {
 		return nil, fmt.Errorf("json.Marshal: %w", err)
 	}
 
 	paramProtoCreateOrder := protoOrders.ParamCreateOrderRequest{
-		AccountID:     in.UserId,
-		Type:          protoOrders.OrderType_PRODUCT_PURCHASE,
-		PaymentMethod: protoOrders.PaymentMethod_INTERNAL_BALANCE,
-		Status:        protoOrders.OrderStatus_PAID,
-		Metadata:      metadata,
+		AccountID:            in.UserId,
+		Type:                 protoOrders.OrderType_PRODUCT_PURCHASE,
+		PaymentMethod:        protoOrders.PaymentMethod_INTERNAL_BALANCE,
+		Status:               protoOrders.OrderStatus_PAID,
+		Amount:               amountProducts,
+		Metadata:             metadata,
+		GatewayTransactionID: refrenceId,
 	}
 
 	orderCreate, err := u.clientOrdersGRPC.Create(ctx, &paramProtoCreateOrder)
@@ -138,31 +228,106 @@ func (u *orderUC) Create(ctx context.Context, in *orders.ParamBuyProductInput) (
 			fmt.Printf("failed to credit account: %v", err)
 		}
 
+		u.releaseStock(ctx, refrenceId, stockItems)
+
 		return nil, fmt.Errorf("u.clientOrdersGRPC.Create: %w", err)
 	}
 
-	out := orders.OrderCreateOutput{
-		OrderId:   orderCreate.Order.OrderID,
-		AccountId: orderCreate.Order.AccountID,
-		CreatedAt: orderCreate.Order.CreatedAT.AsTime(),
+	u.commitStock(ctx, refrenceId, stockItems)
+	u.indexOrder(ctx, orderCreate.Order)
+	u.issueInvoice(ctx, orderCreate.Order)
+
+	return newOrderCreateOutput(orderCreate.Order)
+}
+
+// priceProducts turns the requested products into line items priced at the
+// current product price. Repeated ids are merged into a single line, which
+// must stay within MaxItemQuantity as well, and at most MaxOrderProducts
+// lines are accepted.
+func (u *orderUC) priceProducts(ctx context.Context, products []orders.ProductItem) ([]orders.ProductItem, []*orders.StockItem, int64, error) {
+	var amount int64
+
+	items := make([]orders.ProductItem, 0, len(products))
+	stockItems := make([]*orders.StockItem, 0, len(products))
+	lines := make(map[string]int, len(products))
+
+	for _, p := range products {
+		quantity := p.Quantity
+		if quantity == 0 {
+			quantity = 1
+		}
+
+		if quantity < 0 || quantity > orders.MaxItemQuantity {
+			return nil, nil, 0, fmt.Errorf("%w: product %s", orders.ErrQuantityInvalid, p.Id)
+		}
+
+		product, err := u.clientProductsGRPC.Find(ctx, &protoProduct.ProductFindRequest{Id: p.Id})
+		if err != nil {
+			return nil, nil, 0, fmt.Errorf("u.clientProductsGRPC.Find: product %s: %w", p.Id, err)
+		}
+
+		subtotal := product.Product.Price * quantity
+		amount += subtotal
+
+		if i, ok := lines[p.Id]; ok {
+			if items[i].Quantity+quantity > orders.MaxItemQuantity {
+				return nil, nil, 0, fmt.Errorf("%w: product %s", orders.ErrQuantityInvalid, p.Id)
+			}
+
+			items[i].Quantity += quantity
+			items[i].Subtotal += subtotal
+			stockItems[i].Quantity += quantity
+			continue
+		}
+
+		if len(items) == orders.MaxOrderProducts {
+			return nil, nil, 0, orders.ErrTooManyProducts
+		}
+
+		lines[p.Id] = len(items)
+
+		items = append(items, orders.ProductItem{
+			Id:        p.Id,
+			Quantity:  quantity,
+			UnitPrice: product.Product.Price,
+			Subtotal:  subtotal,
+		})
+
+		stockItems = append(stockItems, &orders.StockItem{
+			ProductId: p.Id,
+			Quantity:  quantity,
+			Available: product.Product.Quantity,
+		})
 	}
 
-	if err := json.Unmarshal(orderCreate.Order.Metadata, &out.ProductsIDS); err != nil {
-		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	return items, stockItems, amount, nil
+}
+
+func newOrderCreateOutput(order *protoOrders.Orders) (*orders.OrderCreateOutput, error) {
+	metadata, err := orders.DecodeProductOrderMetadata(order.Metadata)
+	if err != nil {
+		return nil, err
 	}
 
+	out := orders.OrderCreateOutput{
+		OrderId:       order.OrderID,
+		AccountId:     order.AccountID,
+		PaymentMethod: models.PaymentMethodInternalBalance,
+		ProductsIDS:   metadata.Products,
+		Discount:      metadata.Discount,
+		CreatedAt:     order.CreatedAT.AsTime(),
+	}
+
+	out.Subtotal, _, out.Total, out.TotalItems = metadata.Totals()
+
 	return &out, nil
 }
 
 // create order v2 using saga orchestration
 func (u *orderUC) CreateWithSaga(ctx context.Context, in *orders.ParamBuyProductInput) (*orders.OrderCreateOutput, error) {
-	var amountProducts int64
-	for _, pID := range in.ProductsIDS {
-		product, err := u.clientProductsGRPC.Find(ctx, &protoProduct.ProductFindRequest{Id: pID.Id})
-		if err != nil {
-			return nil, fmt.Errorf("failed to find product %s: %w", pID, err)
-		}
-		amountProducts += product.Product.Price
+	items, stockItems, amountProducts, err := u.priceProducts(ctx, in.ProductsIDS)
+	if err != nil {
+		return nil, err
 	}
 
 	wallet, err := u.clientBalanceGPRC.GetWalletByAccount(ctx, &protoBalance.ParamGetWalletByAccountRequest{AccountID: in.UserId})
@@ -170,99 +335,128 @@ func (u *orderUC) CreateWithSaga(ctx context.Context, in *orders.ParamBuyProduct
 		return nil, fmt.Errorf("failed to get wallet: %w", err)
 	}
 
-	if wallet.Balance < amountProducts {
-		return nil, fmt.Errorf("insufficient funds: amount is %d, balance is %d", amountProducts, wallet.Balance)
-	}
-
-	var compensations []func(context.Context) error
-
-	rollback := func(originalErr error) error {
-		u.workerSaga.AppendTask(&orders.CompensationTask{
-			OriginalErr:   originalErr,
-			Compensations: compensations,
-		})
-		return originalErr
-	}
-
 	referenceId := uuid.NewString()
+	referenceIdCreditCompensate := "refund-" + referenceId
 
-	_, err = u.clientBalanceGPRC.Debit(ctx, &protoBalance.ParamDebitWalletRequest{
-		WalletID:    wallet.WalletID,
+	coupon := promotion.ParamsApplyCouponInput{
+		Code:        in.CouponCode,
+		UserId:      in.UserId,
+		ReferenceId: referenceId,
+		Action:      string(orders.BuyProduct),
 		Amount:      amountProducts,
-		ReferenceID: referenceId,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to debit wallet: %w", err)
+		Items:       make([]promotion.CouponItem, 0, len(items)),
+	}
+
+	for _, item := range items {
+		coupon.Items = append(coupon.Items, promotion.CouponItem{ProductId: item.Id, Amount: item.Subtotal})
 	}
 
-	referenceIdCreditCompensate := uuid.NewString()
+	saga := orders.NewSaga(string(orders.BuyProduct), u.workerSaga)
 
-	compensations = append(compensations, func(ctx context.Context) error {
-		_, err := u.clientBalanceGPRC.Credit(ctx, &protoBalance.ParamCreditWalletRequest{
-			WalletID:    wallet.WalletID,
-			Amount:      amountProducts,
-			ReferenceID: referenceIdCreditCompensate,
-		})
-		return err
-	})
+	saga.AddStep(u.couponStep(&coupon))
+	saga.AddStep(u.reserveStockStep(referenceId, stockItems))
+
+	saga.AddStep(&orders.SagaStep{
+		Name:    "debit-wallet",
+		Timeout: defaultStepTimeout,
+		Action: func(ctx context.Context, state *orders.SagaState) error {
+			amount := chargedAmount(state, amountProducts)
 
-	successfullyUpdatedProducts := make([]string, 0, len(in.ProductsIDS))
+			if wallet.Balance < amount {
+				return fmt.Errorf("insufficient funds: amount is %d, balance is %d", amount, wallet.Balance)
+			}
 
-	compensations = append(compensations, func(ctx context.Context) error {
-		for _, pId := range successfullyUpdatedProducts {
-			_, err := u.clientProductsGRPC.Update(ctx, &protoProduct.ProductUpdateRequest{
-				Id:         pId,
-				HasOrdered: false,
+			_, err := u.clientBalanceGPRC.Debit(ctx, &protoBalance.ParamDebitWalletRequest{
+				WalletID:    wallet.WalletID,
+				Amount:      amount,
+				ReferenceID: referenceId,
 			})
 			if err != nil {
-				return err
+				return fmt.Errorf("failed to debit wallet: %w", err)
 			}
-		}
 
-		return nil
+			return nil
+		},
+		Compensation: orders.StepCreditWallet,
+		CompensationPayload: func(state *orders.SagaState) any {
+			return &orders.ParamsCompensateCreditWallet{
+				WalletID:    wallet.WalletID,
+				Amount:      chargedAmount(state, amountProducts),
+				ReferenceID: referenceIdCreditCompensate,
+			}
+		},
 	})
 
-	for _, pID := range in.ProductsIDS {
-		_, err := u.clientProductsGRPC.Update(ctx, &protoProduct.ProductUpdateRequest{
-			Id:         pID.Id,
-			HasOrdered: true,
+	for _, pID := range items {
+		saga.AddStep(&orders.SagaStep{
+			Name:    "mark-product-ordered",
+			Timeout: defaultStepTimeout,
+			Action: func(ctx context.Context, state *orders.SagaState) error {
+				_, err := u.clientProductsGRPC.Update(ctx, &protoProduct.ProductUpdateRequest{
+					Id:         pID.Id,
+					HasOrdered: true,
+				})
+				if err != nil {
+					return fmt.Errorf("failed to update product %s: %w", pID.Id, err)
+				}
+
+				return nil
+			},
+			Compensation: orders.StepRevertProductsOrdered,
+			CompensationPayload: func(state *orders.SagaState) any {
+				return &orders.ParamsCompensateRevertProducts{ProductsIDS: []string{pID.Id}}
+			},
 		})
-		if err != nil {
-			return nil, rollback(fmt.Errorf("failed to update product %s: %w", pID.Id, err))
-		}
-		successfullyUpdatedProducts = append(successfullyUpdatedProducts, pID.Id)
 	}
 
-	metadata, err := json.Marshal(in.ProductsIDS)
-	if err != nil {
-		return nil, fmt.Errorf("json.Marshal: %v", err)
-	}
+	saga.AddStep(u.confirmStockStep(referenceId, stockItems))
 
-	paramProtoCreateOrder := protoOrders.ParamCreateOrderRequest{
-		AccountID:     in.UserId,
-		Type:          protoOrders.OrderType_PRODUCT_PURCHASE,
-		PaymentMethod: protoOrders.PaymentMethod_INTERNAL_BALANCE,
-		Status:        protoOrders.OrderStatus_PAID,
-		Metadata:      metadata,
-	}
+	saga.AddStep(&orders.SagaStep{
+		Name:    "create-order",
+		Timeout: defaultStepTimeout,
+		Action: func(ctx context.Context, state *orders.SagaState) error {
+			metadata, err := json.Marshal(orders.ProductOrderMetadata{
+				Products: items,
+				Discount: discountFrom(state),
+			})
+			if err != nil {
+				return fmt.Errorf("json.Marshal: %w", err)
+			}
 
-	orderCreate, err := u.clientOrdersGRPC.Create(ctx, &paramProtoCreateOrder)
-	if err != nil {
-		return nil, rollback(fmt.Errorf("failed to create order: %w", err))
-	}
+			// the wallet debit is the payment, so its reference links the
+			// wallet statement back to the order
+			paramProtoCreateOrder := protoOrders.ParamCreateOrderRequest{
+				AccountID:            in.UserId,
+				Type:                 protoOrders.OrderType_PRODUCT_PURCHASE,
+				PaymentMethod:        protoOrders.PaymentMethod_INTERNAL_BALANCE,
+				Status:               protoOrders.OrderStatus_PAID,
+				Amount:               chargedAmount(state, amountProducts),
+				Metadata:             metadata,
+				GatewayTransactionID: referenceId,
+			}
 
-	out := &orders.OrderCreateOutput{
-		OrderId:       orderCreate.Order.OrderID,
-		AccountId:     orderCreate.Order.AccountID,
-		PaymentMethod: models.PaymentMethodInternalBalance,
-		CreatedAt:     orderCreate.Order.CreatedAT.AsTime(),
-	}
+			orderCreate, err := u.clientOrdersGRPC.Create(ctx, &paramProtoCreateOrder)
+			if err != nil {
+				return fmt.Errorf("failed to create order: %w", err)
+			}
+
+			state.Set(sagaKeyOrder, orderCreate.Order)
+			u.indexOrder(ctx, orderCreate.Order)
+
+			return nil
+		},
+	})
 
-	if err := json.Unmarshal(orderCreate.Order.Metadata, &out.ProductsIDS); err != nil {
-		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	saga.AddStep(u.commitStockStep(referenceId, stockItems))
+
+	if err := saga.Execute(ctx); err != nil {
+		return nil, err
 	}
 
-	return out, nil
+	order, _ := orders.SagaValue[*protoOrders.Orders](saga.State(), sagaKeyOrder)
+	u.issueInvoice(ctx, order)
+
+	return newOrderCreateOutput(order)
 }
 
 func (u *orderUC) FindById(ctx context.Context, in *orders.OrderFindByIdInput) (*orders.OrderFindByIdOutput, error) {
@@ -279,229 +473,159 @@ func (u *orderUC) FindById(ctx context.Context, in *orders.OrderFindByIdInput) (
 	out := orders.OrderFindByIdOutput{
 		OrderId:   find.Order.OrderID,
 		AccountId: find.Order.AccountID,
+		Type:      find.Order.Type.String(),
+		Status:    find.Order.Status.String(),
+		Amount:    find.Order.Amount,
 		CreatedAt: find.Order.CreatedAT.AsTime(),
 	}
 
-	if err := json.Unmarshal(find.Order.Metadata, &out.ProductsIDS); err != nil {
-		return nil, fmt.Errorf("json.Unmarshal: %w", err)
-	}
-
-	return &out, nil
-}
-func (u *orderUC) FindByAccount(ctx context.Context, in *orders.OrderByAccountInput) ([]*orders.OrderByAccountOutput, error) {
-
-	protoParamFind := protoOrders.ParamFindOrderByAccountRequest{
-		AccountID: in.AccountId,
-	}
-
-	ords, err := u.clientOrdersGRPC.FindOrderByAccount(ctx, &protoParamFind)
-	if err != nil {
-		return nil, fmt.Errorf("u.clientOrdersGRPC.FindOrderByAccount: %w", err)
-	}
-
-	var results []*orders.OrderByAccountOutput
-
-	for i := range ords.Orders {
-		ord := orders.OrderByAccountOutput{
-			OrderId:   ords.Orders[i].OrderID,
-			AccountId: ords.Orders[i].AccountID,
-			CreatedAt: ords.Orders[i].CreatedAT.AsTime(),
-		}
-
-		if err := json.Unmarshal(ords.Orders[i].Metadata, &ord.ProductsIDS); err != nil {
-			return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	if find.Order.Type == protoOrders.OrderType_PRODUCT_PURCHASE {
+		metadata, err := orders.DecodeProductOrderMetadata(find.Order.Metadata)
+		if err != nil {
+			return nil, err
 		}
 
-		results = append(results, &ord)
-
+		out.ProductsIDS = metadata.Products
+		out.Discount = metadata.Discount
+		out.Subtotal, _, out.Total, out.TotalItems = metadata.Totals()
 	}
 
-	return results, nil
+	return &out, nil
 }
+func (u *orderUC) CreateSubscriptionOrExtend(ctx context.Context,
+	params *orders.ParamsCreateOrderSubscriptionInput) (*orders.ParamsCreateOrderSubscriptionOutput, error) {
 
-func (u *orderUC) FindByProduct(ctx context.Context, in *orders.OrderByProductInput) ([]*orders.OrderByProductOutput, error) {
-
-	protoParamFind := protoOrders.ParamFindOrderByProductRequest{
-		ProductID: in.ProductId,
-	}
-
-	finds, err := u.clientOrdersGRPC.FindOrderByProduct(ctx, &protoParamFind)
+	// the days are the plan's own on fixed-price plans, not the asked ones
+	days, amount, err := u.catalog.PlanTerm(ctx, params.Plan, params.Days)
 	if err != nil {
-		return nil, fmt.Errorf("u.clientOrdersGRPC.FindOrderByProduct: %w", err)
-	}
-
-	outs := make([]*orders.OrderByProductOutput, 0, len(finds.Orders))
-
-	for i := range finds.Orders {
-		out := orders.OrderByProductOutput{
-			OrderId:   finds.Orders[i].OrderID,
-			AccountId: finds.Orders[i].AccountID,
-			CreatedAt: finds.Orders[i].CreatedAT.AsTime(),
-		}
-
-		if err := json.Unmarshal(finds.Orders[i].Metadata, &out.ProductsIDS); err != nil {
-			return nil, fmt.Errorf("json.Unmarshal: %w", err)
+		if errors.Is(err, catalog.ErrPlanNotFound) || errors.Is(err, catalog.ErrPlanDays) {
+			return nil, fmt.Errorf("%w: %v", orders.ErrPlanInvalid, err)
 		}
 
-		outs = append(outs, &out)
+		return nil, fmt.Errorf("u.catalog.PlanTerm: %w", err)
 	}
 
-	return outs, nil
-}
-
-func (u *orderUC) CreateSubscriptionOrExtend(ctx context.Context,
-	params *orders.ParamsCreateOrderSubscriptionInput) (*orders.ParamsCreateOrderSubscriptionOutput, error) {
-
-	var amount int64
-	switch params.Plan {
-	case string(models.Plan_Week):
-		amount = 1999
-	case string(models.Plan_Month):
-		amount = 3499
-	case string(models.Plan_Year):
-		amount = 28900
-	case string(models.Plan_Undefined):
-		amount = params.Days * 199
-	default:
-		return nil, orders.ErrPlanInvalid
-	}
+	referenceId := uuid.NewString()
 
 	pg := models.ParamPaymentProcessInput{
 		Method:         params.MethodPayment,
 		AccountId:      params.UserId,
-		ReferenceId:    "",
+		ReferenceId:    referenceId,
 		Amount:         amount,
 		CardToken:      params.CardToken,
 		CardExpiration: params.CardExpiration,
 	}
 
-	payment, err := u.gateway.GeneratePayment(ctx, &pg)
-	if err != nil {
-		log.Printf("u.gateway.GeneratePayment: %v\n", err)
+	coupon := promotion.ParamsApplyCouponInput{
+		Code:        params.CouponCode,
+		UserId:      params.UserId,
+		ReferenceId: referenceId,
+		Action:      string(orders.NewSubscription),
+		Plan:        params.Plan,
+		Amount:      amount,
 	}
 
-	var out orders.ParamsCreateOrderSubscriptionOutput
-
-	status := protoOrders.OrderStatus_PENDING
-
-	switch payment.Status {
-	case models.PaymentPaid:
-		status = protoOrders.OrderStatus_PAID
-
-		ps := models.ParamsActivateSubscriptionInput{
-			AccountID: params.UserId,
-			Plan:      params.Plan,
-			Days:      params.Days,
+	metadata := func(discount *orders.OrderDiscount) any {
+		return orders.ParamsSaveSubscriptionMetadata{
+			UserId:   params.UserId,
+			Plan:     params.Plan,
+			Days:     days,
+			Discount: discount,
 		}
+	}
 
-		act, err := u.subscription.ActivateSubscription(ctx, &ps)
-		if err != nil {
-			return nil, fmt.Errorf("u.subscription.Activate: %w", err)
-		}
+	saga := orders.NewSaga(string(orders.NewSubscription), u.workerSaga)
 
-		out.SubscriptionData = act
+	saga.AddStep(u.couponStep(&coupon))
+	saga.AddStep(u.paymentStep(&pg))
+	saga.AddStep(u.createPaymentOrderStep(protoOrders.OrderType_PREMIUM_SUBSCRIPTION, params.UserId, amount, metadata))
 
-	case models.PaymentFailed:
-		status = protoOrders.OrderStatus_FAILED
-	case models.PaymentPending:
-		status = protoOrders.OrderStatus_PENDING
-	case models.PaymentCancelled:
-		status = protoOrders.OrderStatus_CANCELLED
-	case models.PaymentRefunded:
-		status = protoOrders.OrderStatus_REFUNDED
-	case models.PaymentUnspecified:
-		status = protoOrders.OrderStatus_ORDER_STATUS_UNSPECIFIED
-	}
+	saga.AddStep(&orders.SagaStep{
+		Name:    "activate-subscription",
+		Timeout: defaultStepTimeout,
+		Action: func(ctx context.Context, state *orders.SagaState) error {
+			payment, _ := orders.SagaValue[*models.ParamPaymentProcessOutput](state, sagaKeyPayment)
+			if payment.Status != models.PaymentPaid {
+				return nil
+			}
 
-	method := protoOrders.PaymentMethod_PAYMENT_METHOD_UNSPECIFIED
-	switch payment.Method {
-	case models.PaymentMethodPix:
-		method = protoOrders.PaymentMethod_PIX
-	case models.PaymentMethodCard:
-		method = protoOrders.PaymentMethod_CREDIT_CARD
-	case models.PaymentMethodBoleto:
-		method = protoOrders.PaymentMethod_BOLETO
-	case models.PaymentMethodInternalBalance:
-		method = protoOrders.PaymentMethod_INTERNAL_BALANCE
-	}
+			ps := models.ParamsActivateSubscriptionInput{
+				AccountID: params.UserId,
+				Plan:      params.Plan,
+				Days:      days,
+			}
 
-	var pixExp *timestamppb.Timestamp
-	if !payment.PixExpiration.IsZero() {
-		pixExp = timestamppb.New(payment.PixExpiration)
-	}
+			act, err := u.subscription.ActivateSubscription(ctx, &ps)
+			if err != nil {
+				return fmt.Errorf("u.subscription.Activate: %w", err)
+			}
 
-	var boletoExp *timestamppb.Timestamp
-	if !payment.BoletoExpiration.IsZero() {
-		boletoExp = timestamppb.New(payment.BoletoExpiration)
-	}
+			state.Set(sagaKeySubscription, act)
 
-	metadataObj := orders.ParamsSaveSubscriptionMetadata{
-		UserId: params.UserId,
-		Plan:   params.Plan,
-		Days:   params.Days,
-	}
+			return nil
+		},
+	})
 
-	metadataJson, err := json.Marshal(metadataObj)
-	if err != nil {
-		return nil, fmt.Errorf("json.Marshal: %v\n", err)
-	}
-
-	paramsNewOrder := protoOrders.ParamCreateOrderRequest{
-		AccountID:            params.UserId,
-		Type:                 protoOrders.OrderType_PREMIUM_SUBSCRIPTION,
-		Status:               status,
-		Amount:               amount,
-		PaymentMethod:        method,
-		Metadata:             metadataJson,
-		GatewayTransactionID: payment.GatewayTransactionID,
-		PixQRCode:            payment.PixQRCode,
-		PixExpiration:        pixExp,
-		CardToken:            payment.CardToken,
-		CardExpiration:       payment.CardExpiration,
-		BoletoURL:            payment.BoletoURL,
-		BoletoBarcode:        payment.BoletoBarcode,
-		BoletoExpiration:     boletoExp,
-	}
-
-	newOrder, err := u.clientOrdersGRPC.Create(ctx, &paramsNewOrder)
-	if err != nil {
+	if err := saga.Execute(ctx); err != nil {
 		return nil, err
 	}
 
+	newOrder, _ := orders.SagaValue[*protoOrders.Orders](saga.State(), sagaKeyOrder)
+	payment, _ := orders.SagaValue[*models.ParamPaymentProcessOutput](saga.State(), sagaKeyPayment)
+	u.issueInvoice(ctx, newOrder)
+	subscriptionData, _ := orders.SagaValue[*models.ParamsActivateSubscriptionOutput](saga.State(), sagaKeySubscription)
+
 	var outPixExp, outBoletoExp time.Time
-	if newOrder.Order.PixExpiration != nil {
-		outPixExp = newOrder.Order.PixExpiration.AsTime()
+	if newOrder.PixExpiration != nil {
+		outPixExp = newOrder.PixExpiration.AsTime()
 	}
-	if newOrder.Order.BoletoExpiration != nil {
-		outBoletoExp = newOrder.Order.BoletoExpiration.AsTime()
+	if newOrder.BoletoExpiration != nil {
+		outBoletoExp = newOrder.BoletoExpiration.AsTime()
 	}
 
-	out = orders.ParamsCreateOrderSubscriptionOutput{
-		OrderID:              newOrder.Order.OrderID,
-		Status:               newOrder.Order.Status.String(),
+	out := orders.ParamsCreateOrderSubscriptionOutput{
+		OrderID:              newOrder.OrderID,
+		Status:               newOrder.Status.String(),
 		PaymentMethod:        params.MethodPayment,
-		GatewayTransactionID: newOrder.Order.GatewayTransactionID,
-		PixQRCode:            newOrder.Order.PixQRCode,
+		Amount:               newOrder.Amount,
+		Discount:             discountFrom(saga.State()),
+		SubscriptionData:     subscriptionData,
+		FailureReason:        payment.FailureReason,
+		GatewayTransactionID: newOrder.GatewayTransactionID,
+		CardRedirectURL:      payment.CardRedirectURL,
+		PixQRCode:            newOrder.PixQRCode,
 		PixExpiration:        outPixExp,
-		BoletoURL:            newOrder.Order.BoletoURL,
-		BoletoBarcode:        newOrder.Order.BoletoBarcode,
+		BoletoURL:            newOrder.BoletoURL,
+		BoletoBarcode:        newOrder.BoletoBarcode,
+		BoletoDigitableLine:  payment.BoletoDigitableLine,
 		BoletoExpiration:     outBoletoExp,
 	}
 
 	return &out, nil
 }
 
+// AddBalance takes no coupon: the wallet gets what was paid, so a discount
+// would only shrink the deposit, while crediting the value before the
+// discount would create balance to transfer or withdraw.
 func (u *orderUC) AddBalance(ctx context.Context, params *orders.ParamsAddBalanceInput) (*orders.ParamsAddBalanceOutput, error) {
+	if params.CouponCode != "" {
+		return nil, fmt.Errorf("%w: deposits take no coupon", promotion.ErrCouponNotApplicable)
+	}
 
-	switch params.Amount {
-	case 2500, 5000, 10000:
-	default:
-		return nil, orders.ErrAmountInvalid
+	if err := u.catalog.ValidateTopUp(ctx, params.Amount); err != nil {
+		if errors.Is(err, catalog.ErrTopUpNotFound) {
+			return nil, orders.ErrAmountInvalid
+		}
+
+		return nil, fmt.Errorf("u.catalog.ValidateTopUp: %w", err)
 	}
 
+	referenceId := uuid.NewString()
+
 	mp := models.ParamPaymentProcessInput{
 		Method:         params.MethodPayment,
 		AccountId:      params.UserId,
+		ReferenceId:    referenceId,
 		Amount:         params.Amount,
 		CardToken:      params.CardToken,
 		CardExpiration: params.CardExpiration,
@@ -513,108 +637,81 @@ func (u *orderUC) AddBalance(ctx context.Context, params *orders.ParamsAddBalanc
 		return nil, errors.New("method pay invalid")
 	}
 
-	payment, err := u.gateway.GeneratePayment(ctx, &mp)
-	if err != nil {
-		log.Printf("u.gateway.GeneratePayment: %v\n", err)
+	metadata := func(discount *orders.OrderDiscount) any {
+		return orders.ParamsSaveBalanceMetadata{
+			Amount:   params.Amount,
+			Discount: discount,
+		}
 	}
 
-	status := protoOrders.OrderStatus_PENDING
+	saga := orders.NewSaga(string(orders.AddBalance), u.workerSaga)
 
-	switch payment.Status {
-	case models.PaymentPaid:
-		status = protoOrders.OrderStatus_PAID
+	saga.AddStep(u.paymentStep(&mp))
+	saga.AddStep(u.createPaymentOrderStep(protoOrders.OrderType_BALANCE_DEPOSIT, params.UserId, params.Amount, metadata))
 
-		pf := protoBalance.ParamGetWalletByAccountRequest{
-			AccountID: params.UserId,
-		}
+	saga.AddStep(&orders.SagaStep{
+		Name:    "credit-wallet",
+		Timeout: defaultStepTimeout,
+		Action: func(ctx context.Context, state *orders.SagaState) error {
+			payment, _ := orders.SagaValue[*models.ParamPaymentProcessOutput](state, sagaKeyPayment)
+			if payment.Status != models.PaymentPaid {
+				return nil
+			}
 
-		wlt, err := u.clientBalanceGPRC.GetWalletByAccount(ctx, &pf)
-		if err != nil {
-			return nil, fmt.Errorf("u.clientBalanceGPRC.GetWalletByAccount: %w", err)
-		}
+			pf := protoBalance.ParamGetWalletByAccountRequest{
+				AccountID: params.UserId,
+			}
 
-		pb := protoBalance.ParamCreditWalletRequest{
-			Amount:      params.Amount,
-			WalletID:    wlt.WalletID,
-			ReferenceID: payment.GatewayTransactionID,
-		}
+			wlt, err := u.clientBalanceGPRC.GetWalletByAccount(ctx, &pf)
+			if err != nil {
+				return fmt.Errorf("u.clientBalanceGPRC.GetWalletByAccount: %w", err)
+			}
 
-		_, err = u.clientBalanceGPRC.Credit(ctx, &pb)
-		if err != nil {
-			return nil, fmt.Errorf("u.clientBalanceGPRC.Credit: %w", err)
-		}
+			pb := protoBalance.ParamCreditWalletRequest{
+				Amount:      params.Amount,
+				WalletID:    wlt.WalletID,
+				ReferenceID: payment.GatewayTransactionID,
+			}
 
-	case models.PaymentFailed:
-		status = protoOrders.OrderStatus_FAILED
-	case models.PaymentPending:
-		status = protoOrders.OrderStatus_PENDING
-	case models.PaymentCancelled:
-		status = protoOrders.OrderStatus_CANCELLED
-	case models.PaymentRefunded:
-		status = protoOrders.OrderStatus_REFUNDED
-	case models.PaymentUnspecified:
-		status = protoOrders.OrderStatus_ORDER_STATUS_UNSPECIFIED
-	}
-
-	method := protoOrders.PaymentMethod_PAYMENT_METHOD_UNSPECIFIED
-	switch payment.Method {
-	case models.PaymentMethodPix:
-		method = protoOrders.PaymentMethod_PIX
-	case models.PaymentMethodCard:
-		method = protoOrders.PaymentMethod_CREDIT_CARD
-	case models.PaymentMethodBoleto:
-		method = protoOrders.PaymentMethod_BOLETO
-	}
-
-	var pixExp *timestamppb.Timestamp
-	if !payment.PixExpiration.IsZero() {
-		pixExp = timestamppb.New(payment.PixExpiration)
-	}
-
-	var boletoExp *timestamppb.Timestamp
-	if !payment.BoletoExpiration.IsZero() {
-		boletoExp = timestamppb.New(payment.BoletoExpiration)
-	}
-
-	paramsNewOrder := protoOrders.ParamCreateOrderRequest{
-		AccountID:            params.UserId,
-		Type:                 protoOrders.OrderType_BALANCE_DEPOSIT,
-		Status:               status,
-		Amount:               params.Amount,
-		PaymentMethod:        method,
-		Metadata:             []byte(`{}`),
-		GatewayTransactionID: payment.GatewayTransactionID,
-		PixQRCode:            payment.PixQRCode,
-		PixExpiration:        pixExp,
-		CardToken:            payment.CardToken,
-		CardExpiration:       payment.CardExpiration,
-		BoletoURL:            payment.BoletoURL,
-		BoletoBarcode:        payment.BoletoBarcode,
-		BoletoExpiration:     boletoExp,
-	}
-
-	newOrder, err := u.clientOrdersGRPC.Create(ctx, &paramsNewOrder)
-	if err != nil {
-		return nil, fmt.Errorf("u.clientOrdersGRPC.Create: %w", err)
+			_, err = u.clientBalanceGPRC.Credit(ctx, &pb)
+			if err != nil {
+				return fmt.Errorf("u.clientBalanceGPRC.Credit: %w", err)
+			}
+
+			return nil
+		},
+	})
+
+	if err := saga.Execute(ctx); err != nil {
+		return nil, err
 	}
 
+	newOrder, _ := orders.SagaValue[*protoOrders.Orders](saga.State(), sagaKeyOrder)
+	payment, _ := orders.SagaValue[*models.ParamPaymentProcessOutput](saga.State(), sagaKeyPayment)
+	u.issueInvoice(ctx, newOrder)
+
 	var outPixExp, outBoletoExp time.Time
-	if newOrder.Order.PixExpiration != nil {
-		outPixExp = newOrder.Order.PixExpiration.AsTime()
+	if newOrder.PixExpiration != nil {
+		outPixExp = newOrder.PixExpiration.AsTime()
 	}
-	if newOrder.Order.BoletoExpiration != nil {
-		outBoletoExp = newOrder.Order.BoletoExpiration.AsTime()
+	if newOrder.BoletoExpiration != nil {
+		outBoletoExp = newOrder.BoletoExpiration.AsTime()
 	}
 
 	out := orders.ParamsAddBalanceOutput{
-		OrderID:              newOrder.Order.OrderID,
+		OrderID:              newOrder.OrderID,
 		PaymentMethod:        params.MethodPayment,
-		Status:               newOrder.Order.Status.String(),
-		GatewayTransactionID: newOrder.Order.GatewayTransactionID,
-		PixQRCode:            newOrder.Order.PixQRCode,
+		Status:               newOrder.Status.String(),
+		Amount:               newOrder.Amount,
+		Discount:             discountFrom(saga.State()),
+		FailureReason:        payment.FailureReason,
+		GatewayTransactionID: newOrder.GatewayTransactionID,
+		CardRedirectURL:      payment.CardRedirectURL,
+		PixQRCode:            newOrder.PixQRCode,
 		PixExpiration:        outPixExp,
-		BoletoURL:            newOrder.Order.BoletoURL,
-		BoletoBarcode:        newOrder.Order.BoletoBarcode,
+		BoletoURL:            newOrder.BoletoURL,
+		BoletoBarcode:        newOrder.BoletoBarcode,
+		BoletoDigitableLine:  payment.BoletoDigitableLine,
 		BoletoExpiration:     outBoletoExp,
 	}
 
@@ -0,0 +1,170 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+const (
+	OperationCharge = "charge"
+	OperationRefund = "refund"
+
+	// AttemptSucceeded is an attempt the provider answered, even when it
+	// declined the payment; the payment status tells which.
+	AttemptSucceeded = "succeeded"
+	AttemptFailed    = "failed"
+	// AttemptSkipped is a provider passed over because its circuit was open.
+	AttemptSkipped = "skipped"
+	// AttemptUnknown is an attempt the provider may have charged without
+	// telling, left for reconciliation with the provider.
+	AttemptUnknown = "unknown"
+)
+
+// Attempt is one call to a provider, kept so support can tell why a payment
+// failed without reading logs. Card data in the request and response is
+// redacted before it is stored.
+type Attempt struct {
+	Id            string          `json:"id"`
+	ReferenceId   string          `json:"reference_id"`
+	OrderId       string          `json:"order_id,omitempty"`
+	AccountId     string          `json:"account_id"`
+	Operation     string          `json:"operation"`
+	Method        string          `json:"method"`
+	Provider      string          `json:"provider"`
+	Amount        int64           `json:"amount"`
+	Status        string          `json:"status"`
+	PaymentStatus string          `json:"payment_status,omitempty"`
+	FailureReason string          `json:"failure_reason,omitempty"`
+	Error         string          `json:"error,omitempty"`
+	Request       json.RawMessage `json:"request"`
+	Response      json.RawMessage `json:"response,omitempty"`
+	LatencyMs     int64           `json:"latency_ms"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+type AttemptRepository interface {
+	Create(ctx context.Context, attempt *Attempt) error
+	// LinkOrder sets the order of the attempts made under the reference,
+	// which only exists once the payment went through.
+	LinkOrder(ctx context.Context, referenceId string, orderId string) error
+	// OrdersByReference maps the given references to the order their
+	// attempts were linked to. References without an order are left out.
+	OrdersByReference(ctx context.Context, referenceIds []string) (map[string]string, error)
+	Search(ctx context.Context, params *ParamsSearchAttemptsInput) ([]*Attempt, int, error)
+}
+
+// RedactCardToken keeps the last four characters of a card token.
+func RedactCardToken(token string) string {
+	if token == "" {
+		return ""
+	}
+
+	if len(token) <= 4 {
+		return strings.Repeat("*", len(token))
+	}
+
+	return strings.Repeat("*", len(token)-4) + token[len(token)-4:]
+}
+
+// RedactedRequest is the charge as stored in the attempts.
+func RedactedRequest(in *models.ParamPaymentProcessInput) json.RawMessage {
+	redacted := *in
+	redacted.CardToken = RedactCardToken(in.CardToken)
+	if redacted.CardExpiration != "" {
+		redacted.CardExpiration = "**/**"
+	}
+
+	data, _ := json.Marshal(&redacted)
+
+	return data
+}
+
+// RedactedResponse is the processor output as stored in the attempts.
+func RedactedResponse(out *models.ParamPaymentProcessOutput) json.RawMessage {
+	if out == nil {
+		return nil
+	}
+
+	redacted := *out
+	redacted.CardToken = RedactCardToken(out.CardToken)
+	if redacted.CardExpiration != "" {
+		redacted.CardExpiration = "**/**"
+	}
+
+	data, _ := json.Marshal(&redacted)
+
+	return data
+}
+
+// ParamsSearchAttemptsInput holds the filters as received in the query
+// string; Validate parses them into the typed fields.
+type ParamsSearchAttemptsInput struct {
+	OrderId     string
+	AccountId   string
+	ReferenceId string
+	Provider    string
+	Status      string
+	Page        string
+	Limit       string
+
+	PageInt  int
+	LimitInt int
+}
+
+func (p *ParamsSearchAttemptsInput) Validate() error {
+	if p.OrderId != "" {
+		if _, err := uuid.Parse(p.OrderId); err != nil {
+			return errors.New("invalid uuid order")
+		}
+	}
+
+	if p.AccountId != "" {
+		if _, err := uuid.Parse(p.AccountId); err != nil {
+			return errors.New("invalid uuid account")
+		}
+	}
+
+	switch p.Status {
+	case "", AttemptSucceeded, AttemptFailed, AttemptSkipped, AttemptUnknown:
+	default:
+		return errors.New("status invalid")
+	}
+
+	p.PageInt = 1
+	p.LimitInt = 20
+
+	if p.Page != "" {
+		page, err := strconv.Atoi(p.Page)
+		if err != nil || page <= 0 {
+			return errors.New("page invalid")
+		}
+
+		p.PageInt = page
+	}
+
+	if p.Limit != "" {
+		limit, err := strconv.Atoi(p.Limit)
+		if err != nil || limit <= 0 || limit > 100 {
+			return errors.New("limit invalid")
+		}
+
+		p.LimitInt = limit
+	}
+
+	return nil
+}
+
+type ParamsSearchAttemptsOutput struct {
+	Attempts   []*Attempt `json:"attempts"`
+	Page       int        `json:"page"`
+	Limit      int        `json:"limit"`
+	TotalItens int        `json:"total_itens"`
+	TotalPages int        `json:"total_pages"`
+}
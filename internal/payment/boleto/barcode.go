@@ -0,0 +1,163 @@
+package boleto
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	currencyReal = "9"
+	maxAmount    = 9_999_999_999
+)
+
+// dueFactorBase is day zero of the due date factor. The factor ran out at
+// 9999 on 2025-02-21 and restarted at 1000 the day after.
+var dueFactorBase = time.Date(1997, time.October, 7, 0, 0, 0, 0, time.UTC)
+
+// Location is the time zone due dates are set in; Brazil has no daylight
+// saving time since 2019.
+var Location = time.FixedZone("BRT", -3*60*60)
+
+// Barcode is the FEBRABAN barcode of a boleto: 44 digits holding the bank,
+// currency, general check digit, due date factor, amount and the free field
+// the bank lays out as it wants.
+type Barcode struct {
+	BankCode  string
+	Due       time.Time
+	Amount    int64
+	FreeField string
+}
+
+func (b *Barcode) validate() error {
+	if len(b.BankCode) != 3 || !digits(b.BankCode) {
+		return fmt.Errorf("%w: bank code %q", ErrInvalidBarcode, b.BankCode)
+	}
+
+	if len(b.FreeField) != 25 || !digits(b.FreeField) {
+		return fmt.Errorf("%w: free field %q", ErrInvalidBarcode, b.FreeField)
+	}
+
+	if b.Amount <= 0 || b.Amount > maxAmount {
+		return fmt.Errorf("%w: amount %d", ErrInvalidBarcode, b.Amount)
+	}
+
+	return nil
+}
+
+// Code returns the 44 digits encoded in the bars.
+func (b *Barcode) Code() (string, error) {
+	if err := b.validate(); err != nil {
+		return "", err
+	}
+
+	body := fmt.Sprintf("%s%s%04d%010d%s", b.BankCode, currencyReal, DueFactor(b.Due), b.Amount, b.FreeField)
+
+	return body[:4] + mod11(body) + body[4:], nil
+}
+
+// DigitableLine returns the "linha digitável" the customer types at the
+// bank: the barcode reordered in five fields, the first three with a
+// check digit of their own.
+func (b *Barcode) DigitableLine() (string, error) {
+	code, err := b.Code()
+	if err != nil {
+		return "", err
+	}
+
+	return DigitableLine(code), nil
+}
+
+// DigitableLine converts a 44 digit barcode to its formatted digitable line.
+func DigitableLine(code string) string {
+	field1 := code[0:4] + code[19:24]
+	field1 += mod10(field1)
+
+	field2 := code[24:34]
+	field2 += mod10(field2)
+
+	field3 := code[34:44]
+	field3 += mod10(field3)
+
+	return fmt.Sprintf("%s.%s %s.%s %s.%s %s %s",
+		field1[:5], field1[5:], field2[:5], field2[5:], field3[:5], field3[5:], code[4:5], code[5:19])
+}
+
+// DueFactor counts the days from the factor base to the due date, wrapping
+// from 9999 back to 1000.
+func DueFactor(due time.Time) int {
+	y, m, d := due.In(Location).Date()
+	days := int(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Sub(dueFactorBase).Hours() / 24)
+
+	if days > 9999 {
+		days = (days-10000)%9000 + 1000
+	}
+
+	return days
+}
+
+// DueDate reads the due date back from a barcode. The factor repeats every
+// 9000 days, so the cycle closest to near is taken.
+func DueDate(code string, near time.Time) time.Time {
+	factor, err := strconv.Atoi(code[5:9])
+	if err != nil {
+		return time.Time{}
+	}
+
+	y, m, d := dueFactorBase.Date()
+
+	due := time.Date(y, m, d+factor, 0, 0, 0, 0, Location)
+	for due.AddDate(0, 0, 4500).Before(near) {
+		due = due.AddDate(0, 0, 9000)
+	}
+
+	return due
+}
+
+// FreeFieldAgreement lays out the free field of Banco do Brasil for 7 digit
+// agreements: six zeros, the 17 digit "nosso número" and the wallet.
+func FreeFieldAgreement(ourNumber string, wallet string) string {
+	return "000000" + ourNumber + wallet
+}
+
+// mod10 is the check digit of each digitable line field: digits weighted
+// 2, 1, 2... from the right, products summed digit by digit.
+func mod10(number string) string {
+	sum, weight := 0, 2
+
+	for i := len(number) - 1; i >= 0; i-- {
+		product := int(number[i]-'0') * weight
+		sum += product/10 + product%10
+
+		weight = 3 - weight
+	}
+
+	return fmt.Sprint((10 - sum%10) % 10)
+}
+
+// mod11 is the general check digit of the barcode: digits weighted 2 to 9
+// from the right, where 0, 10 and 11 become 1.
+func mod11(number string) string {
+	sum, weight := 0, 2
+
+	for i := len(number) - 1; i >= 0; i-- {
+		sum += int(number[i]-'0') * weight
+
+		weight++
+		if weight > 9 {
+			weight = 2
+		}
+	}
+
+	dv := 11 - sum%11
+	if dv == 0 || dv == 10 || dv == 11 {
+		dv = 1
+	}
+
+	return fmt.Sprint(dv)
+}
+
+func digits(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
@@ -0,0 +1,173 @@
+package boleto
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 12, 0, 0, 0, Location)
+}
+
+func TestDueFactor(t *testing.T) {
+	tests := []struct {
+		name string
+		due  time.Time
+		want int
+	}{
+		{name: "first factor", due: date(2000, time.July, 3), want: 1000},
+		{name: "last day of the first cycle", due: date(2025, time.February, 21), want: 9999},
+		{name: "restarts at 1000", due: date(2025, time.February, 22), want: 1000},
+		{name: "second cycle", due: date(2025, time.March, 3), want: 1009},
+		{
+			name: "date taken in brazilian time",
+			due:  time.Date(2025, time.February, 22, 1, 0, 0, 0, time.UTC),
+			want: 9999,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DueFactor(tt.due); got != tt.want {
+				t.Errorf("DueFactor(%v) = %d, want %d", tt.due, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDueDate(t *testing.T) {
+	tests := []struct {
+		name string
+		due  time.Time
+		near time.Time
+	}{
+		{name: "first cycle", due: date(2007, time.December, 31), near: date(2008, time.January, 1)},
+		{name: "end of the first cycle", due: date(2025, time.February, 21), near: date(2025, time.February, 1)},
+		{name: "second cycle", due: date(2025, time.February, 22), near: date(2025, time.February, 20)},
+		{name: "second cycle far ahead", due: date(2030, time.June, 10), near: date(2030, time.May, 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			barcode := Barcode{BankCode: "001", Due: tt.due, Amount: 100, FreeField: FreeFieldAgreement("12345670000000001", "18")}
+
+			code, err := barcode.Code()
+			if err != nil {
+				t.Fatalf("Code: %v", err)
+			}
+
+			y, m, d := DueDate(code, tt.near).Date()
+			wantY, wantM, wantD := tt.due.Date()
+
+			if y != wantY || m != wantM || d != wantD {
+				t.Errorf("DueDate = %d-%02d-%02d, want %d-%02d-%02d", y, m, d, wantY, wantM, wantD)
+			}
+		})
+	}
+}
+
+func TestBarcode(t *testing.T) {
+	tests := []struct {
+		name          string
+		barcode       Barcode
+		wantCode      string
+		wantDigitable string
+	}{
+		{
+			// the Banco do Brasil layout example
+			name: "bank example",
+			barcode: Barcode{
+				BankCode:  "001",
+				Due:       date(2007, time.December, 31),
+				Amount:    100,
+				FreeField: "0500940144816060680935031",
+			},
+			wantCode:      "00193373700000001000500940144816060680935031",
+			wantDigitable: "00190.50095 40144.816069 06809.350314 3 37370000000100",
+		},
+		{
+			name: "check digit 10 becomes 1",
+			barcode: Barcode{
+				BankCode:  "001",
+				Due:       date(2025, time.February, 22),
+				Amount:    1993,
+				FreeField: "0000001234567000000000118",
+			},
+			wantCode:      "00191100000000019930000001234567000000000118",
+			wantDigitable: "00190.00009 01234.567004 00000.001180 1 10000000001993",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := tt.barcode.Code()
+			if err != nil {
+				t.Fatalf("Code: %v", err)
+			}
+
+			if code != tt.wantCode {
+				t.Errorf("Code = %s, want %s", code, tt.wantCode)
+			}
+
+			line, err := tt.barcode.DigitableLine()
+			if err != nil {
+				t.Fatalf("DigitableLine: %v", err)
+			}
+
+			if line != tt.wantDigitable {
+				t.Errorf("DigitableLine = %s, want %s", line, tt.wantDigitable)
+			}
+		})
+	}
+}
+
+func TestBarcodeRejectsInvalidFields(t *testing.T) {
+	valid := Barcode{BankCode: "001", Due: date(2026, time.January, 10), Amount: 100, FreeField: "0000001234567000000000118"}
+
+	tests := []struct {
+		name   string
+		change func(b *Barcode)
+	}{
+		{name: "short bank code", change: func(b *Barcode) { b.BankCode = "01" }},
+		{name: "bank code with letters", change: func(b *Barcode) { b.BankCode = "0A1" }},
+		{name: "short free field", change: func(b *Barcode) { b.FreeField = "123" }},
+		{name: "free field with letters", change: func(b *Barcode) { b.FreeField = "000000123456700000000011X" }},
+		{name: "zero amount", change: func(b *Barcode) { b.Amount = 0 }},
+		{name: "amount over ten digits", change: func(b *Barcode) { b.Amount = maxAmount + 1 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			barcode := valid
+			tt.change(&barcode)
+
+			if _, err := barcode.Code(); !errors.Is(err, ErrInvalidBarcode) {
+				t.Errorf("Code err = %v, want %v", err, ErrInvalidBarcode)
+			}
+		})
+	}
+}
+
+func TestCheckDigits(t *testing.T) {
+	tests := []struct {
+		name   string
+		check  func(string) string
+		number string
+		want   string
+	}{
+		{name: "mod10 first field", check: mod10, number: "001905009", want: "5"},
+		{name: "mod10 second field", check: mod10, number: "4014481606", want: "9"},
+		{name: "mod10 third field", check: mod10, number: "0680935031", want: "4"},
+		{name: "mod10 sum multiple of ten", check: mod10, number: "0000000000", want: "0"},
+		{name: "mod11 bank example", check: mod11, number: "0019373700000001000500940144816060680935031", want: "3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.check(tt.number); got != tt.want {
+				t.Errorf("check digit of %s = %s, want %s", tt.number, got, tt.want)
+			}
+		})
+	}
+}
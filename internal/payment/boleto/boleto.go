@@ -0,0 +1,86 @@
+package boleto
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/domain/models"
+)
+
+type UseCase interface {
+	models.PaymentProcessor
+	// Webhook settles the order of a boleto the bank reports as paid.
+	Webhook(ctx context.Context, params *ParamsBoletoWebHookInput) error
+	// Find returns the payment slip of a pending boleto order.
+	Find(ctx context.Context, params *ParamsFindBoletoInput) (*Boleto, error)
+}
+
+type Repository interface {
+	// NextSequence returns the next number of the "nosso número", unique
+	// across every boleto issued under the agreement.
+	NextSequence(ctx context.Context) (int64, error)
+}
+
+var (
+	ErrInvalidBarcode      = errors.New("invalid boleto barcode")
+	ErrBoletoNotAvailable  = errors.New("order has no boleto to pay")
+	ErrPaidAmountMismatch  = errors.New("paid amount does not match the boleto")
+	ErrPaidAfterExpiration = errors.New("boleto paid after it expired")
+)
+
+const (
+	DefaultBankCode         = "001"
+	DefaultWallet           = "17"
+	DefaultDueDays          = 3
+	DefaultSettlementWindow = 3 * 24 * time.Hour
+)
+
+// Config identifies the agreement ("convênio") with the bank issuing the
+// boletos. The settlement window is how long after the due date the order
+// waits for the bank to report a payment made on time.
+type Config struct {
+	BankCode         string
+	Agreement        string
+	Wallet           string
+	DueDays          int
+	SettlementWindow time.Duration
+}
+
+type Boleto struct {
+	OrderId       string    `json:"order_id"`
+	OurNumber     string    `json:"our_number"`
+	Amount        int64     `json:"amount"`
+	Barcode       string    `json:"barcode"`
+	DigitableLine string    `json:"digitable_line"`
+	DueDate       string    `json:"due_date"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+type ParamsBoletoWebHookInput struct {
+	OurNumber  string    `json:"our_number"`
+	PaidAmount int64     `json:"paid_amount"`
+	PaidAt     time.Time `json:"paid_at"`
+}
+
+func (p *ParamsBoletoWebHookInput) Validate() error {
+	if p.OurNumber == "" || !digits(p.OurNumber) {
+		return errors.New("invalid our number")
+	}
+
+	if p.PaidAmount <= 0 {
+		return errors.New("invalid paid amount")
+	}
+
+	if p.PaidAt.IsZero() {
+		return errors.New("paid at empty")
+	}
+
+	return nil
+}
+
+type ParamsFindBoletoInput struct {
+	OrderId string
+	// UserId restricts the lookup to orders of that account; admins leave it empty.
+	UserId string
+}
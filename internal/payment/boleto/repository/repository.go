@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aclgo/simple-api-gateway/internal/payment/boleto"
+	"github.com/jmoiron/sqlx"
+)
+
+type boletoRepository struct {
+	db *sqlx.DB
+}
+
+func NewBoletoRepository(db *sqlx.DB) boleto.Repository {
+	return &boletoRepository{
+		db: db,
+	}
+}
+
+// NextSequence draws from a sequence: a number skipped by a failed order is
+// harmless, unlike one issued twice.
+func (r *boletoRepository) NextSequence(ctx context.Context) (int64, error) {
+	var next int64
+
+	if err := r.db.GetContext(ctx, &next, `SELECT nextval('boleto_our_number_seq')`); err != nil {
+		return 0, fmt.Errorf("r.db.GetContext: %w", err)
+	}
+
+	return next, nil
+}
@@ -0,0 +1,154 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/domain/models"
+	"github.com/aclgo/simple-api-gateway/internal/orders"
+	"github.com/aclgo/simple-api-gateway/internal/payment/boleto"
+	"github.com/aclgo/simple-api-gateway/internal/payment/settlement"
+	protoOrders "github.com/aclgo/simple-api-gateway/proto-service/orders"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// paymentProcessorBoleto issues boletos registered under the agreement with
+// the bank. The order stays PENDING until the bank reports the payment
+// through the webhook, which may take the settlement window after the due
+// date.
+type paymentProcessorBoleto struct {
+	cfg              boleto.Config
+	repo             boleto.Repository
+	clientOrdersGRPC protoOrders.ServiceOrderClient
+	settler          settlement.Settler
+}
+
+func NewpaymentProcessorBoleto(cfg boleto.Config, repo boleto.Repository, clientOrdersGRPC protoOrders.ServiceOrderClient,
+	settler settlement.Settler) boleto.UseCase {
+	if cfg.BankCode == "" {
+		cfg.BankCode = boleto.DefaultBankCode
+	}
+
+	if cfg.Wallet == "" {
+		cfg.Wallet = boleto.DefaultWallet
+	}
+
+	if cfg.DueDays <= 0 {
+		cfg.DueDays = boleto.DefaultDueDays
+	}
+
+	if cfg.SettlementWindow <= 0 {
+		cfg.SettlementWindow = boleto.DefaultSettlementWindow
+	}
+
+	return &paymentProcessorBoleto{
+		cfg:              cfg,
+		repo:             repo,
+		clientOrdersGRPC: clientOrdersGRPC,
+		settler:          settler,
+	}
+}
+
+func (p *paymentProcessorBoleto) Proccess(ctx context.Context, in *models.ParamPaymentProcessInput) (*models.ParamPaymentProcessOutput, error) {
+	if len(p.cfg.Agreement) != 7 {
+		return nil, fmt.Errorf("%w: agreement must have 7 digits", boleto.ErrInvalidBarcode)
+	}
+
+	sequence, err := p.repo.NextSequence(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("p.repo.NextSequence: %w", err)
+	}
+
+	ourNumber := fmt.Sprintf("%s%010d", p.cfg.Agreement, sequence)
+
+	now := time.Now().In(boleto.Location)
+	due := time.Date(now.Year(), now.Month(), now.Day()+p.cfg.DueDays, 0, 0, 0, 0, boleto.Location)
+
+	barcode := boleto.Barcode{
+		BankCode:  p.cfg.BankCode,
+		Due:       due,
+		Amount:    in.Amount,
+		FreeField: boleto.FreeFieldAgreement(ourNumber, p.cfg.Wallet),
+	}
+
+	code, err := barcode.Code()
+	if err != nil {
+		return nil, err
+	}
+
+	out := models.ParamPaymentProcessOutput{
+		Method:               in.Method,
+		Status:               models.PaymentPending,
+		GatewayTransactionID: ourNumber,
+		BoletoBarcode:        code,
+		BoletoDigitableLine:  boleto.DigitableLine(code),
+		BoletoExpiration:     expiration(due, p.cfg.SettlementWindow),
+	}
+
+	return &out, nil
+}
+
+// expiration is when the order stops waiting for the bank: the end of the
+// due date plus the settlement window.
+func expiration(due time.Time, window time.Duration) time.Time {
+	return due.AddDate(0, 0, 1).Add(window)
+}
+
+func (p *paymentProcessorBoleto) Webhook(ctx context.Context, in *boleto.ParamsBoletoWebHookInput) error {
+	return p.settler.Settle(ctx, &settlement.ParamsSettleInput{
+		GatewayTransactionId: in.OurNumber,
+		Actor:                orders.ActorBoletoWebhook,
+		Reason:               "boleto payment settled",
+		Amount:               in.PaidAmount,
+		Check: func(order *protoOrders.Orders) error {
+			// the bank reports payments made up to the due date days later,
+			// so what counts is when it was paid
+			if orders.PaymentExpired(order, in.PaidAt) {
+				return fmt.Errorf("%w: %w", boleto.ErrPaidAfterExpiration, orders.ErrOrderExpired)
+			}
+
+			if in.PaidAmount < order.Amount {
+				return fmt.Errorf("%w: paid %d of %d", boleto.ErrPaidAmountMismatch, in.PaidAmount, order.Amount)
+			}
+
+			return nil
+		},
+	})
+}
+
+func (p *paymentProcessorBoleto) Find(ctx context.Context, params *boleto.ParamsFindBoletoInput) (*boleto.Boleto, error) {
+	find, err := p.clientOrdersGRPC.Find(ctx, &protoOrders.ParamFindOrderRequest{OrderID: params.OrderId})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, orders.ErrOrderNotFound
+		}
+
+		return nil, fmt.Errorf("p.clientOrdersGRPC.Find: %w", err)
+	}
+
+	order := find.Order
+
+	if order == nil || (params.UserId != "" && order.AccountID != params.UserId) {
+		return nil, orders.ErrOrderNotFound
+	}
+
+	if len(order.BoletoBarcode) != 44 || order.Status != protoOrders.OrderStatus_PENDING {
+		return nil, boleto.ErrBoletoNotAvailable
+	}
+
+	expiresAt := orders.PaymentExpiration(order)
+
+	out := boleto.Boleto{
+		OrderId:       order.OrderID,
+		OurNumber:     order.GatewayTransactionID,
+		Amount:        order.Amount,
+		Barcode:       order.BoletoBarcode,
+		DigitableLine: boleto.DigitableLine(order.BoletoBarcode),
+		DueDate:       boleto.DueDate(order.BoletoBarcode, expiresAt).Format(time.DateOnly),
+		ExpiresAt:     expiresAt,
+	}
+
+	return &out, nil
+}
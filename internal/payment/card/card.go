@@ -1,4 +1,91 @@
 package card
 
+import (
+	"errors"
+	"fmt"
+	"time"
 
+	"github.com/aclgo/simple-api-gateway/internal/payment"
+)
 
+var (
+	ErrProviderUnavailable = fmt.Errorf("card %w", payment.ErrProviderUnavailable)
+	ErrOutcomeUnknown      = fmt.Errorf("card %w", payment.ErrPaymentOutcomeUnknown)
+	ErrProviderResponse    = errors.New("unexpected response from card provider")
+	ErrCardTokenEmpty      = errors.New("card token empty")
+)
+
+const DefaultTimeout = 30 * time.Second
+
+// ProviderConfig points the processor at the card provider API. The
+// provider only ever sees tokenized cards.
+type ProviderConfig struct {
+	BaseURL string
+	APIKey  string
+	Timeout time.Duration
+}
+
+// Statuses of a payment at the provider.
+const (
+	StatusAuthorized     = "authorized"
+	StatusCaptured       = "captured"
+	StatusRequiresAction = "requires_action"
+	StatusDeclined       = "declined"
+	StatusRefunded       = "succeeded"
+)
+
+// DeclineReasons turns provider decline codes into reasons that can be shown
+// to the customer. Fraud related codes are reported as a plain decline on
+// purpose.
+var DeclineReasons = map[string]string{
+	"insufficient_funds": "insufficient funds",
+	"expired_card":       "card expired",
+	"incorrect_cvc":      "incorrect security code",
+	"incorrect_number":   "incorrect card number",
+	"invalid_amount":     "amount not accepted by the card",
+	"limit_exceeded":     "card limit exceeded",
+	"processing_error":   "card could not be processed, try again",
+	"do_not_honor":       "card declined",
+	"card_declined":      "card declined",
+	"fraudulent":         "card declined",
+	"lost_card":          "card declined",
+	"stolen_card":        "card declined",
+}
+
+func DeclineReason(code string) string {
+	if reason, ok := DeclineReasons[code]; ok {
+		return reason
+	}
+
+	return "card declined"
+}
+
+// PaymentRequest authorizes a charge on a tokenized card. Capture is done
+// in a second call once the authorization succeeds.
+type PaymentRequest struct {
+	Amount         int64  `json:"amount"`
+	Currency       string `json:"currency"`
+	CardToken      string `json:"card_token"`
+	CardExpiration string `json:"card_expiration,omitempty"`
+	Reference      string `json:"reference"`
+	Capture        bool   `json:"capture"`
+}
+
+type PaymentResponse struct {
+	Id             string `json:"id"`
+	Status         string `json:"status"`
+	DeclineCode    string `json:"decline_code,omitempty"`
+	DeclineMessage string `json:"decline_message,omitempty"`
+	Action         *struct {
+		RedirectURL string `json:"redirect_url"`
+	} `json:"action,omitempty"`
+}
+
+type AmountRequest struct {
+	Amount int64 `json:"amount"`
+}
+
+type ErrorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
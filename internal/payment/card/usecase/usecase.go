@@ -1,19 +1,275 @@
 package usecase
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/aclgo/simple-api-gateway/internal/domain/models"
+	"github.com/aclgo/simple-api-gateway/internal/payment/card"
 	"github.com/google/uuid"
 )
 
+const currencyBRL = "BRL"
+
+// paymentProcessorCard charges tokenized cards through the provider HTTP API:
+// the amount is authorized first and captured right after, so a failed
+// capture never leaves the customer charged without an order.
 type paymentProcessorCard struct {
+	baseURL string
+	apiKey  string
+	timeout time.Duration
+	client  *http.Client
 }
 
-func NewpaymentProcessorCard() models.PaymentProcessor {
-	return &paymentProcessorCard{}
+func NewpaymentProcessorCard(cfg card.ProviderConfig) models.PaymentProcessor {
+	return NewpaymentProcessorCardWithClient(cfg, nil)
+}
+
+// NewpaymentProcessorCardWithClient lets the HTTP client be replaced, e.g. by
+// the one of an httptest server standing in for the provider.
+func NewpaymentProcessorCardWithClient(cfg card.ProviderConfig, client *http.Client) models.PaymentProcessor {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = card.DefaultTimeout
+	}
+
+	if client == nil {
+		client = &http.Client{Timeout: cfg.Timeout}
+	}
+
+	return &paymentProcessorCard{
+		baseURL: strings.TrimRight(cfg.BaseURL, "/"),
+		apiKey:  cfg.APIKey,
+		timeout: cfg.Timeout,
+		client:  client,
+	}
 }
 
 func (p *paymentProcessorCard) Proccess(ctx context.Context, in *models.ParamPaymentProcessInput) (*models.ParamPaymentProcessOutput, error) {
-	return &models.ParamPaymentProcessOutput{Method: in.Method, Status: models.PaymentPaid, GatewayTransactionID: uuid.NewString()}, nil
+	if in.CardToken == "" {
+		return nil, card.ErrCardTokenEmpty
+	}
+
+	reference := in.ReferenceId
+	if reference == "" {
+		reference = uuid.NewString()
+	}
+
+	req := card.PaymentRequest{
+		Amount:         in.Amount,
+		Currency:       currencyBRL,
+		CardToken:      in.CardToken,
+		CardExpiration: in.CardExpiration,
+		Reference:      reference,
+	}
+
+	var authorized card.PaymentResponse
+
+	// the reference doubles as idempotency key, so a retried authorization
+	// is not charged twice
+	if err := p.doOnce(ctx, "/v1/payments", reference, &req, &authorized); err != nil {
+		if errors.Is(err, card.ErrOutcomeUnknown) {
+			return p.unknown(in, reference, fmt.Errorf("authorize: %w", err))
+		}
+
+		return nil, fmt.Errorf("authorize: %w", err)
+	}
+
+	out := models.ParamPaymentProcessOutput{
+		Method:               in.Method,
+		Status:               models.PaymentFailed,
+		GatewayTransactionID: authorized.Id,
+		CardToken:            in.CardToken,
+		CardExpiration:       in.CardExpiration,
+	}
+
+	switch authorized.Status {
+	case card.StatusDeclined:
+		out.FailureReason = card.DeclineReason(authorized.DeclineCode)
+		return &out, nil
+	case card.StatusRequiresAction:
+		// 3DS: the customer finishes the challenge and the provider
+		// notifies the outcome
+		out.Status = models.PaymentPending
+		if authorized.Action != nil {
+			out.CardRedirectURL = authorized.Action.RedirectURL
+		}
+		return &out, nil
+	case card.StatusCaptured:
+		out.Status = models.PaymentPaid
+		return &out, nil
+	case card.StatusAuthorized:
+	default:
+		return nil, fmt.Errorf("%w: authorization status %q", card.ErrProviderResponse, authorized.Status)
+	}
+
+	var captured card.PaymentResponse
+
+	err := p.doOnce(ctx, "/v1/payments/"+authorized.Id+"/capture", reference+"-capture", &card.AmountRequest{Amount: in.Amount}, &captured)
+	if err != nil {
+		// the capture may have gone through, voiding would not undo it
+		if errors.Is(err, card.ErrOutcomeUnknown) {
+			return p.unknown(in, authorized.Id, fmt.Errorf("capture: %w", err))
+		}
+
+		p.void(ctx, authorized.Id, reference)
+		return nil, fmt.Errorf("capture: %w", err)
+	}
+
+	if captured.Status != card.StatusCaptured {
+		p.void(ctx, authorized.Id, reference)
+
+		if captured.Status == card.StatusDeclined {
+			out.FailureReason = card.DeclineReason(captured.DeclineCode)
+			return &out, nil
+		}
+
+		return nil, fmt.Errorf("%w: capture status %q", card.ErrProviderResponse, captured.Status)
+	}
+
+	out.Status = models.PaymentPaid
+
+	return &out, nil
+}
+
+// doOnce sends the call and, when the provider may have taken it without
+// answering, asks again under the same idempotency key: the provider then
+// answers with the outcome of the first call instead of running it twice.
+func (p *paymentProcessorCard) doOnce(ctx context.Context, path string, idempotencyKey string, body any, out *card.PaymentResponse) error {
+	err := p.do(ctx, path, idempotencyKey, body, out)
+	if !errors.Is(err, card.ErrOutcomeUnknown) {
+		return err
+	}
+
+	// the route timeout may be what cut the first call short
+	replayCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), p.timeout)
+	defer cancel()
+
+	replayErr := p.do(replayCtx, path, idempotencyKey, body, out)
+	if replayErr == nil {
+		return nil
+	}
+
+	// a replay that did not get an answer tells nothing about the first call
+	if errors.Is(replayErr, card.ErrOutcomeUnknown) || errors.Is(replayErr, card.ErrProviderUnavailable) {
+		return err
+	}
+
+	return replayErr
+}
+
+// unknown leaves the payment PENDING under gatewayTransactionId when the
+// provider may have charged it. The error goes along so the attempt is
+// recorded for reconciliation and the payment does not fail over.
+func (p *paymentProcessorCard) unknown(in *models.ParamPaymentProcessInput, gatewayTransactionId string,
+	err error) (*models.ParamPaymentProcessOutput, error) {
+	out := models.ParamPaymentProcessOutput{
+		Method:               in.Method,
+		Status:               models.PaymentPending,
+		GatewayTransactionID: gatewayTransactionId,
+		CardToken:            in.CardToken,
+		CardExpiration:       in.CardExpiration,
+	}
+
+	return &out, err
+}
+
+// void releases an authorization that could not be captured. The provider
+// drops stale authorizations on its own, so a failure is not reported.
+func (p *paymentProcessorCard) void(ctx context.Context, id string, reference string) {
+	var voided card.PaymentResponse
+	_ = p.do(context.WithoutCancel(ctx), "/v1/payments/"+id+"/void", reference+"-void", struct{}{}, &voided)
+}
+
+func (p *paymentProcessorCard) Refund(ctx context.Context, in *models.ParamPaymentRefundInput) error {
+	var refunded card.PaymentResponse
+
+	// one refund per amount of a payment, so retries from the saga worker
+	// do not refund twice
+	key := fmt.Sprintf("%s-refund-%d", in.GatewayTransactionID, in.Amount)
+
+	err := p.do(ctx, "/v1/payments/"+in.GatewayTransactionID+"/refunds", key, &card.AmountRequest{Amount: in.Amount}, &refunded)
+	if err != nil {
+		return fmt.Errorf("refund: %w", err)
+	}
+
+	if refunded.Status != card.StatusRefunded {
+		return fmt.Errorf("%w: refund status %q", card.ErrProviderResponse, refunded.Status)
+	}
+
+	return nil
+}
+
+func (p *paymentProcessorCard) do(ctx context.Context, path string, idempotencyKey string, body any, out *card.PaymentResponse) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("http.NewRequestWithContext: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	req.Header.Set("Idempotency-Key", idempotencyKey)
+
+	// once the request is out the provider may act on it whatever happens
+	// to the answer
+	var sent atomic.Bool
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		WroteRequest: func(info httptrace.WroteRequestInfo) {
+			if info.Err == nil {
+				sent.Store(true)
+			}
+		},
+	}))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		if sent.Load() {
+			return fmt.Errorf("%w: %v", card.ErrOutcomeUnknown, err)
+		}
+
+		return fmt.Errorf("%w: %v", card.ErrProviderUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("%w: %v", card.ErrOutcomeUnknown, err)
+	}
+
+	// only an empty 503 means the provider turned the call away unread, any
+	// other server error may come after it charged
+	if resp.StatusCode == http.StatusServiceUnavailable && len(bytes.TrimSpace(data)) == 0 {
+		return fmt.Errorf("%w: status %d", card.ErrProviderUnavailable, resp.StatusCode)
+	}
+
+	// declines come back as 402 with the payment in the body
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("%w: status %d", card.ErrOutcomeUnknown, resp.StatusCode)
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest && resp.StatusCode != http.StatusPaymentRequired {
+		var providerErr card.ErrorResponse
+		_ = json.Unmarshal(data, &providerErr)
+
+		return fmt.Errorf("%w: status %d: %s %s", card.ErrProviderResponse, resp.StatusCode, providerErr.Code, providerErr.Message)
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return errors.Join(card.ErrProviderResponse, err)
+	}
+
+	return nil
 }
@@ -0,0 +1,397 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/domain/models"
+	"github.com/aclgo/simple-api-gateway/internal/payment"
+	"github.com/aclgo/simple-api-gateway/internal/payment/card"
+)
+
+const (
+	testAPIKey    = "test-key"
+	testReference = "ref-1"
+	testPaymentId = "pay_1"
+)
+
+// providerCall is a request the fake provider got.
+type providerCall struct {
+	path           string
+	idempotencyKey string
+	body           map[string]any
+}
+
+type providerReply struct {
+	status int
+	body   string
+}
+
+// fakeProvider answers every path with its queued replies in order, the last
+// one repeating, and records the calls it got.
+type fakeProvider struct {
+	t       *testing.T
+	mu      sync.Mutex
+	replies map[string][]providerReply
+	calls   []providerCall
+}
+
+func newFakeProvider(t *testing.T, replies map[string][]providerReply) (*fakeProvider, models.PaymentProcessor) {
+	t.Helper()
+
+	provider := &fakeProvider{t: t, replies: replies}
+
+	server := httptest.NewServer(provider)
+	t.Cleanup(server.Close)
+
+	processor := NewpaymentProcessorCardWithClient(card.ProviderConfig{
+		BaseURL: server.URL + "/",
+		APIKey:  testAPIKey,
+		Timeout: 5 * time.Second,
+	}, server.Client())
+
+	return provider, processor
+}
+
+func (f *fakeProvider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if got := r.Header.Get("Authorization"); got != "Bearer "+testAPIKey {
+		f.t.Errorf("Authorization header = %q", got)
+	}
+
+	data, _ := io.ReadAll(r.Body)
+
+	var body map[string]any
+	_ = json.Unmarshal(data, &body)
+
+	f.mu.Lock()
+	f.calls = append(f.calls, providerCall{path: r.URL.Path, idempotencyKey: r.Header.Get("Idempotency-Key"), body: body})
+
+	queue := f.replies[r.URL.Path]
+	if len(queue) == 0 {
+		f.mu.Unlock()
+		f.t.Errorf("unexpected call to %s", r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	reply := queue[0]
+	if len(queue) > 1 {
+		f.replies[r.URL.Path] = queue[1:]
+	}
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(reply.status)
+	io.WriteString(w, reply.body)
+}
+
+func (f *fakeProvider) callsTo(path string) []providerCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	calls := make([]providerCall, 0)
+	for _, call := range f.calls {
+		if call.path == path {
+			calls = append(calls, call)
+		}
+	}
+
+	return calls
+}
+
+func paymentInput() *models.ParamPaymentProcessInput {
+	return &models.ParamPaymentProcessInput{
+		Method:         models.PaymentMethodCard,
+		AccountId:      "account-1",
+		ReferenceId:    testReference,
+		Amount:         1990,
+		CardToken:      "tok_visa",
+		CardExpiration: "12/30",
+	}
+}
+
+const (
+	pathAuthorize = "/v1/payments"
+	pathCapture   = "/v1/payments/" + testPaymentId + "/capture"
+	pathVoid      = "/v1/payments/" + testPaymentId + "/void"
+)
+
+var (
+	replyAuthorized = providerReply{http.StatusOK, `{"id":"pay_1","status":"authorized"}`}
+	replyCaptured   = providerReply{http.StatusOK, `{"id":"pay_1","status":"captured"}`}
+	replyVoided     = providerReply{http.StatusOK, `{"id":"pay_1","status":"voided"}`}
+)
+
+func TestProccessAuthorizesAndCaptures(t *testing.T) {
+	provider, processor := newFakeProvider(t, map[string][]providerReply{
+		pathAuthorize: {replyAuthorized},
+		pathCapture:   {replyCaptured},
+	})
+
+	out, err := processor.Proccess(context.Background(), paymentInput())
+	if err != nil {
+		t.Fatalf("Proccess: %v", err)
+	}
+
+	if out.Status != models.PaymentPaid {
+		t.Errorf("status = %s, want %s", out.Status, models.PaymentPaid)
+	}
+
+	if out.GatewayTransactionID != testPaymentId {
+		t.Errorf("gateway transaction id = %q, want %q", out.GatewayTransactionID, testPaymentId)
+	}
+
+	authorize := provider.callsTo(pathAuthorize)
+	if len(authorize) != 1 {
+		t.Fatalf("authorize calls = %d, want 1", len(authorize))
+	}
+
+	if authorize[0].idempotencyKey != testReference {
+		t.Errorf("authorize idempotency key = %q, want %q", authorize[0].idempotencyKey, testReference)
+	}
+
+	if authorize[0].body["amount"] != float64(1990) || authorize[0].body["currency"] != currencyBRL ||
+		authorize[0].body["card_token"] != "tok_visa" || authorize[0].body["reference"] != testReference {
+		t.Errorf("authorize body = %v", authorize[0].body)
+	}
+
+	capture := provider.callsTo(pathCapture)
+	if len(capture) != 1 {
+		t.Fatalf("capture calls = %d, want 1", len(capture))
+	}
+
+	if capture[0].idempotencyKey != testReference+"-capture" {
+		t.Errorf("capture idempotency key = %q", capture[0].idempotencyKey)
+	}
+
+	if capture[0].body["amount"] != float64(1990) {
+		t.Errorf("capture body = %v", capture[0].body)
+	}
+
+	if voids := provider.callsTo(pathVoid); len(voids) != 0 {
+		t.Errorf("void calls = %d, want 0", len(voids))
+	}
+}
+
+func TestProccessVoidsWhenCaptureFails(t *testing.T) {
+	tests := []struct {
+		name       string
+		capture    providerReply
+		wantErr    error
+		wantReason string
+	}{
+		{
+			name:    "rejected",
+			capture: providerReply{http.StatusUnprocessableEntity, `{"code":"capture_failed","message":"authorization expired"}`},
+			wantErr: card.ErrProviderResponse,
+		},
+		{
+			name:       "declined",
+			capture:    providerReply{http.StatusPaymentRequired, `{"id":"pay_1","status":"declined","decline_code":"insufficient_funds"}`},
+			wantReason: "insufficient funds",
+		},
+		{
+			name:    "unexpected status",
+			capture: providerReply{http.StatusOK, `{"id":"pay_1","status":"authorized"}`},
+			wantErr: card.ErrProviderResponse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, processor := newFakeProvider(t, map[string][]providerReply{
+				pathAuthorize: {replyAuthorized},
+				pathCapture:   {tt.capture},
+				pathVoid:      {replyVoided},
+			})
+
+			out, err := processor.Proccess(context.Background(), paymentInput())
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+
+				if errors.Is(err, payment.ErrProviderUnavailable) {
+					t.Errorf("err = %v, must not fail over", err)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("Proccess: %v", err)
+				}
+
+				if out.Status != models.PaymentFailed || out.FailureReason != tt.wantReason {
+					t.Errorf("out = %s %q, want %s %q", out.Status, out.FailureReason, models.PaymentFailed, tt.wantReason)
+				}
+			}
+
+			voids := provider.callsTo(pathVoid)
+			if len(voids) != 1 {
+				t.Fatalf("void calls = %d, want 1", len(voids))
+			}
+
+			if voids[0].idempotencyKey != testReference+"-void" {
+				t.Errorf("void idempotency key = %q", voids[0].idempotencyKey)
+			}
+		})
+	}
+}
+
+func TestProccessMapsDeclines(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{code: "insufficient_funds", want: "insufficient funds"},
+		{code: "expired_card", want: "card expired"},
+		{code: "incorrect_cvc", want: "incorrect security code"},
+		{code: "limit_exceeded", want: "card limit exceeded"},
+		// fraud signals are not told to the customer
+		{code: "fraudulent", want: "card declined"},
+		{code: "stolen_card", want: "card declined"},
+		{code: "some_new_code", want: "card declined"},
+		{code: "", want: "card declined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			body, _ := json.Marshal(map[string]string{"id": testPaymentId, "status": card.StatusDeclined, "decline_code": tt.code})
+
+			provider, processor := newFakeProvider(t, map[string][]providerReply{
+				pathAuthorize: {{http.StatusPaymentRequired, string(body)}},
+			})
+
+			out, err := processor.Proccess(context.Background(), paymentInput())
+			if err != nil {
+				t.Fatalf("Proccess: %v", err)
+			}
+
+			if out.Status != models.PaymentFailed {
+				t.Errorf("status = %s, want %s", out.Status, models.PaymentFailed)
+			}
+
+			if out.FailureReason != tt.want {
+				t.Errorf("failure reason = %q, want %q", out.FailureReason, tt.want)
+			}
+
+			if captures := provider.callsTo(pathCapture); len(captures) != 0 {
+				t.Errorf("capture calls = %d, want 0", len(captures))
+			}
+		})
+	}
+}
+
+func TestProccessRequiresAction(t *testing.T) {
+	provider, processor := newFakeProvider(t, map[string][]providerReply{
+		pathAuthorize: {{http.StatusOK, `{"id":"pay_1","status":"requires_action","action":{"redirect_url":"https://acs.example/3ds/pay_1"}}`}},
+	})
+
+	out, err := processor.Proccess(context.Background(), paymentInput())
+	if err != nil {
+		t.Fatalf("Proccess: %v", err)
+	}
+
+	if out.Status != models.PaymentPending {
+		t.Errorf("status = %s, want %s", out.Status, models.PaymentPending)
+	}
+
+	if out.CardRedirectURL != "https://acs.example/3ds/pay_1" {
+		t.Errorf("redirect url = %q", out.CardRedirectURL)
+	}
+
+	if out.GatewayTransactionID != testPaymentId {
+		t.Errorf("gateway transaction id = %q, want %q", out.GatewayTransactionID, testPaymentId)
+	}
+
+	if captures := provider.callsTo(pathCapture); len(captures) != 0 {
+		t.Errorf("capture calls = %d, want 0", len(captures))
+	}
+}
+
+func TestProccessReplaysUnansweredCalls(t *testing.T) {
+	provider, processor := newFakeProvider(t, map[string][]providerReply{
+		pathAuthorize: {{http.StatusBadGateway, `upstream timeout`}, replyAuthorized},
+		pathCapture:   {replyCaptured},
+	})
+
+	out, err := processor.Proccess(context.Background(), paymentInput())
+	if err != nil {
+		t.Fatalf("Proccess: %v", err)
+	}
+
+	if out.Status != models.PaymentPaid {
+		t.Errorf("status = %s, want %s", out.Status, models.PaymentPaid)
+	}
+
+	authorize := provider.callsTo(pathAuthorize)
+	if len(authorize) != 2 {
+		t.Fatalf("authorize calls = %d, want 2", len(authorize))
+	}
+
+	if authorize[0].idempotencyKey != authorize[1].idempotencyKey {
+		t.Errorf("replay idempotency key = %q, want %q", authorize[1].idempotencyKey, authorize[0].idempotencyKey)
+	}
+}
+
+func TestProccessLeavesUnknownCapturePending(t *testing.T) {
+	provider, processor := newFakeProvider(t, map[string][]providerReply{
+		pathAuthorize: {replyAuthorized},
+		pathCapture:   {{http.StatusInternalServerError, `{"code":"internal"}`}},
+		pathVoid:      {replyVoided},
+	})
+
+	out, err := processor.Proccess(context.Background(), paymentInput())
+	if !errors.Is(err, payment.ErrPaymentOutcomeUnknown) {
+		t.Fatalf("err = %v, want %v", err, payment.ErrPaymentOutcomeUnknown)
+	}
+
+	if out == nil || out.Status != models.PaymentPending || out.GatewayTransactionID != testPaymentId {
+		t.Fatalf("out = %+v, want pending payment %s", out, testPaymentId)
+	}
+
+	if captures := provider.callsTo(pathCapture); len(captures) != 2 {
+		t.Errorf("capture calls = %d, want 2", len(captures))
+	}
+
+	// the capture may have gone through
+	if voids := provider.callsTo(pathVoid); len(voids) != 0 {
+		t.Errorf("void calls = %d, want 0", len(voids))
+	}
+}
+
+func TestProccessFailsOverWhenTurnedAway(t *testing.T) {
+	provider, processor := newFakeProvider(t, map[string][]providerReply{
+		pathAuthorize: {{http.StatusServiceUnavailable, ``}},
+	})
+
+	out, err := processor.Proccess(context.Background(), paymentInput())
+	if !errors.Is(err, payment.ErrProviderUnavailable) || errors.Is(err, payment.ErrPaymentOutcomeUnknown) {
+		t.Fatalf("err = %v, want only %v", err, payment.ErrProviderUnavailable)
+	}
+
+	if out != nil {
+		t.Errorf("out = %+v, want nil", out)
+	}
+
+	if authorize := provider.callsTo(pathAuthorize); len(authorize) != 1 {
+		t.Errorf("authorize calls = %d, want 1", len(authorize))
+	}
+}
+
+func TestProccessRequiresCardToken(t *testing.T) {
+	_, processor := newFakeProvider(t, map[string][]providerReply{})
+
+	in := paymentInput()
+	in.CardToken = ""
+
+	if _, err := processor.Proccess(context.Background(), in); !errors.Is(err, card.ErrCardTokenEmpty) {
+		t.Fatalf("err = %v, want %v", err, card.ErrCardTokenEmpty)
+	}
+}
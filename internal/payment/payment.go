@@ -9,13 +9,17 @@ import (
 )
 
 type PaymentInterface interface {
-	RegisterProvider(string, models.PaymentProcessor)
+	RegisterProvider(string, *Provider)
 	GeneratePayment(context.Context, *models.ParamPaymentProcessInput) (*models.ParamPaymentProcessOutput, error)
+	RefundPayment(context.Context, *models.ParamPaymentRefundInput) error
+	LinkAttempts(ctx context.Context, referenceId string, orderId string) error
+	SearchAttempts(context.Context, *ParamsSearchAttemptsInput) (*ParamsSearchAttemptsOutput, error)
 }
 
 
 var (
 	ErrPaymentMethodNotSupported = errors.New("payment method not supported")
+	ErrRefundNotSupported        = errors.New("refund not supported by payment method")
 	ErrExceddedLimitGenPix       = errors.New("excedded limit generate pix")
 )
 
@@ -0,0 +1,132 @@
+package pix
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BRCode is the EMV payload of a pix charge, the "copia e cola" string the
+// customer pastes in the bank app and the content of the QR code. A charge
+// created at the PSP is referenced by its Location; otherwise the payload
+// carries the key, amount and txid itself.
+type BRCode struct {
+	Key          string
+	Location     string
+	Description  string
+	MerchantName string
+	MerchantCity string
+	Amount       int64
+	TxId         string
+}
+
+const (
+	pixGUI          = "br.gov.bcb.pix"
+	maxMerchantName = 25
+	maxMerchantCity = 15
+	maxTxId         = 25
+)
+
+// Payload lays the fields out as EMV TLVs and appends the CRC16 checksum.
+func (b *BRCode) Payload() string {
+	var account strings.Builder
+	account.WriteString(emvField("00", pixGUI))
+
+	if b.Location != "" {
+		account.WriteString(emvField("25", strings.TrimPrefix(b.Location, "https://")))
+	} else {
+		account.WriteString(emvField("01", b.Key))
+		if b.Description != "" {
+			account.WriteString(emvField("02", b.Description))
+		}
+	}
+
+	// dynamic charges hold the txid and amount at the location
+	txId := "***"
+	if b.Location == "" && b.TxId != "" {
+		txId = truncate(b.TxId, maxTxId)
+	}
+
+	var payload strings.Builder
+	payload.WriteString(emvField("00", "01"))
+	payload.WriteString(emvField("01", "12"))
+	payload.WriteString(emvField("26", account.String()))
+	payload.WriteString(emvField("52", "0000"))
+	payload.WriteString(emvField("53", "986"))
+
+	if b.Location == "" && b.Amount > 0 {
+		payload.WriteString(emvField("54", FormatAmount(b.Amount)))
+	}
+
+	payload.WriteString(emvField("58", "BR"))
+	payload.WriteString(emvField("59", truncate(asciiOnly(b.MerchantName), maxMerchantName)))
+	payload.WriteString(emvField("60", truncate(asciiOnly(b.MerchantCity), maxMerchantCity)))
+	payload.WriteString(emvField("62", emvField("05", txId)))
+	payload.WriteString("6304")
+
+	return payload.String() + fmt.Sprintf("%04X", CRC16(payload.String()))
+}
+
+// CRC16 is the CRC-16/CCITT-FALSE checksum the BR Code spec requires.
+func CRC16(data string) uint16 {
+	crc := uint16(0xFFFF)
+
+	for i := 0; i < len(data); i++ {
+		crc ^= uint16(data[i]) << 8
+
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+
+	return crc
+}
+
+// FormatAmount prints cents the way pix expects them, e.g. 1234.56.
+func FormatAmount(cents int64) string {
+	return fmt.Sprintf("%d.%02d", cents/100, cents%100)
+}
+
+func emvField(id string, value string) string {
+	return fmt.Sprintf("%s%02d%s", id, len(value), value)
+}
+
+func truncate(value string, max int) string {
+	if len(value) > max {
+		return value[:max]
+	}
+
+	return value
+}
+
+var accents = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+	"Á", "A", "À", "A", "Â", "A", "Ã", "A", "Ä", "A",
+	"É", "E", "È", "E", "Ê", "E", "Ë", "E",
+	"Í", "I", "Ì", "I", "Î", "I", "Ï", "I",
+	"Ó", "O", "Ò", "O", "Ô", "O", "Õ", "O", "Ö", "O",
+	"Ú", "U", "Ù", "U", "Û", "U", "Ü", "U",
+	"Ç", "C", "Ñ", "N",
+)
+
+// asciiOnly drops accents, as banks reject names and cities outside ASCII.
+func asciiOnly(value string) string {
+	value = accents.Replace(value)
+
+	var b strings.Builder
+	for _, r := range value {
+		if r >= 32 && r < 127 {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,195 @@
+package pix
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// parseEMV splits a payload in its top level TLVs, failing on any length
+// that runs past the end.
+func parseEMV(t *testing.T, payload string) map[string]string {
+	t.Helper()
+
+	fields := make(map[string]string)
+
+	for i := 0; i < len(payload); {
+		if i+4 > len(payload) {
+			t.Fatalf("truncated field at %d in %q", i, payload)
+		}
+
+		var size int
+		if _, err := fmt.Sscanf(payload[i+2:i+4], "%02d", &size); err != nil {
+			t.Fatalf("bad length at %d in %q", i, payload)
+		}
+
+		if i+4+size > len(payload) {
+			t.Fatalf("field %s runs past the end of %q", payload[i:i+2], payload)
+		}
+
+		fields[payload[i:i+2]] = payload[i+4 : i+4+size]
+		i += 4 + size
+	}
+
+	return fields
+}
+
+func TestCRC16(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want uint16
+	}{
+		{name: "empty", data: "", want: 0xFFFF},
+		{name: "check value", data: "123456789", want: 0x29B1},
+		{
+			name: "central bank example",
+			data: "00020126580014br.gov.bcb.pix0136123e4567-e12b-12d1-a456-4266554400005204000053039865802BR5913Fulano de Tal6008BRASILIA62070503***6304",
+			want: 0x1D3D,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CRC16(tt.data); got != tt.want {
+				t.Errorf("CRC16(%q) = %04X, want %04X", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBRCodePayload(t *testing.T) {
+	tests := []struct {
+		name        string
+		code        BRCode
+		wantAccount map[string]string
+		wantAmount  string
+		wantName    string
+		wantCity    string
+		wantTxId    string
+	}{
+		{
+			name: "static with amount",
+			code: BRCode{
+				Key:          "pix@example.com",
+				Description:  "Pedido 42",
+				MerchantName: "Loja",
+				MerchantCity: "Sao Paulo",
+				Amount:       1990,
+				TxId:         "order42",
+			},
+			wantAccount: map[string]string{"00": pixGUI, "01": "pix@example.com", "02": "Pedido 42"},
+			wantAmount:  "19.90",
+			wantName:    "Loja",
+			wantCity:    "Sao Paulo",
+			wantTxId:    "order42",
+		},
+		{
+			name: "static without amount or txid",
+			code: BRCode{
+				Key:          "+5511999999999",
+				MerchantName: "Loja",
+				MerchantCity: "Recife",
+			},
+			wantAccount: map[string]string{"00": pixGUI, "01": "+5511999999999"},
+			wantName:    "Loja",
+			wantCity:    "Recife",
+			wantTxId:    "***",
+		},
+		{
+			name: "dynamic leaves amount and txid at the location",
+			code: BRCode{
+				Key:          "pix@example.com",
+				Location:     "https://psp.example.com/qr/v2/abc123",
+				MerchantName: "Loja",
+				MerchantCity: "Curitiba",
+				Amount:       5000,
+				TxId:         "order43",
+			},
+			wantAccount: map[string]string{"00": pixGUI, "25": "psp.example.com/qr/v2/abc123"},
+			wantName:    "Loja",
+			wantCity:    "Curitiba",
+			wantTxId:    "***",
+		},
+		{
+			name: "accents dropped and long fields truncated",
+			code: BRCode{
+				Key:          "pix@example.com",
+				MerchantName: "Padaria São João da Esquina Ltda",
+				MerchantCity: "São José dos Campos",
+				Amount:       5,
+				TxId:         "abcdefghijklmnopqrstuvwxyz0123",
+			},
+			wantAccount: map[string]string{"00": pixGUI, "01": "pix@example.com"},
+			wantAmount:  "0.05",
+			wantName:    "Padaria Sao Joao da Esqui",
+			wantCity:    "Sao Jose dos Ca",
+			wantTxId:    "abcdefghijklmnopqrstuvwxy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := tt.code.Payload()
+
+			body, crc := payload[:len(payload)-4], payload[len(payload)-4:]
+			if !strings.HasSuffix(body, "6304") {
+				t.Fatalf("payload %q does not end with the CRC field", payload)
+			}
+
+			if want := fmt.Sprintf("%04X", CRC16(body)); crc != want {
+				t.Errorf("crc = %s, want %s", crc, want)
+			}
+
+			fields := parseEMV(t, payload)
+
+			want := map[string]string{
+				"00": "01",
+				"01": "12",
+				"52": "0000",
+				"53": "986",
+				"58": "BR",
+				"59": tt.wantName,
+				"60": tt.wantCity,
+				"63": crc,
+			}
+
+			for id, value := range want {
+				if fields[id] != value {
+					t.Errorf("field %s = %q, want %q", id, fields[id], value)
+				}
+			}
+
+			if amount, ok := fields["54"]; amount != tt.wantAmount || ok != (tt.wantAmount != "") {
+				t.Errorf("amount = %q, want %q", amount, tt.wantAmount)
+			}
+
+			account := parseEMV(t, fields["26"])
+			if fmt.Sprint(account) != fmt.Sprint(tt.wantAccount) {
+				t.Errorf("account = %v, want %v", account, tt.wantAccount)
+			}
+
+			if txId := parseEMV(t, fields["62"])["05"]; txId != tt.wantTxId {
+				t.Errorf("txid = %q, want %q", txId, tt.wantTxId)
+			}
+		})
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	tests := []struct {
+		cents int64
+		want  string
+	}{
+		{cents: 0, want: "0.00"},
+		{cents: 5, want: "0.05"},
+		{cents: 100, want: "1.00"},
+		{cents: 123456, want: "1234.56"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatAmount(tt.cents); got != tt.want {
+			t.Errorf("FormatAmount(%d) = %q, want %q", tt.cents, got, tt.want)
+		}
+	}
+}
@@ -4,9 +4,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
-)
+	"time"
 
+	"github.com/aclgo/simple-api-gateway/internal/domain/models"
+	"github.com/aclgo/simple-api-gateway/internal/payment"
+	"github.com/google/uuid"
+)
 
+type UseCase interface {
+	models.PaymentProcessor
+	models.PixPaymentWebHook
+	// QRCode renders the pix "copia e cola" of a pending order as a PNG.
+	QRCode(ctx context.Context, params *ParamsQRCodeInput) ([]byte, error)
+}
 
 type Repository interface {
 	Get(ctx context.Context, key string) error
@@ -17,10 +27,75 @@ func FormatPixKeyRepository(id string) string {
 	return fmt.Sprintf("pix_generated:%s", id)
 }
 
-type ParamsPixOutput struct {
-	Teste string `json:"teste"`
-}
-
 var (
 	ErrExceddedLimitGenPix = errors.New("excedded limit gen pix")
-)
\ No newline at end of file
+	ErrPSPUnavailable      = fmt.Errorf("pix %w", payment.ErrProviderUnavailable)
+	ErrPSPResponse         = errors.New("unexpected response from pix provider")
+	ErrQRCodeNotAvailable  = errors.New("order has no pix to pay")
+)
+
+const (
+	DefaultExpiration = 30 * time.Minute
+	DefaultTimeout    = 30 * time.Second
+	DefaultQRCodeSize = 256
+)
+
+// ProviderConfig points the processor at the PSP holding the pix key. The
+// merchant name and city are printed in the customer's bank app.
+type ProviderConfig struct {
+	BaseURL       string
+	Authorization string
+	Key           string
+	MerchantName  string
+	MerchantCity  string
+	Expiration    time.Duration
+	Timeout       time.Duration
+}
+
+// ChargeRequest creates an immediate charge ("cobrança imediata") following
+// the Pix API of the Central Bank.
+type ChargeRequest struct {
+	Calendar struct {
+		Expiration int64 `json:"expiracao"`
+	} `json:"calendario"`
+	Value struct {
+		Original string `json:"original"`
+	} `json:"valor"`
+	Key         string `json:"chave"`
+	Description string `json:"solicitacaoPagador,omitempty"`
+}
+
+type ChargeResponse struct {
+	TxId     string `json:"txid"`
+	Status   string `json:"status"`
+	Location string `json:"location"`
+	Calendar struct {
+		CreatedAt  time.Time `json:"criacao"`
+		Expiration int64     `json:"expiracao"`
+	} `json:"calendario"`
+}
+
+// ChargeActive is the status of a charge waiting to be paid.
+const ChargeActive = "ATIVA"
+
+type ParamsQRCodeInput struct {
+	OrderId string
+	UserId  string
+	Size    int
+}
+
+func (p *ParamsQRCodeInput) Validate() error {
+	if _, err := uuid.Parse(p.OrderId); err != nil {
+		return errors.New("invalid uuid order")
+	}
+
+	if p.Size == 0 {
+		p.Size = DefaultQRCodeSize
+	}
+
+	if p.Size < 64 || p.Size > 1024 {
+		return errors.New("size invalid")
+	}
+
+	return nil
+}
@@ -1,173 +1,200 @@
 package usecase
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/aclgo/simple-api-gateway/internal/domain/models"
 	"github.com/aclgo/simple-api-gateway/internal/orders"
 	"github.com/aclgo/simple-api-gateway/internal/payment/pix"
-	protoBalance "github.com/aclgo/simple-api-gateway/proto-service/balance"
+	"github.com/aclgo/simple-api-gateway/internal/payment/settlement"
 	protoOrders "github.com/aclgo/simple-api-gateway/proto-service/orders"
-	protoUser "github.com/aclgo/simple-api-gateway/proto-service/user"
 	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/skip2/go-qrcode"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type paymentProcessorPix struct {
-	PixAuthorization  string
-	repo              pix.Repository
-	clientOrdersGRPC  protoOrders.ServiceOrderClient
-	clientBalanceGrpc protoBalance.WalletServiceClient
-	clientUserGrpc    protoUser.SubscriptionServiceClient
+	PixAuthorization string
+	provider         pix.ProviderConfig
+	client           *http.Client
+	repo             pix.Repository
+	clientOrdersGRPC protoOrders.ServiceOrderClient
+	settler          settlement.Settler
 }
 
-func NewpaymentProcessorPix(authorization string, repo pix.Repository, clientOrdersGRPC protoOrders.ServiceOrderClient,
-	clientBalanceGrpc protoBalance.WalletServiceClient,
-	clientUserGrpc protoUser.SubscriptionServiceClient) *paymentProcessorPix {
-	return &paymentProcessorPix{
-		PixAuthorization:  authorization,
-		repo:              repo,
-		clientOrdersGRPC:  clientOrdersGRPC,
-		clientBalanceGrpc: clientBalanceGrpc,
-		clientUserGrpc:    clientUserGrpc,
+func NewpaymentProcessorPix(provider pix.ProviderConfig, repo pix.Repository, clientOrdersGRPC protoOrders.ServiceOrderClient,
+	settler settlement.Settler) *paymentProcessorPix {
+	if provider.Expiration <= 0 {
+		provider.Expiration = pix.DefaultExpiration
 	}
-}
-
-func (p *paymentProcessorPix) Proccess(ctx context.Context, in *models.ParamPaymentProcessInput) (*models.ParamPaymentProcessOutput, error) {
 
-	out := models.ParamPaymentProcessOutput{
-		Method:               in.Method,
-		Status:               models.PaymentFailed,
-		GatewayTransactionID: uuid.NewString(),
+	if provider.Timeout <= 0 {
+		provider.Timeout = pix.DefaultTimeout
 	}
 
-	// err := p.repo.Get(ctx, in.AccountId)
+	provider.BaseURL = strings.TrimRight(provider.BaseURL, "/")
 
-	// if err != nil && err != redis.Nil {
-	// 	return &out, err
-	// }
-
-	// if err == nil {
-	// 	return &out, pix.ErrExceddedLimitGenPix
-	// }
-
-	// client := &http.Client{
-	// 	Timeout: time.Second * 30,
-	// }
+	return &paymentProcessorPix{
+		PixAuthorization: provider.Authorization,
+		provider:         provider,
+		client:           &http.Client{Timeout: provider.Timeout},
+		repo:             repo,
+		clientOrdersGRPC: clientOrdersGRPC,
+		settler:          settler,
+	}
+}
 
-	// reqBody := fmt.Sprintf(`%s`, "ok")
+// Proccess creates a charge at the PSP and returns its BR Code. The order
+// stays PENDING until the PSP confirms the payment through the webhook.
+// An account can generate one pix per rate limit window.
+func (p *paymentProcessorPix) Proccess(ctx context.Context, in *models.ParamPaymentProcessInput) (*models.ParamPaymentProcessOutput, error) {
+	err := p.repo.Get(ctx, in.AccountId)
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("p.repo.Get: %w", err)
+	}
 
-	// req, err := http.NewRequestWithContext(ctx, "POST", "", strings.NewReader(reqBody))
-	// if err != nil {
-	// 	return &out, err
-	// }
+	if err == nil {
+		return nil, pix.ErrExceddedLimitGenPix
+	}
 
-	// req.Header.Add("Content-Type:", "application/json")
-	// req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", p.PixAuthorization))
+	// the txid must be 26 to 35 alphanumerics
+	txId := strings.ReplaceAll(uuid.NewString(), "-", "")
 
-	// resp, err := client.Do(req)
-	// if err != nil {
-	// 	return &out, err
-	// }
+	charge, err := p.createCharge(ctx, txId, in.Amount)
+	if err != nil {
+		return nil, err
+	}
 
-	// respBody, err := io.ReadAll(resp.Body)
-	// if err != nil {
-	// 	return &out, err
-	// }
+	if err := p.repo.Set(ctx, in.AccountId); err != nil {
+		log.Printf("p.repo.Set: account %s: %v\n", in.AccountId, err)
+	}
 
-	// fmt.Println(string(respBody))
+	code := pix.BRCode{
+		Key:          p.provider.Key,
+		Location:     charge.Location,
+		MerchantName: p.provider.MerchantName,
+		MerchantCity: p.provider.MerchantCity,
+		Amount:       in.Amount,
+		TxId:         txId,
+	}
 
-	// p.repo.Set(ctx, in.AccountId)
+	expiration := time.Now().Add(p.provider.Expiration)
+	if !charge.Calendar.CreatedAt.IsZero() && charge.Calendar.Expiration > 0 {
+		expiration = charge.Calendar.CreatedAt.Add(time.Duration(charge.Calendar.Expiration) * time.Second)
+	}
 
-	out.GatewayTransactionID = uuid.NewString()
-	out.Status = models.PaymentPaid
+	out := models.ParamPaymentProcessOutput{
+		Method:               in.Method,
+		Status:               models.PaymentPending,
+		GatewayTransactionID: txId,
+		PixQRCode:            code.Payload(),
+		PixExpiration:        expiration,
+	}
 
 	return &out, nil
 }
 
-func (p *paymentProcessorPix) Webhook(ctx context.Context, in *models.ParamPixWebHookInput) error {
+func (p *paymentProcessorPix) createCharge(ctx context.Context, txId string, amount int64) (*pix.ChargeResponse, error) {
+	var body pix.ChargeRequest
+	body.Calendar.Expiration = int64(p.provider.Expiration / time.Second)
+	body.Value.Original = pix.FormatAmount(amount)
+	body.Key = p.provider.Key
 
-	po := protoOrders.ParamFindOrderByGatewayTransactionIdRequest{
-		GatewayTransactionId: in.GatewayTransactionId,
+	payload, err := json.Marshal(&body)
+	if err != nil {
+		return nil, fmt.Errorf("json.Marshal: %w", err)
 	}
 
-	resp, err := p.clientOrdersGRPC.FindOrderByGatewayTransactionId(ctx, &po)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, p.provider.BaseURL+"/v2/cob/"+txId, bytes.NewReader(payload))
 	if err != nil {
-		return fmt.Errorf("p.clientOrdersGRPC.FindOrderByGatewayTransactionId: %w", err)
+		return nil, fmt.Errorf("http.NewRequestWithContext: %w", err)
 	}
 
-	order := resp.Order
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", p.PixAuthorization))
 
-	if order.Status == protoOrders.OrderStatus_PAID {
-		return nil
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", pix.ErrPSPUnavailable, err)
 	}
+	defer resp.Body.Close()
 
-	switch order.Type {
-	case protoOrders.OrderType_BALANCE_DEPOSIT:
-		if err := p.processDepositBalance(ctx, order); err != nil {
-			return fmt.Errorf("processing balance deposity: %w", err)
-		}
-	case protoOrders.OrderType_PREMIUM_SUBSCRIPTION:
-		if err := p.processSubscription(ctx, order); err != nil {
-			return fmt.Errorf("processing premiun subscription: %w", err)
-		}
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", pix.ErrPSPUnavailable, err)
 	}
 
-	pupd := protoOrders.ParamUpdateOrderStatusRequest{
-		OrderId: order.OrderID,
-		Status:  protoOrders.OrderStatus_PAID,
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return nil, fmt.Errorf("%w: status %d", pix.ErrPSPUnavailable, resp.StatusCode)
 	}
 
-	_, err = p.clientOrdersGRPC.UpdateOrderStatus(ctx, &pupd)
-	if err != nil {
-		return fmt.Errorf("failed to update order to status paid: %w", err)
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("%w: status %d: %s", pix.ErrPSPResponse, resp.StatusCode, respBody)
 	}
 
-	return nil
-}
-
-func (p *paymentProcessorPix) processSubscription(ctx context.Context, order *protoOrders.Orders) error {
-	var meta orders.ParamsSaveSubscriptionMetadata
-
-	if err := json.Unmarshal(order.Metadata, &meta); err != nil {
-		return fmt.Errorf("json.Unmarshal: %w", err)
-	}
+	var charge pix.ChargeResponse
 
-	psub := protoUser.CreateOrExtendSubscriptionRequest{
-		UserId: meta.UserId,
-		Plan:   meta.Plan,
-		Days:   int64(meta.Days),
+	if err := json.Unmarshal(respBody, &charge); err != nil {
+		return nil, fmt.Errorf("%w: %v", pix.ErrPSPResponse, err)
 	}
 
-	_, err := p.clientUserGrpc.CreateOrExtend(ctx, &psub)
-	if err != nil {
-		return fmt.Errorf("p.clientUserGrpc.CreateOrExtend: %w", err)
+	if charge.Status != pix.ChargeActive {
+		return nil, fmt.Errorf("%w: charge status %q", pix.ErrPSPResponse, charge.Status)
 	}
 
-	return nil
+	return &charge, nil
 }
 
-func (p *paymentProcessorPix) processDepositBalance(ctx context.Context, order *protoOrders.Orders) error {
+func (p *paymentProcessorPix) QRCode(ctx context.Context, params *pix.ParamsQRCodeInput) ([]byte, error) {
+	find, err := p.clientOrdersGRPC.Find(ctx, &protoOrders.ParamFindOrderRequest{OrderID: params.OrderId})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, orders.ErrOrderNotFound
+		}
 
-	pf := protoBalance.ParamGetWalletByAccountRequest{
-		AccountID: order.AccountID,
+		return nil, fmt.Errorf("p.clientOrdersGRPC.Find: %w", err)
 	}
 
-	find, err := p.clientBalanceGrpc.GetWalletByAccount(ctx, &pf)
-	if err != nil {
-		return fmt.Errorf("p.clientBalanceGrpc.GetWalletByAccount: %w", err)
+	order := find.Order
+
+	if order == nil || (params.UserId != "" && order.AccountID != params.UserId) {
+		return nil, orders.ErrOrderNotFound
 	}
 
-	pb := protoBalance.ParamCreditWalletRequest{
-		WalletID:    find.WalletID,
-		Amount:      order.Amount,
-		ReferenceID: order.GatewayTransactionID,
+	if order.PixQRCode == "" || order.Status != protoOrders.OrderStatus_PENDING || orders.PaymentExpired(order, time.Now()) {
+		return nil, pix.ErrQRCodeNotAvailable
 	}
 
-	_, err = p.clientBalanceGrpc.Credit(ctx, &pb)
+	png, err := qrcode.Encode(order.PixQRCode, qrcode.Medium, params.Size)
 	if err != nil {
-		return fmt.Errorf("p.clientBalanceGrpc.Credit: %w", err)
+		return nil, fmt.Errorf("qrcode.Encode: %w", err)
 	}
-	return nil
+
+	return png, nil
+}
+
+func (p *paymentProcessorPix) Webhook(ctx context.Context, in *models.ParamPixWebHookInput) error {
+	return p.settler.Settle(ctx, &settlement.ParamsSettleInput{
+		GatewayTransactionId: in.GatewayTransactionId,
+		Actor:                orders.ActorPixWebhook,
+		Reason:               "pix payment confirmed",
+		Check: func(order *protoOrders.Orders) error {
+			// the sweeper may not have cancelled it yet
+			if orders.PaymentExpired(order, time.Now()) {
+				return orders.ErrOrderExpired
+			}
+
+			return nil
+		},
+	})
 }
@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/payment"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type attemptRepository struct {
+	db *sqlx.DB
+}
+
+func NewAttemptRepository(db *sqlx.DB) payment.AttemptRepository {
+	return &attemptRepository{
+		db: db,
+	}
+}
+
+type attemptRow struct {
+	Id            string         `db:"id"`
+	ReferenceId   string         `db:"reference_id"`
+	OrderId       sql.NullString `db:"order_id"`
+	AccountId     string         `db:"account_id"`
+	Operation     string         `db:"operation"`
+	Method        string         `db:"method"`
+	Provider      string         `db:"provider"`
+	Amount        int64          `db:"amount"`
+	Status        string         `db:"status"`
+	PaymentStatus string         `db:"payment_status"`
+	FailureReason string         `db:"failure_reason"`
+	Error         string         `db:"error"`
+	Request       []byte         `db:"request"`
+	Response      []byte         `db:"response"`
+	LatencyMs     int64          `db:"latency_ms"`
+	CreatedAt     time.Time      `db:"created_at"`
+}
+
+func (r *attemptRepository) Create(ctx context.Context, attempt *payment.Attempt) error {
+	var orderId sql.NullString
+	if attempt.OrderId != "" {
+		orderId = sql.NullString{String: attempt.OrderId, Valid: true}
+	}
+
+	var response []byte
+	if len(attempt.Response) > 0 {
+		response = attempt.Response
+	}
+
+	query := `INSERT INTO payment_attempts (id, reference_id, order_id, account_id, operation, method, provider, amount,
+	status, payment_status, failure_reason, error, request, response, latency_ms, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)`
+
+	_, err := r.db.ExecContext(ctx, query, attempt.Id, attempt.ReferenceId, orderId, attempt.AccountId, attempt.Operation,
+		attempt.Method, attempt.Provider, attempt.Amount, attempt.Status, attempt.PaymentStatus, attempt.FailureReason,
+		attempt.Error, []byte(attempt.Request), response, attempt.LatencyMs, attempt.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("r.db.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (r *attemptRepository) LinkOrder(ctx context.Context, referenceId string, orderId string) error {
+	query := `UPDATE payment_attempts SET order_id = $1 WHERE reference_id = $2 AND order_id IS NULL`
+
+	if _, err := r.db.ExecContext(ctx, query, orderId, referenceId); err != nil {
+		return fmt.Errorf("r.db.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (r *attemptRepository) OrdersByReference(ctx context.Context, referenceIds []string) (map[string]string, error) {
+	query := `SELECT DISTINCT reference_id, order_id FROM payment_attempts
+	WHERE reference_id = ANY($1) AND order_id IS NOT NULL`
+
+	var rows []struct {
+		ReferenceId string `db:"reference_id"`
+		OrderId     string `db:"order_id"`
+	}
+
+	if err := r.db.SelectContext(ctx, &rows, query, pq.StringArray(referenceIds)); err != nil {
+		return nil, fmt.Errorf("r.db.SelectContext: %w", err)
+	}
+
+	orders := make(map[string]string, len(rows))
+	for _, row := range rows {
+		orders[row.ReferenceId] = row.OrderId
+	}
+
+	return orders, nil
+}
+
+func (r *attemptRepository) Search(ctx context.Context, params *payment.ParamsSearchAttemptsInput) ([]*payment.Attempt, int, error) {
+	var where whereClause
+
+	if params.OrderId != "" {
+		where.add("order_id = ?", params.OrderId)
+	}
+
+	if params.AccountId != "" {
+		where.add("account_id = ?", params.AccountId)
+	}
+
+	if params.ReferenceId != "" {
+		where.add("reference_id = ?", params.ReferenceId)
+	}
+
+	if params.Provider != "" {
+		where.add("provider = ?", params.Provider)
+	}
+
+	if params.Status != "" {
+		where.add("status = ?", params.Status)
+	}
+
+	filter := where.String()
+
+	var total int
+
+	if err := r.db.GetContext(ctx, &total, `SELECT count(*) FROM payment_attempts`+filter, where.args...); err != nil {
+		return nil, 0, fmt.Errorf("r.db.GetContext: %w", err)
+	}
+
+	offset := (params.PageInt - 1) * params.LimitInt
+
+	query := `SELECT id, reference_id, order_id, account_id, operation, method, provider, amount, status, payment_status,
+	failure_reason, error, request, response, latency_ms, created_at
+	FROM payment_attempts` + filter + ` ORDER BY created_at DESC, id` +
+		` LIMIT ` + strconv.Itoa(params.LimitInt) + ` OFFSET ` + strconv.Itoa(offset)
+
+	var rows []attemptRow
+
+	if err := r.db.SelectContext(ctx, &rows, query, where.args...); err != nil {
+		return nil, 0, fmt.Errorf("r.db.SelectContext: %w", err)
+	}
+
+	attempts := make([]*payment.Attempt, 0, len(rows))
+	for _, row := range rows {
+		attempts = append(attempts, &payment.Attempt{
+			Id:            row.Id,
+			ReferenceId:   row.ReferenceId,
+			OrderId:       row.OrderId.String,
+			AccountId:     row.AccountId,
+			Operation:     row.Operation,
+			Method:        row.Method,
+			Provider:      row.Provider,
+			Amount:        row.Amount,
+			Status:        row.Status,
+			PaymentStatus: row.PaymentStatus,
+			FailureReason: row.FailureReason,
+			Error:         row.Error,
+			Request:       row.Request,
+			Response:      row.Response,
+			LatencyMs:     row.LatencyMs,
+			CreatedAt:     row.CreatedAt,
+		})
+	}
+
+	return attempts, total, nil
+}
+
+// whereClause numbers the placeholders of the conditions as they are added.
+type whereClause struct {
+	conditions []string
+	args       []any
+}
+
+func (w *whereClause) add(condition string, args ...any) {
+	for _, arg := range args {
+		w.args = append(w.args, arg)
+		condition = strings.Replace(condition, "?", "$"+strconv.Itoa(len(w.args)), 1)
+	}
+
+	w.conditions = append(w.conditions, condition)
+}
+
+func (w *whereClause) String() string {
+	if len(w.conditions) == 0 {
+		return ""
+	}
+
+	return " WHERE " + strings.Join(w.conditions, " AND ")
+}
@@ -0,0 +1,111 @@
+package payment
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/domain/models"
+)
+
+var (
+	// ErrProviderUnavailable is wrapped by the errors processors return when
+	// the provider surely did not take the payment, e.g. the connection was
+	// refused, which makes the payment fail over to the next provider of the
+	// method.
+	ErrProviderUnavailable = errors.New("payment provider unavailable")
+	// ErrPaymentOutcomeUnknown is wrapped by the errors processors return
+	// when the provider may have charged the customer, e.g. it timed out
+	// after the request was sent. The payment never fails over then, another
+	// provider would charge the customer a second time.
+	ErrPaymentOutcomeUnknown = errors.New("payment outcome unknown")
+	ErrNoProviderForRoute    = errors.New("no payment provider accepts the payment")
+	ErrCircuitOpen           = errors.New("circuit open")
+	ErrProviderNotRegistered = errors.New("payment provider not registered")
+)
+
+// Provider is one processor of a payment method, e.g. one of the PSPs
+// taking pix.
+type Provider struct {
+	Name      string
+	Processor models.PaymentProcessor
+	Route     Route
+}
+
+// Route decides when and in which order a provider is tried. Providers are
+// tried by ascending priority; among the same priority the weight sets the
+// share of payments each one gets first. Amount and account rules leave the
+// provider out of payments they do not match.
+type Route struct {
+	Priority   int           `json:"priority"`
+	Weight     int           `json:"weight"`
+	Timeout    time.Duration `json:"-"`
+	MinAmount  int64         `json:"min_amount"`
+	MaxAmount  int64         `json:"max_amount"`
+	AccountIds []string      `json:"account_ids"`
+}
+
+func (r *Route) Matches(in *models.ParamPaymentProcessInput) bool {
+	if r.MinAmount > 0 && in.Amount < r.MinAmount {
+		return false
+	}
+
+	if r.MaxAmount > 0 && in.Amount > r.MaxAmount {
+		return false
+	}
+
+	if len(r.AccountIds) > 0 && !slices.Contains(r.AccountIds, in.AccountId) {
+		return false
+	}
+
+	return true
+}
+
+// Config sets how many consecutive failures open the circuit of a provider
+// and for how long it stays open.
+type Config struct {
+	BreakerFailures    int
+	BreakerOpenTimeout time.Duration
+}
+
+// ParseRoutes reads the routes of the providers by name from JSON, e.g.
+// {"pix-fallback": {"priority": 1, "timeout": "5s"}}. Providers left out
+// get the zero route.
+func ParseRoutes(raw string) (map[string]Route, error) {
+	routes := make(map[string]Route)
+	if raw == "" {
+		return routes, nil
+	}
+
+	var decoded map[string]struct {
+		Route
+		Timeout string `json:"timeout"`
+	}
+
+	if err := json.Unmarshal([]byte(raw), &decoded); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	for name, item := range decoded {
+		route := item.Route
+
+		if item.Timeout != "" {
+			timeout, err := time.ParseDuration(item.Timeout)
+			if err != nil {
+				return nil, fmt.Errorf("route %s: time.ParseDuration: %w", name, err)
+			}
+
+			route.Timeout = timeout
+		}
+
+		if route.Weight < 0 || route.MinAmount < 0 || route.MaxAmount < 0 {
+			return nil, fmt.Errorf("route %s: negative weight or amount", name)
+		}
+
+		routes[name] = route
+	}
+
+	return routes, nil
+}
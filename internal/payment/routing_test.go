@@ -0,0 +1,80 @@
+package payment
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/domain/models"
+)
+
+func TestParseRoutes(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    map[string]Route
+		wantErr bool
+	}{
+		{name: "empty", raw: "", want: map[string]Route{}},
+		{
+			name: "full route",
+			raw:  `{"pix-main": {"priority": 0, "weight": 3, "timeout": "5s", "min_amount": 100, "max_amount": 500000, "account_ids": ["a1", "a2"]}}`,
+			want: map[string]Route{
+				"pix-main": {Weight: 3, Timeout: 5 * time.Second, MinAmount: 100, MaxAmount: 500000, AccountIds: []string{"a1", "a2"}},
+			},
+		},
+		{
+			name: "several providers",
+			raw:  `{"card": {"priority": 0}, "card-fallback": {"priority": 1, "timeout": "1500ms"}}`,
+			want: map[string]Route{
+				"card":          {},
+				"card-fallback": {Priority: 1, Timeout: 1500 * time.Millisecond},
+			},
+		},
+		{name: "not json", raw: "card=1", wantErr: true},
+		{name: "bad timeout", raw: `{"card": {"timeout": "soon"}}`, wantErr: true},
+		{name: "negative weight", raw: `{"card": {"weight": -1}}`, wantErr: true},
+		{name: "negative amount", raw: `{"card": {"min_amount": -100}}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routes, err := ParseRoutes(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRoutes err = %v, want error %v", err, tt.wantErr)
+			}
+
+			if err == nil && !reflect.DeepEqual(routes, tt.want) {
+				t.Errorf("ParseRoutes = %+v, want %+v", routes, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouteMatches(t *testing.T) {
+	tests := []struct {
+		name      string
+		route     Route
+		amount    int64
+		accountId string
+		want      bool
+	}{
+		{name: "zero route takes everything", amount: 1, accountId: "a1", want: true},
+		{name: "at the minimum", route: Route{MinAmount: 100}, amount: 100, want: true},
+		{name: "below the minimum", route: Route{MinAmount: 100}, amount: 99},
+		{name: "at the maximum", route: Route{MaxAmount: 100}, amount: 100, want: true},
+		{name: "above the maximum", route: Route{MaxAmount: 100}, amount: 101},
+		{name: "listed account", route: Route{AccountIds: []string{"a1", "a2"}}, accountId: "a2", want: true},
+		{name: "account not listed", route: Route{AccountIds: []string{"a1", "a2"}}, accountId: "a3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := &models.ParamPaymentProcessInput{Amount: tt.amount, AccountId: tt.accountId}
+
+			if got := tt.route.Matches(in); got != tt.want {
+				t.Errorf("Matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/payment/settlement"
+	"github.com/jmoiron/sqlx"
+)
+
+type latePaymentRepository struct {
+	db *sqlx.DB
+}
+
+func NewLatePaymentRepository(db *sqlx.DB) settlement.LatePaymentRepository {
+	return &latePaymentRepository{
+		db: db,
+	}
+}
+
+type latePaymentRow struct {
+	Id                   string       `db:"id"`
+	OrderId              string       `db:"order_id"`
+	AccountId            string       `db:"account_id"`
+	GatewayTransactionId string       `db:"gateway_transaction_id"`
+	Amount               int64        `db:"amount"`
+	Reason               string       `db:"reason"`
+	Status               string       `db:"status"`
+	CreatedAt            time.Time    `db:"created_at"`
+	CreditedAt           sql.NullTime `db:"credited_at"`
+}
+
+func (r *latePaymentRow) toLatePayment() *settlement.LatePayment {
+	latePayment := settlement.LatePayment{
+		Id:                   r.Id,
+		OrderId:              r.OrderId,
+		AccountId:            r.AccountId,
+		GatewayTransactionId: r.GatewayTransactionId,
+		Amount:               r.Amount,
+		Reason:               r.Reason,
+		Status:               settlement.LatePaymentStatus(r.Status),
+		CreatedAt:            r.CreatedAt,
+	}
+
+	if r.CreditedAt.Valid {
+		creditedAt := r.CreditedAt.Time
+		latePayment.CreditedAt = &creditedAt
+	}
+
+	return &latePayment
+}
+
+func (r *latePaymentRepository) Create(ctx context.Context, latePayment *settlement.LatePayment) (*settlement.LatePayment, error) {
+	// the no-op update makes RETURNING give back the row already recorded
+	const query = `INSERT INTO late_payments
+	(id, order_id, account_id, gateway_transaction_id, amount, reason, status, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	ON CONFLICT (gateway_transaction_id) DO UPDATE SET gateway_transaction_id = EXCLUDED.gateway_transaction_id
+	RETURNING id, order_id, account_id, gateway_transaction_id, amount, reason, status, created_at, credited_at`
+
+	var row latePaymentRow
+
+	err := r.db.GetContext(ctx, &row, query,
+		latePayment.Id,
+		latePayment.OrderId,
+		latePayment.AccountId,
+		latePayment.GatewayTransactionId,
+		latePayment.Amount,
+		latePayment.Reason,
+		latePayment.Status,
+		latePayment.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("r.db.GetContext: %w", err)
+	}
+
+	return row.toLatePayment(), nil
+}
+
+func (r *latePaymentRepository) MarkCredited(ctx context.Context, id string) error {
+	const query = `UPDATE late_payments SET status = $2, credited_at = NOW() WHERE id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, id, settlement.LatePaymentCredited); err != nil {
+		return fmt.Errorf("r.db.ExecContext: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package settlement
+
+import (
+	"context"
+	"time"
+
+	protoOrders "github.com/aclgo/simple-api-gateway/proto-service/orders"
+)
+
+// Settler marks the order of a confirmed payment PAID and delivers what was
+// bought, for the payment methods confirmed asynchronously by a webhook. A
+// payment too late to settle its order is credited to the wallet instead.
+type Settler interface {
+	Settle(ctx context.Context, params *ParamsSettleInput) error
+}
+
+type ParamsSettleInput struct {
+	GatewayTransactionId string
+	Actor                string
+	Reason               string
+	// Amount is what was paid, credited back when the payment is late. Zero
+	// means the order amount.
+	Amount int64
+	// Check runs on the pending order before it is marked PAID and rejects
+	// payments that cannot settle it. A payment made after the order
+	// expired is rejected with an error wrapping orders.ErrOrderExpired and
+	// is then handled as a late payment.
+	Check func(order *protoOrders.Orders) error
+}
+
+type LatePaymentStatus string
+
+const (
+	LatePaymentPending  LatePaymentStatus = "pending"
+	LatePaymentCredited LatePaymentStatus = "credited"
+)
+
+// LatePayment is a payment confirmed after its order was cancelled or its
+// payment window closed. The money was received but the order cannot be
+// delivered, so it is credited to the wallet of the customer.
+type LatePayment struct {
+	Id                   string            `json:"late_payment_id"`
+	OrderId              string            `json:"order_id"`
+	AccountId            string            `json:"account_id"`
+	GatewayTransactionId string            `json:"gateway_transaction_id"`
+	Amount               int64             `json:"amount"`
+	Reason               string            `json:"reason"`
+	Status               LatePaymentStatus `json:"status"`
+	CreatedAt            time.Time         `json:"created_at"`
+	CreditedAt           *time.Time        `json:"credited_at,omitempty"`
+}
+
+type LatePaymentRepository interface {
+	// Create records the late payment. A notification delivered again finds
+	// the one already recorded for its gateway transaction id, which is
+	// returned in place of the new one.
+	Create(ctx context.Context, latePayment *LatePayment) (*LatePayment, error)
+	MarkCredited(ctx context.Context, id string) error
+}
@@ -0,0 +1,203 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/orders"
+	"github.com/aclgo/simple-api-gateway/internal/payment/settlement"
+	"github.com/aclgo/simple-api-gateway/pkg/logger"
+	protoBalance "github.com/aclgo/simple-api-gateway/proto-service/balance"
+	protoOrders "github.com/aclgo/simple-api-gateway/proto-service/orders"
+	protoUser "github.com/aclgo/simple-api-gateway/proto-service/user"
+	"github.com/google/uuid"
+)
+
+type settlementUC struct {
+	clientOrdersGRPC  protoOrders.ServiceOrderClient
+	clientBalanceGrpc protoBalance.WalletServiceClient
+	clientUserGrpc    protoUser.SubscriptionServiceClient
+	status            orders.StatusMachine
+	invoices          orders.InvoiceInterface
+	latePayments      settlement.LatePaymentRepository
+	logger            logger.Logger
+}
+
+func NewSettlementUC(clientOrdersGRPC protoOrders.ServiceOrderClient, clientBalanceGrpc protoBalance.WalletServiceClient,
+	clientUserGrpc protoUser.SubscriptionServiceClient, status orders.StatusMachine,
+	invoices orders.InvoiceInterface, latePayments settlement.LatePaymentRepository, logger logger.Logger) settlement.Settler {
+	return &settlementUC{
+		clientOrdersGRPC:  clientOrdersGRPC,
+		clientBalanceGrpc: clientBalanceGrpc,
+		clientUserGrpc:    clientUserGrpc,
+		status:            status,
+		invoices:          invoices,
+		latePayments:      latePayments,
+		logger:            logger,
+	}
+}
+
+func (u *settlementUC) Settle(ctx context.Context, params *settlement.ParamsSettleInput) error {
+	po := protoOrders.ParamFindOrderByGatewayTransactionIdRequest{
+		GatewayTransactionId: params.GatewayTransactionId,
+	}
+
+	resp, err := u.clientOrdersGRPC.FindOrderByGatewayTransactionId(ctx, &po)
+	if err != nil {
+		return fmt.Errorf("u.clientOrdersGRPC.FindOrderByGatewayTransactionId: %w", err)
+	}
+
+	// providers deliver the same notification more than once
+	if resp.Order.Status == protoOrders.OrderStatus_PAID {
+		return nil
+	}
+
+	transition := orders.ParamsStatusTransitionInput{
+		OrderId: resp.Order.OrderID,
+		To:      protoOrders.OrderStatus_PAID,
+		Actor:   params.Actor,
+		Reason:  params.Reason,
+	}
+
+	_, err = u.status.Transition(ctx, &transition, func(ctx context.Context, order *protoOrders.Orders) error {
+		if params.Check != nil {
+			if err := params.Check(order); err != nil {
+				return err
+			}
+		}
+
+		switch order.Type {
+		case protoOrders.OrderType_BALANCE_DEPOSIT:
+			if err := u.processDepositBalance(ctx, order); err != nil {
+				return fmt.Errorf("processing balance deposity: %w", err)
+			}
+		case protoOrders.OrderType_PREMIUM_SUBSCRIPTION:
+			if err := u.processSubscription(ctx, order); err != nil {
+				return fmt.Errorf("processing premiun subscription: %w", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, orders.ErrOrderExpired) || errors.Is(err, orders.ErrInvalidStatusTransition) {
+			return u.settleLate(ctx, params, resp.Order.OrderID, err)
+		}
+
+		return fmt.Errorf("failed to update order to status paid: %w", err)
+	}
+
+	// the payment is confirmed already, the receipt can still be issued on download
+	if err := u.invoices.Issue(ctx, resp.Order.OrderID); err != nil {
+		u.logger.Errorf("settlement.Settle: order %s: %v", resp.Order.OrderID, err)
+	}
+
+	return nil
+}
+
+// settleLate records a payment that came after its order was cancelled or
+// its payment window closed, and credits it to the wallet of the customer
+// so the money is not kept for nothing. The order is read again, as a paid
+// or refunded order means the notification is not late but repeated.
+func (u *settlementUC) settleLate(ctx context.Context, params *settlement.ParamsSettleInput, orderId string, cause error) error {
+	find, err := u.clientOrdersGRPC.Find(ctx, &protoOrders.ParamFindOrderRequest{OrderID: orderId})
+	if err != nil {
+		return fmt.Errorf("u.clientOrdersGRPC.Find: %w", err)
+	}
+
+	order := find.Order
+
+	switch {
+	case order.Status == protoOrders.OrderStatus_CANCELLED, order.Status == protoOrders.OrderStatus_FAILED:
+	case order.Status == protoOrders.OrderStatus_PENDING && errors.Is(cause, orders.ErrOrderExpired):
+	default:
+		return fmt.Errorf("failed to update order to status paid: %w", cause)
+	}
+
+	amount := params.Amount
+	if amount == 0 {
+		amount = order.Amount
+	}
+
+	latePayment, err := u.latePayments.Create(ctx, &settlement.LatePayment{
+		Id:                   uuid.NewString(),
+		OrderId:              order.OrderID,
+		AccountId:            order.AccountID,
+		GatewayTransactionId: params.GatewayTransactionId,
+		Amount:               amount,
+		Reason:               cause.Error(),
+		Status:               settlement.LatePaymentPending,
+		CreatedAt:            time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("u.latePayments.Create: %w", err)
+	}
+
+	if latePayment.Status == settlement.LatePaymentCredited {
+		return nil
+	}
+
+	if err := u.creditWallet(ctx, latePayment.AccountId, latePayment.Amount, latePayment.GatewayTransactionId); err != nil {
+		return err
+	}
+
+	// the wallet has the money already, a redelivery must not credit it again
+	if err := u.latePayments.MarkCredited(context.WithoutCancel(ctx), latePayment.Id); err != nil {
+		u.logger.Errorf("u.latePayments.MarkCredited: late payment %s: %v", latePayment.Id, err)
+	}
+
+	return nil
+}
+
+func (u *settlementUC) processSubscription(ctx context.Context, order *protoOrders.Orders) error {
+	var meta orders.ParamsSaveSubscriptionMetadata
+
+	if err := json.Unmarshal(order.Metadata, &meta); err != nil {
+		return fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	psub := protoUser.CreateOrExtendSubscriptionRequest{
+		UserId: meta.UserId,
+		Plan:   meta.Plan,
+		Days:   int64(meta.Days),
+	}
+
+	_, err := u.clientUserGrpc.CreateOrExtend(ctx, &psub)
+	if err != nil {
+		return fmt.Errorf("u.clientUserGrpc.CreateOrExtend: %w", err)
+	}
+
+	return nil
+}
+
+func (u *settlementUC) processDepositBalance(ctx context.Context, order *protoOrders.Orders) error {
+	// the wallet gets what was paid, never more
+	return u.creditWallet(ctx, order.AccountID, order.Amount, order.GatewayTransactionID)
+}
+
+func (u *settlementUC) creditWallet(ctx context.Context, accountId string, amount int64, referenceId string) error {
+	pf := protoBalance.ParamGetWalletByAccountRequest{
+		AccountID: accountId,
+	}
+
+	find, err := u.clientBalanceGrpc.GetWalletByAccount(ctx, &pf)
+	if err != nil {
+		return fmt.Errorf("u.clientBalanceGrpc.GetWalletByAccount: %w", err)
+	}
+
+	pb := protoBalance.ParamCreditWalletRequest{
+		WalletID:    find.WalletID,
+		Amount:      amount,
+		ReferenceID: referenceId,
+	}
+
+	_, err = u.clientBalanceGrpc.Credit(ctx, &pb)
+	if err != nil {
+		return fmt.Errorf("u.clientBalanceGrpc.Credit: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,234 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/domain/models"
+	"github.com/aclgo/simple-api-gateway/internal/payment"
+	"github.com/aclgo/simple-api-gateway/pkg/logger"
+)
+
+func routed(name string, route payment.Route) *routedProvider {
+	return &routedProvider{Provider: &payment.Provider{Name: name, Route: route}}
+}
+
+func names(providers []*routedProvider) []string {
+	out := make([]string, 0, len(providers))
+	for _, provider := range providers {
+		out = append(out, provider.Name)
+	}
+
+	return out
+}
+
+func TestRoute(t *testing.T) {
+	tests := []struct {
+		name      string
+		providers []*routedProvider
+		amount    int64
+		want      []string
+	}{
+		{
+			name:      "by priority",
+			providers: []*routedProvider{routed("main", payment.Route{}), routed("fallback", payment.Route{Priority: 1}), routed("last", payment.Route{Priority: 2})},
+			amount:    1000,
+			want:      []string{"main", "fallback", "last"},
+		},
+		{
+			name: "rules leave providers out",
+			providers: []*routedProvider{
+				routed("small", payment.Route{MaxAmount: 500}),
+				routed("large", payment.Route{MinAmount: 501}),
+				routed("fallback", payment.Route{Priority: 1}),
+			},
+			amount: 1000,
+			want:   []string{"large", "fallback"},
+		},
+		{
+			name:      "none matches",
+			providers: []*routedProvider{routed("small", payment.Route{MaxAmount: 500})},
+			amount:    1000,
+			want:      []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := names(route(tt.providers, &models.ParamPaymentProcessInput{Amount: tt.amount}))
+
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("route = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouteWeights(t *testing.T) {
+	tests := []struct {
+		name      string
+		providers []*routedProvider
+		// wantFirst is the share of payments each provider should get first
+		wantFirst map[string]float64
+	}{
+		{
+			name:      "by weight",
+			providers: []*routedProvider{routed("a", payment.Route{Weight: 3}), routed("b", payment.Route{Weight: 1})},
+			wantFirst: map[string]float64{"a": 0.75, "b": 0.25},
+		},
+		{
+			name:      "zero weight counts as one",
+			providers: []*routedProvider{routed("a", payment.Route{}), routed("b", payment.Route{Weight: 1})},
+			wantFirst: map[string]float64{"a": 0.5, "b": 0.5},
+		},
+		{
+			name: "weights only shuffle within a priority",
+			providers: []*routedProvider{
+				routed("a", payment.Route{Weight: 1}),
+				routed("b", payment.Route{Weight: 1}),
+				routed("fallback", payment.Route{Priority: 1, Weight: 100}),
+			},
+			wantFirst: map[string]float64{"a": 0.5, "b": 0.5},
+		},
+	}
+
+	const runs = 10000
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := make(map[string]int)
+
+			for range runs {
+				providers := append([]*routedProvider(nil), tt.providers...)
+
+				routes := route(providers, &models.ParamPaymentProcessInput{Amount: 1000})
+				if len(routes) != len(tt.providers) {
+					t.Fatalf("route = %v, want every provider", names(routes))
+				}
+
+				first[routes[0].Name]++
+			}
+
+			for name, share := range tt.wantFirst {
+				if got := float64(first[name]) / runs; got < share-0.03 || got > share+0.03 {
+					t.Errorf("%s first in %.3f of the payments, want %.2f", name, got, share)
+				}
+			}
+
+			for name := range first {
+				if _, ok := tt.wantFirst[name]; !ok {
+					t.Errorf("%s came first %d times", name, first[name])
+				}
+			}
+		})
+	}
+}
+
+// blockingProcessor waits for the context of the payment to end and returns
+// its error, like a provider that never answers.
+type blockingProcessor struct{}
+
+func (blockingProcessor) Proccess(ctx context.Context, in *models.ParamPaymentProcessInput) (*models.ParamPaymentProcessOutput, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestProcessTimeout(t *testing.T) {
+	tests := []struct {
+		name            string
+		routeTimeout    time.Duration
+		callerTimeout   time.Duration
+		wantUnavailable bool
+	}{
+		{name: "route timeout", routeTimeout: 10 * time.Millisecond, callerTimeout: time.Second, wantUnavailable: true},
+		{name: "caller went away", routeTimeout: time.Second, callerTimeout: 10 * time.Millisecond},
+		{name: "caller went away without a route timeout", callerTimeout: 10 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), tt.callerTimeout)
+			defer cancel()
+
+			provider := &routedProvider{Provider: &payment.Provider{
+				Name:      "slow",
+				Processor: blockingProcessor{},
+				Route:     payment.Route{Timeout: tt.routeTimeout},
+			}}
+
+			_, err := (&paymentUC{}).process(ctx, provider, &models.ParamPaymentProcessInput{Amount: 1000})
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("err = %v, want the deadline", err)
+			}
+
+			if got := errors.Is(err, payment.ErrProviderUnavailable); got != tt.wantUnavailable {
+				t.Errorf("unavailable = %v, want %v: %v", got, tt.wantUnavailable, err)
+			}
+		})
+	}
+}
+
+type fakeRefunder struct {
+	blockingProcessor
+	refunded *[]string
+	name     string
+}
+
+func (f fakeRefunder) Refund(ctx context.Context, in *models.ParamPaymentRefundInput) error {
+	*f.refunded = append(*f.refunded, f.name)
+	return nil
+}
+
+type fakeAttempts struct {
+	payment.AttemptRepository
+}
+
+func (fakeAttempts) Create(ctx context.Context, attempt *payment.Attempt) error {
+	return nil
+}
+
+type nopLogger struct {
+	logger.Logger
+}
+
+func (nopLogger) Errorf(template string, args ...any) {}
+
+func TestRefundPaymentProvider(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		want     []string
+		wantErr  error
+	}{
+		{name: "recorded provider", provider: "pix-fallback", want: []string{"pix-fallback"}},
+		{name: "paid before providers were recorded", want: []string{"pix-main"}},
+		{name: "recorded provider no longer registered", provider: "pix-old", want: []string{}, wantErr: payment.ErrProviderNotRegistered},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refunded := make([]string, 0)
+
+			uc := NewPaymentUC(nil, fakeAttempts{}, payment.Config{}, nopLogger{})
+			for _, name := range []string{"pix-main", "pix-fallback"} {
+				uc.RegisterProvider("pix", &payment.Provider{Name: name, Processor: fakeRefunder{refunded: &refunded, name: name}})
+			}
+
+			err := uc.RefundPayment(context.Background(), &models.ParamPaymentRefundInput{
+				Method:   "pix",
+				Provider: tt.provider,
+				Amount:   1000,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+
+			if fmt.Sprint(refunded) != fmt.Sprint(tt.want) {
+				t.Errorf("refunded through %v, want %v", refunded, tt.want)
+			}
+		})
+	}
+}
@@ -2,38 +2,81 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/aclgo/simple-api-gateway/internal/domain/models"
 	"github.com/aclgo/simple-api-gateway/internal/payment"
+	"github.com/aclgo/simple-api-gateway/pkg/breaker"
 	"github.com/aclgo/simple-api-gateway/pkg/logger"
 	proto "github.com/aclgo/simple-api-gateway/proto-service/balance"
+	"github.com/google/uuid"
 )
 
 type paymentUC struct {
 	clientBalanceGPRC proto.WalletServiceClient
-	providers         map[string]models.PaymentProcessor
+	providers         map[string][]*routedProvider
+	attempts          payment.AttemptRepository
+	cfg               payment.Config
 	mu                sync.RWMutex
 	logger            logger.Logger
 }
 
-func NewPaymentUC(clientBalanceGRPC proto.WalletServiceClient,logger logger.Logger) payment.PaymentInterface {
+// routedProvider keeps the circuit breaker of a provider next to it.
+type routedProvider struct {
+	*payment.Provider
+	breaker *breaker.Breaker
+}
+
+func NewPaymentUC(clientBalanceGRPC proto.WalletServiceClient, attempts payment.AttemptRepository, cfg payment.Config,
+	logger logger.Logger) payment.PaymentInterface {
 	return &paymentUC{
 		clientBalanceGPRC: clientBalanceGRPC,
-		providers:         make(map[string]models.PaymentProcessor),
+		providers:         make(map[string][]*routedProvider),
+		attempts:          attempts,
+		cfg:               cfg,
 		logger:            logger,
 	}
 }
 
-func (w *paymentUC) RegisterProvider(method string, proccessor models.PaymentProcessor) {
+// RegisterProvider adds a provider to the method. A provider registered
+// again under the same name replaces the previous one.
+func (w *paymentUC) RegisterProvider(method string, provider *payment.Provider) {
+	if provider.Name == "" {
+		provider.Name = method
+	}
+
+	routed := &routedProvider{
+		Provider: provider,
+		breaker:  breaker.New(w.cfg.BreakerFailures, w.cfg.BreakerOpenTimeout),
+	}
+
 	w.mu.Lock()
-	w.providers[method] = proccessor
-	w.mu.Unlock()
+	defer w.mu.Unlock()
+
+	providers := make([]*routedProvider, 0, len(w.providers[method])+1)
+	for _, registered := range w.providers[method] {
+		if registered.Name != provider.Name {
+			providers = append(providers, registered)
+		}
+	}
+
+	providers = append(providers, routed)
+
+	sort.SliceStable(providers, func(i, j int) bool {
+		return providers[i].Route.Priority < providers[j].Route.Priority
+	})
+
+	w.providers[method] = providers
 }
 
 // func (u *paymentUC) Credit(ctx context.Context, in *payment.ParamCreditInput) (*payment.ParamCreditOutput, error) {
 
-
 // 	ig := proto.ParamGetWalletByAccountRequest{
 // 		AccountID: in.AccountId,
 // 	}
@@ -65,16 +108,270 @@ func (w *paymentUC) RegisterProvider(method string, proccessor models.PaymentPro
 // 	return &out, nil
 // }
 
+// GeneratePayment charges through the providers of the method in route
+// order, failing over to the next one when a provider surely did not take
+// the payment. Providers whose circuit is open are skipped. A payment whose
+// outcome is unknown is returned as is, along with the pending output of the
+// processor if any, so it is reconciled instead of charged again elsewhere.
+// Other errors, like a rate limit or an invalid card, are the customer's and
+// returned as is. Every provider tried is recorded as an attempt under the
+// reference of the payment.
 func (u *paymentUC) GeneratePayment(ctx context.Context, in *models.ParamPaymentProcessInput) (*models.ParamPaymentProcessOutput, error) {
 	u.mu.RLock()
-	provider, ok := u.providers[in.Method]
+	providers, ok := u.providers[in.Method]
 	u.mu.RUnlock()
 
 	if !ok {
 		return nil, payment.ErrPaymentMethodNotSupported
 	}
 
-	return provider.Proccess(ctx,in)
+	candidates := route(providers, in)
+	if len(candidates) == 0 {
+		return nil, payment.ErrNoProviderForRoute
+	}
+
+	charge := *in
+	if charge.ReferenceId == "" {
+		charge.ReferenceId = uuid.NewString()
+	}
+
+	var failures []error
+
+	for _, provider := range candidates {
+		if !provider.breaker.Allow() {
+			u.recordCharge(ctx, provider.Name, &charge, nil, payment.ErrCircuitOpen, 0)
+			failures = append(failures, fmt.Errorf("%s: %w", provider.Name, payment.ErrCircuitOpen))
+			continue
+		}
+
+		started := time.Now()
+		out, err := u.process(ctx, provider, &charge)
+		u.recordCharge(ctx, provider.Name, &charge, out, err, time.Since(started))
+
+		if err == nil || !failover(err) {
+			if errors.Is(err, payment.ErrPaymentOutcomeUnknown) {
+				provider.breaker.Failure()
+			} else {
+				provider.breaker.Success()
+			}
+
+			if out != nil {
+				out.Provider = provider.Name
+				out.ReferenceId = charge.ReferenceId
+			}
+
+			return out, err
+		}
+
+		provider.breaker.Failure()
+		failures = append(failures, fmt.Errorf("%s: %w", provider.Name, err))
+
+		// the caller gave up, the next provider would not be waited for
+		if ctx.Err() != nil {
+			break
+		}
+
+		u.logger.Errorf("payment.GeneratePayment: %s failed, trying next provider: %v", provider.Name, err)
+	}
+
+	return nil, fmt.Errorf("%w: %w", payment.ErrProviderUnavailable, errors.Join(failures...))
+}
+
+func (u *paymentUC) recordCharge(ctx context.Context, provider string, in *models.ParamPaymentProcessInput,
+	out *models.ParamPaymentProcessOutput, err error, latency time.Duration) {
+	attempt := payment.Attempt{
+		ReferenceId: in.ReferenceId,
+		AccountId:   in.AccountId,
+		Operation:   payment.OperationCharge,
+		Method:      in.Method,
+		Provider:    provider,
+		Amount:      in.Amount,
+		Request:     payment.RedactedRequest(in),
+		Response:    payment.RedactedResponse(out),
+	}
+
+	if out != nil {
+		attempt.PaymentStatus = string(out.Status)
+		attempt.FailureReason = out.FailureReason
+	}
+
+	u.record(ctx, &attempt, err, latency)
+}
+
+// record stores the attempt. The payment went through or failed already,
+// so losing the record is only logged.
+func (u *paymentUC) record(ctx context.Context, attempt *payment.Attempt, err error, latency time.Duration) {
+	attempt.Id = uuid.NewString()
+	attempt.LatencyMs = latency.Milliseconds()
+	attempt.CreatedAt = time.Now()
+	attempt.Status = payment.AttemptSucceeded
+
+	switch {
+	case errors.Is(err, payment.ErrCircuitOpen):
+		attempt.Status = payment.AttemptSkipped
+		attempt.Error = err.Error()
+	case errors.Is(err, payment.ErrPaymentOutcomeUnknown):
+		attempt.Status = payment.AttemptUnknown
+		attempt.Error = err.Error()
+	case err != nil:
+		attempt.Status = payment.AttemptFailed
+		attempt.Error = err.Error()
+	}
+
+	if err := u.attempts.Create(context.WithoutCancel(ctx), attempt); err != nil {
+		u.logger.Errorf("u.attempts.Create: reference %s: %v", attempt.ReferenceId, err)
+	}
+}
+
+func (u *paymentUC) LinkAttempts(ctx context.Context, referenceId string, orderId string) error {
+	if referenceId == "" {
+		return nil
+	}
+
+	return u.attempts.LinkOrder(ctx, referenceId, orderId)
+}
+
+func (u *paymentUC) SearchAttempts(ctx context.Context, params *payment.ParamsSearchAttemptsInput) (*payment.ParamsSearchAttemptsOutput, error) {
+	attempts, total, err := u.attempts.Search(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("u.attempts.Search: %w", err)
+	}
+
+	out := payment.ParamsSearchAttemptsOutput{
+		Attempts:   attempts,
+		Page:       params.PageInt,
+		Limit:      params.LimitInt,
+		TotalItens: total,
+		TotalPages: (total + params.LimitInt - 1) / params.LimitInt,
+	}
+
+	return &out, nil
+}
+
+func (u *paymentUC) process(ctx context.Context, provider *routedProvider, in *models.ParamPaymentProcessInput) (*models.ParamPaymentProcessOutput, error) {
+	routeCtx := ctx
+	if provider.Route.Timeout > 0 {
+		var cancel context.CancelFunc
+		routeCtx, cancel = context.WithTimeout(ctx, provider.Route.Timeout)
+		defer cancel()
+	}
+
+	out, err := provider.Processor.Proccess(routeCtx, in)
+
+	// only the timeout of the route makes the provider unavailable; a caller
+	// that went away says nothing about the provider
+	if err != nil && routeCtx.Err() != nil && ctx.Err() == nil && !errors.Is(err, payment.ErrPaymentOutcomeUnknown) {
+		return out, fmt.Errorf("%w: %w", payment.ErrProviderUnavailable, err)
+	}
+
+	return out, err
+}
+
+// failover tells the errors of a provider that surely did not take the
+// payment apart from the ones the next provider would return as well and
+// the ones where the customer may have been charged already.
+func failover(err error) bool {
+	return errors.Is(err, payment.ErrProviderUnavailable) && !errors.Is(err, payment.ErrPaymentOutcomeUnknown)
 }
 
+// route returns the providers matching the payment, by priority and, within
+// a priority, in a weighted random order.
+func route(providers []*routedProvider, in *models.ParamPaymentProcessInput) []*routedProvider {
+	matched := make([]*routedProvider, 0, len(providers))
+	for _, provider := range providers {
+		if provider.Route.Matches(in) {
+			matched = append(matched, provider)
+		}
+	}
+
+	for start := 0; start < len(matched); {
+		end := start + 1
+		for end < len(matched) && matched[end].Route.Priority == matched[start].Route.Priority {
+			end++
+		}
 
+		weightedShuffle(matched[start:end])
+		start = end
+	}
+
+	return matched
+}
+
+// weightedShuffle orders providers so each one comes first with a chance
+// proportional to its weight. A weight of zero counts as one.
+func weightedShuffle(providers []*routedProvider) {
+	for i := 0; i < len(providers)-1; i++ {
+		total := 0
+		for _, provider := range providers[i:] {
+			total += weight(provider)
+		}
+
+		pick := rand.IntN(total)
+		for j := i; j < len(providers); j++ {
+			pick -= weight(providers[j])
+			if pick < 0 {
+				providers[i], providers[j] = providers[j], providers[i]
+				break
+			}
+		}
+	}
+}
+
+func weight(provider *routedProvider) int {
+	return max(provider.Route.Weight, 1)
+}
+
+// RefundPayment refunds through the provider that took the payment. Orders
+// paid before providers were recorded go to the first provider of the
+// method. A recorded provider that is no longer registered is an error, as
+// another provider has no payment to give back.
+func (u *paymentUC) RefundPayment(ctx context.Context, in *models.ParamPaymentRefundInput) error {
+	u.mu.RLock()
+	providers, ok := u.providers[in.Method]
+	u.mu.RUnlock()
+
+	if !ok || len(providers) == 0 {
+		return payment.ErrPaymentMethodNotSupported
+	}
+
+	provider := providers[0]
+
+	if in.Provider != "" {
+		provider = nil
+
+		for _, registered := range providers {
+			if registered.Name == in.Provider {
+				provider = registered
+				break
+			}
+		}
+
+		if provider == nil {
+			return fmt.Errorf("%w: %s", payment.ErrProviderNotRegistered, in.Provider)
+		}
+	}
+
+	refunder, ok := provider.Processor.(models.PaymentRefunder)
+	if !ok {
+		return payment.ErrRefundNotSupported
+	}
+
+	started := time.Now()
+	err := refunder.Refund(ctx, in)
+
+	request, _ := json.Marshal(in)
+
+	// refunds are filed under the transaction they give back
+	u.record(ctx, &payment.Attempt{
+		ReferenceId: in.GatewayTransactionID,
+		OrderId:     in.OrderId,
+		AccountId:   in.AccountId,
+		Operation:   payment.OperationRefund,
+		Method:      in.Method,
+		Provider:    provider.Name,
+		Amount:      in.Amount,
+		Request:     request,
+	}, err, time.Since(started))
+
+	return err
+}
@@ -0,0 +1,183 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aclgo/simple-api-gateway/internal/orders"
+	"github.com/aclgo/simple-api-gateway/internal/payment"
+	"github.com/aclgo/simple-api-gateway/internal/payment/wallet"
+	"github.com/aclgo/simple-api-gateway/pkg/logger"
+	protoBalance "github.com/aclgo/simple-api-gateway/proto-service/balance"
+	protoOrders "github.com/aclgo/simple-api-gateway/proto-service/orders"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type statementUC struct {
+	walletGRPC protoBalance.WalletServiceClient
+	ordersGRPC protoOrders.ServiceOrderClient
+	attempts   payment.AttemptRepository
+	refunds    orders.RefundRepository
+	logger     logger.Logger
+}
+
+func NewStatementUC(walletClient protoBalance.WalletServiceClient, ordersClient protoOrders.ServiceOrderClient,
+	attempts payment.AttemptRepository, refunds orders.RefundRepository, logger logger.Logger) wallet.UseCase {
+	return &statementUC{
+		walletGRPC: walletClient,
+		ordersGRPC: ordersClient,
+		attempts:   attempts,
+		refunds:    refunds,
+		logger:     logger,
+	}
+}
+
+func (u *statementUC) Statement(ctx context.Context, params *wallet.ParamsStatementInput) (*wallet.ParamsStatementOutput, error) {
+	wlt, err := u.walletGRPC.GetWalletByAccount(ctx, &protoBalance.ParamGetWalletByAccountRequest{AccountID: params.UserId})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, wallet.ErrWalletNotFound
+		}
+
+		return nil, fmt.Errorf("u.walletGRPC.GetWalletByAccount: %w", err)
+	}
+
+	request := protoBalance.ParamListTransactionsRequest{
+		WalletID: wlt.WalletID,
+		Type:     params.Type,
+		Page:     int32(params.PageInt),
+		Limit:    int32(params.LimitInt),
+	}
+
+	if !params.FromTime.IsZero() {
+		request.From = timestamppb.New(params.FromTime)
+	}
+
+	if !params.ToTime.IsZero() {
+		request.To = timestamppb.New(params.ToTime)
+	}
+
+	list, err := u.walletGRPC.ListTransactions(ctx, &request)
+	if err != nil {
+		return nil, fmt.Errorf("u.walletGRPC.ListTransactions: %w", err)
+	}
+
+	transactions := make([]*wallet.Transaction, 0, len(list.Transactions))
+	for _, t := range list.Transactions {
+		transactions = append(transactions, &wallet.Transaction{
+			TransactionId: t.TransactionID,
+			Type:          t.Type,
+			Amount:        t.Amount,
+			BalanceAfter:  t.BalanceAfter,
+			ReferenceId:   t.ReferenceID,
+			CreatedAt:     t.CreatedAT.AsTime(),
+		})
+	}
+
+	u.linkOrders(ctx, transactions)
+
+	out := wallet.ParamsStatementOutput{
+		WalletId:     wlt.WalletID,
+		Balance:      wlt.Balance,
+		Transactions: transactions,
+		Page:         params.PageInt,
+		Limit:        params.LimitInt,
+		TotalItens:   int(list.Total),
+		TotalPages:   (int(list.Total) + params.LimitInt - 1) / params.LimitInt,
+	}
+
+	return &out, nil
+}
+
+// linkOrders fills the order of each transaction from its reference, which is
+// one of: the reference of a payment made through the gateway, the id of a
+// refund, or the gateway transaction id of the order, optionally prefixed
+// with "refund-" when the wallet was credited back by the processor. Lookups
+// that fail only leave the order out, the statement is still useful.
+func (u *statementUC) linkOrders(ctx context.Context, transactions []*wallet.Transaction) {
+	references := make([]string, 0, len(transactions))
+	for _, t := range transactions {
+		if t.ReferenceId != "" {
+			references = append(references, t.ReferenceId)
+		}
+	}
+
+	if len(references) == 0 {
+		return
+	}
+
+	linked, err := u.attempts.OrdersByReference(ctx, references)
+	if err != nil {
+		u.logger.Errorf("u.attempts.OrdersByReference: %v", err)
+		linked = make(map[string]string)
+	}
+
+	refundIds := make([]string, 0, len(references))
+	for _, ref := range references {
+		if _, ok := linked[ref]; ok {
+			continue
+		}
+
+		if _, err := uuid.Parse(ref); err == nil {
+			refundIds = append(refundIds, ref)
+		}
+	}
+
+	if len(refundIds) > 0 {
+		refunded, err := u.refunds.OrdersByRefund(ctx, refundIds)
+		if err != nil {
+			u.logger.Errorf("u.refunds.OrdersByRefund: %v", err)
+		}
+
+		for ref, orderId := range refunded {
+			linked[ref] = orderId
+		}
+	}
+
+	// the same order shows up twice when it was paid and refunded
+	byGateway := make(map[string]string)
+
+	for _, t := range transactions {
+		if t.ReferenceId == "" {
+			continue
+		}
+
+		if orderId, ok := linked[t.ReferenceId]; ok {
+			t.OrderId = orderId
+			continue
+		}
+
+		gatewayTransactionId := strings.TrimPrefix(t.ReferenceId, "refund-")
+
+		orderId, ok := byGateway[gatewayTransactionId]
+		if !ok {
+			orderId = u.findOrderByGatewayTransaction(ctx, gatewayTransactionId)
+			byGateway[gatewayTransactionId] = orderId
+		}
+
+		t.OrderId = orderId
+	}
+}
+
+func (u *statementUC) findOrderByGatewayTransaction(ctx context.Context, gatewayTransactionId string) string {
+	resp, err := u.ordersGRPC.FindOrderByGatewayTransactionId(ctx, &protoOrders.ParamFindOrderByGatewayTransactionIdRequest{
+		GatewayTransactionId: gatewayTransactionId,
+	})
+	if err != nil {
+		if status.Code(err) != codes.NotFound {
+			u.logger.Errorf("u.ordersGRPC.FindOrderByGatewayTransactionId: %s: %v", gatewayTransactionId, err)
+		}
+
+		return ""
+	}
+
+	if resp.Order == nil {
+		return ""
+	}
+
+	return resp.Order.OrderID
+}
@@ -0,0 +1,329 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/payment/wallet"
+	"github.com/aclgo/simple-api-gateway/pkg/logger"
+	protoBalance "github.com/aclgo/simple-api-gateway/proto-service/balance"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	testUserId   = "user-1"
+	testWalletId = "wallet-1"
+)
+
+// fakeWalletClient serves ListTransactions from memory the way the wallet
+// service does: filtered by type and period, newest first, one page at a time.
+type fakeWalletClient struct {
+	protoBalance.WalletServiceClient
+	transactions []*protoBalance.WalletTransaction
+	requests     []*protoBalance.ParamListTransactionsRequest
+}
+
+func (f *fakeWalletClient) GetWalletByAccount(ctx context.Context, in *protoBalance.ParamGetWalletByAccountRequest,
+	opts ...grpc.CallOption) (*protoBalance.ParamgGetWalletByAccountResponse, error) {
+	return &protoBalance.ParamgGetWalletByAccountResponse{WalletID: testWalletId, AccountID: in.AccountID, Balance: 5000}, nil
+}
+
+func (f *fakeWalletClient) ListTransactions(ctx context.Context, in *protoBalance.ParamListTransactionsRequest,
+	opts ...grpc.CallOption) (*protoBalance.ParamListTransactionsResponse, error) {
+	f.requests = append(f.requests, in)
+
+	matched := make([]*protoBalance.WalletTransaction, 0, len(f.transactions))
+
+	for _, t := range f.transactions {
+		switch {
+		case t.WalletID != in.WalletID,
+			in.Type != "" && t.Type != in.Type,
+			in.From != nil && t.CreatedAT.AsTime().Before(in.From.AsTime()),
+			in.To != nil && t.CreatedAT.AsTime().After(in.To.AsTime()):
+			continue
+		}
+
+		matched = append(matched, t)
+	}
+
+	start := min(int((in.Page-1)*in.Limit), len(matched))
+	end := min(start+int(in.Limit), len(matched))
+
+	return &protoBalance.ParamListTransactionsResponse{Transactions: matched[start:end], Total: int64(len(matched))}, nil
+}
+
+type nopLogger struct {
+	logger.Logger
+}
+
+func (nopLogger) Errorf(template string, args ...any) {}
+
+// newWalletClient has one transaction a day from January 1st 2026, credits
+// on odd days and debits on even ones, the newest first.
+func newWalletClient(days int) *fakeWalletClient {
+	client := &fakeWalletClient{}
+
+	for day := days; day >= 1; day-- {
+		kind := wallet.TransactionCredit
+		if day%2 == 0 {
+			kind = wallet.TransactionDebit
+		}
+
+		client.transactions = append(client.transactions, &protoBalance.WalletTransaction{
+			TransactionID: fmt.Sprintf("tx-%02d", day),
+			WalletID:      testWalletId,
+			Type:          kind,
+			Amount:        int64(day * 100),
+			CreatedAT:     timestamppb.New(time.Date(2026, time.January, day, 12, 0, 0, 0, time.UTC)),
+		})
+	}
+
+	return client
+}
+
+func statement(t *testing.T, client *fakeWalletClient, params *wallet.ParamsStatementInput) *wallet.ParamsStatementOutput {
+	t.Helper()
+
+	params.UserId = testUserId
+
+	if err := params.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	uc := NewStatementUC(client, nil, nil, nil, nopLogger{})
+
+	out, err := uc.Statement(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Statement: %v", err)
+	}
+
+	return out
+}
+
+func transactionIds(transactions []*wallet.Transaction) []string {
+	ids := make([]string, 0, len(transactions))
+	for _, t := range transactions {
+		ids = append(ids, t.TransactionId)
+	}
+
+	return ids
+}
+
+func TestStatementPagination(t *testing.T) {
+	tests := []struct {
+		name      string
+		days      int
+		page      string
+		limit     string
+		wantIds   []string
+		wantPage  int
+		wantLimit int
+		wantTotal int
+		wantPages int
+	}{
+		{
+			name:      "defaults",
+			days:      25,
+			wantIds:   []string{"tx-25", "tx-24", "tx-23", "tx-22", "tx-21", "tx-20", "tx-19", "tx-18", "tx-17", "tx-16", "tx-15", "tx-14", "tx-13", "tx-12", "tx-11", "tx-10", "tx-09", "tx-08", "tx-07", "tx-06"},
+			wantPage:  1,
+			wantLimit: 20,
+			wantTotal: 25,
+			wantPages: 2,
+		},
+		{
+			name:      "middle page",
+			days:      25,
+			page:      "2",
+			limit:     "10",
+			wantIds:   []string{"tx-15", "tx-14", "tx-13", "tx-12", "tx-11", "tx-10", "tx-09", "tx-08", "tx-07", "tx-06"},
+			wantPage:  2,
+			wantLimit: 10,
+			wantTotal: 25,
+			wantPages: 3,
+		},
+		{
+			name:      "last partial page",
+			days:      25,
+			page:      "3",
+			limit:     "10",
+			wantIds:   []string{"tx-05", "tx-04", "tx-03", "tx-02", "tx-01"},
+			wantPage:  3,
+			wantLimit: 10,
+			wantTotal: 25,
+			wantPages: 3,
+		},
+		{
+			name:      "exact pages",
+			days:      20,
+			page:      "2",
+			limit:     "10",
+			wantIds:   []string{"tx-10", "tx-09", "tx-08", "tx-07", "tx-06", "tx-05", "tx-04", "tx-03", "tx-02", "tx-01"},
+			wantPage:  2,
+			wantLimit: 10,
+			wantTotal: 20,
+			wantPages: 2,
+		},
+		{
+			name:      "past the end",
+			days:      5,
+			page:      "4",
+			limit:     "10",
+			wantIds:   []string{},
+			wantPage:  4,
+			wantLimit: 10,
+			wantTotal: 5,
+			wantPages: 1,
+		},
+		{
+			name:      "empty wallet",
+			days:      0,
+			wantIds:   []string{},
+			wantPage:  1,
+			wantLimit: 20,
+			wantTotal: 0,
+			wantPages: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newWalletClient(tt.days)
+
+			out := statement(t, client, &wallet.ParamsStatementInput{Page: tt.page, Limit: tt.limit})
+
+			if got := transactionIds(out.Transactions); fmt.Sprint(got) != fmt.Sprint(tt.wantIds) {
+				t.Errorf("transactions = %v, want %v", got, tt.wantIds)
+			}
+
+			if out.Page != tt.wantPage || out.Limit != tt.wantLimit {
+				t.Errorf("page %d limit %d, want page %d limit %d", out.Page, out.Limit, tt.wantPage, tt.wantLimit)
+			}
+
+			if out.TotalItens != tt.wantTotal || out.TotalPages != tt.wantPages {
+				t.Errorf("total %d pages %d, want total %d pages %d", out.TotalItens, out.TotalPages, tt.wantTotal, tt.wantPages)
+			}
+
+			if out.WalletId != testWalletId || out.Balance != 5000 {
+				t.Errorf("wallet %s balance %d", out.WalletId, out.Balance)
+			}
+
+			request := client.requests[0]
+			if request.WalletID != testWalletId || int(request.Page) != tt.wantPage || int(request.Limit) != tt.wantLimit {
+				t.Errorf("request = %v", request)
+			}
+		})
+	}
+}
+
+func TestStatementFilters(t *testing.T) {
+	tests := []struct {
+		name      string
+		kind      string
+		from      string
+		to        string
+		wantIds   []string
+		wantTotal int
+	}{
+		{
+			name:      "credits",
+			kind:      "credit",
+			wantIds:   []string{"tx-09", "tx-07", "tx-05", "tx-03", "tx-01"},
+			wantTotal: 5,
+		},
+		{
+			name:      "debits in upper case",
+			kind:      "DEBIT",
+			wantIds:   []string{"tx-10", "tx-08", "tx-06", "tx-04", "tx-02"},
+			wantTotal: 5,
+		},
+		{
+			name:      "plain dates cover the whole last day",
+			from:      "2026-01-03",
+			to:        "2026-01-05",
+			wantIds:   []string{"tx-05", "tx-04", "tx-03"},
+			wantTotal: 3,
+		},
+		{
+			name:      "timestamps are exact",
+			from:      "2026-01-03T12:00:00Z",
+			to:        "2026-01-05T11:59:59Z",
+			wantIds:   []string{"tx-04", "tx-03"},
+			wantTotal: 2,
+		},
+		{
+			name:      "only from",
+			from:      "2026-01-08",
+			wantIds:   []string{"tx-10", "tx-09", "tx-08"},
+			wantTotal: 3,
+		},
+		{
+			name:      "only to",
+			to:        "2026-01-02",
+			wantIds:   []string{"tx-02", "tx-01"},
+			wantTotal: 2,
+		},
+		{
+			name:      "type and period",
+			kind:      "debit",
+			from:      "2026-01-03",
+			to:        "2026-01-08",
+			wantIds:   []string{"tx-08", "tx-06", "tx-04"},
+			wantTotal: 3,
+		},
+		{
+			name:      "nothing in the period",
+			from:      "2026-02-01",
+			wantIds:   []string{},
+			wantTotal: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newWalletClient(10)
+
+			out := statement(t, client, &wallet.ParamsStatementInput{Type: tt.kind, From: tt.from, To: tt.to})
+
+			if got := transactionIds(out.Transactions); fmt.Sprint(got) != fmt.Sprint(tt.wantIds) {
+				t.Errorf("transactions = %v, want %v", got, tt.wantIds)
+			}
+
+			if out.TotalItens != tt.wantTotal {
+				t.Errorf("total = %d, want %d", out.TotalItens, tt.wantTotal)
+			}
+
+			request := client.requests[0]
+
+			if (tt.from == "") != (request.From == nil) || (tt.to == "") != (request.To == nil) {
+				t.Errorf("request period = %v to %v, want from %q to %q", request.From, request.To, tt.from, tt.to)
+			}
+		})
+	}
+}
+
+func TestStatementRejectsInvalidFilters(t *testing.T) {
+	tests := []struct {
+		name   string
+		params wallet.ParamsStatementInput
+	}{
+		{name: "unknown type", params: wallet.ParamsStatementInput{Type: "refund"}},
+		{name: "bad from", params: wallet.ParamsStatementInput{From: "01/02/2026"}},
+		{name: "bad to", params: wallet.ParamsStatementInput{To: "yesterday"}},
+		{name: "to before from", params: wallet.ParamsStatementInput{From: "2026-01-05", To: "2026-01-04"}},
+		{name: "zero page", params: wallet.ParamsStatementInput{Page: "0"}},
+		{name: "page not a number", params: wallet.ParamsStatementInput{Page: "two"}},
+		{name: "zero limit", params: wallet.ParamsStatementInput{Limit: "0"}},
+		{name: "limit too big", params: wallet.ParamsStatementInput{Limit: "101"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.params.Validate(); err == nil {
+				t.Errorf("Validate accepted %+v", tt.params)
+			}
+		})
+	}
+}
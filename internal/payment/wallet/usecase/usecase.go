@@ -56,3 +56,26 @@ func (p *paymentProcessorWallet) Proccess(ctx context.Context, params *models.Pa
 
 	return &out, nil
 }
+
+func (p *paymentProcessorWallet) Refund(ctx context.Context, params *models.ParamPaymentRefundInput) error {
+	paramGet := proto.ParamGetWalletByAccountRequest{
+		AccountID: params.AccountId,
+	}
+
+	wlt, err := p.walletGRPC.GetWalletByAccount(ctx, &paramGet)
+	if err != nil {
+		return fmt.Errorf("p.walletGRPC.GetWalletByAccount: %w", err)
+	}
+
+	paramCredit := proto.ParamCreditWalletRequest{
+		WalletID:    wlt.WalletID,
+		Amount:      params.Amount,
+		ReferenceID: "refund-" + params.GatewayTransactionID,
+	}
+
+	if _, err := p.walletGRPC.Credit(ctx, &paramCredit); err != nil {
+		return fmt.Errorf("p.walletGRPC.Credit: %w", err)
+	}
+
+	return nil
+}
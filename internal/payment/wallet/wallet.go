@@ -1,3 +1,159 @@
 package wallet
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
+	protoBalance "github.com/aclgo/simple-api-gateway/proto-service/balance"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	TransactionCredit = "credit"
+	TransactionDebit  = "debit"
+)
+
+var ErrWalletNotFound = errors.New("wallet not found")
+
+// FindOrCreate returns the wallet of the account, creating it for users that
+// never had one, the same way the user service does when they log in.
+func FindOrCreate(ctx context.Context, client protoBalance.WalletServiceClient, accountId string) (*protoBalance.ParamgGetWalletByAccountResponse, error) {
+	wlt, err := client.GetWalletByAccount(ctx, &protoBalance.ParamGetWalletByAccountRequest{AccountID: accountId})
+	if err == nil {
+		return wlt, nil
+	}
+
+	if status.Code(err) != codes.NotFound {
+		return nil, fmt.Errorf("client.GetWalletByAccount: %w", err)
+	}
+
+	created, err := client.Create(ctx, &protoBalance.ParamCreateWalletRequest{AccountID: accountId})
+	if err != nil {
+		return nil, fmt.Errorf("client.Create: %w", err)
+	}
+
+	return &protoBalance.ParamgGetWalletByAccountResponse{
+		WalletID:  created.WalletID,
+		AccountID: created.AccountID,
+		Balance:   created.Balance,
+		CreatedAT: created.CreatedAT,
+		UpdatedAT: created.UpdatedAT,
+	}, nil
+}
+
+type UseCase interface {
+	Statement(ctx context.Context, params *ParamsStatementInput) (*ParamsStatementOutput, error)
+}
+
+// Transaction is a credit or debit of the wallet. OrderId is left empty when
+// the movement was not made for an order placed through the gateway.
+type Transaction struct {
+	TransactionId string    `json:"transaction_id"`
+	Type          string    `json:"type"`
+	Amount        int64     `json:"amount"`
+	BalanceAfter  int64     `json:"balance_after"`
+	ReferenceId   string    `json:"reference_id"`
+	OrderId       string    `json:"order_id,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ParamsStatementInput holds the filters as received in the query string;
+// Validate parses them into the typed fields.
+type ParamsStatementInput struct {
+	UserId string `json:"-"`
+	Type   string
+	From   string
+	To     string
+	Page   string
+	Limit  string
+
+	FromTime time.Time
+	ToTime   time.Time
+	PageInt  int
+	LimitInt int
+}
+
+func (p *ParamsStatementInput) Validate() error {
+	p.Type = strings.ToLower(p.Type)
+
+	switch p.Type {
+	case "", TransactionCredit, TransactionDebit:
+	default:
+		return errors.New("type invalid")
+	}
+
+	var err error
+
+	if p.FromTime, err = parseStatementDate(p.From, false); err != nil {
+		return errors.New("from invalid")
+	}
+
+	if p.ToTime, err = parseStatementDate(p.To, true); err != nil {
+		return errors.New("to invalid")
+	}
+
+	if !p.FromTime.IsZero() && !p.ToTime.IsZero() && p.ToTime.Before(p.FromTime) {
+		return errors.New("to before from")
+	}
+
+	p.PageInt = 1
+	p.LimitInt = 20
+
+	if p.Page != "" {
+		page, err := strconv.Atoi(p.Page)
+		if err != nil || page <= 0 {
+			return errors.New("page invalid")
+		}
+
+		p.PageInt = page
+	}
+
+	if p.Limit != "" {
+		limit, err := strconv.Atoi(p.Limit)
+		if err != nil || limit <= 0 || limit > 100 {
+			return errors.New("limit invalid")
+		}
+
+		p.LimitInt = limit
+	}
+
+	return nil
+}
+
+// parseStatementDate accepts RFC 3339 timestamps or plain dates. A plain date
+// used as the upper bound covers the whole day.
+func parseStatementDate(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	return t, nil
+}
+
+type ParamsStatementOutput struct {
+	WalletId     string         `json:"wallet_id"`
+	Balance      int64          `json:"balance"`
+	Transactions []*Transaction `json:"transactions"`
+	Page         int            `json:"page"`
+	Limit        int            `json:"limit"`
+	TotalItens   int            `json:"total_itens"`
+	TotalPages   int            `json:"total_pages"`
+}
@@ -0,0 +1,353 @@
+package promotion
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Promotion interface {
+	CreateCoupon(ctx context.Context, params *ParamsCreateCouponInput) (*Coupon, error)
+	UpdateCoupon(ctx context.Context, params *ParamsUpdateCouponInput) (*Coupon, error)
+	FindCoupon(ctx context.Context, params *ParamsCouponInput) (*Coupon, error)
+	ListCoupons(ctx context.Context, params *ParamsListCouponsInput) (*ParamsListCouponsOutput, error)
+	DeleteCoupon(ctx context.Context, params *ParamsCouponInput) error
+	// ApplyCoupon validates the coupon against the order and redeems it. The
+	// redemption counts towards the usage limits until it is released.
+	ApplyCoupon(ctx context.Context, params *ParamsApplyCouponInput) (*ParamsApplyCouponOutput, error)
+	ReleaseCoupon(ctx context.Context, redemptionId string) error
+}
+
+type Repository interface {
+	Create(ctx context.Context, coupon *Coupon) error
+	Update(ctx context.Context, coupon *Coupon) error
+	Find(ctx context.Context, id string) (*Coupon, error)
+	FindByCode(ctx context.Context, code string) (*Coupon, error)
+	List(ctx context.Context, params *ParamsListCouponsInput) ([]*Coupon, int, error)
+	Delete(ctx context.Context, id string) error
+	// Redeem checks the usage limits and stores the redemption atomically.
+	Redeem(ctx context.Context, redemption *Redemption) error
+	Release(ctx context.Context, redemptionId string) error
+}
+
+type CouponKind string
+
+type RedemptionStatus string
+
+var (
+	CouponPercentage CouponKind = "percentage"
+	CouponFixed      CouponKind = "fixed"
+
+	RedemptionActive   RedemptionStatus = "active"
+	RedemptionReleased RedemptionStatus = "released"
+
+	ErrCouponNotFound      = errors.New("coupon not found")
+	ErrCouponCodeExists    = errors.New("coupon code already exists")
+	ErrCouponInvalid       = errors.New("coupon invalid")
+	ErrCouponInactive      = errors.New("coupon inactive")
+	ErrCouponNotStarted    = errors.New("coupon not valid yet")
+	ErrCouponExpired       = errors.New("coupon expired")
+	ErrCouponExhausted     = errors.New("coupon usage limit reached")
+	ErrCouponUserLimit     = errors.New("coupon usage limit per user reached")
+	ErrCouponNotApplicable = errors.New("coupon not applicable to this order")
+	ErrCouponMinAmount     = errors.New("order amount below coupon minimum")
+)
+
+type Coupon struct {
+	Id             string     `json:"coupon_id"`
+	Code           string     `json:"code"`
+	Kind           CouponKind `json:"kind"`
+	Value          int64      `json:"value"`
+	MinAmount      int64      `json:"min_amount"`
+	MaxUses        int        `json:"max_uses"`
+	MaxUsesPerUser int        `json:"max_uses_per_user"`
+	UsedCount      int        `json:"used_count"`
+	Plans          []string   `json:"plans"`
+	Products       []string   `json:"products"`
+	Actions        []string   `json:"actions"`
+	Active         bool       `json:"active"`
+	StartsAt       time.Time  `json:"starts_at"`
+	EndsAt         *time.Time `json:"ends_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// Check reports whether the coupon can be used right now, leaving usage
+// limits to the repository.
+func (c *Coupon) Check(now time.Time) error {
+	if !c.Active {
+		return ErrCouponInactive
+	}
+
+	if now.Before(c.StartsAt) {
+		return ErrCouponNotStarted
+	}
+
+	if c.EndsAt != nil && now.After(*c.EndsAt) {
+		return ErrCouponExpired
+	}
+
+	return nil
+}
+
+// EligibleAmount returns the part of the order the coupon applies to.
+func (c *Coupon) EligibleAmount(params *ParamsApplyCouponInput) (int64, error) {
+	if len(c.Actions) > 0 && !slices.Contains(c.Actions, params.Action) {
+		return 0, ErrCouponNotApplicable
+	}
+
+	if len(c.Plans) > 0 && !slices.Contains(c.Plans, params.Plan) {
+		return 0, ErrCouponNotApplicable
+	}
+
+	if len(c.Products) == 0 {
+		return params.Amount, nil
+	}
+
+	var eligible int64
+	for _, item := range params.Items {
+		if slices.Contains(c.Products, item.ProductId) {
+			eligible += item.Amount
+		}
+	}
+
+	if eligible == 0 {
+		return 0, ErrCouponNotApplicable
+	}
+
+	return eligible, nil
+}
+
+func (c *Coupon) Discount(eligible int64) int64 {
+	var discount int64
+
+	switch c.Kind {
+	case CouponPercentage:
+		discount = eligible * c.Value / 100
+	case CouponFixed:
+		discount = c.Value
+	}
+
+	return min(discount, eligible)
+}
+
+type Redemption struct {
+	Id          string           `json:"redemption_id"`
+	CouponId    string           `json:"coupon_id"`
+	UserId      string           `json:"user_id"`
+	ReferenceId string           `json:"reference_id"`
+	Discount    int64            `json:"discount"`
+	Status      RedemptionStatus `json:"status"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+type couponRules struct {
+	Value          int64      `json:"value"`
+	MinAmount      int64      `json:"min_amount"`
+	MaxUses        int        `json:"max_uses"`
+	MaxUsesPerUser int        `json:"max_uses_per_user"`
+	Plans          []string   `json:"plans"`
+	Products       []string   `json:"products"`
+	Actions        []string   `json:"actions"`
+	Active         *bool      `json:"active"`
+	StartsAt       *time.Time `json:"starts_at"`
+	EndsAt         *time.Time `json:"ends_at"`
+}
+
+func (r *couponRules) validate(kind CouponKind) error {
+	switch kind {
+	case CouponPercentage:
+		if r.Value <= 0 || r.Value > 100 {
+			return errors.New("percentage value must be between 1 and 100")
+		}
+	case CouponFixed:
+		if r.Value <= 0 {
+			return errors.New("fixed value must be positive")
+		}
+	default:
+		return errors.New("kind invalid")
+	}
+
+	if r.MinAmount < 0 || r.MaxUses < 0 || r.MaxUsesPerUser < 0 {
+		return errors.New("limits must not be negative")
+	}
+
+	for _, p := range r.Products {
+		if _, err := uuid.Parse(p); err != nil {
+			return errors.New("invalid uuid product")
+		}
+	}
+
+	if r.StartsAt != nil && r.EndsAt != nil && !r.EndsAt.After(*r.StartsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+
+	return nil
+}
+
+// apply copies the rules to the coupon. A nil Active keeps the current value
+// and a nil StartsAt keeps the current start.
+func (r *couponRules) apply(c *Coupon) {
+	c.Value = r.Value
+	c.MinAmount = r.MinAmount
+	c.MaxUses = r.MaxUses
+	c.MaxUsesPerUser = r.MaxUsesPerUser
+	c.Plans = nonNil(r.Plans)
+	c.Products = nonNil(r.Products)
+	c.Actions = nonNil(r.Actions)
+	c.EndsAt = r.EndsAt
+
+	if r.Active != nil {
+		c.Active = *r.Active
+	}
+
+	if r.StartsAt != nil {
+		c.StartsAt = *r.StartsAt
+	}
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+
+	return values
+}
+
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+type ParamsCreateCouponInput struct {
+	Code string     `json:"code"`
+	Kind CouponKind `json:"kind"`
+	couponRules
+}
+
+func (p *ParamsCreateCouponInput) Validate() error {
+	p.Code = NormalizeCode(p.Code)
+
+	if p.Code == "" || len(p.Code) > 64 {
+		return errors.New("code invalid")
+	}
+
+	return p.couponRules.validate(p.Kind)
+}
+
+func (p *ParamsCreateCouponInput) NewCoupon() *Coupon {
+	now := time.Now()
+
+	coupon := Coupon{
+		Id:        uuid.NewString(),
+		Code:      p.Code,
+		Kind:      p.Kind,
+		Active:    true,
+		StartsAt:  now,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	p.couponRules.apply(&coupon)
+
+	return &coupon
+}
+
+type ParamsUpdateCouponInput struct {
+	CouponId string `json:"-"`
+	couponRules
+}
+
+func (p *ParamsUpdateCouponInput) Validate(kind CouponKind) error {
+	return p.couponRules.validate(kind)
+}
+
+func (p *ParamsUpdateCouponInput) Apply(coupon *Coupon) {
+	p.couponRules.apply(coupon)
+}
+
+type ParamsCouponInput struct {
+	CouponId string `json:"coupon_id"`
+}
+
+func (p *ParamsCouponInput) Validate() error {
+	if _, err := uuid.Parse(p.CouponId); err != nil {
+		return errors.New("invalid uuid coupon")
+	}
+
+	return nil
+}
+
+type ParamsListCouponsInput struct {
+	Active   string `json:"active"`
+	Page     string `json:"page"`
+	Limit    string `json:"limit"`
+	PageInt  int
+	LimitInt int
+}
+
+func (p *ParamsListCouponsInput) Validate() error {
+	switch p.Active {
+	case "", "true", "false":
+	default:
+		return errors.New("active invalid")
+	}
+
+	p.PageInt = 1
+	p.LimitInt = 20
+
+	if p.Page != "" {
+		page, err := strconv.Atoi(p.Page)
+		if err != nil || page <= 0 {
+			return errors.New("page invalid")
+		}
+
+		p.PageInt = page
+	}
+
+	if p.Limit != "" {
+		limit, err := strconv.Atoi(p.Limit)
+		if err != nil || limit <= 0 || limit > 100 {
+			return errors.New("limit invalid")
+		}
+
+		p.LimitInt = limit
+	}
+
+	return nil
+}
+
+type ParamsListCouponsOutput struct {
+	Coupons    []*Coupon `json:"coupons"`
+	Page       int       `json:"page"`
+	Limit      int       `json:"limit"`
+	TotalItens int       `json:"total_itens"`
+	TotalPages int       `json:"total_pages"`
+}
+
+type CouponItem struct {
+	ProductId string
+	Amount    int64
+}
+
+type ParamsApplyCouponInput struct {
+	Code        string
+	UserId      string
+	ReferenceId string
+	Action      string
+	Plan        string
+	Amount      int64
+	Items       []CouponItem
+}
+
+type ParamsApplyCouponOutput struct {
+	CouponId     string `json:"coupon_id"`
+	Code         string `json:"code"`
+	RedemptionId string `json:"redemption_id"`
+	Discount     int64  `json:"discount"`
+}
@@ -0,0 +1,342 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/promotion"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const uniqueViolation = "23505"
+
+type promotionRepository struct {
+	db *sqlx.DB
+}
+
+func NewPromotionRepository(db *sqlx.DB) promotion.Repository {
+	return &promotionRepository{
+		db: db,
+	}
+}
+
+type couponRow struct {
+	Id             string         `db:"id"`
+	Code           string         `db:"code"`
+	Kind           string         `db:"kind"`
+	Value          int64          `db:"value"`
+	MinAmount      int64          `db:"min_amount"`
+	MaxUses        int            `db:"max_uses"`
+	MaxUsesPerUser int            `db:"max_uses_per_user"`
+	UsedCount      int            `db:"used_count"`
+	Plans          pq.StringArray `db:"plans"`
+	Products       pq.StringArray `db:"products"`
+	Actions        pq.StringArray `db:"actions"`
+	Active         bool           `db:"active"`
+	StartsAt       time.Time      `db:"starts_at"`
+	EndsAt         sql.NullTime   `db:"ends_at"`
+	CreatedAt      time.Time      `db:"created_at"`
+	UpdatedAt      time.Time      `db:"updated_at"`
+}
+
+func (r *couponRow) toCoupon() *promotion.Coupon {
+	coupon := promotion.Coupon{
+		Id:             r.Id,
+		Code:           r.Code,
+		Kind:           promotion.CouponKind(r.Kind),
+		Value:          r.Value,
+		MinAmount:      r.MinAmount,
+		MaxUses:        r.MaxUses,
+		MaxUsesPerUser: r.MaxUsesPerUser,
+		UsedCount:      r.UsedCount,
+		Plans:          []string(r.Plans),
+		Products:       []string(r.Products),
+		Actions:        []string(r.Actions),
+		Active:         r.Active,
+		StartsAt:       r.StartsAt,
+		CreatedAt:      r.CreatedAt,
+		UpdatedAt:      r.UpdatedAt,
+	}
+
+	if r.EndsAt.Valid {
+		endsAt := r.EndsAt.Time
+		coupon.EndsAt = &endsAt
+	}
+
+	return &coupon
+}
+
+const couponColumns = `id, code, kind, value, min_amount, max_uses, max_uses_per_user, used_count,
+	plans, products, actions, active, starts_at, ends_at, created_at, updated_at`
+
+func (r *promotionRepository) Create(ctx context.Context, coupon *promotion.Coupon) error {
+	const query = `INSERT INTO coupons
+	(id, code, kind, value, min_amount, max_uses, max_uses_per_user, plans, products, actions,
+	active, starts_at, ends_at, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		coupon.Id,
+		coupon.Code,
+		coupon.Kind,
+		coupon.Value,
+		coupon.MinAmount,
+		coupon.MaxUses,
+		coupon.MaxUsesPerUser,
+		pq.StringArray(coupon.Plans),
+		pq.StringArray(coupon.Products),
+		pq.StringArray(coupon.Actions),
+		coupon.Active,
+		coupon.StartsAt,
+		coupon.EndsAt,
+		coupon.CreatedAt,
+		coupon.UpdatedAt,
+	)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return promotion.ErrCouponCodeExists
+		}
+
+		return fmt.Errorf("r.db.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (r *promotionRepository) Update(ctx context.Context, coupon *promotion.Coupon) error {
+	const query = `UPDATE coupons
+	SET value = $2, min_amount = $3, max_uses = $4, max_uses_per_user = $5, plans = $6,
+	products = $7, actions = $8, active = $9, starts_at = $10, ends_at = $11, updated_at = $12
+	WHERE id = $1 AND deleted_at IS NULL`
+
+	coupon.UpdatedAt = time.Now()
+
+	result, err := r.db.ExecContext(ctx, query,
+		coupon.Id,
+		coupon.Value,
+		coupon.MinAmount,
+		coupon.MaxUses,
+		coupon.MaxUsesPerUser,
+		pq.StringArray(coupon.Plans),
+		pq.StringArray(coupon.Products),
+		pq.StringArray(coupon.Actions),
+		coupon.Active,
+		coupon.StartsAt,
+		coupon.EndsAt,
+		coupon.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("r.db.ExecContext: %w", err)
+	}
+
+	return affected(result)
+}
+
+func (r *promotionRepository) Find(ctx context.Context, id string) (*promotion.Coupon, error) {
+	query := `SELECT ` + couponColumns + ` FROM coupons WHERE id = $1 AND deleted_at IS NULL`
+
+	return r.get(ctx, query, id)
+}
+
+func (r *promotionRepository) FindByCode(ctx context.Context, code string) (*promotion.Coupon, error) {
+	query := `SELECT ` + couponColumns + ` FROM coupons WHERE code = $1 AND deleted_at IS NULL`
+
+	return r.get(ctx, query, code)
+}
+
+func (r *promotionRepository) get(ctx context.Context, query string, arg any) (*promotion.Coupon, error) {
+	var row couponRow
+
+	if err := r.db.GetContext(ctx, &row, query, arg); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, promotion.ErrCouponNotFound
+		}
+
+		return nil, fmt.Errorf("r.db.GetContext: %w", err)
+	}
+
+	return row.toCoupon(), nil
+}
+
+func (r *promotionRepository) List(ctx context.Context, params *promotion.ParamsListCouponsInput) ([]*promotion.Coupon, int, error) {
+	const countQuery = `SELECT count(*) FROM coupons
+	WHERE deleted_at IS NULL AND ($1 = '' OR active = ($1 = 'true'))`
+
+	var total int
+
+	if err := r.db.GetContext(ctx, &total, countQuery, params.Active); err != nil {
+		return nil, 0, fmt.Errorf("r.db.GetContext: %w", err)
+	}
+
+	query := `SELECT ` + couponColumns + ` FROM coupons
+	WHERE deleted_at IS NULL AND ($1 = '' OR active = ($1 = 'true'))
+	ORDER BY created_at DESC LIMIT $2 OFFSET $3`
+
+	var rows []couponRow
+
+	offset := (params.PageInt - 1) * params.LimitInt
+
+	if err := r.db.SelectContext(ctx, &rows, query, params.Active, params.LimitInt, offset); err != nil {
+		return nil, 0, fmt.Errorf("r.db.SelectContext: %w", err)
+	}
+
+	coupons := make([]*promotion.Coupon, 0, len(rows))
+
+	for i := range rows {
+		coupons = append(coupons, rows[i].toCoupon())
+	}
+
+	return coupons, total, nil
+}
+
+// Delete is soft so the redemptions keep pointing at an existing coupon.
+func (r *promotionRepository) Delete(ctx context.Context, id string) error {
+	const query = `UPDATE coupons SET active = FALSE, deleted_at = NOW(), updated_at = NOW()
+	WHERE id = $1 AND deleted_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("r.db.ExecContext: %w", err)
+	}
+
+	return affected(result)
+}
+
+func (r *promotionRepository) Redeem(ctx context.Context, redemption *promotion.Redemption) (err error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("r.db.BeginTxx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var limits struct {
+		MaxUses        int `db:"max_uses"`
+		MaxUsesPerUser int `db:"max_uses_per_user"`
+		UsedCount      int `db:"used_count"`
+	}
+
+	// the row lock serializes concurrent redemptions of the same coupon
+	const lockQuery = `SELECT max_uses, max_uses_per_user, used_count FROM coupons
+	WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+
+	if err = tx.GetContext(ctx, &limits, lockQuery, redemption.CouponId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return promotion.ErrCouponNotFound
+		}
+
+		return fmt.Errorf("tx.GetContext: %w", err)
+	}
+
+	if limits.MaxUses > 0 && limits.UsedCount >= limits.MaxUses {
+		return promotion.ErrCouponExhausted
+	}
+
+	if limits.MaxUsesPerUser > 0 {
+		const userQuery = `SELECT count(*) FROM coupon_redemptions
+		WHERE coupon_id = $1 AND user_id = $2 AND status = $3`
+
+		var used int
+
+		if err = tx.GetContext(ctx, &used, userQuery, redemption.CouponId, redemption.UserId, promotion.RedemptionActive); err != nil {
+			return fmt.Errorf("tx.GetContext: %w", err)
+		}
+
+		if used >= limits.MaxUsesPerUser {
+			return promotion.ErrCouponUserLimit
+		}
+	}
+
+	const insertQuery = `INSERT INTO coupon_redemptions
+	(id, coupon_id, user_id, reference_id, discount, status, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	_, err = tx.ExecContext(ctx, insertQuery,
+		redemption.Id,
+		redemption.CouponId,
+		redemption.UserId,
+		redemption.ReferenceId,
+		redemption.Discount,
+		redemption.Status,
+		redemption.CreatedAt,
+		redemption.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("tx.ExecContext: %w", err)
+	}
+
+	const usedQuery = `UPDATE coupons SET used_count = used_count + 1 WHERE id = $1`
+
+	if _, err = tx.ExecContext(ctx, usedQuery, redemption.CouponId); err != nil {
+		return fmt.Errorf("tx.ExecContext: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("tx.Commit: %w", err)
+	}
+
+	return nil
+}
+
+// Release is idempotent: a redemption already released is left untouched.
+func (r *promotionRepository) Release(ctx context.Context, redemptionId string) (err error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("r.db.BeginTxx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	const releaseQuery = `UPDATE coupon_redemptions SET status = $2, updated_at = NOW()
+	WHERE id = $1 AND status = $3 RETURNING coupon_id`
+
+	var couponId string
+
+	err = tx.GetContext(ctx, &couponId, releaseQuery, redemptionId, promotion.RedemptionReleased, promotion.RedemptionActive)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = nil
+			return tx.Rollback()
+		}
+
+		return fmt.Errorf("tx.GetContext: %w", err)
+	}
+
+	const usedQuery = `UPDATE coupons SET used_count = GREATEST(used_count - 1, 0) WHERE id = $1`
+
+	if _, err = tx.ExecContext(ctx, usedQuery, couponId); err != nil {
+		return fmt.Errorf("tx.ExecContext: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("tx.Commit: %w", err)
+	}
+
+	return nil
+}
+
+func affected(result sql.Result) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("result.RowsAffected: %w", err)
+	}
+
+	if rows == 0 {
+		return promotion.ErrCouponNotFound
+	}
+
+	return nil
+}
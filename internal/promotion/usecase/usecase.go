@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/promotion"
+	"github.com/aclgo/simple-api-gateway/pkg/logger"
+	"github.com/google/uuid"
+)
+
+type promotionUC struct {
+	repo   promotion.Repository
+	logger logger.Logger
+}
+
+func NewPromotionUC(repo promotion.Repository, logger logger.Logger) promotion.Promotion {
+	return &promotionUC{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+func (u *promotionUC) CreateCoupon(ctx context.Context, params *promotion.ParamsCreateCouponInput) (*promotion.Coupon, error) {
+	coupon := params.NewCoupon()
+
+	if err := u.repo.Create(ctx, coupon); err != nil {
+		return nil, err
+	}
+
+	return coupon, nil
+}
+
+func (u *promotionUC) UpdateCoupon(ctx context.Context, params *promotion.ParamsUpdateCouponInput) (*promotion.Coupon, error) {
+	coupon, err := u.repo.Find(ctx, params.CouponId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := params.Validate(coupon.Kind); err != nil {
+		return nil, fmt.Errorf("%w: %v", promotion.ErrCouponInvalid, err)
+	}
+
+	params.Apply(coupon)
+
+	if err := u.repo.Update(ctx, coupon); err != nil {
+		return nil, err
+	}
+
+	return coupon, nil
+}
+
+func (u *promotionUC) FindCoupon(ctx context.Context, params *promotion.ParamsCouponInput) (*promotion.Coupon, error) {
+	return u.repo.Find(ctx, params.CouponId)
+}
+
+func (u *promotionUC) ListCoupons(ctx context.Context, params *promotion.ParamsListCouponsInput) (*promotion.ParamsListCouponsOutput, error) {
+	coupons, total, err := u.repo.List(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("u.repo.List: %w", err)
+	}
+
+	out := promotion.ParamsListCouponsOutput{
+		Coupons:    coupons,
+		Page:       params.PageInt,
+		Limit:      params.LimitInt,
+		TotalItens: total,
+		TotalPages: int(math.Ceil(float64(total) / float64(params.LimitInt))),
+	}
+
+	return &out, nil
+}
+
+func (u *promotionUC) DeleteCoupon(ctx context.Context, params *promotion.ParamsCouponInput) error {
+	return u.repo.Delete(ctx, params.CouponId)
+}
+
+func (u *promotionUC) ApplyCoupon(ctx context.Context, params *promotion.ParamsApplyCouponInput) (*promotion.ParamsApplyCouponOutput, error) {
+	coupon, err := u.repo.FindByCode(ctx, promotion.NormalizeCode(params.Code))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	if err := coupon.Check(now); err != nil {
+		return nil, err
+	}
+
+	if params.Amount < coupon.MinAmount {
+		return nil, promotion.ErrCouponMinAmount
+	}
+
+	eligible, err := coupon.EligibleAmount(params)
+	if err != nil {
+		return nil, err
+	}
+
+	redemption := promotion.Redemption{
+		Id:          uuid.NewString(),
+		CouponId:    coupon.Id,
+		UserId:      params.UserId,
+		ReferenceId: params.ReferenceId,
+		Discount:    coupon.Discount(eligible),
+		Status:      promotion.RedemptionActive,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if err := u.repo.Redeem(ctx, &redemption); err != nil {
+		return nil, err
+	}
+
+	out := promotion.ParamsApplyCouponOutput{
+		CouponId:     coupon.Id,
+		Code:         coupon.Code,
+		RedemptionId: redemption.Id,
+		Discount:     redemption.Discount,
+	}
+
+	return &out, nil
+}
+
+func (u *promotionUC) ReleaseCoupon(ctx context.Context, redemptionId string) error {
+	if err := u.repo.Release(ctx, redemptionId); err != nil {
+		return fmt.Errorf("u.repo.Release: %w", err)
+	}
+
+	return nil
+}
@@ -21,6 +21,39 @@ func (p *ParamsCancelSubscriptionInput) Validate() error {
 	return nil
 }
 
+// ParamsShortenSubscriptionInput takes Days off the current subscription of
+// the user. The user service never extends a subscription on this call and
+// ends it if the expiry would go past now; Reference makes a retry a no-op.
+type ParamsShortenSubscriptionInput struct {
+	UserId    string
+	Days      int64
+	Reference string
+}
+
+func (p *ParamsShortenSubscriptionInput) Validate() error {
+	if p.UserId == "" {
+		return errors.New("user id empty")
+	}
+
+	if p.Days <= 0 {
+		return errors.New("days must be positive")
+	}
+
+	if p.Reference == "" {
+		return errors.New("reference empty")
+	}
+
+	return nil
+}
+
+type ParamsShortenSubscriptionOutput struct {
+	SubscriptionId string    `json:"subscription_id"`
+	UserId         string    `json:"user_id"`
+	Status         string    `json:"status"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
 type ParamsCancelSubscriptionOutput struct {
 	SubscriptionId string    `json:"subscription_id"`
 	UserId         string    `json:"user_id"`
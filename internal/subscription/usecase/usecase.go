@@ -66,3 +66,30 @@ func (u *subscriptionUC) CancelSubscription(ctx context.Context, params *subscri
 
 	return &out, nil
 }
+
+func (u *subscriptionUC) ShortenSubscription(ctx context.Context, params *subscription.ParamsShortenSubscriptionInput) (*subscription.ParamsShortenSubscriptionOutput, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	ps := protoUser.ShortenSubscriptionRequest{
+		UserId:    params.UserId,
+		Days:      params.Days,
+		Reference: params.Reference,
+	}
+
+	shortened, err := u.subscriptionGRPC.ShortenSubscription(ctx, &ps)
+	if err != nil {
+		return nil, fmt.Errorf("u.subscriptionGRPC.ShortenSubscription: %w", err)
+	}
+
+	out := subscription.ParamsShortenSubscriptionOutput{
+		SubscriptionId: shortened.Id,
+		UserId:         shortened.UserId,
+		Status:         shortened.Status,
+		ExpiresAt:      shortened.ExpiresAt.AsTime(),
+		UpdatedAt:      shortened.UpdatedAt.AsTime(),
+	}
+
+	return &out, nil
+}
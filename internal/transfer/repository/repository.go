@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/transfer"
+	"github.com/jmoiron/sqlx"
+)
+
+type transferRepository struct {
+	db *sqlx.DB
+}
+
+func NewTransferRepository(db *sqlx.DB) transfer.Repository {
+	return &transferRepository{
+		db: db,
+	}
+}
+
+type transferRow struct {
+	Id                    string         `db:"id"`
+	SenderId              string         `db:"sender_id"`
+	RecipientId           string         `db:"recipient_id"`
+	Amount                int64          `db:"amount"`
+	Description           string         `db:"description"`
+	Status                string         `db:"status"`
+	SenderOrderId         sql.NullString `db:"sender_order_id"`
+	RecipientOrderId      sql.NullString `db:"recipient_order_id"`
+	FailureReason         string         `db:"failure_reason"`
+	ConfirmationHash      string         `db:"confirmation_hash"`
+	ConfirmationAttempts  int            `db:"confirmation_attempts"`
+	ConfirmationExpiresAt sql.NullTime   `db:"confirmation_expires_at"`
+	CompletedAt           sql.NullTime   `db:"completed_at"`
+	CreatedAt             time.Time      `db:"created_at"`
+	UpdatedAt             time.Time      `db:"updated_at"`
+}
+
+func (r *transferRow) toTransfer() *transfer.Transfer {
+	t := transfer.Transfer{
+		Id:                   r.Id,
+		SenderId:             r.SenderId,
+		RecipientId:          r.RecipientId,
+		Amount:               r.Amount,
+		Description:          r.Description,
+		Status:               r.Status,
+		SenderOrderId:        r.SenderOrderId.String,
+		RecipientOrderId:     r.RecipientOrderId.String,
+		FailureReason:        r.FailureReason,
+		ConfirmationHash:     r.ConfirmationHash,
+		ConfirmationAttempts: r.ConfirmationAttempts,
+		CreatedAt:            r.CreatedAt,
+		UpdatedAt:            r.UpdatedAt,
+	}
+
+	if r.ConfirmationExpiresAt.Valid {
+		t.ConfirmationExpires = &r.ConfirmationExpiresAt.Time
+	}
+
+	if r.CompletedAt.Valid {
+		t.CompletedAt = &r.CompletedAt.Time
+	}
+
+	return &t
+}
+
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+func nullTime(value *time.Time) sql.NullTime {
+	if value == nil {
+		return sql.NullTime{}
+	}
+
+	return sql.NullTime{Time: *value, Valid: true}
+}
+
+func (r *transferRepository) Create(ctx context.Context, t *transfer.Transfer, limits *transfer.Limits) (err error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("r.db.BeginTxx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// serializes the transfers of the sender so concurrent ones cannot pass
+	// the limits together
+	if _, err = tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, "transfer:"+t.SenderId); err != nil {
+		return fmt.Errorf("tx.ExecContext: %w", err)
+	}
+
+	var sent struct {
+		Amount int64 `db:"amount"`
+		Count  int   `db:"count"`
+	}
+
+	// a code nobody confirmed in time stops holding the limit
+	const sumQuery = `SELECT COALESCE(SUM(amount), 0) AS amount, count(*) AS count FROM wallet_transfers
+	WHERE sender_id = $1 AND created_at >= $2 AND status NOT IN ($3, $4)
+	AND NOT (status = $5 AND confirmation_expires_at < NOW())`
+
+	err = tx.GetContext(ctx, &sent, sumQuery, t.SenderId, limits.Since,
+		transfer.StatusFailed, transfer.StatusExpired, transfer.StatusAwaitingConfirmation)
+	if err != nil {
+		return fmt.Errorf("tx.GetContext: %w", err)
+	}
+
+	if sent.Count+1 > limits.Count {
+		return transfer.ErrDailyCountExceeded
+	}
+
+	if sent.Amount+t.Amount > limits.Amount {
+		return transfer.ErrDailyLimitExceeded
+	}
+
+	const insertQuery = `INSERT INTO wallet_transfers (id, sender_id, recipient_id, amount, description, status,
+	confirmation_hash, confirmation_attempts, confirmation_expires_at, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+
+	_, err = tx.ExecContext(ctx, insertQuery,
+		t.Id,
+		t.SenderId,
+		t.RecipientId,
+		t.Amount,
+		t.Description,
+		t.Status,
+		t.ConfirmationHash,
+		t.ConfirmationAttempts,
+		nullTime(t.ConfirmationExpires),
+		t.CreatedAt,
+		t.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("tx.ExecContext: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("tx.Commit: %w", err)
+	}
+
+	return nil
+}
+
+func (r *transferRepository) Find(ctx context.Context, id string) (*transfer.Transfer, error) {
+	const query = `SELECT id, sender_id, recipient_id, amount, description, status, sender_order_id,
+	recipient_order_id, failure_reason, confirmation_hash, confirmation_attempts, confirmation_expires_at,
+	completed_at, created_at, updated_at
+	FROM wallet_transfers WHERE id = $1`
+
+	var row transferRow
+
+	if err := r.db.GetContext(ctx, &row, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, transfer.ErrTransferNotFound
+		}
+
+		return nil, fmt.Errorf("r.db.GetContext: %w", err)
+	}
+
+	return row.toTransfer(), nil
+}
+
+func (r *transferRepository) ChangeStatus(ctx context.Context, id string, from string, to string) error {
+	const query = `UPDATE wallet_transfers SET status = $1, updated_at = NOW() WHERE id = $2 AND status = $3`
+
+	result, err := r.db.ExecContext(ctx, query, to, id, from)
+	if err != nil {
+		return fmt.Errorf("r.db.ExecContext: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("result.RowsAffected: %w", err)
+	}
+
+	if affected == 0 {
+		return transfer.ErrTransferNotPending
+	}
+
+	return nil
+}
+
+func (r *transferRepository) Update(ctx context.Context, t *transfer.Transfer) error {
+	const query = `UPDATE wallet_transfers SET status = $1, sender_order_id = $2, recipient_order_id = $3,
+	failure_reason = $4, completed_at = $5, updated_at = $6
+	WHERE id = $7`
+
+	_, err := r.db.ExecContext(ctx, query,
+		t.Status,
+		nullString(t.SenderOrderId),
+		nullString(t.RecipientOrderId),
+		t.FailureReason,
+		nullTime(t.CompletedAt),
+		t.UpdatedAt,
+		t.Id,
+	)
+	if err != nil {
+		return fmt.Errorf("r.db.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (r *transferRepository) AddConfirmationAttempt(ctx context.Context, id string) (int, error) {
+	const query = `UPDATE wallet_transfers SET confirmation_attempts = confirmation_attempts + 1, updated_at = NOW()
+	WHERE id = $1 RETURNING confirmation_attempts`
+
+	var attempts int
+
+	if err := r.db.GetContext(ctx, &attempts, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, transfer.ErrTransferNotFound
+		}
+
+		return 0, fmt.Errorf("r.db.GetContext: %w", err)
+	}
+
+	return attempts, nil
+}
@@ -0,0 +1,207 @@
+package transfer
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	StatusAwaitingConfirmation = "awaiting_confirmation"
+	StatusProcessing           = "processing"
+	StatusCompleted            = "completed"
+	StatusFailed               = "failed"
+	StatusExpired              = "expired"
+
+	DirectionSent     = "sent"
+	DirectionReceived = "received"
+
+	DefaultDailyLimit              int64 = 500000
+	DefaultDailyCount                    = 10
+	DefaultConfirmationTTL               = 10 * time.Minute
+	DefaultConfirmationMaxAttempts       = 5
+
+	// LimitWindow is how far back the daily limits look.
+	LimitWindow = 24 * time.Hour
+
+	maxDescriptionLength = 140
+	confirmationCodeSize = 6
+)
+
+var (
+	ErrTransferNotFound        = errors.New("transfer not found")
+	ErrRecipientNotFound       = errors.New("recipient not found")
+	ErrSelfTransfer            = errors.New("cannot transfer to yourself")
+	ErrInsufficientBalance     = errors.New("wallet balance is lower than the transfer amount")
+	ErrDailyLimitExceeded      = errors.New("transfer exceeds the daily amount limit")
+	ErrDailyCountExceeded      = errors.New("daily number of transfers reached")
+	ErrTransferNotPending      = errors.New("transfer is not awaiting confirmation")
+	ErrConfirmationExpired     = errors.New("confirmation code expired")
+	ErrConfirmationCodeInvalid = errors.New("confirmation code invalid")
+	ErrTooManyAttempts         = errors.New("too many wrong confirmation codes, start a new transfer")
+)
+
+type UseCase interface {
+	// Transfer moves funds to another user right away, or holds the
+	// transfer until Confirm when its amount requires a confirmation code.
+	Transfer(ctx context.Context, params *ParamsTransferInput) (*Transfer, error)
+	Confirm(ctx context.Context, params *ParamsConfirmTransferInput) (*Transfer, error)
+}
+
+type Repository interface {
+	// Create stores the transfer unless it would take the sender over the
+	// limits within LimitWindow, in which case ErrDailyLimitExceeded or
+	// ErrDailyCountExceeded is returned. Failed and expired transfers do not
+	// count.
+	Create(ctx context.Context, transfer *Transfer, limits *Limits) error
+	Find(ctx context.Context, id string) (*Transfer, error)
+	// ChangeStatus moves the transfer from one status to another, or returns
+	// ErrTransferNotPending if it is no longer in from.
+	ChangeStatus(ctx context.Context, id string, from string, to string) error
+	// AddConfirmationAttempt counts a wrong code and returns how many were
+	// entered so far.
+	AddConfirmationAttempt(ctx context.Context, id string) (int, error)
+	// Update saves the outcome of the transfer: status, orders, failure
+	// reason and completion time.
+	Update(ctx context.Context, transfer *Transfer) error
+}
+
+// Config holds the limits of each sender and when a confirmation code is
+// asked. A zero ConfirmationMinAmount never asks for one.
+type Config struct {
+	DailyLimit              int64
+	DailyCount              int
+	ConfirmationMinAmount   int64
+	ConfirmationTTL         time.Duration
+	ConfirmationMaxAttempts int
+	FromSendMail            string
+	ServiceNameSendMail     string
+}
+
+type Limits struct {
+	Amount int64
+	Count  int
+	Since  time.Time
+}
+
+type Transfer struct {
+	Id                   string     `json:"transfer_id"`
+	SenderId             string     `json:"sender_id"`
+	RecipientId          string     `json:"recipient_id"`
+	Amount               int64      `json:"amount"`
+	Description          string     `json:"description,omitempty"`
+	Status               string     `json:"status"`
+	SenderOrderId        string     `json:"sender_order_id,omitempty"`
+	RecipientOrderId     string     `json:"recipient_order_id,omitempty"`
+	FailureReason        string     `json:"failure_reason,omitempty"`
+	ConfirmationHash     string     `json:"-"`
+	ConfirmationAttempts int        `json:"-"`
+	ConfirmationExpires  *time.Time `json:"confirmation_expires_at,omitempty"`
+	CompletedAt          *time.Time `json:"completed_at,omitempty"`
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
+}
+
+// DebitReference is the reference of the sender debit and the gateway
+// transaction id of the sender order, so the wallet statement finds it.
+func (t *Transfer) DebitReference() string {
+	return t.Id
+}
+
+// CreditReference is the reference of the recipient credit and the gateway
+// transaction id of the recipient order.
+func (t *Transfer) CreditReference() string {
+	return "transfer-" + t.Id
+}
+
+// OrderMetadata is stored in both orders of a transfer, one on each side.
+type OrderMetadata struct {
+	TransferId     string `json:"transfer_id"`
+	Direction      string `json:"direction"`
+	CounterpartyId string `json:"counterparty_id"`
+	Description    string `json:"description,omitempty"`
+}
+
+// ParamsTransferInput finds the recipient by Recipient, which holds either
+// the account id or the email of the user.
+type ParamsTransferInput struct {
+	UserId      string `json:"-"`
+	Recipient   string `json:"recipient"`
+	Amount      int64  `json:"amount"`
+	Description string `json:"description"`
+}
+
+func (p *ParamsTransferInput) Validate() error {
+	p.Recipient = strings.TrimSpace(p.Recipient)
+
+	if p.Recipient == "" {
+		return errors.New("recipient empty")
+	}
+
+	if _, err := uuid.Parse(p.Recipient); err != nil && !strings.Contains(p.Recipient, "@") {
+		return errors.New("recipient must be an account id or an email")
+	}
+
+	if p.Amount <= 0 {
+		return errors.New("amount invalid")
+	}
+
+	p.Description = strings.TrimSpace(p.Description)
+
+	if len(p.Description) > maxDescriptionLength {
+		return fmt.Errorf("description longer than %d characters", maxDescriptionLength)
+	}
+
+	return nil
+}
+
+type ParamsConfirmTransferInput struct {
+	UserId     string `json:"-"`
+	TransferId string `json:"-"`
+	Code       string `json:"code"`
+}
+
+func (p *ParamsConfirmTransferInput) Validate() error {
+	if _, err := uuid.Parse(p.TransferId); err != nil {
+		return errors.New("invalid uuid transfer")
+	}
+
+	p.Code = strings.TrimSpace(p.Code)
+
+	if p.Code == "" {
+		return errors.New("code empty")
+	}
+
+	return nil
+}
+
+// NewConfirmationCode returns a random numeric code and the hash stored in
+// its place.
+func NewConfirmationCode() (string, string, error) {
+	max := big.NewInt(1)
+	for range confirmationCodeSize {
+		max.Mul(max, big.NewInt(10))
+	}
+
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", "", fmt.Errorf("rand.Int: %w", err)
+	}
+
+	code := fmt.Sprintf("%0*d", confirmationCodeSize, n)
+
+	return code, HashConfirmationCode(code), nil
+}
+
+func HashConfirmationCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,459 @@
+package usecase
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/invoice"
+	"github.com/aclgo/simple-api-gateway/internal/orders"
+	"github.com/aclgo/simple-api-gateway/internal/payment/wallet"
+	"github.com/aclgo/simple-api-gateway/internal/transfer"
+	"github.com/aclgo/simple-api-gateway/pkg/logger"
+	protoBalance "github.com/aclgo/simple-api-gateway/proto-service/balance"
+	protoMail "github.com/aclgo/simple-api-gateway/proto-service/mail"
+	protoOrders "github.com/aclgo/simple-api-gateway/proto-service/orders"
+	protoUser "github.com/aclgo/simple-api-gateway/proto-service/user"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	sagaWalletTransfer = "wallet-transfer"
+	stepTimeout        = 10 * time.Second
+
+	sagaKeySenderOrder    = "sender-order"
+	sagaKeyRecipientOrder = "recipient-order"
+)
+
+type transferUC struct {
+	cfg               transfer.Config
+	repo              transfer.Repository
+	clientBalanceGRPC protoBalance.WalletServiceClient
+	clientOrdersGRPC  protoOrders.ServiceOrderClient
+	clientUserGRPC    protoUser.UserServiceClient
+	clientMailGRPC    protoMail.MailServiceClient
+	workerSaga        orders.SagaWorker
+	index             orders.IndexRepository
+	logger            logger.Logger
+}
+
+// NewTransferUC relies on the compensations the orders use case registers
+// on workerSaga to credit the sender back and roll the orders back.
+func NewTransferUC(cfg transfer.Config, repo transfer.Repository, clientBalanceGRPC protoBalance.WalletServiceClient,
+	clientOrdersGRPC protoOrders.ServiceOrderClient, clientUserGRPC protoUser.UserServiceClient,
+	clientMailGRPC protoMail.MailServiceClient, workerSaga orders.SagaWorker, index orders.IndexRepository,
+	logger logger.Logger) transfer.UseCase {
+	if cfg.DailyLimit <= 0 {
+		cfg.DailyLimit = transfer.DefaultDailyLimit
+	}
+
+	if cfg.DailyCount <= 0 {
+		cfg.DailyCount = transfer.DefaultDailyCount
+	}
+
+	if cfg.ConfirmationTTL <= 0 {
+		cfg.ConfirmationTTL = transfer.DefaultConfirmationTTL
+	}
+
+	if cfg.ConfirmationMaxAttempts <= 0 {
+		cfg.ConfirmationMaxAttempts = transfer.DefaultConfirmationMaxAttempts
+	}
+
+	return &transferUC{
+		cfg:               cfg,
+		repo:              repo,
+		clientBalanceGRPC: clientBalanceGRPC,
+		clientOrdersGRPC:  clientOrdersGRPC,
+		clientUserGRPC:    clientUserGRPC,
+		clientMailGRPC:    clientMailGRPC,
+		workerSaga:        workerSaga,
+		index:             index,
+		logger:            logger,
+	}
+}
+
+func (u *transferUC) Transfer(ctx context.Context, params *transfer.ParamsTransferInput) (*transfer.Transfer, error) {
+	recipient, err := u.findRecipient(ctx, params.Recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	if recipient.Id == params.UserId {
+		return nil, transfer.ErrSelfTransfer
+	}
+
+	senderWallet, err := wallet.FindOrCreate(ctx, u.clientBalanceGRPC, params.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("wallet.FindOrCreate: %w", err)
+	}
+
+	if senderWallet.Balance < params.Amount {
+		return nil, transfer.ErrInsufficientBalance
+	}
+
+	// checked before anything is stored so a transfer to a user without a
+	// wallet fails early
+	recipientWallet, err := wallet.FindOrCreate(ctx, u.clientBalanceGRPC, recipient.Id)
+	if err != nil {
+		return nil, fmt.Errorf("wallet.FindOrCreate: %w", err)
+	}
+
+	now := time.Now()
+
+	t := transfer.Transfer{
+		Id:          uuid.NewString(),
+		SenderId:    params.UserId,
+		RecipientId: recipient.Id,
+		Amount:      params.Amount,
+		Description: params.Description,
+		Status:      transfer.StatusProcessing,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	var code string
+
+	needsCode := u.cfg.ConfirmationMinAmount > 0 && params.Amount >= u.cfg.ConfirmationMinAmount
+	if needsCode {
+		code, t.ConfirmationHash, err = transfer.NewConfirmationCode()
+		if err != nil {
+			return nil, err
+		}
+
+		expires := now.Add(u.cfg.ConfirmationTTL)
+		t.ConfirmationExpires = &expires
+		t.Status = transfer.StatusAwaitingConfirmation
+	}
+
+	limits := transfer.Limits{
+		Amount: u.cfg.DailyLimit,
+		Count:  u.cfg.DailyCount,
+		Since:  now.Add(-transfer.LimitWindow),
+	}
+
+	if err := u.repo.Create(ctx, &t, &limits); err != nil {
+		if errors.Is(err, transfer.ErrDailyLimitExceeded) || errors.Is(err, transfer.ErrDailyCountExceeded) {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("u.repo.Create: %w", err)
+	}
+
+	if !needsCode {
+		return u.execute(ctx, &t, senderWallet, recipientWallet)
+	}
+
+	if err := u.sendCode(ctx, &t, code, recipient); err != nil {
+		u.fail(ctx, &t, transfer.StatusAwaitingConfirmation, err)
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func (u *transferUC) Confirm(ctx context.Context, params *transfer.ParamsConfirmTransferInput) (*transfer.Transfer, error) {
+	t, err := u.repo.Find(ctx, params.TransferId)
+	if err != nil {
+		return nil, err
+	}
+
+	// nobody gets to know whether other people's transfers exist
+	if t.SenderId != params.UserId {
+		return nil, transfer.ErrTransferNotFound
+	}
+
+	if t.Status != transfer.StatusAwaitingConfirmation {
+		return nil, transfer.ErrTransferNotPending
+	}
+
+	if t.ConfirmationExpires != nil && time.Now().After(*t.ConfirmationExpires) {
+		if err := u.repo.ChangeStatus(ctx, t.Id, transfer.StatusAwaitingConfirmation, transfer.StatusExpired); err != nil &&
+			!errors.Is(err, transfer.ErrTransferNotPending) {
+			u.logger.Errorf("u.repo.ChangeStatus: transfer %s: %v", t.Id, err)
+		}
+
+		return nil, transfer.ErrConfirmationExpired
+	}
+
+	if t.ConfirmationAttempts >= u.cfg.ConfirmationMaxAttempts {
+		return nil, transfer.ErrTooManyAttempts
+	}
+
+	hash := transfer.HashConfirmationCode(params.Code)
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(t.ConfirmationHash)) != 1 {
+		return nil, u.wrongCode(ctx, t)
+	}
+
+	// two confirmations racing each other move the money once
+	if err := u.repo.ChangeStatus(ctx, t.Id, transfer.StatusAwaitingConfirmation, transfer.StatusProcessing); err != nil {
+		if errors.Is(err, transfer.ErrTransferNotPending) {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("u.repo.ChangeStatus: %w", err)
+	}
+
+	t.Status = transfer.StatusProcessing
+
+	senderWallet, err := wallet.FindOrCreate(ctx, u.clientBalanceGRPC, t.SenderId)
+	if err != nil {
+		u.fail(ctx, t, transfer.StatusProcessing, err)
+		return nil, err
+	}
+
+	recipientWallet, err := wallet.FindOrCreate(ctx, u.clientBalanceGRPC, t.RecipientId)
+	if err != nil {
+		u.fail(ctx, t, transfer.StatusProcessing, err)
+		return nil, err
+	}
+
+	return u.execute(ctx, t, senderWallet, recipientWallet)
+}
+
+func (u *transferUC) wrongCode(ctx context.Context, t *transfer.Transfer) error {
+	attempts, err := u.repo.AddConfirmationAttempt(ctx, t.Id)
+	if err != nil {
+		return fmt.Errorf("u.repo.AddConfirmationAttempt: %w", err)
+	}
+
+	if attempts < u.cfg.ConfirmationMaxAttempts {
+		return transfer.ErrConfirmationCodeInvalid
+	}
+
+	t.ConfirmationAttempts = attempts
+	u.fail(ctx, t, transfer.StatusAwaitingConfirmation, transfer.ErrTooManyAttempts)
+
+	return transfer.ErrTooManyAttempts
+}
+
+// execute moves the money in a saga: the sender is debited and both orders
+// are created, each step undone by the saga worker if a later one fails. The
+// recipient is credited once nothing can fail the transfer anymore; a
+// failing credit is handed to the worker to retry instead.
+func (u *transferUC) execute(ctx context.Context, t *transfer.Transfer, senderWallet *protoBalance.ParamgGetWalletByAccountResponse,
+	recipientWallet *protoBalance.ParamgGetWalletByAccountResponse) (*transfer.Transfer, error) {
+	saga := orders.NewSaga(sagaWalletTransfer, u.workerSaga)
+
+	saga.AddStep(&orders.SagaStep{
+		Name:    "debit-sender",
+		Timeout: stepTimeout,
+		Action: func(ctx context.Context, state *orders.SagaState) error {
+			if senderWallet.Balance < t.Amount {
+				return transfer.ErrInsufficientBalance
+			}
+
+			_, err := u.clientBalanceGRPC.Debit(ctx, &protoBalance.ParamDebitWalletRequest{
+				WalletID:    senderWallet.WalletID,
+				Amount:      t.Amount,
+				ReferenceID: t.DebitReference(),
+			})
+			if err != nil {
+				return fmt.Errorf("u.clientBalanceGRPC.Debit: %w", err)
+			}
+
+			return nil
+		},
+		Compensation: orders.StepCreditWallet,
+		CompensationPayload: func(state *orders.SagaState) any {
+			return &orders.ParamsCompensateCreditWallet{
+				WalletID:    senderWallet.WalletID,
+				Amount:      t.Amount,
+				ReferenceID: "refund-" + t.DebitReference(),
+			}
+		},
+	})
+
+	saga.AddStep(u.createOrderStep(t, sagaKeySenderOrder, t.SenderId, t.RecipientId, transfer.DirectionSent, t.DebitReference()))
+	saga.AddStep(u.createOrderStep(t, sagaKeyRecipientOrder, t.RecipientId, t.SenderId, transfer.DirectionReceived, t.CreditReference()))
+
+	if err := saga.Execute(ctx); err != nil {
+		u.fail(ctx, t, transfer.StatusProcessing, err)
+		return nil, err
+	}
+
+	senderOrder, _ := orders.SagaValue[*protoOrders.Orders](saga.State(), sagaKeySenderOrder)
+	recipientOrder, _ := orders.SagaValue[*protoOrders.Orders](saga.State(), sagaKeyRecipientOrder)
+
+	u.creditRecipient(ctx, t, recipientWallet)
+
+	now := time.Now()
+
+	t.Status = transfer.StatusCompleted
+	t.SenderOrderId = senderOrder.OrderID
+	t.RecipientOrderId = recipientOrder.OrderID
+	t.CompletedAt = &now
+	t.UpdatedAt = now
+
+	// the money already moved, the record catching up later is harmless
+	if err := u.repo.Update(context.WithoutCancel(ctx), t); err != nil {
+		u.logger.Errorf("u.repo.Update: transfer %s: %v", t.Id, err)
+	}
+
+	return t, nil
+}
+
+// createOrderStep creates the order of one side of the transfer, so it shows
+// in the history of both users.
+func (u *transferUC) createOrderStep(t *transfer.Transfer, key string, accountId string, counterpartyId string,
+	direction string, gatewayTransactionId string) *orders.SagaStep {
+	return &orders.SagaStep{
+		Name:    "create-" + key,
+		Timeout: stepTimeout,
+		Action: func(ctx context.Context, state *orders.SagaState) error {
+			metadata, err := json.Marshal(transfer.OrderMetadata{
+				TransferId:     t.Id,
+				Direction:      direction,
+				CounterpartyId: counterpartyId,
+				Description:    t.Description,
+			})
+			if err != nil {
+				return fmt.Errorf("json.Marshal: %w", err)
+			}
+
+			created, err := u.clientOrdersGRPC.Create(ctx, &protoOrders.ParamCreateOrderRequest{
+				AccountID:            accountId,
+				Type:                 protoOrders.OrderType_WALLET_TRANSFER,
+				PaymentMethod:        protoOrders.PaymentMethod_INTERNAL_BALANCE,
+				Status:               protoOrders.OrderStatus_PAID,
+				Amount:               t.Amount,
+				Metadata:             metadata,
+				GatewayTransactionID: gatewayTransactionId,
+			})
+			if err != nil {
+				return fmt.Errorf("u.clientOrdersGRPC.Create: %w", err)
+			}
+
+			state.Set(key, created.Order)
+			if err := orders.IndexNewOrder(ctx, u.index, created.Order); err != nil {
+				u.logger.Errorf("orders.IndexNewOrder: %v", err)
+			}
+
+			return nil
+		},
+		Compensation: orders.StepUpdateOrderStatus,
+		CompensationPayload: func(state *orders.SagaState) any {
+			order, _ := orders.SagaValue[*protoOrders.Orders](state, key)
+
+			return &orders.ParamsCompensateOrderStatus{
+				OrderId: order.OrderID,
+				Status:  protoOrders.OrderStatus_REFUNDED.String(),
+				Reason:  "transfer rolled back",
+			}
+		},
+	}
+}
+
+func (u *transferUC) creditRecipient(ctx context.Context, t *transfer.Transfer, recipientWallet *protoBalance.ParamgGetWalletByAccountResponse) {
+	credit := orders.ParamsCompensateCreditWallet{
+		WalletID:    recipientWallet.WalletID,
+		Amount:      t.Amount,
+		ReferenceID: t.CreditReference(),
+	}
+
+	_, err := u.clientBalanceGRPC.Credit(ctx, &protoBalance.ParamCreditWalletRequest{
+		WalletID:    credit.WalletID,
+		Amount:      credit.Amount,
+		ReferenceID: credit.ReferenceID,
+	})
+	if err == nil {
+		return
+	}
+
+	u.logger.Errorf("u.clientBalanceGRPC.Credit: transfer %s: %v", t.Id, err)
+
+	task := orders.NewCompensationTask(err)
+	if err := task.AddStep(orders.StepCreditWallet, &credit); err != nil {
+		u.logger.Errorf("task.AddStep: transfer %s: %v", t.Id, err)
+		return
+	}
+
+	if err := u.workerSaga.AppendTask(context.WithoutCancel(ctx), task); err != nil {
+		u.logger.Errorf("u.workerSaga.AppendTask: transfer %s: %v", t.Id, err)
+	}
+}
+
+// fail records why the transfer did not go through. Nothing was moved, or
+// the saga worker is undoing it, so a failure to record is only logged.
+func (u *transferUC) fail(ctx context.Context, t *transfer.Transfer, from string, reason error) {
+	if err := u.repo.ChangeStatus(context.WithoutCancel(ctx), t.Id, from, transfer.StatusFailed); err != nil {
+		u.logger.Errorf("u.repo.ChangeStatus: transfer %s: %v", t.Id, err)
+		return
+	}
+
+	t.Status = transfer.StatusFailed
+	t.FailureReason = reason.Error()
+	t.UpdatedAt = time.Now()
+
+	if err := u.repo.Update(context.WithoutCancel(ctx), t); err != nil {
+		u.logger.Errorf("u.repo.Update: transfer %s: %v", t.Id, err)
+	}
+}
+
+func (u *transferUC) findRecipient(ctx context.Context, recipient string) (*protoUser.User, error) {
+	var found *protoUser.User
+
+	if _, err := uuid.Parse(recipient); err == nil {
+		resp, err := u.clientUserGRPC.FindById(ctx, &protoUser.FindByIdRequest{Id: recipient})
+		if err != nil {
+			return nil, recipientError("u.clientUserGRPC.FindById", err)
+		}
+
+		found = resp.User
+	} else {
+		resp, err := u.clientUserGRPC.FindByEmail(ctx, &protoUser.FindByEmailRequest{Email: recipient})
+		if err != nil {
+			return nil, recipientError("u.clientUserGRPC.FindByEmail", err)
+		}
+
+		found = resp.User
+	}
+
+	if found == nil || found.Id == "" {
+		return nil, transfer.ErrRecipientNotFound
+	}
+
+	return found, nil
+}
+
+func recipientError(call string, err error) error {
+	if status.Code(err) == codes.NotFound {
+		return transfer.ErrRecipientNotFound
+	}
+
+	return fmt.Errorf("%s: %w", call, err)
+}
+
+func (u *transferUC) sendCode(ctx context.Context, t *transfer.Transfer, code string, recipient *protoUser.User) error {
+	sender, err := u.clientUserGRPC.FindById(ctx, &protoUser.FindByIdRequest{Id: t.SenderId})
+	if err != nil {
+		return fmt.Errorf("u.clientUserGRPC.FindById: %w", err)
+	}
+
+	to := strings.TrimSpace(recipient.Name + " " + recipient.LastName)
+	if to == "" {
+		to = recipient.Email
+	}
+
+	body := fmt.Sprintf(transfer.DefaultBodySendCode, code, invoice.FormatAmount(t.Amount), to, int(u.cfg.ConfirmationTTL.Minutes()))
+
+	req := &protoMail.MailRequest{
+		From:        u.cfg.FromSendMail,
+		To:          sender.User.Email,
+		Subject:     transfer.DefaultSubjectSendCode,
+		Body:        body,
+		Template:    transfer.DefaultTemplateSendCode,
+		Servicename: u.cfg.ServiceNameSendMail,
+	}
+
+	if _, err := u.clientMailGRPC.SendService(ctx, req); err != nil {
+		return fmt.Errorf("u.clientMailGRPC.SendService: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,14 @@
+package transfer
+
+var (
+	DefaultSubjectSendCode  = "Confirm your transfer"
+	DefaultBodySendCode     = "Use the code %s to confirm the transfer of %s to %s. It expires in %d minutes."
+	DefaultTemplateSendCode = `
+        <div style="font-family: sans-serif; max-width: 600px; margin: 0 auto;">
+            <h2>Confirm your transfer</h2>
+            <p>%s</p>
+            <p style="margin-top: 20px; font-size: 12px; color: #666;">
+               If you did not start this transfer, change your password and do not share the code.
+            </p>
+        </div>`
+)
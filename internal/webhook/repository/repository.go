@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/webhook"
+	"github.com/redis/go-redis/v9"
+)
+
+type webhookRepository struct {
+	redis *redis.Client
+}
+
+func NewWebhookRepository(rds *redis.Client) webhook.Repository {
+	return &webhookRepository{
+		redis: rds,
+	}
+}
+
+func (r *webhookRepository) Claim(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	ok, err := r.redis.SetNX(ctx, key, time.Now().Unix(), ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("r.redis.SetNX: %w", err)
+	}
+
+	return ok, nil
+}
+
+func (r *webhookRepository) Release(ctx context.Context, key string) error {
+	if err := r.redis.Del(ctx, key).Err(); err != nil {
+		return fmt.Errorf("r.redis.Del: %w", err)
+	}
+
+	return nil
+}
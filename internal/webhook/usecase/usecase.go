@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/delivery/http/service"
+	"github.com/aclgo/simple-api-gateway/internal/webhook"
+	"github.com/aclgo/simple-api-gateway/pkg/logger"
+)
+
+type webhookUC struct {
+	cfg    webhook.Config
+	repo   webhook.Repository
+	logger logger.Logger
+}
+
+func NewWebhookUC(cfg webhook.Config, repo webhook.Repository, logger logger.Logger) webhook.Webhook {
+	if cfg.Tolerance <= 0 {
+		cfg.Tolerance = webhook.DefaultTolerance
+	}
+
+	if cfg.Secret == "" {
+		logger.Errorf("webhook %s: no secret configured, every callback will be rejected", cfg.Provider)
+	}
+
+	return &webhookUC{
+		cfg:    cfg,
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+func (u *webhookUC) Verify(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !u.allowed(r) {
+			resp := service.NewRestError(http.StatusText(http.StatusForbidden), webhook.ErrAddressNotAllowed.Error())
+			service.JSON(w, resp, http.StatusForbidden)
+			return
+		}
+
+		// the signature covers the exact bytes sent, so the body is read
+		// before anything decodes it
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, webhook.MaxBodySize))
+		if err != nil {
+			status := http.StatusBadRequest
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				status = http.StatusRequestEntityTooLarge
+			}
+
+			resp := service.NewRestError(http.StatusText(status), err.Error())
+			service.JSON(w, resp, status)
+			return
+		}
+
+		if err := webhook.VerifySignature(&u.cfg, r.Header, body, time.Now()); err != nil {
+			resp := service.NewRestError(http.StatusText(http.StatusUnauthorized), err.Error())
+			service.JSON(w, resp, http.StatusUnauthorized)
+			return
+		}
+
+		// without an event id the signature itself is unique per delivery
+		eventId := r.Header.Get(webhook.HeaderEventId)
+		if eventId == "" {
+			eventId = r.Header.Get(webhook.HeaderSignature)
+		}
+
+		key := webhook.FormatEventKeyRepository(u.cfg.Provider, eventId)
+
+		// a timestamp is accepted up to the tolerance on either side, so
+		// the event has to be remembered for twice as long
+		claimed, err := u.repo.Claim(r.Context(), key, 2*u.cfg.Tolerance)
+		if err != nil {
+			u.logger.Errorf("u.repo.Claim: webhook %s: %v", u.cfg.Provider, err)
+			resp := service.NewRestError(http.StatusText(http.StatusServiceUnavailable), "try again later")
+			service.JSON(w, resp, http.StatusServiceUnavailable)
+			return
+		}
+
+		// acknowledged so the provider stops delivering it
+		if !claimed {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(recorder, r)
+
+		// the provider retries failed deliveries, which must not be taken
+		// for replays
+		if recorder.status >= http.StatusInternalServerError {
+			if err := u.repo.Release(context.WithoutCancel(r.Context()), key); err != nil {
+				u.logger.Errorf("u.repo.Release: webhook %s: %v", u.cfg.Provider, err)
+			}
+		}
+	}
+}
+
+// allowed checks the address the connection came from; forwarding headers
+// are ignored since any caller can set them.
+func (u *webhookUC) allowed(r *http.Request) bool {
+	if len(u.cfg.AllowedIPs) == 0 {
+		return true
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+
+	addr = addr.Unmap()
+
+	for _, prefix := range u.cfg.AllowedIPs {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
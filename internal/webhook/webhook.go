@@ -0,0 +1,138 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Webhook guards the callback route of a payment provider.
+type Webhook interface {
+	// Verify lets through only requests signed with the provider secret,
+	// within the timestamp tolerance, not delivered before and coming from
+	// an allowed address.
+	Verify(next http.HandlerFunc) http.HandlerFunc
+}
+
+type Repository interface {
+	// Claim records the event and reports false when it was claimed before.
+	Claim(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	// Release forgets the event so the provider can deliver it again.
+	Release(ctx context.Context, key string) error
+}
+
+func FormatEventKeyRepository(provider string, eventId string) string {
+	return fmt.Sprintf("webhook_event:%s:%s", provider, eventId)
+}
+
+const (
+	HeaderSignature = "X-Webhook-Signature"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderEventId   = "X-Webhook-Id"
+
+	signaturePrefix = "sha256="
+
+	DefaultTolerance = 5 * time.Minute
+	MaxBodySize      = 1 << 20
+)
+
+var (
+	ErrSecretNotConfigured = errors.New("webhook secret not configured")
+	ErrMissingSignature    = errors.New("webhook signature missing")
+	ErrInvalidSignature    = errors.New("webhook signature invalid")
+	ErrInvalidTimestamp    = errors.New("webhook timestamp invalid")
+	ErrTimestampTolerance  = errors.New("webhook timestamp outside tolerance")
+	ErrAddressNotAllowed   = errors.New("webhook address not allowed")
+)
+
+// Config holds what a provider shares with us to sign its callbacks. An
+// empty AllowedIPs accepts any address.
+type Config struct {
+	Provider   string
+	Secret     string
+	Tolerance  time.Duration
+	AllowedIPs []netip.Prefix
+}
+
+// Sign returns the signature header of a callback: the hex HMAC-SHA256 of
+// the timestamp, a dot and the raw body. Binding the timestamp keeps an old
+// body from being replayed under a fresh one.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature checks the signature and timestamp headers against body.
+func VerifySignature(cfg *Config, header http.Header, body []byte, now time.Time) error {
+	if cfg.Secret == "" {
+		return ErrSecretNotConfigured
+	}
+
+	signature := header.Get(HeaderSignature)
+	if signature == "" {
+		return ErrMissingSignature
+	}
+
+	timestamp, err := strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+
+	skew := now.Sub(time.Unix(timestamp, 0))
+	if skew < 0 {
+		skew = -skew
+	}
+
+	if skew > cfg.Tolerance {
+		return ErrTimestampTolerance
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(Sign(cfg.Secret, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// ParseAllowedIPs reads a comma separated list of addresses and CIDR ranges.
+func ParseAllowedIPs(list string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		if strings.Contains(item, "/") {
+			prefix, err := netip.ParsePrefix(item)
+			if err != nil {
+				return nil, fmt.Errorf("netip.ParsePrefix: %w", err)
+			}
+
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(item)
+		if err != nil {
+			return nil, fmt.Errorf("netip.ParseAddr: %w", err)
+		}
+
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+
+	return prefixes, nil
+}
@@ -0,0 +1,161 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+const testSecret = "whsec_test"
+
+func TestVerifySignature(t *testing.T) {
+	now := time.Unix(1_767_225_600, 0)
+	body := []byte(`{"event":"payment.paid","id":"evt_1"}`)
+
+	signed := func(secret string, timestamp int64) http.Header {
+		header := http.Header{}
+		header.Set(HeaderSignature, Sign(secret, timestamp, body))
+		header.Set(HeaderTimestamp, fmt.Sprint(timestamp))
+
+		return header
+	}
+
+	tests := []struct {
+		name    string
+		secret  string
+		header  http.Header
+		body    []byte
+		wantErr error
+	}{
+		{
+			name:   "valid",
+			secret: testSecret,
+			header: signed(testSecret, now.Unix()),
+			body:   body,
+		},
+		{
+			name:   "valid at the edge of the tolerance",
+			secret: testSecret,
+			header: signed(testSecret, now.Add(-DefaultTolerance).Unix()),
+			body:   body,
+		},
+		{
+			name:   "clock of the provider slightly ahead",
+			secret: testSecret,
+			header: signed(testSecret, now.Add(time.Minute).Unix()),
+			body:   body,
+		},
+		{
+			name:    "secret not configured",
+			header:  signed(testSecret, now.Unix()),
+			body:    body,
+			wantErr: ErrSecretNotConfigured,
+		},
+		{
+			name:    "no signature",
+			secret:  testSecret,
+			header:  http.Header{HeaderTimestamp: {fmt.Sprint(now.Unix())}},
+			body:    body,
+			wantErr: ErrMissingSignature,
+		},
+		{
+			name:    "no timestamp",
+			secret:  testSecret,
+			header:  http.Header{HeaderSignature: {Sign(testSecret, now.Unix(), body)}},
+			body:    body,
+			wantErr: ErrInvalidTimestamp,
+		},
+		{
+			name:    "timestamp not a number",
+			secret:  testSecret,
+			header:  http.Header{HeaderSignature: {Sign(testSecret, now.Unix(), body)}, HeaderTimestamp: {"yesterday"}},
+			body:    body,
+			wantErr: ErrInvalidTimestamp,
+		},
+		{
+			name:    "too old",
+			secret:  testSecret,
+			header:  signed(testSecret, now.Add(-DefaultTolerance-time.Second).Unix()),
+			body:    body,
+			wantErr: ErrTimestampTolerance,
+		},
+		{
+			name:    "too far ahead",
+			secret:  testSecret,
+			header:  signed(testSecret, now.Add(DefaultTolerance+time.Second).Unix()),
+			body:    body,
+			wantErr: ErrTimestampTolerance,
+		},
+		{
+			name:    "other secret",
+			secret:  testSecret,
+			header:  signed("whsec_other", now.Unix()),
+			body:    body,
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "body changed",
+			secret:  testSecret,
+			header:  signed(testSecret, now.Unix()),
+			body:    []byte(`{"event":"payment.paid","id":"evt_2"}`),
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:   "old signature under a fresh timestamp",
+			secret: testSecret,
+			header: http.Header{
+				HeaderSignature: {Sign(testSecret, now.Add(-time.Hour).Unix(), body)},
+				HeaderTimestamp: {fmt.Sprint(now.Unix())},
+			},
+			body:    body,
+			wantErr: ErrInvalidSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Provider: "psp", Secret: tt.secret, Tolerance: DefaultTolerance}
+
+			if err := VerifySignature(cfg, tt.header, tt.body, now); !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifySignature = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseAllowedIPs(t *testing.T) {
+	tests := []struct {
+		name    string
+		list    string
+		want    string
+		wantErr bool
+	}{
+		{name: "empty", list: "", want: "[]"},
+		{name: "single address", list: "203.0.113.10", want: "[203.0.113.10/32]"},
+		{name: "ipv6 address", list: "2001:db8::1", want: "[2001:db8::1/128]"},
+		{name: "range masked", list: "198.51.100.77/24", want: "[198.51.100.0/24]"},
+		{
+			name: "mixed with spaces and empty items",
+			list: " 203.0.113.10 , ,198.51.100.0/24,",
+			want: "[203.0.113.10/32 198.51.100.0/24]",
+		},
+		{name: "bad address", list: "203.0.113.300", wantErr: true},
+		{name: "bad range", list: "198.51.100.0/33", wantErr: true},
+		{name: "hostname", list: "psp.example.com", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefixes, err := ParseAllowedIPs(tt.list)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseAllowedIPs(%q) err = %v, want error %v", tt.list, err, tt.wantErr)
+			}
+
+			if err == nil && fmt.Sprint(prefixes) != tt.want {
+				t.Errorf("ParseAllowedIPs(%q) = %v, want %s", tt.list, prefixes, tt.want)
+			}
+		})
+	}
+}
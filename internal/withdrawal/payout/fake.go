@@ -0,0 +1,40 @@
+package payout
+
+import (
+	"context"
+
+	"github.com/aclgo/simple-api-gateway/internal/withdrawal"
+	"github.com/aclgo/simple-api-gateway/pkg/logger"
+)
+
+type fakeProvider struct {
+	logger logger.Logger
+}
+
+// NewFakeProvider pays every withdrawal without moving any money, for local
+// environments without a PSP.
+func NewFakeProvider(logger logger.Logger) withdrawal.PayoutProvider {
+	return &fakeProvider{
+		logger: logger,
+	}
+}
+
+func (p *fakeProvider) Payout(ctx context.Context, params *withdrawal.PayoutInput) (*withdrawal.PayoutOutput, error) {
+	p.logger.Infof("fake payout: withdrawal %s: %d to %s key %s", params.Id, params.Amount, params.PixKeyType, params.PixKey)
+
+	out := withdrawal.PayoutOutput{
+		PayoutId: "fake-" + params.Id,
+		Status:   withdrawal.PayoutDone,
+	}
+
+	return &out, nil
+}
+
+func (p *fakeProvider) Status(ctx context.Context, id string) (*withdrawal.PayoutOutput, error) {
+	out := withdrawal.PayoutOutput{
+		PayoutId: "fake-" + id,
+		Status:   withdrawal.PayoutDone,
+	}
+
+	return &out, nil
+}
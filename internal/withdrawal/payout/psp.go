@@ -0,0 +1,178 @@
+package payout
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync/atomic"
+
+	"github.com/aclgo/simple-api-gateway/internal/payment/pix"
+	"github.com/aclgo/simple-api-gateway/internal/withdrawal"
+)
+
+var ErrPSPResponse = errors.New("unexpected response from payout provider")
+
+type pspProvider struct {
+	config withdrawal.PayoutConfig
+	client *http.Client
+}
+
+// NewPSPProvider sends the payouts through the pix API of the PSP holding
+// the account of the gateway.
+func NewPSPProvider(config withdrawal.PayoutConfig) withdrawal.PayoutProvider {
+	if config.Timeout <= 0 {
+		config.Timeout = withdrawal.DefaultPayoutTimeout
+	}
+
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+
+	return &pspProvider{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+	}
+}
+
+// Payout puts the pix under the id of the withdrawal, so a payout retried
+// after a timeout is not sent twice; a conflict means it was sent before and
+// is looked up instead. Keys the PSP refuses come back as
+// withdrawal.ErrPayoutRejected. Only errors before the PSP took the request
+// are withdrawal.ErrPayoutUnavailable, the rest may have paid and are
+// withdrawal.ErrPayoutOutcomeUnknown.
+func (p *pspProvider) Payout(ctx context.Context, params *withdrawal.PayoutInput) (*withdrawal.PayoutOutput, error) {
+	body := withdrawal.PayoutRequest{
+		Value:       pix.FormatAmount(params.Amount),
+		Key:         params.PixKey,
+		Description: params.Description,
+	}
+
+	payload, err := json.Marshal(&body)
+	if err != nil {
+		return nil, fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, p.url(params.Id), bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("http.NewRequestWithContext: %w", err)
+	}
+
+	status, respBody, err := p.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case status == http.StatusConflict:
+		// the id was used before: the payout was sent by an earlier attempt
+		sent, err := p.Status(ctx, params.Id)
+		if err != nil {
+			return nil, fmt.Errorf("%w: p.Status: %w", withdrawal.ErrPayoutOutcomeUnknown, err)
+		}
+
+		return sent, nil
+	case status == http.StatusBadRequest, status == http.StatusNotFound, status == http.StatusUnprocessableEntity:
+		return nil, fmt.Errorf("%w: status %d: %s", withdrawal.ErrPayoutRejected, status, respBody)
+	}
+
+	sent, err := decodePayout(status, respBody)
+	if err != nil {
+		return nil, err
+	}
+
+	if sent.Status == withdrawal.PayoutNotDone {
+		return nil, fmt.Errorf("%w: payout status %q", withdrawal.ErrPayoutRejected, sent.Status)
+	}
+
+	return sent, nil
+}
+
+// Status gets the pix sent under id.
+func (p *pspProvider) Status(ctx context.Context, id string) (*withdrawal.PayoutOutput, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url(id), nil)
+	if err != nil {
+		return nil, fmt.Errorf("http.NewRequestWithContext: %w", err)
+	}
+
+	status, respBody, err := p.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if status == http.StatusNotFound {
+		return nil, withdrawal.ErrPayoutNotFound
+	}
+
+	return decodePayout(status, respBody)
+}
+
+// url is the address of the pix sent under the id of a withdrawal. The PSP
+// only takes alphanumerics as id.
+func (p *pspProvider) url(id string) string {
+	return p.config.BaseURL + "/v2/pix/" + strings.ReplaceAll(id, "-", "")
+}
+
+// do sends the request and reads the answer. A request that never left, a
+// 429 or an empty 503 mean the PSP did not take it; any other failure, a
+// timeout or a 5xx, may come after it did.
+func (p *pspProvider) do(req *http.Request) (int, []byte, error) {
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", p.config.Authorization))
+
+	var sent atomic.Bool
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		WroteRequest: func(info httptrace.WroteRequestInfo) {
+			if info.Err == nil {
+				sent.Store(true)
+			}
+		},
+	}))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		if !sent.Load() {
+			return 0, nil, fmt.Errorf("%w: %v", withdrawal.ErrPayoutUnavailable, err)
+		}
+
+		return 0, nil, fmt.Errorf("%w: %v", withdrawal.ErrPayoutOutcomeUnknown, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %v", withdrawal.ErrPayoutOutcomeUnknown, err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests,
+		resp.StatusCode == http.StatusServiceUnavailable && len(bytes.TrimSpace(respBody)) == 0:
+		return 0, nil, fmt.Errorf("%w: status %d", withdrawal.ErrPayoutUnavailable, resp.StatusCode)
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return 0, nil, fmt.Errorf("%w: status %d", withdrawal.ErrPayoutOutcomeUnknown, resp.StatusCode)
+	}
+
+	return resp.StatusCode, respBody, nil
+}
+
+func decodePayout(status int, body []byte) (*withdrawal.PayoutOutput, error) {
+	if status != http.StatusOK && status != http.StatusCreated {
+		return nil, fmt.Errorf("%w: %w: status %d: %s", withdrawal.ErrPayoutOutcomeUnknown, ErrPSPResponse, status, body)
+	}
+
+	var sent withdrawal.PayoutResponse
+
+	if err := json.Unmarshal(body, &sent); err != nil {
+		return nil, fmt.Errorf("%w: %w: %v", withdrawal.ErrPayoutOutcomeUnknown, ErrPSPResponse, err)
+	}
+
+	out := withdrawal.PayoutOutput{
+		PayoutId: sent.EndToEndId,
+		Status:   sent.Status,
+	}
+
+	return &out, nil
+}
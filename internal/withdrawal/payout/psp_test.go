@@ -0,0 +1,197 @@
+package payout
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aclgo/simple-api-gateway/internal/withdrawal"
+)
+
+const (
+	testWithdrawalId = "0b6e4c3a-5f0e-4a8e-9d7b-2f1c3e4d5a6b"
+	testPixPath      = "/v2/pix/0b6e4c3a5f0e4a8e9d7b2f1c3e4d5a6b"
+)
+
+type pspReply struct {
+	status int
+	body   string
+}
+
+// newPSP answers PUT and GET on the pix of the test withdrawal with the
+// given replies and counts the calls of each method.
+func newPSP(t *testing.T, put pspReply, get pspReply) (withdrawal.PayoutProvider, map[string]int) {
+	t.Helper()
+
+	calls := make(map[string]int)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != testPixPath {
+			t.Errorf("unexpected call to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		calls[r.Method]++
+
+		reply := get
+		if r.Method == http.MethodPut {
+			reply = put
+		}
+
+		w.WriteHeader(reply.status)
+		io.WriteString(w, reply.body)
+	}))
+	t.Cleanup(server.Close)
+
+	return NewPSPProvider(withdrawal.PayoutConfig{BaseURL: server.URL + "/", Authorization: "token"}), calls
+}
+
+func TestPSPPayout(t *testing.T) {
+	tests := []struct {
+		name       string
+		put        pspReply
+		get        pspReply
+		wantStatus string
+		wantErr    error
+		wantGets   int
+	}{
+		{
+			name:       "done",
+			put:        pspReply{http.StatusCreated, `{"e2eId":"E1","status":"REALIZADO"}`},
+			wantStatus: withdrawal.PayoutDone,
+		},
+		{
+			name:       "still processing",
+			put:        pspReply{http.StatusCreated, `{"e2eId":"E1","status":"EM_PROCESSAMENTO"}`},
+			wantStatus: withdrawal.PayoutProcessing,
+		},
+		{
+			name:    "not done",
+			put:     pspReply{http.StatusCreated, `{"e2eId":"E1","status":"NAO_REALIZADO"}`},
+			wantErr: withdrawal.ErrPayoutRejected,
+		},
+		{
+			name:    "key refused",
+			put:     pspReply{http.StatusUnprocessableEntity, `{"detail":"chave inexistente"}`},
+			wantErr: withdrawal.ErrPayoutRejected,
+		},
+		{
+			name:       "conflict looks the payout up",
+			put:        pspReply{http.StatusConflict, `{"detail":"id em uso"}`},
+			get:        pspReply{http.StatusOK, `{"e2eId":"E1","status":"REALIZADO"}`},
+			wantStatus: withdrawal.PayoutDone,
+			wantGets:   1,
+		},
+		{
+			name:     "conflict without an answer to the lookup",
+			put:      pspReply{http.StatusConflict, `{"detail":"id em uso"}`},
+			get:      pspReply{http.StatusInternalServerError, ``},
+			wantErr:  withdrawal.ErrPayoutOutcomeUnknown,
+			wantGets: 1,
+		},
+		{
+			name:    "server error",
+			put:     pspReply{http.StatusInternalServerError, `{"detail":"erro interno"}`},
+			wantErr: withdrawal.ErrPayoutOutcomeUnknown,
+		},
+		{
+			name:    "turned away",
+			put:     pspReply{http.StatusServiceUnavailable, ``},
+			wantErr: withdrawal.ErrPayoutUnavailable,
+		},
+		{
+			name:    "rate limited",
+			put:     pspReply{http.StatusTooManyRequests, ``},
+			wantErr: withdrawal.ErrPayoutUnavailable,
+		},
+		{
+			name:    "unreadable answer",
+			put:     pspReply{http.StatusCreated, `<html>`},
+			wantErr: withdrawal.ErrPayoutOutcomeUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, calls := newPSP(t, tt.put, tt.get)
+
+			sent, err := provider.Payout(context.Background(), &withdrawal.PayoutInput{
+				Id:         testWithdrawalId,
+				PixKey:     "pix@example.com",
+				PixKeyType: withdrawal.KeyTypeEmail,
+				Amount:     1000,
+			})
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("Payout: %v", err)
+				}
+
+				if sent.Status != tt.wantStatus || sent.PayoutId != "E1" {
+					t.Errorf("payout = %+v, want E1 %s", sent, tt.wantStatus)
+				}
+			}
+
+			if calls[http.MethodPut] != 1 || calls[http.MethodGet] != tt.wantGets {
+				t.Errorf("calls = %v, want 1 PUT and %d GET", calls, tt.wantGets)
+			}
+		})
+	}
+}
+
+func TestPSPStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		get        pspReply
+		wantStatus string
+		wantErr    error
+	}{
+		{name: "done", get: pspReply{http.StatusOK, `{"e2eId":"E1","status":"REALIZADO"}`}, wantStatus: withdrawal.PayoutDone},
+		{name: "not done", get: pspReply{http.StatusOK, `{"e2eId":"E1","status":"NAO_REALIZADO"}`}, wantStatus: withdrawal.PayoutNotDone},
+		{name: "never sent", get: pspReply{http.StatusNotFound, `{"detail":"nao encontrado"}`}, wantErr: withdrawal.ErrPayoutNotFound},
+		{name: "server error", get: pspReply{http.StatusBadGateway, ``}, wantErr: withdrawal.ErrPayoutOutcomeUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, _ := newPSP(t, pspReply{}, tt.get)
+
+			sent, err := provider.Status(context.Background(), testWithdrawalId)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Status: %v", err)
+			}
+
+			if sent.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", sent.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestPSPPayoutNeverSent(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	provider := NewPSPProvider(withdrawal.PayoutConfig{BaseURL: server.URL})
+
+	_, err := provider.Payout(context.Background(), &withdrawal.PayoutInput{Id: testWithdrawalId, Amount: 1000})
+	if !errors.Is(err, withdrawal.ErrPayoutUnavailable) || errors.Is(err, withdrawal.ErrPayoutOutcomeUnknown) {
+		t.Fatalf("err = %v, want only %v", err, withdrawal.ErrPayoutUnavailable)
+	}
+}
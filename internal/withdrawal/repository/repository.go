@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/withdrawal"
+	"github.com/jmoiron/sqlx"
+)
+
+type withdrawalRepository struct {
+	db *sqlx.DB
+}
+
+func NewWithdrawalRepository(db *sqlx.DB) withdrawal.Repository {
+	return &withdrawalRepository{
+		db: db,
+	}
+}
+
+const withdrawalColumns = `id, account_id, wallet_id, amount, pix_key, pix_key_type, status, order_id,
+	payout_id, reviewed_by, reason, reviewed_at, created_at, updated_at`
+
+type withdrawalRow struct {
+	Id         string       `db:"id"`
+	AccountId  string       `db:"account_id"`
+	WalletId   string       `db:"wallet_id"`
+	Amount     int64        `db:"amount"`
+	PixKey     string       `db:"pix_key"`
+	PixKeyType string       `db:"pix_key_type"`
+	Status     string       `db:"status"`
+	OrderId    string       `db:"order_id"`
+	PayoutId   string       `db:"payout_id"`
+	ReviewedBy string       `db:"reviewed_by"`
+	Reason     string       `db:"reason"`
+	ReviewedAt sql.NullTime `db:"reviewed_at"`
+	CreatedAt  time.Time    `db:"created_at"`
+	UpdatedAt  time.Time    `db:"updated_at"`
+}
+
+func (r *withdrawalRow) toWithdrawal() *withdrawal.Withdrawal {
+	w := withdrawal.Withdrawal{
+		Id:         r.Id,
+		AccountId:  r.AccountId,
+		WalletId:   r.WalletId,
+		Amount:     r.Amount,
+		PixKey:     r.PixKey,
+		PixKeyType: r.PixKeyType,
+		Status:     r.Status,
+		OrderId:    r.OrderId,
+		PayoutId:   r.PayoutId,
+		ReviewedBy: r.ReviewedBy,
+		Reason:     r.Reason,
+		CreatedAt:  r.CreatedAt,
+		UpdatedAt:  r.UpdatedAt,
+	}
+
+	if r.ReviewedAt.Valid {
+		w.ReviewedAt = &r.ReviewedAt.Time
+	}
+
+	return &w
+}
+
+func nullTime(value *time.Time) sql.NullTime {
+	if value == nil {
+		return sql.NullTime{}
+	}
+
+	return sql.NullTime{Time: *value, Valid: true}
+}
+
+func (r *withdrawalRepository) Create(ctx context.Context, w *withdrawal.Withdrawal) error {
+	const query = `INSERT INTO wallet_withdrawals (id, account_id, wallet_id, amount, pix_key, pix_key_type,
+	status, order_id, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		w.Id,
+		w.AccountId,
+		w.WalletId,
+		w.Amount,
+		w.PixKey,
+		w.PixKeyType,
+		w.Status,
+		w.OrderId,
+		w.CreatedAt,
+		w.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("r.db.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (r *withdrawalRepository) Find(ctx context.Context, id string) (*withdrawal.Withdrawal, error) {
+	query := `SELECT ` + withdrawalColumns + ` FROM wallet_withdrawals WHERE id = $1`
+
+	var row withdrawalRow
+
+	if err := r.db.GetContext(ctx, &row, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, withdrawal.ErrWithdrawalNotFound
+		}
+
+		return nil, fmt.Errorf("r.db.GetContext: %w", err)
+	}
+
+	return row.toWithdrawal(), nil
+}
+
+func (r *withdrawalRepository) ChangeStatus(ctx context.Context, id string, from string, to string) error {
+	const query = `UPDATE wallet_withdrawals SET status = $1, updated_at = NOW() WHERE id = $2 AND status = $3`
+
+	result, err := r.db.ExecContext(ctx, query, to, id, from)
+	if err != nil {
+		return fmt.Errorf("r.db.ExecContext: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("result.RowsAffected: %w", err)
+	}
+
+	if affected == 0 {
+		return withdrawal.ErrWithdrawalNotPending
+	}
+
+	return nil
+}
+
+func (r *withdrawalRepository) Update(ctx context.Context, w *withdrawal.Withdrawal) error {
+	const query = `UPDATE wallet_withdrawals SET status = $1, payout_id = $2, reviewed_by = $3, reason = $4,
+	reviewed_at = $5, updated_at = $6
+	WHERE id = $7`
+
+	_, err := r.db.ExecContext(ctx, query,
+		w.Status,
+		w.PayoutId,
+		w.ReviewedBy,
+		w.Reason,
+		nullTime(w.ReviewedAt),
+		w.UpdatedAt,
+		w.Id,
+	)
+	if err != nil {
+		return fmt.Errorf("r.db.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (r *withdrawalRepository) List(ctx context.Context, params *withdrawal.ParamsListInput) ([]*withdrawal.Withdrawal, int, error) {
+	const countQuery = `SELECT count(*) FROM wallet_withdrawals WHERE status = $1`
+
+	var total int
+
+	if err := r.db.GetContext(ctx, &total, countQuery, params.Status); err != nil {
+		return nil, 0, fmt.Errorf("r.db.GetContext: %w", err)
+	}
+
+	query := `SELECT ` + withdrawalColumns + ` FROM wallet_withdrawals
+	WHERE status = $1 ORDER BY created_at ASC LIMIT $2 OFFSET $3`
+
+	var rows []withdrawalRow
+
+	offset := (params.PageInt - 1) * params.LimitInt
+
+	if err := r.db.SelectContext(ctx, &rows, query, params.Status, params.LimitInt, offset); err != nil {
+		return nil, 0, fmt.Errorf("r.db.SelectContext: %w", err)
+	}
+
+	withdrawals := make([]*withdrawal.Withdrawal, 0, len(rows))
+
+	for i := range rows {
+		withdrawals = append(withdrawals, rows[i].toWithdrawal())
+	}
+
+	return withdrawals, total, nil
+}
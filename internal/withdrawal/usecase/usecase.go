@@ -0,0 +1,456 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/orders"
+	"github.com/aclgo/simple-api-gateway/internal/payment/wallet"
+	"github.com/aclgo/simple-api-gateway/internal/withdrawal"
+	"github.com/aclgo/simple-api-gateway/pkg/logger"
+	protoBalance "github.com/aclgo/simple-api-gateway/proto-service/balance"
+	protoOrders "github.com/aclgo/simple-api-gateway/proto-service/orders"
+	"github.com/google/uuid"
+)
+
+const (
+	sagaWalletWithdrawal = "wallet-withdrawal"
+	stepTimeout          = 10 * time.Second
+
+	sagaKeyOrder = "order"
+)
+
+type withdrawalUC struct {
+	cfg               withdrawal.Config
+	repo              withdrawal.Repository
+	payout            withdrawal.PayoutProvider
+	clientBalanceGRPC protoBalance.WalletServiceClient
+	clientOrdersGRPC  protoOrders.ServiceOrderClient
+	status            orders.StatusMachine
+	workerSaga        orders.SagaWorker
+	index             orders.IndexRepository
+	logger            logger.Logger
+}
+
+// NewWithdrawalUC relies on the compensations the orders use case registers
+// on workerSaga to give the funds back and roll the order back.
+func NewWithdrawalUC(cfg withdrawal.Config, repo withdrawal.Repository, payout withdrawal.PayoutProvider,
+	clientBalanceGRPC protoBalance.WalletServiceClient, clientOrdersGRPC protoOrders.ServiceOrderClient,
+	status orders.StatusMachine, workerSaga orders.SagaWorker, index orders.IndexRepository,
+	logger logger.Logger) withdrawal.UseCase {
+	if cfg.MinAmount <= 0 {
+		cfg.MinAmount = withdrawal.DefaultMinAmount
+	}
+
+	if cfg.MaxAmount <= 0 {
+		cfg.MaxAmount = withdrawal.DefaultMaxAmount
+	}
+
+	return &withdrawalUC{
+		cfg:               cfg,
+		repo:              repo,
+		payout:            payout,
+		clientBalanceGRPC: clientBalanceGRPC,
+		clientOrdersGRPC:  clientOrdersGRPC,
+		status:            status,
+		workerSaga:        workerSaga,
+		index:             index,
+		logger:            logger,
+	}
+}
+
+// Request debits the wallet and creates the PENDING order of the withdrawal
+// in a saga, so the funds are held while it waits in the review queue.
+func (u *withdrawalUC) Request(ctx context.Context, params *withdrawal.ParamsRequestInput) (*withdrawal.Withdrawal, error) {
+	if params.Amount < u.cfg.MinAmount || params.Amount > u.cfg.MaxAmount {
+		return nil, withdrawal.ErrAmountOutOfRange
+	}
+
+	wlt, err := wallet.FindOrCreate(ctx, u.clientBalanceGRPC, params.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("wallet.FindOrCreate: %w", err)
+	}
+
+	if wlt.Balance < params.Amount {
+		return nil, withdrawal.ErrInsufficientBalance
+	}
+
+	now := time.Now()
+
+	w := withdrawal.Withdrawal{
+		Id:         uuid.NewString(),
+		AccountId:  params.UserId,
+		WalletId:   wlt.WalletID,
+		Amount:     params.Amount,
+		PixKey:     params.PixKey,
+		PixKeyType: params.PixKeyType,
+		Status:     withdrawal.StatusPending,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	saga := orders.NewSaga(sagaWalletWithdrawal, u.workerSaga)
+
+	saga.AddStep(&orders.SagaStep{
+		Name:    "debit-wallet",
+		Timeout: stepTimeout,
+		Action: func(ctx context.Context, state *orders.SagaState) error {
+			_, err := u.clientBalanceGRPC.Debit(ctx, &protoBalance.ParamDebitWalletRequest{
+				WalletID:    w.WalletId,
+				Amount:      w.Amount,
+				ReferenceID: w.DebitReference(),
+			})
+			if err != nil {
+				return fmt.Errorf("u.clientBalanceGRPC.Debit: %w", err)
+			}
+
+			return nil
+		},
+		Compensation: orders.StepCreditWallet,
+		CompensationPayload: func(state *orders.SagaState) any {
+			return &orders.ParamsCompensateCreditWallet{
+				WalletID:    w.WalletId,
+				Amount:      w.Amount,
+				ReferenceID: w.CreditReference(),
+			}
+		},
+	})
+
+	saga.AddStep(&orders.SagaStep{
+		Name:    "create-order",
+		Timeout: stepTimeout,
+		Action: func(ctx context.Context, state *orders.SagaState) error {
+			metadata, err := json.Marshal(withdrawal.OrderMetadata{
+				WithdrawalId: w.Id,
+				PixKey:       w.PixKey,
+				PixKeyType:   w.PixKeyType,
+			})
+			if err != nil {
+				return fmt.Errorf("json.Marshal: %w", err)
+			}
+
+			created, err := u.clientOrdersGRPC.Create(ctx, &protoOrders.ParamCreateOrderRequest{
+				AccountID:            w.AccountId,
+				Type:                 protoOrders.OrderType_WITHDRAWAL,
+				PaymentMethod:        protoOrders.PaymentMethod_INTERNAL_BALANCE,
+				Status:               protoOrders.OrderStatus_PENDING,
+				Amount:               w.Amount,
+				Metadata:             metadata,
+				GatewayTransactionID: w.DebitReference(),
+			})
+			if err != nil {
+				return fmt.Errorf("u.clientOrdersGRPC.Create: %w", err)
+			}
+
+			state.Set(sagaKeyOrder, created.Order)
+			if err := orders.IndexNewOrder(ctx, u.index, created.Order); err != nil {
+				u.logger.Errorf("orders.IndexNewOrder: %v", err)
+			}
+
+			return nil
+		},
+		Compensation: orders.StepUpdateOrderStatus,
+		CompensationPayload: func(state *orders.SagaState) any {
+			order, _ := orders.SagaValue[*protoOrders.Orders](state, sagaKeyOrder)
+
+			return &orders.ParamsCompensateOrderStatus{
+				OrderId: order.OrderID,
+				Status:  protoOrders.OrderStatus_FAILED.String(),
+				Reason:  "withdrawal rolled back",
+			}
+		},
+	})
+
+	saga.AddStep(&orders.SagaStep{
+		Name:    "save-withdrawal",
+		Timeout: stepTimeout,
+		Action: func(ctx context.Context, state *orders.SagaState) error {
+			order, _ := orders.SagaValue[*protoOrders.Orders](state, sagaKeyOrder)
+			w.OrderId = order.OrderID
+
+			if err := u.repo.Create(ctx, &w); err != nil {
+				return fmt.Errorf("u.repo.Create: %w", err)
+			}
+
+			return nil
+		},
+	})
+
+	if err := saga.Execute(ctx); err != nil {
+		return nil, err
+	}
+
+	return &w, nil
+}
+
+// Approve sends the payout and marks the order PAID once the PSP reports it
+// done. A payout the provider refuses gives the funds back and fails the
+// withdrawal. One that surely did not reach the provider goes back to the
+// queue to be approved again. One that may have left, or is still being
+// processed, waits in StatusPayoutUnknown for Settle, out of the reach of
+// Reject.
+func (u *withdrawalUC) Approve(ctx context.Context, params *withdrawal.ParamsReviewInput) (*withdrawal.Withdrawal, error) {
+	w, err := u.claim(ctx, params.WithdrawalId, withdrawal.StatusProcessing)
+	if err != nil {
+		return nil, err
+	}
+
+	sent, err := u.payout.Payout(ctx, &withdrawal.PayoutInput{
+		Id:          w.Id,
+		PixKey:      w.PixKey,
+		PixKeyType:  w.PixKeyType,
+		Amount:      w.Amount,
+		Description: "withdrawal " + w.Id,
+	})
+
+	switch {
+	case errors.Is(err, withdrawal.ErrPayoutRejected):
+		u.fail(ctx, w, params.AdminId, err.Error())
+
+		return nil, err
+	case errors.Is(err, withdrawal.ErrPayoutUnavailable) && !errors.Is(err, withdrawal.ErrPayoutOutcomeUnknown):
+		if err := u.repo.ChangeStatus(context.WithoutCancel(ctx), w.Id, withdrawal.StatusProcessing, withdrawal.StatusPending); err != nil {
+			u.logger.Errorf("u.repo.ChangeStatus: withdrawal %s: %v", w.Id, err)
+		}
+
+		return nil, fmt.Errorf("u.payout.Payout: %w", err)
+	case err != nil:
+		u.review(ctx, w, withdrawal.StatusPayoutUnknown, params.AdminId, err.Error())
+
+		return nil, fmt.Errorf("u.payout.Payout: %w", err)
+	}
+
+	w.PayoutId = sent.PayoutId
+
+	if sent.Status != withdrawal.PayoutDone {
+		u.review(ctx, w, withdrawal.StatusPayoutUnknown, params.AdminId, "payout "+sent.Status)
+
+		return w, nil
+	}
+
+	u.pay(ctx, w, params.AdminId, params.Reason)
+
+	return w, nil
+}
+
+// Settle looks up the payout of a withdrawal whose outcome is unknown. A
+// payout done pays the withdrawal, one the PSP gave up on gives the funds
+// back, and one the PSP never got returns it to the queue. A payout still
+// being processed leaves it as it is.
+func (u *withdrawalUC) Settle(ctx context.Context, params *withdrawal.ParamsReviewInput) (*withdrawal.Withdrawal, error) {
+	w, err := u.repo.Find(ctx, params.WithdrawalId)
+	if err != nil {
+		return nil, err
+	}
+
+	// an approval in flight holds processing until its lease runs out
+	stuck := w.Status == withdrawal.StatusProcessing && time.Since(w.UpdatedAt) > withdrawal.ProcessingLease
+	if w.Status != withdrawal.StatusPayoutUnknown && !stuck {
+		return nil, withdrawal.ErrWithdrawalNotUnknown
+	}
+
+	sent, err := u.payout.Status(ctx, w.Id)
+	if errors.Is(err, withdrawal.ErrPayoutNotFound) {
+		if err := u.repo.ChangeStatus(ctx, w.Id, w.Status, withdrawal.StatusPending); err != nil {
+			return nil, u.settleError(err)
+		}
+
+		w.Status = withdrawal.StatusPending
+
+		return w, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("u.payout.Status: %w", err)
+	}
+
+	switch sent.Status {
+	case withdrawal.PayoutDone:
+		if err := u.repo.ChangeStatus(ctx, w.Id, w.Status, withdrawal.StatusPaid); err != nil {
+			return nil, u.settleError(err)
+		}
+
+		w.PayoutId = sent.PayoutId
+		u.pay(ctx, w, params.AdminId, params.Reason)
+	case withdrawal.PayoutNotDone:
+		if err := u.repo.ChangeStatus(ctx, w.Id, w.Status, withdrawal.StatusFailed); err != nil {
+			return nil, u.settleError(err)
+		}
+
+		u.fail(ctx, w, params.AdminId, "payout "+sent.Status)
+	default:
+		if w.Status == withdrawal.StatusProcessing {
+			w.PayoutId = sent.PayoutId
+			u.review(ctx, w, withdrawal.StatusPayoutUnknown, params.AdminId, "payout "+sent.Status)
+		}
+	}
+
+	return w, nil
+}
+
+// settleError tells a withdrawal settled by someone else apart from a
+// failure to record it.
+func (u *withdrawalUC) settleError(err error) error {
+	if errors.Is(err, withdrawal.ErrWithdrawalNotPending) {
+		return withdrawal.ErrWithdrawalNotUnknown
+	}
+
+	return fmt.Errorf("u.repo.ChangeStatus: %w", err)
+}
+
+// pay records a payout the PSP reported done.
+func (u *withdrawalUC) pay(ctx context.Context, w *withdrawal.Withdrawal, adminId string, reason string) {
+	u.closeOrder(ctx, w, protoOrders.OrderStatus_PAID, adminId, "withdrawal paid out")
+	u.review(ctx, w, withdrawal.StatusPaid, adminId, reason)
+}
+
+// fail gives the funds of a payout that did not happen back.
+func (u *withdrawalUC) fail(ctx context.Context, w *withdrawal.Withdrawal, adminId string, reason string) {
+	u.creditBack(ctx, w)
+	u.closeOrder(ctx, w, protoOrders.OrderStatus_FAILED, adminId, reason)
+	u.review(ctx, w, withdrawal.StatusFailed, adminId, reason)
+}
+
+// Reject gives the funds back to the wallet and cancels the order with the
+// reason of the admin.
+func (u *withdrawalUC) Reject(ctx context.Context, params *withdrawal.ParamsReviewInput) (*withdrawal.Withdrawal, error) {
+	if params.Reason == "" {
+		return nil, withdrawal.ErrReasonRequired
+	}
+
+	w, err := u.claim(ctx, params.WithdrawalId, withdrawal.StatusRejected)
+	if err != nil {
+		return nil, err
+	}
+
+	u.creditBack(ctx, w)
+	u.closeOrder(ctx, w, protoOrders.OrderStatus_CANCELLED, params.AdminId, params.Reason)
+	u.review(ctx, w, withdrawal.StatusRejected, params.AdminId, params.Reason)
+
+	return w, nil
+}
+
+func (u *withdrawalUC) List(ctx context.Context, params *withdrawal.ParamsListInput) (*withdrawal.ParamsListOutput, error) {
+	withdrawals, total, err := u.repo.List(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("u.repo.List: %w", err)
+	}
+
+	out := withdrawal.ParamsListOutput{
+		Withdrawals: withdrawals,
+		Page:        params.PageInt,
+		Limit:       params.LimitInt,
+		TotalItens:  total,
+		TotalPages:  int(math.Ceil(float64(total) / float64(params.LimitInt))),
+	}
+
+	return &out, nil
+}
+
+// claim moves a pending withdrawal to status, so two admins reviewing the
+// same one at once cannot both pay or refund it.
+func (u *withdrawalUC) claim(ctx context.Context, id string, to string) (*withdrawal.Withdrawal, error) {
+	w, err := u.repo.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if w.Status != withdrawal.StatusPending {
+		return nil, withdrawal.ErrWithdrawalNotPending
+	}
+
+	if err := u.repo.ChangeStatus(ctx, w.Id, withdrawal.StatusPending, to); err != nil {
+		if errors.Is(err, withdrawal.ErrWithdrawalNotPending) {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("u.repo.ChangeStatus: %w", err)
+	}
+
+	w.Status = to
+
+	return w, nil
+}
+
+// review records the outcome. The money already moved by then, so a failure
+// to record is only logged.
+func (u *withdrawalUC) review(ctx context.Context, w *withdrawal.Withdrawal, to string, adminId string, reason string) {
+	now := time.Now()
+
+	w.Status = to
+	w.ReviewedBy = adminId
+	w.Reason = reason
+	w.ReviewedAt = &now
+	w.UpdatedAt = now
+
+	if err := u.repo.Update(context.WithoutCancel(ctx), w); err != nil {
+		u.logger.Errorf("u.repo.Update: withdrawal %s: %v", w.Id, err)
+	}
+}
+
+// creditBack returns the held funds to the wallet, handing the credit to the
+// saga worker to retry when it fails.
+func (u *withdrawalUC) creditBack(ctx context.Context, w *withdrawal.Withdrawal) {
+	credit := orders.ParamsCompensateCreditWallet{
+		WalletID:    w.WalletId,
+		Amount:      w.Amount,
+		ReferenceID: w.CreditReference(),
+	}
+
+	_, err := u.clientBalanceGRPC.Credit(ctx, &protoBalance.ParamCreditWalletRequest{
+		WalletID:    credit.WalletID,
+		Amount:      credit.Amount,
+		ReferenceID: credit.ReferenceID,
+	})
+	if err == nil {
+		return
+	}
+
+	u.logger.Errorf("u.clientBalanceGRPC.Credit: withdrawal %s: %v", w.Id, err)
+	u.retry(ctx, w, err, orders.StepCreditWallet, &credit)
+}
+
+// closeOrder moves the order of the withdrawal to its final status, handing
+// it to the saga worker to retry when it fails.
+func (u *withdrawalUC) closeOrder(ctx context.Context, w *withdrawal.Withdrawal, to protoOrders.OrderStatus, actor string, reason string) {
+	transition := orders.ParamsStatusTransitionInput{
+		OrderId: w.OrderId,
+		To:      to,
+		Actor:   actor,
+		Reason:  reason,
+	}
+
+	_, err := u.status.Transition(context.WithoutCancel(ctx), &transition, nil)
+	if err == nil {
+		return
+	}
+
+	u.logger.Errorf("u.status.Transition: withdrawal %s: %v", w.Id, err)
+
+	if errors.Is(err, orders.ErrInvalidStatusTransition) {
+		return
+	}
+
+	u.retry(ctx, w, err, orders.StepUpdateOrderStatus, &orders.ParamsCompensateOrderStatus{
+		OrderId: w.OrderId,
+		Status:  to.String(),
+		Actor:   actor,
+		Reason:  reason,
+	})
+}
+
+func (u *withdrawalUC) retry(ctx context.Context, w *withdrawal.Withdrawal, cause error, step string, payload any) {
+	task := orders.NewCompensationTask(cause)
+	if err := task.AddStep(step, payload); err != nil {
+		u.logger.Errorf("task.AddStep: withdrawal %s: %v", w.Id, err)
+		return
+	}
+
+	if err := u.workerSaga.AppendTask(context.WithoutCancel(ctx), task); err != nil {
+		u.logger.Errorf("u.workerSaga.AppendTask: withdrawal %s: %v", w.Id, err)
+	}
+}
@@ -0,0 +1,343 @@
+package withdrawal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aclgo/simple-api-gateway/internal/payment"
+	"github.com/google/uuid"
+)
+
+const (
+	StatusPending    = "pending"
+	StatusProcessing = "processing"
+	// StatusPayoutUnknown holds a withdrawal whose payout may have left, e.g.
+	// the PSP timed out or is still processing it. Only Settle moves it on,
+	// after asking the PSP, so it is never paid or given back twice.
+	StatusPayoutUnknown = "payout_unknown"
+	StatusPaid          = "paid"
+	StatusRejected      = "rejected"
+	StatusFailed        = "failed"
+
+	KeyTypeCPF   = "cpf"
+	KeyTypeCNPJ  = "cnpj"
+	KeyTypeEmail = "email"
+	KeyTypePhone = "phone"
+	KeyTypeEVP   = "evp"
+
+	DefaultMinAmount     int64 = 100
+	DefaultMaxAmount     int64 = 500000
+	DefaultPayoutTimeout       = 30 * time.Second
+
+	// ProcessingLease is how long an approval may hold a withdrawal in
+	// processing before Settle takes it over, e.g. after a crash.
+	ProcessingLease = 5 * time.Minute
+
+	maxReasonLength = 280
+)
+
+var (
+	ErrWithdrawalNotFound   = errors.New("withdrawal not found")
+	ErrWithdrawalNotPending = errors.New("withdrawal is not pending")
+	ErrInsufficientBalance  = errors.New("wallet balance is lower than the withdrawal amount")
+	ErrAmountOutOfRange     = errors.New("withdrawal amount out of the allowed range")
+	ErrReasonRequired       = errors.New("a reason is required to reject a withdrawal")
+	ErrWithdrawalNotUnknown = errors.New("withdrawal payout is not waiting to be settled")
+	// ErrPayoutRejected is returned by a PayoutProvider that refused the
+	// payout for good, e.g. the key does not exist. Trying again does not help.
+	ErrPayoutRejected = errors.New("payout rejected by provider")
+	// ErrPayoutUnavailable is returned when the payout surely did not reach
+	// the provider, so it can be approved again or rejected.
+	ErrPayoutUnavailable = fmt.Errorf("payout %w", payment.ErrProviderUnavailable)
+	// ErrPayoutOutcomeUnknown is returned when the payout may have been sent,
+	// e.g. the provider timed out after the request left.
+	ErrPayoutOutcomeUnknown = fmt.Errorf("payout %w", payment.ErrPaymentOutcomeUnknown)
+	// ErrPayoutNotFound is returned by PayoutProvider.Status for a payout
+	// the provider never got.
+	ErrPayoutNotFound = errors.New("payout not found at provider")
+)
+
+type UseCase interface {
+	// Request holds the amount out of the wallet of the user until an admin
+	// approves or rejects the withdrawal.
+	Request(ctx context.Context, params *ParamsRequestInput) (*Withdrawal, error)
+	Approve(ctx context.Context, params *ParamsReviewInput) (*Withdrawal, error)
+	Reject(ctx context.Context, params *ParamsReviewInput) (*Withdrawal, error)
+	// Settle asks the provider how a payout in StatusPayoutUnknown, or stuck
+	// in processing past ProcessingLease, ended and pays or fails the
+	// withdrawal accordingly.
+	Settle(ctx context.Context, params *ParamsReviewInput) (*Withdrawal, error)
+	List(ctx context.Context, params *ParamsListInput) (*ParamsListOutput, error)
+}
+
+type Repository interface {
+	Create(ctx context.Context, withdrawal *Withdrawal) error
+	Find(ctx context.Context, id string) (*Withdrawal, error)
+	// ChangeStatus moves the withdrawal from one status to another, or
+	// returns ErrWithdrawalNotPending if it is no longer in from.
+	ChangeStatus(ctx context.Context, id string, from string, to string) error
+	// Update saves the outcome of the review: status, payout, reviewer and
+	// reason.
+	Update(ctx context.Context, withdrawal *Withdrawal) error
+	// List returns the withdrawals in params.Status, the oldest first, and
+	// how many there are in total.
+	List(ctx context.Context, params *ParamsListInput) ([]*Withdrawal, int, error)
+}
+
+// PayoutProvider sends money out to a pix key. Id identifies the payout at
+// the provider, so asking twice for the same one pays it once.
+type PayoutProvider interface {
+	Payout(ctx context.Context, params *PayoutInput) (*PayoutOutput, error)
+	// Status looks the payout up by the id it was sent with, or returns
+	// ErrPayoutNotFound when the provider never got it.
+	Status(ctx context.Context, id string) (*PayoutOutput, error)
+}
+
+type PayoutInput struct {
+	Id          string
+	PixKey      string
+	PixKeyType  string
+	Amount      int64
+	Description string
+}
+
+type PayoutOutput struct {
+	PayoutId string
+	Status   string
+}
+
+// PayoutConfig points the PSP payout provider at the account the payouts
+// leave from.
+type PayoutConfig struct {
+	BaseURL       string
+	Authorization string
+	Timeout       time.Duration
+}
+
+// PayoutRequest sends a pix to a key ("envio de pix") at the PSP.
+type PayoutRequest struct {
+	Value       string `json:"valor"`
+	Key         string `json:"chave"`
+	Description string `json:"infoPagador,omitempty"`
+}
+
+type PayoutResponse struct {
+	EndToEndId string `json:"e2eId"`
+	Status     string `json:"status"`
+}
+
+// Statuses of a payout at the PSP. Only PayoutDone means the money left.
+const (
+	PayoutDone       = "REALIZADO"
+	PayoutProcessing = "EM_PROCESSAMENTO"
+	PayoutNotDone    = "NAO_REALIZADO"
+)
+
+// Config bounds the amount of each withdrawal, in cents.
+type Config struct {
+	MinAmount int64
+	MaxAmount int64
+}
+
+type Withdrawal struct {
+	Id         string     `json:"withdrawal_id"`
+	AccountId  string     `json:"account_id"`
+	WalletId   string     `json:"wallet_id"`
+	Amount     int64      `json:"amount"`
+	PixKey     string     `json:"pix_key"`
+	PixKeyType string     `json:"pix_key_type"`
+	Status     string     `json:"status"`
+	OrderId    string     `json:"order_id"`
+	PayoutId   string     `json:"payout_id,omitempty"`
+	ReviewedBy string     `json:"reviewed_by,omitempty"`
+	Reason     string     `json:"reason,omitempty"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// DebitReference is the reference of the debit that holds the funds and the
+// gateway transaction id of the order, so the wallet statement finds it.
+func (w *Withdrawal) DebitReference() string {
+	return w.Id
+}
+
+// CreditReference is the reference of the credit that gives the funds back
+// when the withdrawal does not go through.
+func (w *Withdrawal) CreditReference() string {
+	return "refund-" + w.Id
+}
+
+// OrderMetadata is stored in the order of the withdrawal.
+type OrderMetadata struct {
+	WithdrawalId string `json:"withdrawal_id"`
+	PixKey       string `json:"pix_key"`
+	PixKeyType   string `json:"pix_key_type"`
+}
+
+type ParamsRequestInput struct {
+	UserId     string `json:"-"`
+	Amount     int64  `json:"amount"`
+	PixKey     string `json:"pix_key"`
+	PixKeyType string `json:"pix_key_type"`
+}
+
+func (p *ParamsRequestInput) Validate() error {
+	if p.Amount <= 0 {
+		return errors.New("amount invalid")
+	}
+
+	p.PixKeyType = strings.ToLower(strings.TrimSpace(p.PixKeyType))
+	p.PixKey = strings.TrimSpace(p.PixKey)
+
+	key, err := NormalizePixKey(p.PixKeyType, p.PixKey)
+	if err != nil {
+		return err
+	}
+
+	p.PixKey = key
+
+	return nil
+}
+
+var (
+	emailKey = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+	phoneKey = regexp.MustCompile(`^\+55\d{10,11}$`)
+)
+
+// NormalizePixKey checks the key has the format of its type and returns it
+// the way the PSPs expect: documents with digits only, emails lower case and
+// random keys (EVP) as a lower case uuid.
+func NormalizePixKey(keyType string, key string) (string, error) {
+	if key == "" {
+		return "", errors.New("pix key empty")
+	}
+
+	switch keyType {
+	case KeyTypeCPF, KeyTypeCNPJ:
+		digits := onlyDigits(key)
+
+		size := 11
+		if keyType == KeyTypeCNPJ {
+			size = 14
+		}
+
+		if len(digits) != size {
+			return "", fmt.Errorf("pix key is not a valid %s", keyType)
+		}
+
+		return digits, nil
+	case KeyTypeEmail:
+		key = strings.ToLower(key)
+
+		if len(key) > 77 || !emailKey.MatchString(key) {
+			return "", errors.New("pix key is not a valid email")
+		}
+
+		return key, nil
+	case KeyTypePhone:
+		if !phoneKey.MatchString(key) {
+			return "", errors.New("pix key is not a valid phone, use +55 with area code")
+		}
+
+		return key, nil
+	case KeyTypeEVP:
+		id, err := uuid.Parse(key)
+		if err != nil {
+			return "", errors.New("pix key is not a valid random key")
+		}
+
+		return id.String(), nil
+	}
+
+	return "", errors.New("pix key type invalid")
+}
+
+func onlyDigits(value string) string {
+	var b strings.Builder
+
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// ParamsReviewInput approves or rejects a withdrawal. A rejection needs a
+// reason, which is also recorded in the order history.
+type ParamsReviewInput struct {
+	WithdrawalId string `json:"-"`
+	AdminId      string `json:"-"`
+	Reason       string `json:"reason"`
+}
+
+func (p *ParamsReviewInput) Validate() error {
+	if _, err := uuid.Parse(p.WithdrawalId); err != nil {
+		return errors.New("invalid uuid withdrawal")
+	}
+
+	p.Reason = strings.TrimSpace(p.Reason)
+
+	if len(p.Reason) > maxReasonLength {
+		return fmt.Errorf("reason longer than %d characters", maxReasonLength)
+	}
+
+	return nil
+}
+
+type ParamsListInput struct {
+	Status   string `json:"status"`
+	Page     string `json:"page"`
+	Limit    string `json:"limit"`
+	PageInt  int
+	LimitInt int
+}
+
+// Validate defaults to the pending withdrawals, the ones waiting for review.
+func (p *ParamsListInput) Validate() error {
+	switch p.Status {
+	case "":
+		p.Status = StatusPending
+	case StatusPending, StatusProcessing, StatusPayoutUnknown, StatusPaid, StatusRejected, StatusFailed:
+	default:
+		return errors.New("status invalid")
+	}
+
+	p.PageInt = 1
+	p.LimitInt = 20
+
+	if p.Page != "" {
+		page, err := strconv.Atoi(p.Page)
+		if err != nil || page <= 0 {
+			return errors.New("page invalid")
+		}
+
+		p.PageInt = page
+	}
+
+	if p.Limit != "" {
+		limit, err := strconv.Atoi(p.Limit)
+		if err != nil || limit <= 0 || limit > 100 {
+			return errors.New("limit invalid")
+		}
+
+		p.LimitInt = limit
+	}
+
+	return nil
+}
+
+type ParamsListOutput struct {
+	Withdrawals []*Withdrawal `json:"withdrawals"`
+	Page        int           `json:"page"`
+	Limit       int           `json:"limit"`
+	TotalItens  int           `json:"total_itens"`
+	TotalPages  int           `json:"total_pages"`
+}
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS saga_compensation_tasks (
+	id             UUID PRIMARY KEY,
+	steps          JSONB NOT NULL,
+	original_error TEXT NOT NULL DEFAULT '',
+	last_error     TEXT NOT NULL DEFAULT '',
+	status         TEXT NOT NULL,
+	attempts       INTEGER NOT NULL DEFAULT 0,
+	created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_saga_compensation_tasks_status ON saga_compensation_tasks (status);
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS saga_dead_letters (
+	id              UUID PRIMARY KEY,
+	task_id         UUID NOT NULL REFERENCES saga_compensation_tasks (id),
+	step            TEXT NOT NULL,
+	payload         JSONB NOT NULL,
+	original_error  TEXT NOT NULL DEFAULT '',
+	last_error      TEXT NOT NULL DEFAULT '',
+	status          TEXT NOT NULL,
+	attempts        INTEGER NOT NULL DEFAULT 0,
+	resolved_by     TEXT NOT NULL DEFAULT '',
+	resolution_note TEXT NOT NULL DEFAULT '',
+	resolved_at     TIMESTAMPTZ,
+	created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_saga_dead_letters_status ON saga_dead_letters (status, created_at);
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS coupons (
+	id                UUID PRIMARY KEY,
+	code              TEXT NOT NULL UNIQUE,
+	kind              TEXT NOT NULL,
+	value             BIGINT NOT NULL,
+	min_amount        BIGINT NOT NULL DEFAULT 0,
+	max_uses          INTEGER NOT NULL DEFAULT 0,
+	max_uses_per_user INTEGER NOT NULL DEFAULT 0,
+	used_count        INTEGER NOT NULL DEFAULT 0,
+	plans             TEXT[] NOT NULL DEFAULT '{}',
+	products          TEXT[] NOT NULL DEFAULT '{}',
+	actions           TEXT[] NOT NULL DEFAULT '{}',
+	active            BOOLEAN NOT NULL DEFAULT TRUE,
+	starts_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	ends_at           TIMESTAMPTZ,
+	deleted_at        TIMESTAMPTZ,
+	created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS coupon_redemptions (
+	id           UUID PRIMARY KEY,
+	coupon_id    UUID NOT NULL REFERENCES coupons (id),
+	user_id      UUID NOT NULL,
+	reference_id TEXT NOT NULL,
+	discount     BIGINT NOT NULL,
+	status       TEXT NOT NULL,
+	created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_coupon_redemptions_user ON coupon_redemptions (coupon_id, user_id, status);
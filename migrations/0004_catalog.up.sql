@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS plans (
+	code          TEXT PRIMARY KEY,
+	name          TEXT NOT NULL,
+	price         BIGINT NOT NULL DEFAULT 0,
+	price_per_day BIGINT NOT NULL DEFAULT 0,
+	days          INTEGER NOT NULL DEFAULT 0,
+	active        BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO plans (code, name, price, price_per_day, days) VALUES
+	('7_days', 'Weekly', 1999, 0, 7),
+	('1_month', 'Monthly', 3499, 0, 30),
+	('1_year', 'Yearly', 28900, 0, 365),
+	('undefined', 'Custom days', 0, 199, 0)
+ON CONFLICT (code) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS top_ups (
+	amount     BIGINT PRIMARY KEY,
+	active     BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO top_ups (amount) VALUES (2500), (5000), (10000)
+ON CONFLICT (amount) DO NOTHING;
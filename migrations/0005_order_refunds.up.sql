@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS order_refunds (
+	id          UUID PRIMARY KEY,
+	order_id    UUID NOT NULL,
+	amount      BIGINT NOT NULL,
+	reason      TEXT NOT NULL DEFAULT '',
+	refunded_by TEXT NOT NULL DEFAULT '',
+	created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_refunds_order ON order_refunds (order_id, created_at);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS order_status_history (
+	id          UUID PRIMARY KEY,
+	order_id    UUID NOT NULL,
+	from_status TEXT NOT NULL,
+	to_status   TEXT NOT NULL,
+	actor       TEXT NOT NULL,
+	reason      TEXT NOT NULL DEFAULT '',
+	created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_status_history_order ON order_status_history (order_id, created_at);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS order_expirations (
+	order_id       UUID PRIMARY KEY,
+	expires_at     TIMESTAMPTZ NOT NULL,
+	reservation_id TEXT NOT NULL DEFAULT '',
+	products       TEXT[] NOT NULL DEFAULT '{}',
+	redemption_id  TEXT NOT NULL DEFAULT '',
+	created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_expirations_expires_at ON order_expirations (expires_at);
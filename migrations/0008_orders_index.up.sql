@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS orders_index (
+	order_id       UUID PRIMARY KEY,
+	account_id     UUID NOT NULL,
+	type           TEXT NOT NULL,
+	status         TEXT NOT NULL,
+	payment_method TEXT NOT NULL,
+	amount         BIGINT NOT NULL,
+	product_ids    TEXT[] NOT NULL DEFAULT '{}',
+	metadata       JSONB,
+	created_at     TIMESTAMPTZ NOT NULL,
+	updated_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_orders_index_created_at ON orders_index (created_at);
+CREATE INDEX IF NOT EXISTS idx_orders_index_account ON orders_index (account_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_orders_index_status ON orders_index (status, created_at);
+CREATE INDEX IF NOT EXISTS idx_orders_index_products ON orders_index USING GIN (product_ids);
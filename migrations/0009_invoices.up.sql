@@ -0,0 +1,26 @@
+-- a single counter row keeps invoice numbers gapless, unlike a sequence
+CREATE TABLE IF NOT EXISTS invoice_numbers (
+	id          BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+	last_number BIGINT NOT NULL DEFAULT 0
+);
+
+INSERT INTO invoice_numbers (id, last_number) VALUES (TRUE, 0) ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS invoices (
+	id                     UUID PRIMARY KEY,
+	number                 BIGINT NOT NULL UNIQUE,
+	order_id               UUID NOT NULL UNIQUE,
+	account_id             UUID NOT NULL,
+	order_type             TEXT NOT NULL,
+	payment_method         TEXT NOT NULL,
+	gateway_transaction_id TEXT NOT NULL DEFAULT '',
+	items                  JSONB NOT NULL,
+	subtotal               BIGINT NOT NULL,
+	discount               BIGINT NOT NULL DEFAULT 0,
+	total                  BIGINT NOT NULL,
+	paid_at                TIMESTAMPTZ NOT NULL,
+	issued_at              TIMESTAMPTZ NOT NULL,
+	emailed_at             TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoices_account ON invoices (account_id);
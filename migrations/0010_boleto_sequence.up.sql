@@ -0,0 +1,2 @@
+-- the "nosso número" of a boleto holds 10 digits after the agreement
+CREATE SEQUENCE IF NOT EXISTS boleto_our_number_seq MINVALUE 1 MAXVALUE 9999999999 NO CYCLE;
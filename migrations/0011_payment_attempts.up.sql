@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS payment_attempts (
+	id             UUID PRIMARY KEY,
+	reference_id   TEXT NOT NULL,
+	order_id       UUID,
+	account_id     TEXT NOT NULL DEFAULT '',
+	operation      TEXT NOT NULL,
+	method         TEXT NOT NULL,
+	provider       TEXT NOT NULL,
+	amount         BIGINT NOT NULL,
+	status         TEXT NOT NULL,
+	payment_status TEXT NOT NULL DEFAULT '',
+	failure_reason TEXT NOT NULL DEFAULT '',
+	error          TEXT NOT NULL DEFAULT '',
+	request        JSONB NOT NULL,
+	response       JSONB,
+	latency_ms     BIGINT NOT NULL DEFAULT 0,
+	created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_attempts_reference ON payment_attempts (reference_id);
+CREATE INDEX IF NOT EXISTS idx_payment_attempts_order ON payment_attempts (order_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_payment_attempts_account ON payment_attempts (account_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_payment_attempts_created_at ON payment_attempts (created_at);
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS wallet_transfers (
+	id                      UUID PRIMARY KEY,
+	sender_id               TEXT NOT NULL,
+	recipient_id            TEXT NOT NULL,
+	amount                  BIGINT NOT NULL,
+	description             TEXT NOT NULL DEFAULT '',
+	status                  TEXT NOT NULL,
+	sender_order_id         UUID,
+	recipient_order_id      UUID,
+	failure_reason          TEXT NOT NULL DEFAULT '',
+	confirmation_hash       TEXT NOT NULL DEFAULT '',
+	confirmation_attempts   INT NOT NULL DEFAULT 0,
+	confirmation_expires_at TIMESTAMPTZ,
+	completed_at            TIMESTAMPTZ,
+	created_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_at              TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_wallet_transfers_sender ON wallet_transfers (sender_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_wallet_transfers_recipient ON wallet_transfers (recipient_id, created_at);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS wallet_withdrawals (
+	id           UUID PRIMARY KEY,
+	account_id   TEXT NOT NULL,
+	wallet_id    TEXT NOT NULL,
+	amount       BIGINT NOT NULL,
+	pix_key      TEXT NOT NULL,
+	pix_key_type TEXT NOT NULL,
+	status       TEXT NOT NULL,
+	order_id     UUID NOT NULL,
+	payout_id    TEXT NOT NULL DEFAULT '',
+	reviewed_by  TEXT NOT NULL DEFAULT '',
+	reason       TEXT NOT NULL DEFAULT '',
+	reviewed_at  TIMESTAMPTZ,
+	created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_wallet_withdrawals_status ON wallet_withdrawals (status, created_at);
+CREATE INDEX IF NOT EXISTS idx_wallet_withdrawals_account ON wallet_withdrawals (account_id, created_at);
@@ -0,0 +1,9 @@
+ALTER TABLE order_expirations ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE order_expirations ADD COLUMN IF NOT EXISTS next_try_at TIMESTAMPTZ;
+
+UPDATE order_expirations SET next_try_at = expires_at WHERE next_try_at IS NULL;
+
+ALTER TABLE order_expirations ALTER COLUMN next_try_at SET NOT NULL;
+
+DROP INDEX IF EXISTS idx_order_expirations_expires_at;
+CREATE INDEX IF NOT EXISTS idx_order_expirations_next_try_at ON order_expirations (next_try_at);
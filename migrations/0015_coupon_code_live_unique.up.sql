@@ -0,0 +1,4 @@
+-- deleted coupons keep their row, so the code is only unique among the live ones
+ALTER TABLE coupons DROP CONSTRAINT IF EXISTS coupons_code_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_coupons_code_live ON coupons (code) WHERE deleted_at IS NULL;
@@ -0,0 +1,3 @@
+-- a retry records when it claimed the dead letter, so a claim left behind
+-- by a crash can be taken over once its lease is over
+ALTER TABLE saga_dead_letters ADD COLUMN IF NOT EXISTS claimed_at TIMESTAMPTZ;
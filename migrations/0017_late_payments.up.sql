@@ -0,0 +1,13 @@
+-- payments confirmed after their order was cancelled or expired, credited to
+-- the wallet of the customer instead of settling the order
+CREATE TABLE IF NOT EXISTS late_payments (
+	id                     UUID PRIMARY KEY,
+	order_id               UUID NOT NULL,
+	account_id             UUID NOT NULL,
+	gateway_transaction_id TEXT NOT NULL UNIQUE,
+	amount                 BIGINT NOT NULL,
+	reason                 TEXT NOT NULL DEFAULT '',
+	status                 TEXT NOT NULL,
+	created_at             TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	credited_at            TIMESTAMPTZ
+);
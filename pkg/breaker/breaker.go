@@ -0,0 +1,109 @@
+// Package breaker implements a circuit breaker: after a run of consecutive
+// failures calls are refused for a while, then a single trial call decides
+// whether to close the circuit again or keep it open.
+package breaker
+
+import (
+	"sync"
+	"time"
+)
+
+type State string
+
+const (
+	Closed   State = "closed"
+	Open     State = "open"
+	HalfOpen State = "half-open"
+)
+
+const (
+	DefaultFailures    = 5
+	DefaultOpenTimeout = 30 * time.Second
+)
+
+type Breaker struct {
+	failures    int
+	openTimeout time.Duration
+
+	mu          sync.Mutex
+	state       State
+	consecutive int
+	openedAt    time.Time
+	trial       bool
+}
+
+func New(failures int, openTimeout time.Duration) *Breaker {
+	if failures <= 0 {
+		failures = DefaultFailures
+	}
+
+	if openTimeout <= 0 {
+		openTimeout = DefaultOpenTimeout
+	}
+
+	return &Breaker{
+		failures:    failures,
+		openTimeout: openTimeout,
+		state:       Closed,
+	}
+}
+
+// Allow reports whether a call may go through. Once the open timeout has
+// passed it lets a single trial call through; its outcome must be reported
+// with Success or Failure.
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Closed:
+		return true
+	case Open:
+		if time.Since(b.openedAt) < b.openTimeout {
+			return false
+		}
+
+		b.state = HalfOpen
+		b.trial = true
+
+		return true
+	default:
+		// one trial at a time
+		if b.trial {
+			return false
+		}
+
+		b.trial = true
+
+		return true
+	}
+}
+
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = Closed
+	b.consecutive = 0
+	b.trial = false
+}
+
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.consecutive++
+	b.trial = false
+
+	if b.state == HalfOpen || b.consecutive >= b.failures {
+		b.state = Open
+		b.openedAt = time.Now()
+	}
+}
+
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
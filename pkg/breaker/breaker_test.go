@@ -0,0 +1,136 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+)
+
+// call is one step of a breaker run: ask Allow, then report the outcome
+// when the call went through.
+type call struct {
+	elapse    bool
+	fail      bool
+	wantAllow bool
+	wantState State
+}
+
+func TestBreaker(t *testing.T) {
+	tests := []struct {
+		name  string
+		calls []call
+	}{
+		{
+			name: "stays closed below the threshold",
+			calls: []call{
+				{fail: true, wantAllow: true, wantState: Closed},
+				{fail: true, wantAllow: true, wantState: Closed},
+				{wantAllow: true, wantState: Closed},
+				{fail: true, wantAllow: true, wantState: Closed},
+				{fail: true, wantAllow: true, wantState: Closed},
+			},
+		},
+		{
+			name: "opens after consecutive failures",
+			calls: []call{
+				{fail: true, wantAllow: true, wantState: Closed},
+				{fail: true, wantAllow: true, wantState: Closed},
+				{fail: true, wantAllow: true, wantState: Open},
+				{wantAllow: false, wantState: Open},
+			},
+		},
+		{
+			name: "trial success closes",
+			calls: []call{
+				{fail: true, wantAllow: true, wantState: Closed},
+				{fail: true, wantAllow: true, wantState: Closed},
+				{fail: true, wantAllow: true, wantState: Open},
+				{elapse: true, wantAllow: true, wantState: Closed},
+				{fail: true, wantAllow: true, wantState: Closed},
+			},
+		},
+		{
+			name: "trial failure opens again",
+			calls: []call{
+				{fail: true, wantAllow: true, wantState: Closed},
+				{fail: true, wantAllow: true, wantState: Closed},
+				{fail: true, wantAllow: true, wantState: Open},
+				{elapse: true, fail: true, wantAllow: true, wantState: Open},
+				{wantAllow: false, wantState: Open},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New(3, time.Minute)
+
+			for i, c := range tt.calls {
+				if c.elapse {
+					b.openedAt = time.Now().Add(-time.Minute)
+				}
+
+				if got := b.Allow(); got != c.wantAllow {
+					t.Fatalf("call %d: Allow = %v, want %v", i, got, c.wantAllow)
+				}
+
+				if c.wantAllow {
+					if c.fail {
+						b.Failure()
+					} else {
+						b.Success()
+					}
+				}
+
+				if got := b.State(); got != c.wantState {
+					t.Fatalf("call %d: state = %s, want %s", i, got, c.wantState)
+				}
+			}
+		})
+	}
+}
+
+func TestBreakerSingleTrial(t *testing.T) {
+	b := New(1, time.Minute)
+
+	b.Allow()
+	b.Failure()
+
+	b.openedAt = time.Now().Add(-time.Minute)
+
+	if !b.Allow() {
+		t.Fatal("trial call refused")
+	}
+
+	if b.State() != HalfOpen {
+		t.Fatalf("state = %s, want %s", b.State(), HalfOpen)
+	}
+
+	if b.Allow() {
+		t.Fatal("second call let through while the trial is running")
+	}
+}
+
+func TestNewDefaults(t *testing.T) {
+	tests := []struct {
+		name            string
+		failures        int
+		openTimeout     time.Duration
+		wantFailures    int
+		wantOpenTimeout time.Duration
+	}{
+		{name: "zero", wantFailures: DefaultFailures, wantOpenTimeout: DefaultOpenTimeout},
+		{name: "negative", failures: -1, openTimeout: -time.Second, wantFailures: DefaultFailures, wantOpenTimeout: DefaultOpenTimeout},
+		{name: "set", failures: 2, openTimeout: time.Second, wantFailures: 2, wantOpenTimeout: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New(tt.failures, tt.openTimeout)
+
+			if b.failures != tt.wantFailures || b.openTimeout != tt.wantOpenTimeout || b.State() != Closed {
+				t.Errorf("New(%d, %s) = failures %d, open timeout %s, state %s",
+					tt.failures, tt.openTimeout, b.failures, b.openTimeout, b.State())
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package postgres
+
+import (
+	"log"
+
+	"github.com/aclgo/simple-api-gateway/config"
+	"github.com/jmoiron/sqlx"
+)
+
+func NewPostgresClient(cfg *config.Config) *sqlx.DB {
+	db, err := sqlx.Connect(cfg.DbDriver, cfg.DbUrl)
+	if err != nil {
+		log.Fatalf("sqlx.Connect: %v", err)
+	}
+
+	return db
+}
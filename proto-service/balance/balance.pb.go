@@ -534,6 +534,234 @@ func (x *ParamgGetWalletByAccountResponse) GetUpdatedAT() *timestamppb.Timestamp
 	return nil
 }
 
+type ParamListTransactionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WalletID      string                 `protobuf:"bytes,1,opt,name=walletID,proto3" json:"walletID,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	From          *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=from,proto3" json:"from,omitempty"`
+	To            *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=to,proto3" json:"to,omitempty"`
+	Page          int32                  `protobuf:"varint,5,opt,name=page,proto3" json:"page,omitempty"`
+	Limit         int32                  `protobuf:"varint,6,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ParamListTransactionsRequest) Reset() {
+	*x = ParamListTransactionsRequest{}
+	mi := &file_balance_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ParamListTransactionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ParamListTransactionsRequest) ProtoMessage() {}
+
+func (x *ParamListTransactionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ParamListTransactionsRequest.ProtoReflect.Descriptor instead.
+func (*ParamListTransactionsRequest) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ParamListTransactionsRequest) GetWalletID() string {
+	if x != nil {
+		return x.WalletID
+	}
+	return ""
+}
+
+func (x *ParamListTransactionsRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *ParamListTransactionsRequest) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *ParamListTransactionsRequest) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *ParamListTransactionsRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ParamListTransactionsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type WalletTransaction struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TransactionID string                 `protobuf:"bytes,1,opt,name=transactionID,proto3" json:"transactionID,omitempty"`
+	WalletID      string                 `protobuf:"bytes,2,opt,name=walletID,proto3" json:"walletID,omitempty"`
+	Type          string                 `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	Amount        int64                  `protobuf:"varint,4,opt,name=amount,proto3" json:"amount,omitempty"`
+	BalanceAfter  int64                  `protobuf:"varint,5,opt,name=balanceAfter,proto3" json:"balanceAfter,omitempty"`
+	ReferenceID   string                 `protobuf:"bytes,6,opt,name=referenceID,proto3" json:"referenceID,omitempty"`
+	CreatedAT     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=createdAT,proto3" json:"createdAT,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WalletTransaction) Reset() {
+	*x = WalletTransaction{}
+	mi := &file_balance_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WalletTransaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WalletTransaction) ProtoMessage() {}
+
+func (x *WalletTransaction) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WalletTransaction.ProtoReflect.Descriptor instead.
+func (*WalletTransaction) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *WalletTransaction) GetTransactionID() string {
+	if x != nil {
+		return x.TransactionID
+	}
+	return ""
+}
+
+func (x *WalletTransaction) GetWalletID() string {
+	if x != nil {
+		return x.WalletID
+	}
+	return ""
+}
+
+func (x *WalletTransaction) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *WalletTransaction) GetAmount() int64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *WalletTransaction) GetBalanceAfter() int64 {
+	if x != nil {
+		return x.BalanceAfter
+	}
+	return 0
+}
+
+func (x *WalletTransaction) GetReferenceID() string {
+	if x != nil {
+		return x.ReferenceID
+	}
+	return ""
+}
+
+func (x *WalletTransaction) GetCreatedAT() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAT
+	}
+	return nil
+}
+
+type ParamListTransactionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Transactions  []*WalletTransaction   `protobuf:"bytes,1,rep,name=transactions,proto3" json:"transactions,omitempty"`
+	Total         int64                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ParamListTransactionsResponse) Reset() {
+	*x = ParamListTransactionsResponse{}
+	mi := &file_balance_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ParamListTransactionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ParamListTransactionsResponse) ProtoMessage() {}
+
+func (x *ParamListTransactionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_balance_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ParamListTransactionsResponse.ProtoReflect.Descriptor instead.
+func (*ParamListTransactionsResponse) Descriptor() ([]byte, []int) {
+	return file_balance_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ParamListTransactionsResponse) GetTransactions() []*WalletTransaction {
+	if x != nil {
+		return x.Transactions
+	}
+	return nil
+}
+
+func (x *ParamListTransactionsResponse) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
 var File_balance_proto protoreflect.FileDescriptor
 
 const file_balance_proto_rawDesc = "" +
@@ -574,12 +802,31 @@ const file_balance_proto_rawDesc = "" +
 	"\taccountID\x18\x02 \x01(\tR\taccountID\x12\x18\n" +
 	"\abalance\x18\x03 \x01(\x03R\abalance\x128\n" +
 	"\tcreatedAT\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAT\x128\n" +
-	"\tupdatedAT\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAT2\xa9\x02\n" +
+	"\tupdatedAT\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAT\"\xd4\x01\n" +
+	"\x1cParamListTransactionsRequest\x12\x1a\n" +
+	"\bwalletID\x18\x01 \x01(\tR\bwalletID\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12.\n" +
+	"\x04from\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x12\x12\n" +
+	"\x04page\x18\x05 \x01(\x05R\x04page\x12\x14\n" +
+	"\x05limit\x18\x06 \x01(\x05R\x05limit\"\x81\x02\n" +
+	"\x11WalletTransaction\x12$\n" +
+	"\rtransactionID\x18\x01 \x01(\tR\rtransactionID\x12\x1a\n" +
+	"\bwalletID\x18\x02 \x01(\tR\bwalletID\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12\x16\n" +
+	"\x06amount\x18\x04 \x01(\x03R\x06amount\x12\"\n" +
+	"\fbalanceAfter\x18\x05 \x01(\x03R\fbalanceAfter\x12 \n" +
+	"\vreferenceID\x18\x06 \x01(\tR\vreferenceID\x128\n" +
+	"\tcreatedAT\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAT\"m\n" +
+	"\x1dParamListTransactionsResponse\x126\n" +
+	"\ftransactions\x18\x01 \x03(\v2\x12.WalletTransactionR\ftransactions\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x03R\x05total2\xfc\x02\n" +
 	"\rWalletService\x12?\n" +
 	"\x06Create\x12\x19.ParamCreateWalletRequest\x1a\x1a.ParamCreateWalletResponse\x12?\n" +
 	"\x06Credit\x12\x19.ParamCreditWalletRequest\x1a\x1a.ParamCreditWalletResponse\x12<\n" +
 	"\x05Debit\x12\x18.ParamDebitWalletRequest\x1a\x19.ParamDebitWalletResponse\x12X\n" +
-	"\x12GetWalletByAccount\x12\x1f.ParamGetWalletByAccountRequest\x1a!.ParamgGetWalletByAccountResponseB$Z\"github.com/aclgo/gprc-Wallet/protob\x06proto3"
+	"\x12GetWalletByAccount\x12\x1f.ParamGetWalletByAccountRequest\x1a!.ParamgGetWalletByAccountResponse\x12Q\n" +
+	"\x10ListTransactions\x12\x1d.ParamListTransactionsRequest\x1a\x1e.ParamListTransactionsResponseB$Z\"github.com/aclgo/gprc-Wallet/protob\x06proto3"
 
 var (
 	file_balance_proto_rawDescOnce sync.Once
@@ -593,7 +840,7 @@ func file_balance_proto_rawDescGZIP() []byte {
 	return file_balance_proto_rawDescData
 }
 
-var file_balance_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_balance_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
 var file_balance_proto_goTypes = []any{
 	(*ParamCreateWalletRequest)(nil),         // 0: ParamCreateWalletRequest
 	(*ParamCreateWalletResponse)(nil),        // 1: ParamCreateWalletResponse
@@ -603,30 +850,39 @@ var file_balance_proto_goTypes = []any{
 	(*ParamDebitWalletResponse)(nil),         // 5: ParamDebitWalletResponse
 	(*ParamGetWalletByAccountRequest)(nil),   // 6: ParamGetWalletByAccountRequest
 	(*ParamgGetWalletByAccountResponse)(nil), // 7: ParamgGetWalletByAccountResponse
-	(*timestamppb.Timestamp)(nil),            // 8: google.protobuf.Timestamp
+	(*ParamListTransactionsRequest)(nil),     // 8: ParamListTransactionsRequest
+	(*WalletTransaction)(nil),                // 9: WalletTransaction
+	(*ParamListTransactionsResponse)(nil),    // 10: ParamListTransactionsResponse
+	(*timestamppb.Timestamp)(nil),            // 11: google.protobuf.Timestamp
 }
 var file_balance_proto_depIdxs = []int32{
-	8,  // 0: ParamCreateWalletResponse.createdAT:type_name -> google.protobuf.Timestamp
-	8,  // 1: ParamCreateWalletResponse.updatedAT:type_name -> google.protobuf.Timestamp
-	8,  // 2: ParamCreditWalletResponse.createdAT:type_name -> google.protobuf.Timestamp
-	8,  // 3: ParamCreditWalletResponse.updatedAT:type_name -> google.protobuf.Timestamp
-	8,  // 4: ParamDebitWalletResponse.createdAT:type_name -> google.protobuf.Timestamp
-	8,  // 5: ParamDebitWalletResponse.updatedAT:type_name -> google.protobuf.Timestamp
-	8,  // 6: ParamgGetWalletByAccountResponse.createdAT:type_name -> google.protobuf.Timestamp
-	8,  // 7: ParamgGetWalletByAccountResponse.updatedAT:type_name -> google.protobuf.Timestamp
-	0,  // 8: WalletService.Create:input_type -> ParamCreateWalletRequest
-	2,  // 9: WalletService.Credit:input_type -> ParamCreditWalletRequest
-	4,  // 10: WalletService.Debit:input_type -> ParamDebitWalletRequest
-	6,  // 11: WalletService.GetWalletByAccount:input_type -> ParamGetWalletByAccountRequest
-	1,  // 12: WalletService.Create:output_type -> ParamCreateWalletResponse
-	3,  // 13: WalletService.Credit:output_type -> ParamCreditWalletResponse
-	5,  // 14: WalletService.Debit:output_type -> ParamDebitWalletResponse
-	7,  // 15: WalletService.GetWalletByAccount:output_type -> ParamgGetWalletByAccountResponse
-	12, // [12:16] is the sub-list for method output_type
-	8,  // [8:12] is the sub-list for method input_type
-	8,  // [8:8] is the sub-list for extension type_name
-	8,  // [8:8] is the sub-list for extension extendee
-	0,  // [0:8] is the sub-list for field type_name
+	11, // 0: ParamCreateWalletResponse.createdAT:type_name -> google.protobuf.Timestamp
+	11, // 1: ParamCreateWalletResponse.updatedAT:type_name -> google.protobuf.Timestamp
+	11, // 2: ParamCreditWalletResponse.createdAT:type_name -> google.protobuf.Timestamp
+	11, // 3: ParamCreditWalletResponse.updatedAT:type_name -> google.protobuf.Timestamp
+	11, // 4: ParamDebitWalletResponse.createdAT:type_name -> google.protobuf.Timestamp
+	11, // 5: ParamDebitWalletResponse.updatedAT:type_name -> google.protobuf.Timestamp
+	11, // 6: ParamgGetWalletByAccountResponse.createdAT:type_name -> google.protobuf.Timestamp
+	11, // 7: ParamgGetWalletByAccountResponse.updatedAT:type_name -> google.protobuf.Timestamp
+	11, // 8: ParamListTransactionsRequest.from:type_name -> google.protobuf.Timestamp
+	11, // 9: ParamListTransactionsRequest.to:type_name -> google.protobuf.Timestamp
+	11, // 10: WalletTransaction.createdAT:type_name -> google.protobuf.Timestamp
+	9,  // 11: ParamListTransactionsResponse.transactions:type_name -> WalletTransaction
+	0,  // 12: WalletService.Create:input_type -> ParamCreateWalletRequest
+	2,  // 13: WalletService.Credit:input_type -> ParamCreditWalletRequest
+	4,  // 14: WalletService.Debit:input_type -> ParamDebitWalletRequest
+	6,  // 15: WalletService.GetWalletByAccount:input_type -> ParamGetWalletByAccountRequest
+	8,  // 16: WalletService.ListTransactions:input_type -> ParamListTransactionsRequest
+	1,  // 17: WalletService.Create:output_type -> ParamCreateWalletResponse
+	3,  // 18: WalletService.Credit:output_type -> ParamCreditWalletResponse
+	5,  // 19: WalletService.Debit:output_type -> ParamDebitWalletResponse
+	7,  // 20: WalletService.GetWalletByAccount:output_type -> ParamgGetWalletByAccountResponse
+	10, // 21: WalletService.ListTransactions:output_type -> ParamListTransactionsResponse
+	17, // [17:22] is the sub-list for method output_type
+	12, // [12:17] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
 }
 
 func init() { file_balance_proto_init() }
@@ -640,7 +896,7 @@ func file_balance_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_balance_proto_rawDesc), len(file_balance_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   8,
+			NumMessages:   11,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -29,6 +29,8 @@ const (
 	OrderType_PREMIUM_SUBSCRIPTION   OrderType = 1
 	OrderType_BALANCE_DEPOSIT        OrderType = 2
 	OrderType_PRODUCT_PURCHASE       OrderType = 3
+	OrderType_WALLET_TRANSFER        OrderType = 4
+	OrderType_WITHDRAWAL             OrderType = 5
 )
 
 // Enum value maps for OrderType.
@@ -38,12 +40,16 @@ var (
 		1: "PREMIUM_SUBSCRIPTION",
 		2: "BALANCE_DEPOSIT",
 		3: "PRODUCT_PURCHASE",
+		4: "WALLET_TRANSFER",
+		5: "WITHDRAWAL",
 	}
 	OrderType_value = map[string]int32{
 		"ORDER_TYPE_UNSPECIFIED": 0,
 		"PREMIUM_SUBSCRIPTION":   1,
 		"BALANCE_DEPOSIT":        2,
 		"PRODUCT_PURCHASE":       3,
+		"WALLET_TRANSFER":        4,
+		"WITHDRAWAL":             5,
 	}
 )
 
@@ -903,6 +909,147 @@ func (x *ParamFindOrderByGatewayTransactionIdResponse) GetOrder() *Orders {
 	return nil
 }
 
+// ParamListOrdersRequest pages through every order, oldest first by
+// created_at and then order_id. Each filter left unset matches every order;
+// updated_from matches the orders created or changed since then.
+// after_created_at and after_order_id are the last order of the previous
+// page; unset, the listing starts from the first order.
+type ParamListOrdersRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	CreatedFrom    *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=created_from,json=createdFrom,proto3" json:"created_from,omitempty"`
+	CreatedTo      *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=created_to,json=createdTo,proto3" json:"created_to,omitempty"`
+	UpdatedFrom    *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=updated_from,json=updatedFrom,proto3" json:"updated_from,omitempty"`
+	Statuses       []OrderStatus          `protobuf:"varint,4,rep,packed,name=statuses,proto3,enum=proto.OrderStatus" json:"statuses,omitempty"`
+	AfterCreatedAt *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=after_created_at,json=afterCreatedAt,proto3" json:"after_created_at,omitempty"`
+	AfterOrderId   string                 `protobuf:"bytes,6,opt,name=after_order_id,json=afterOrderId,proto3" json:"after_order_id,omitempty"`
+	Limit          int32                  `protobuf:"varint,7,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ParamListOrdersRequest) Reset() {
+	*x = ParamListOrdersRequest{}
+	mi := &file_orders_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ParamListOrdersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ParamListOrdersRequest) ProtoMessage() {}
+
+func (x *ParamListOrdersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_orders_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ParamListOrdersRequest.ProtoReflect.Descriptor instead.
+func (*ParamListOrdersRequest) Descriptor() ([]byte, []int) {
+	return file_orders_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ParamListOrdersRequest) GetCreatedFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedFrom
+	}
+	return nil
+}
+
+func (x *ParamListOrdersRequest) GetCreatedTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedTo
+	}
+	return nil
+}
+
+func (x *ParamListOrdersRequest) GetUpdatedFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedFrom
+	}
+	return nil
+}
+
+func (x *ParamListOrdersRequest) GetStatuses() []OrderStatus {
+	if x != nil {
+		return x.Statuses
+	}
+	return nil
+}
+
+func (x *ParamListOrdersRequest) GetAfterCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.AfterCreatedAt
+	}
+	return nil
+}
+
+func (x *ParamListOrdersRequest) GetAfterOrderId() string {
+	if x != nil {
+		return x.AfterOrderId
+	}
+	return ""
+}
+
+func (x *ParamListOrdersRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ParamListOrdersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Orders        []*Orders              `protobuf:"bytes,1,rep,name=orders,proto3" json:"orders,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ParamListOrdersResponse) Reset() {
+	*x = ParamListOrdersResponse{}
+	mi := &file_orders_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ParamListOrdersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ParamListOrdersResponse) ProtoMessage() {}
+
+func (x *ParamListOrdersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_orders_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ParamListOrdersResponse.ProtoReflect.Descriptor instead.
+func (*ParamListOrdersResponse) Descriptor() ([]byte, []int) {
+	return file_orders_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ParamListOrdersResponse) GetOrders() []*Orders {
+	if x != nil {
+		return x.Orders
+	}
+	return nil
+}
+
 type ParamUpdateOrderStatusRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
@@ -913,7 +1060,7 @@ type ParamUpdateOrderStatusRequest struct {
 
 func (x *ParamUpdateOrderStatusRequest) Reset() {
 	*x = ParamUpdateOrderStatusRequest{}
-	mi := &file_orders_proto_msgTypes[11]
+	mi := &file_orders_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -925,7 +1072,7 @@ func (x *ParamUpdateOrderStatusRequest) String() string {
 func (*ParamUpdateOrderStatusRequest) ProtoMessage() {}
 
 func (x *ParamUpdateOrderStatusRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_orders_proto_msgTypes[11]
+	mi := &file_orders_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -938,7 +1085,7 @@ func (x *ParamUpdateOrderStatusRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ParamUpdateOrderStatusRequest.ProtoReflect.Descriptor instead.
 func (*ParamUpdateOrderStatusRequest) Descriptor() ([]byte, []int) {
-	return file_orders_proto_rawDescGZIP(), []int{11}
+	return file_orders_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *ParamUpdateOrderStatusRequest) GetOrderId() string {
@@ -964,7 +1111,7 @@ type ParamUpdateOrderStatusResponse struct {
 
 func (x *ParamUpdateOrderStatusResponse) Reset() {
 	*x = ParamUpdateOrderStatusResponse{}
-	mi := &file_orders_proto_msgTypes[12]
+	mi := &file_orders_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -976,7 +1123,7 @@ func (x *ParamUpdateOrderStatusResponse) String() string {
 func (*ParamUpdateOrderStatusResponse) ProtoMessage() {}
 
 func (x *ParamUpdateOrderStatusResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_orders_proto_msgTypes[12]
+	mi := &file_orders_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -989,7 +1136,7 @@ func (x *ParamUpdateOrderStatusResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ParamUpdateOrderStatusResponse.ProtoReflect.Descriptor instead.
 func (*ParamUpdateOrderStatusResponse) Descriptor() ([]byte, []int) {
-	return file_orders_proto_rawDescGZIP(), []int{12}
+	return file_orders_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *ParamUpdateOrderStatusResponse) GetOrder() *Orders {
@@ -1056,17 +1203,31 @@ const file_orders_proto_rawDesc = "" +
 	"+ParamFindOrderByGatewayTransactionIdRequest\x124\n" +
 	"\x16gateway_transaction_id\x18\x01 \x01(\tR\x14gatewayTransactionId\"S\n" +
 	",ParamFindOrderByGatewayTransactionIdResponse\x12#\n" +
-	"\x05order\x18\x01 \x01(\v2\r.proto.OrdersR\x05order\"f\n" +
+	"\x05order\x18\x01 \x01(\v2\r.proto.OrdersR\x05order\"\x83\x03\n" +
+	"\x16ParamListOrdersRequest\x12=\n" +
+	"\fcreated_from\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\vcreatedFrom\x129\n" +
+	"\n" +
+	"created_to\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedTo\x12=\n" +
+	"\fupdated_from\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\vupdatedFrom\x12.\n" +
+	"\bstatuses\x18\x04 \x03(\x0e2\x12.proto.OrderStatusR\bstatuses\x12D\n" +
+	"\x10after_created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\x0eafterCreatedAt\x12$\n" +
+	"\x0eafter_order_id\x18\x06 \x01(\tR\fafterOrderId\x12\x14\n" +
+	"\x05limit\x18\a \x01(\x05R\x05limit\"@\n" +
+	"\x17ParamListOrdersResponse\x12%\n" +
+	"\x06orders\x18\x01 \x03(\v2\r.proto.OrdersR\x06orders\"f\n" +
 	"\x1dParamUpdateOrderStatusRequest\x12\x19\n" +
 	"\border_id\x18\x01 \x01(\tR\aorderId\x12*\n" +
 	"\x06status\x18\x02 \x01(\x0e2\x12.proto.OrderStatusR\x06status\"E\n" +
 	"\x1eParamUpdateOrderStatusResponse\x12#\n" +
-	"\x05order\x18\x01 \x01(\v2\r.proto.OrdersR\x05order*l\n" +
+	"\x05order\x18\x01 \x01(\v2\r.proto.OrdersR\x05order*\x91\x01\n" +
 	"\tOrderType\x12\x1a\n" +
 	"\x16ORDER_TYPE_UNSPECIFIED\x10\x00\x12\x18\n" +
 	"\x14PREMIUM_SUBSCRIPTION\x10\x01\x12\x13\n" +
 	"\x0fBALANCE_DEPOSIT\x10\x02\x12\x14\n" +
-	"\x10PRODUCT_PURCHASE\x10\x03*k\n" +
+	"\x10PRODUCT_PURCHASE\x10\x03\x12\x13\n" +
+	"\x0fWALLET_TRANSFER\x10\x04\x12\x0e\n" +
+	"\n" +
+	"WITHDRAWAL\x10\x05*k\n" +
 	"\rPaymentMethod\x12\x1e\n" +
 	"\x1aPAYMENT_METHOD_UNSPECIFIED\x10\x00\x12\a\n" +
 	"\x03PIX\x10\x01\x12\x0f\n" +
@@ -1081,14 +1242,16 @@ const file_orders_proto_rawDesc = "" +
 	"\n" +
 	"\x06FAILED\x10\x03\x12\r\n" +
 	"\tCANCELLED\x10\x04\x12\f\n" +
-	"\bREFUNDED\x10\x052\xd7\x04\n" +
+	"\bREFUNDED\x10\x052\xa4\x05\n" +
 	"\fServiceOrder\x12I\n" +
 	"\x06Create\x12\x1e.proto.ParamCreateOrderRequest\x1a\x1f.proto.ParamCreateOrderResponse\x12C\n" +
 	"\x04Find\x12\x1c.proto.ParamFindOrderRequest\x1a\x1d.proto.ParamFindOrderResponse\x12c\n" +
 	"\x12FindOrderByAccount\x12%.proto.ParamFindOrderByAccountRequest\x1a&.proto.ParamFindOrderByAccountResponse\x12c\n" +
 	"\x12FindOrderByProduct\x12%.proto.ParamFindOrderByProductRequest\x1a&.proto.ParamFindOrderByProductResponse\x12\x8a\x01\n" +
 	"\x1fFindOrderByGatewayTransactionId\x122.proto.ParamFindOrderByGatewayTransactionIdRequest\x1a3.proto.ParamFindOrderByGatewayTransactionIdResponse\x12`\n" +
-	"\x11UpdateOrderStatus\x12$.proto.ParamUpdateOrderStatusRequest\x1a%.proto.ParamUpdateOrderStatusResponseB\x18Z\x16github.com/aclgo/protob\x06proto3"
+	"\x11UpdateOrderStatus\x12$.proto.ParamUpdateOrderStatusRequest\x1a%.proto.ParamUpdateOrderStatusResponse\x12K\n" +
+	"\n" +
+	"ListOrders\x12\x1d.proto.ParamListOrdersRequest\x1a\x1e.proto.ParamListOrdersResponseB\x18Z\x16github.com/aclgo/protob\x06proto3"
 
 var (
 	file_orders_proto_rawDescOnce sync.Once
@@ -1103,7 +1266,7 @@ func file_orders_proto_rawDescGZIP() []byte {
 }
 
 var file_orders_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
-var file_orders_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_orders_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
 var file_orders_proto_goTypes = []any{
 	(OrderType)(0),                                       // 0: proto.OrderType
 	(PaymentMethod)(0),                                   // 1: proto.PaymentMethod
@@ -1119,47 +1282,57 @@ var file_orders_proto_goTypes = []any{
 	(*ParamFindOrderByProductResponse)(nil),              // 11: proto.ParamFindOrderByProductResponse
 	(*ParamFindOrderByGatewayTransactionIdRequest)(nil),  // 12: proto.ParamFindOrderByGatewayTransactionIdRequest
 	(*ParamFindOrderByGatewayTransactionIdResponse)(nil), // 13: proto.ParamFindOrderByGatewayTransactionIdResponse
-	(*ParamUpdateOrderStatusRequest)(nil),                // 14: proto.ParamUpdateOrderStatusRequest
-	(*ParamUpdateOrderStatusResponse)(nil),               // 15: proto.ParamUpdateOrderStatusResponse
-	(*timestamppb.Timestamp)(nil),                        // 16: google.protobuf.Timestamp
+	(*ParamListOrdersRequest)(nil),                       // 14: proto.ParamListOrdersRequest
+	(*ParamListOrdersResponse)(nil),                      // 15: proto.ParamListOrdersResponse
+	(*ParamUpdateOrderStatusRequest)(nil),                // 16: proto.ParamUpdateOrderStatusRequest
+	(*ParamUpdateOrderStatusResponse)(nil),               // 17: proto.ParamUpdateOrderStatusResponse
+	(*timestamppb.Timestamp)(nil),                        // 18: google.protobuf.Timestamp
 }
 var file_orders_proto_depIdxs = []int32{
 	0,  // 0: proto.Orders.type:type_name -> proto.OrderType
 	1,  // 1: proto.Orders.paymentMethod:type_name -> proto.PaymentMethod
 	2,  // 2: proto.Orders.status:type_name -> proto.OrderStatus
-	16, // 3: proto.Orders.pixExpiration:type_name -> google.protobuf.Timestamp
-	16, // 4: proto.Orders.boletoExpiration:type_name -> google.protobuf.Timestamp
-	16, // 5: proto.Orders.createdAT:type_name -> google.protobuf.Timestamp
-	16, // 6: proto.Orders.updatedAT:type_name -> google.protobuf.Timestamp
+	18, // 3: proto.Orders.pixExpiration:type_name -> google.protobuf.Timestamp
+	18, // 4: proto.Orders.boletoExpiration:type_name -> google.protobuf.Timestamp
+	18, // 5: proto.Orders.createdAT:type_name -> google.protobuf.Timestamp
+	18, // 6: proto.Orders.updatedAT:type_name -> google.protobuf.Timestamp
 	0,  // 7: proto.ParamCreateOrderRequest.type:type_name -> proto.OrderType
 	1,  // 8: proto.ParamCreateOrderRequest.paymentMethod:type_name -> proto.PaymentMethod
 	2,  // 9: proto.ParamCreateOrderRequest.status:type_name -> proto.OrderStatus
-	16, // 10: proto.ParamCreateOrderRequest.pixExpiration:type_name -> google.protobuf.Timestamp
-	16, // 11: proto.ParamCreateOrderRequest.boletoExpiration:type_name -> google.protobuf.Timestamp
+	18, // 10: proto.ParamCreateOrderRequest.pixExpiration:type_name -> google.protobuf.Timestamp
+	18, // 11: proto.ParamCreateOrderRequest.boletoExpiration:type_name -> google.protobuf.Timestamp
 	3,  // 12: proto.ParamCreateOrderResponse.order:type_name -> proto.Orders
 	3,  // 13: proto.ParamFindOrderResponse.order:type_name -> proto.Orders
 	3,  // 14: proto.ParamFindOrderByAccountResponse.orders:type_name -> proto.Orders
 	3,  // 15: proto.ParamFindOrderByProductResponse.orders:type_name -> proto.Orders
 	3,  // 16: proto.ParamFindOrderByGatewayTransactionIdResponse.order:type_name -> proto.Orders
-	2,  // 17: proto.ParamUpdateOrderStatusRequest.status:type_name -> proto.OrderStatus
-	3,  // 18: proto.ParamUpdateOrderStatusResponse.order:type_name -> proto.Orders
-	4,  // 19: proto.ServiceOrder.Create:input_type -> proto.ParamCreateOrderRequest
-	6,  // 20: proto.ServiceOrder.Find:input_type -> proto.ParamFindOrderRequest
-	8,  // 21: proto.ServiceOrder.FindOrderByAccount:input_type -> proto.ParamFindOrderByAccountRequest
-	10, // 22: proto.ServiceOrder.FindOrderByProduct:input_type -> proto.ParamFindOrderByProductRequest
-	12, // 23: proto.ServiceOrder.FindOrderByGatewayTransactionId:input_type -> proto.ParamFindOrderByGatewayTransactionIdRequest
-	14, // 24: proto.ServiceOrder.UpdateOrderStatus:input_type -> proto.ParamUpdateOrderStatusRequest
-	5,  // 25: proto.ServiceOrder.Create:output_type -> proto.ParamCreateOrderResponse
-	7,  // 26: proto.ServiceOrder.Find:output_type -> proto.ParamFindOrderResponse
-	9,  // 27: proto.ServiceOrder.FindOrderByAccount:output_type -> proto.ParamFindOrderByAccountResponse
-	11, // 28: proto.ServiceOrder.FindOrderByProduct:output_type -> proto.ParamFindOrderByProductResponse
-	13, // 29: proto.ServiceOrder.FindOrderByGatewayTransactionId:output_type -> proto.ParamFindOrderByGatewayTransactionIdResponse
-	15, // 30: proto.ServiceOrder.UpdateOrderStatus:output_type -> proto.ParamUpdateOrderStatusResponse
-	25, // [25:31] is the sub-list for method output_type
-	19, // [19:25] is the sub-list for method input_type
-	19, // [19:19] is the sub-list for extension type_name
-	19, // [19:19] is the sub-list for extension extendee
-	0,  // [0:19] is the sub-list for field type_name
+	18, // 17: proto.ParamListOrdersRequest.created_from:type_name -> google.protobuf.Timestamp
+	18, // 18: proto.ParamListOrdersRequest.created_to:type_name -> google.protobuf.Timestamp
+	18, // 19: proto.ParamListOrdersRequest.updated_from:type_name -> google.protobuf.Timestamp
+	2,  // 20: proto.ParamListOrdersRequest.statuses:type_name -> proto.OrderStatus
+	18, // 21: proto.ParamListOrdersRequest.after_created_at:type_name -> google.protobuf.Timestamp
+	3,  // 22: proto.ParamListOrdersResponse.orders:type_name -> proto.Orders
+	2,  // 23: proto.ParamUpdateOrderStatusRequest.status:type_name -> proto.OrderStatus
+	3,  // 24: proto.ParamUpdateOrderStatusResponse.order:type_name -> proto.Orders
+	4,  // 25: proto.ServiceOrder.Create:input_type -> proto.ParamCreateOrderRequest
+	6,  // 26: proto.ServiceOrder.Find:input_type -> proto.ParamFindOrderRequest
+	8,  // 27: proto.ServiceOrder.FindOrderByAccount:input_type -> proto.ParamFindOrderByAccountRequest
+	10, // 28: proto.ServiceOrder.FindOrderByProduct:input_type -> proto.ParamFindOrderByProductRequest
+	12, // 29: proto.ServiceOrder.FindOrderByGatewayTransactionId:input_type -> proto.ParamFindOrderByGatewayTransactionIdRequest
+	16, // 30: proto.ServiceOrder.UpdateOrderStatus:input_type -> proto.ParamUpdateOrderStatusRequest
+	14, // 31: proto.ServiceOrder.ListOrders:input_type -> proto.ParamListOrdersRequest
+	5,  // 32: proto.ServiceOrder.Create:output_type -> proto.ParamCreateOrderResponse
+	7,  // 33: proto.ServiceOrder.Find:output_type -> proto.ParamFindOrderResponse
+	9,  // 34: proto.ServiceOrder.FindOrderByAccount:output_type -> proto.ParamFindOrderByAccountResponse
+	11, // 35: proto.ServiceOrder.FindOrderByProduct:output_type -> proto.ParamFindOrderByProductResponse
+	13, // 36: proto.ServiceOrder.FindOrderByGatewayTransactionId:output_type -> proto.ParamFindOrderByGatewayTransactionIdResponse
+	17, // 37: proto.ServiceOrder.UpdateOrderStatus:output_type -> proto.ParamUpdateOrderStatusResponse
+	15, // 38: proto.ServiceOrder.ListOrders:output_type -> proto.ParamListOrdersResponse
+	32, // [32:39] is the sub-list for method output_type
+	25, // [25:32] is the sub-list for method input_type
+	25, // [25:25] is the sub-list for extension type_name
+	25, // [25:25] is the sub-list for extension extendee
+	0,  // [0:25] is the sub-list for field type_name
 }
 
 func init() { file_orders_proto_init() }
@@ -1173,7 +1346,7 @@ func file_orders_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_orders_proto_rawDesc), len(file_orders_proto_rawDesc)),
 			NumEnums:      3,
-			NumMessages:   13,
+			NumMessages:   15,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
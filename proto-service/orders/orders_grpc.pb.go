@@ -25,6 +25,7 @@ const (
 	ServiceOrder_FindOrderByProduct_FullMethodName              = "/proto.ServiceOrder/FindOrderByProduct"
 	ServiceOrder_FindOrderByGatewayTransactionId_FullMethodName = "/proto.ServiceOrder/FindOrderByGatewayTransactionId"
 	ServiceOrder_UpdateOrderStatus_FullMethodName               = "/proto.ServiceOrder/UpdateOrderStatus"
+	ServiceOrder_ListOrders_FullMethodName                      = "/proto.ServiceOrder/ListOrders"
 )
 
 // ServiceOrderClient is the client API for ServiceOrder service.
@@ -37,6 +38,7 @@ type ServiceOrderClient interface {
 	FindOrderByProduct(ctx context.Context, in *ParamFindOrderByProductRequest, opts ...grpc.CallOption) (*ParamFindOrderByProductResponse, error)
 	FindOrderByGatewayTransactionId(ctx context.Context, in *ParamFindOrderByGatewayTransactionIdRequest, opts ...grpc.CallOption) (*ParamFindOrderByGatewayTransactionIdResponse, error)
 	UpdateOrderStatus(ctx context.Context, in *ParamUpdateOrderStatusRequest, opts ...grpc.CallOption) (*ParamUpdateOrderStatusResponse, error)
+	ListOrders(ctx context.Context, in *ParamListOrdersRequest, opts ...grpc.CallOption) (*ParamListOrdersResponse, error)
 }
 
 type serviceOrderClient struct {
@@ -107,6 +109,16 @@ func (c *serviceOrderClient) UpdateOrderStatus(ctx context.Context, in *ParamUpd
 	return out, nil
 }
 
+func (c *serviceOrderClient) ListOrders(ctx context.Context, in *ParamListOrdersRequest, opts ...grpc.CallOption) (*ParamListOrdersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ParamListOrdersResponse)
+	err := c.cc.Invoke(ctx, ServiceOrder_ListOrders_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ServiceOrderServer is the server API for ServiceOrder service.
 // All implementations must embed UnimplementedServiceOrderServer
 // for forward compatibility.
@@ -117,6 +129,7 @@ type ServiceOrderServer interface {
 	FindOrderByProduct(context.Context, *ParamFindOrderByProductRequest) (*ParamFindOrderByProductResponse, error)
 	FindOrderByGatewayTransactionId(context.Context, *ParamFindOrderByGatewayTransactionIdRequest) (*ParamFindOrderByGatewayTransactionIdResponse, error)
 	UpdateOrderStatus(context.Context, *ParamUpdateOrderStatusRequest) (*ParamUpdateOrderStatusResponse, error)
+	ListOrders(context.Context, *ParamListOrdersRequest) (*ParamListOrdersResponse, error)
 	mustEmbedUnimplementedServiceOrderServer()
 }
 
@@ -145,6 +158,9 @@ func (UnimplementedServiceOrderServer) FindOrderByGatewayTransactionId(context.C
 func (UnimplementedServiceOrderServer) UpdateOrderStatus(context.Context, *ParamUpdateOrderStatusRequest) (*ParamUpdateOrderStatusResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method UpdateOrderStatus not implemented")
 }
+func (UnimplementedServiceOrderServer) ListOrders(context.Context, *ParamListOrdersRequest) (*ParamListOrdersResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListOrders not implemented")
+}
 func (UnimplementedServiceOrderServer) mustEmbedUnimplementedServiceOrderServer() {}
 func (UnimplementedServiceOrderServer) testEmbeddedByValue()                      {}
 
@@ -274,6 +290,24 @@ func _ServiceOrder_UpdateOrderStatus_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ServiceOrder_ListOrders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ParamListOrdersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServiceOrderServer).ListOrders(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ServiceOrder_ListOrders_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServiceOrderServer).ListOrders(ctx, req.(*ParamListOrdersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ServiceOrder_ServiceDesc is the grpc.ServiceDesc for ServiceOrder service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -305,6 +339,10 @@ var ServiceOrder_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "UpdateOrderStatus",
 			Handler:    _ServiceOrder_UpdateOrderStatus_Handler,
 		},
+		{
+			MethodName: "ListOrders",
+			Handler:    _ServiceOrder_ListOrders_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "orders.proto",
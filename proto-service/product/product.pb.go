@@ -578,6 +578,113 @@ func (x *ProductUpdateResponse) GetProduct() *Product {
 	return nil
 }
 
+// ProductDecreaseQuantityRequest takes quantity units off the stock of a
+// sold product, never going below zero. reference identifies the sale, so a
+// retried call decreases once.
+type ProductDecreaseQuantityRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Quantity      int64                  `protobuf:"varint,2,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	Reference     string                 `protobuf:"bytes,3,opt,name=reference,proto3" json:"reference,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProductDecreaseQuantityRequest) Reset() {
+	*x = ProductDecreaseQuantityRequest{}
+	mi := &file_product_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProductDecreaseQuantityRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProductDecreaseQuantityRequest) ProtoMessage() {}
+
+func (x *ProductDecreaseQuantityRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_product_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProductDecreaseQuantityRequest.ProtoReflect.Descriptor instead.
+func (*ProductDecreaseQuantityRequest) Descriptor() ([]byte, []int) {
+	return file_product_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ProductDecreaseQuantityRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ProductDecreaseQuantityRequest) GetQuantity() int64 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (x *ProductDecreaseQuantityRequest) GetReference() string {
+	if x != nil {
+		return x.Reference
+	}
+	return ""
+}
+
+type ProductDecreaseQuantityResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Product       *Product               `protobuf:"bytes,1,opt,name=product,proto3" json:"product,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProductDecreaseQuantityResponse) Reset() {
+	*x = ProductDecreaseQuantityResponse{}
+	mi := &file_product_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProductDecreaseQuantityResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProductDecreaseQuantityResponse) ProtoMessage() {}
+
+func (x *ProductDecreaseQuantityResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_product_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProductDecreaseQuantityResponse.ProtoReflect.Descriptor instead.
+func (*ProductDecreaseQuantityResponse) Descriptor() ([]byte, []int) {
+	return file_product_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ProductDecreaseQuantityResponse) GetProduct() *Product {
+	if x != nil {
+		return x.Product
+	}
+	return nil
+}
+
 type ProductDeleteRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -587,7 +694,7 @@ type ProductDeleteRequest struct {
 
 func (x *ProductDeleteRequest) Reset() {
 	*x = ProductDeleteRequest{}
-	mi := &file_product_proto_msgTypes[9]
+	mi := &file_product_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -599,7 +706,7 @@ func (x *ProductDeleteRequest) String() string {
 func (*ProductDeleteRequest) ProtoMessage() {}
 
 func (x *ProductDeleteRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_product_proto_msgTypes[9]
+	mi := &file_product_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -612,7 +719,7 @@ func (x *ProductDeleteRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ProductDeleteRequest.ProtoReflect.Descriptor instead.
 func (*ProductDeleteRequest) Descriptor() ([]byte, []int) {
-	return file_product_proto_rawDescGZIP(), []int{9}
+	return file_product_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *ProductDeleteRequest) GetId() string {
@@ -631,7 +738,7 @@ type ProductDeleteResponse struct {
 
 func (x *ProductDeleteResponse) Reset() {
 	*x = ProductDeleteResponse{}
-	mi := &file_product_proto_msgTypes[10]
+	mi := &file_product_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -643,7 +750,7 @@ func (x *ProductDeleteResponse) String() string {
 func (*ProductDeleteResponse) ProtoMessage() {}
 
 func (x *ProductDeleteResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_product_proto_msgTypes[10]
+	mi := &file_product_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -656,7 +763,7 @@ func (x *ProductDeleteResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ProductDeleteResponse.ProtoReflect.Descriptor instead.
 func (*ProductDeleteResponse) Descriptor() ([]byte, []int) {
-	return file_product_proto_rawDescGZIP(), []int{10}
+	return file_product_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *ProductDeleteResponse) GetMsg() string {
@@ -714,16 +821,23 @@ const file_product_proto_rawDesc = "" +
 	"\vhas_ordered\x18\x06 \x01(\bR\n" +
 	"hasOrdered\";\n" +
 	"\x15ProductUpdateResponse\x12\"\n" +
+	"\aproduct\x18\x01 \x01(\v2\b.ProductR\aproduct\"j\n" +
+	"\x1eProductDecreaseQuantityRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\bquantity\x18\x02 \x01(\x03R\bquantity\x12\x1c\n" +
+	"\treference\x18\x03 \x01(\tR\treference\"E\n" +
+	"\x1fProductDecreaseQuantityResponse\x12\"\n" +
 	"\aproduct\x18\x01 \x01(\v2\b.ProductR\aproduct\"&\n" +
 	"\x14ProductDeleteRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\")\n" +
 	"\x15ProductDeleteResponse\x12\x10\n" +
-	"\x03msg\x18\x01 \x01(\tR\x03msg2\xaa\x02\n" +
+	"\x03msg\x18\x01 \x01(\tR\x03msg2\x81\x03\n" +
 	"\x0eProductService\x127\n" +
 	"\x06Insert\x12\x15.ProductInsertRequest\x1a\x16.ProductInsertResponse\x121\n" +
 	"\x04Find\x12\x13.ProductFindRequest\x1a\x14.ProductFindResponse\x12:\n" +
 	"\aFindAll\x12\x16.ProductFindAllRequest\x1a\x17.ProductFindAllResponse\x127\n" +
-	"\x06Update\x12\x15.ProductUpdateRequest\x1a\x16.ProductUpdateResponse\x127\n" +
+	"\x06Update\x12\x15.ProductUpdateRequest\x1a\x16.ProductUpdateResponse\x12U\n" +
+	"\x10DecreaseQuantity\x12\x1f.ProductDecreaseQuantityRequest\x1a .ProductDecreaseQuantityResponse\x127\n" +
 	"\x06Delete\x12\x15.ProductDeleteRequest\x1a\x16.ProductDeleteResponseB Z\x1egithub.com/aclgo/product/protob\x06proto3"
 
 var (
@@ -738,43 +852,48 @@ func file_product_proto_rawDescGZIP() []byte {
 	return file_product_proto_rawDescData
 }
 
-var file_product_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_product_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
 var file_product_proto_goTypes = []any{
-	(*Product)(nil),                // 0: Product
-	(*ProductInsertRequest)(nil),   // 1: ProductInsertRequest
-	(*ProductInsertResponse)(nil),  // 2: ProductInsertResponse
-	(*ProductFindRequest)(nil),     // 3: ProductFindRequest
-	(*ProductFindResponse)(nil),    // 4: ProductFindResponse
-	(*ProductFindAllRequest)(nil),  // 5: ProductFindAllRequest
-	(*ProductFindAllResponse)(nil), // 6: ProductFindAllResponse
-	(*ProductUpdateRequest)(nil),   // 7: ProductUpdateRequest
-	(*ProductUpdateResponse)(nil),  // 8: ProductUpdateResponse
-	(*ProductDeleteRequest)(nil),   // 9: ProductDeleteRequest
-	(*ProductDeleteResponse)(nil),  // 10: ProductDeleteResponse
-	(*timestamppb.Timestamp)(nil),  // 11: google.protobuf.Timestamp
+	(*Product)(nil),                         // 0: Product
+	(*ProductInsertRequest)(nil),            // 1: ProductInsertRequest
+	(*ProductInsertResponse)(nil),           // 2: ProductInsertResponse
+	(*ProductFindRequest)(nil),              // 3: ProductFindRequest
+	(*ProductFindResponse)(nil),             // 4: ProductFindResponse
+	(*ProductFindAllRequest)(nil),           // 5: ProductFindAllRequest
+	(*ProductFindAllResponse)(nil),          // 6: ProductFindAllResponse
+	(*ProductUpdateRequest)(nil),            // 7: ProductUpdateRequest
+	(*ProductUpdateResponse)(nil),           // 8: ProductUpdateResponse
+	(*ProductDecreaseQuantityRequest)(nil),  // 9: ProductDecreaseQuantityRequest
+	(*ProductDecreaseQuantityResponse)(nil), // 10: ProductDecreaseQuantityResponse
+	(*ProductDeleteRequest)(nil),            // 11: ProductDeleteRequest
+	(*ProductDeleteResponse)(nil),           // 12: ProductDeleteResponse
+	(*timestamppb.Timestamp)(nil),           // 13: google.protobuf.Timestamp
 }
 var file_product_proto_depIdxs = []int32{
-	11, // 0: Product.created_at:type_name -> google.protobuf.Timestamp
-	11, // 1: Product.updated_at:type_name -> google.protobuf.Timestamp
+	13, // 0: Product.created_at:type_name -> google.protobuf.Timestamp
+	13, // 1: Product.updated_at:type_name -> google.protobuf.Timestamp
 	0,  // 2: ProductInsertResponse.product:type_name -> Product
 	0,  // 3: ProductFindResponse.product:type_name -> Product
 	0,  // 4: ProductFindAllResponse.products:type_name -> Product
 	0,  // 5: ProductUpdateResponse.product:type_name -> Product
-	1,  // 6: ProductService.Insert:input_type -> ProductInsertRequest
-	3,  // 7: ProductService.Find:input_type -> ProductFindRequest
-	5,  // 8: ProductService.FindAll:input_type -> ProductFindAllRequest
-	7,  // 9: ProductService.Update:input_type -> ProductUpdateRequest
-	9,  // 10: ProductService.Delete:input_type -> ProductDeleteRequest
-	2,  // 11: ProductService.Insert:output_type -> ProductInsertResponse
-	4,  // 12: ProductService.Find:output_type -> ProductFindResponse
-	6,  // 13: ProductService.FindAll:output_type -> ProductFindAllResponse
-	8,  // 14: ProductService.Update:output_type -> ProductUpdateResponse
-	10, // 15: ProductService.Delete:output_type -> ProductDeleteResponse
-	11, // [11:16] is the sub-list for method output_type
-	6,  // [6:11] is the sub-list for method input_type
-	6,  // [6:6] is the sub-list for extension type_name
-	6,  // [6:6] is the sub-list for extension extendee
-	0,  // [0:6] is the sub-list for field type_name
+	0,  // 6: ProductDecreaseQuantityResponse.product:type_name -> Product
+	1,  // 7: ProductService.Insert:input_type -> ProductInsertRequest
+	3,  // 8: ProductService.Find:input_type -> ProductFindRequest
+	5,  // 9: ProductService.FindAll:input_type -> ProductFindAllRequest
+	7,  // 10: ProductService.Update:input_type -> ProductUpdateRequest
+	9,  // 11: ProductService.DecreaseQuantity:input_type -> ProductDecreaseQuantityRequest
+	11, // 12: ProductService.Delete:input_type -> ProductDeleteRequest
+	2,  // 13: ProductService.Insert:output_type -> ProductInsertResponse
+	4,  // 14: ProductService.Find:output_type -> ProductFindResponse
+	6,  // 15: ProductService.FindAll:output_type -> ProductFindAllResponse
+	8,  // 16: ProductService.Update:output_type -> ProductUpdateResponse
+	10, // 17: ProductService.DecreaseQuantity:output_type -> ProductDecreaseQuantityResponse
+	12, // 18: ProductService.Delete:output_type -> ProductDeleteResponse
+	13, // [13:19] is the sub-list for method output_type
+	7,  // [7:13] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
 }
 
 func init() { file_product_proto_init() }
@@ -788,7 +907,7 @@ func file_product_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_product_proto_rawDesc), len(file_product_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   11,
+			NumMessages:   13,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
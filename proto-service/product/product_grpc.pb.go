@@ -19,11 +19,12 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	ProductService_Insert_FullMethodName  = "/ProductService/Insert"
-	ProductService_Find_FullMethodName    = "/ProductService/Find"
-	ProductService_FindAll_FullMethodName = "/ProductService/FindAll"
-	ProductService_Update_FullMethodName  = "/ProductService/Update"
-	ProductService_Delete_FullMethodName  = "/ProductService/Delete"
+	ProductService_Insert_FullMethodName           = "/ProductService/Insert"
+	ProductService_Find_FullMethodName             = "/ProductService/Find"
+	ProductService_FindAll_FullMethodName          = "/ProductService/FindAll"
+	ProductService_Update_FullMethodName           = "/ProductService/Update"
+	ProductService_DecreaseQuantity_FullMethodName = "/ProductService/DecreaseQuantity"
+	ProductService_Delete_FullMethodName           = "/ProductService/Delete"
 )
 
 // ProductServiceClient is the client API for ProductService service.
@@ -34,6 +35,7 @@ type ProductServiceClient interface {
 	Find(ctx context.Context, in *ProductFindRequest, opts ...grpc.CallOption) (*ProductFindResponse, error)
 	FindAll(ctx context.Context, in *ProductFindAllRequest, opts ...grpc.CallOption) (*ProductFindAllResponse, error)
 	Update(ctx context.Context, in *ProductUpdateRequest, opts ...grpc.CallOption) (*ProductUpdateResponse, error)
+	DecreaseQuantity(ctx context.Context, in *ProductDecreaseQuantityRequest, opts ...grpc.CallOption) (*ProductDecreaseQuantityResponse, error)
 	Delete(ctx context.Context, in *ProductDeleteRequest, opts ...grpc.CallOption) (*ProductDeleteResponse, error)
 }
 
@@ -85,6 +87,16 @@ func (c *productServiceClient) Update(ctx context.Context, in *ProductUpdateRequ
 	return out, nil
 }
 
+func (c *productServiceClient) DecreaseQuantity(ctx context.Context, in *ProductDecreaseQuantityRequest, opts ...grpc.CallOption) (*ProductDecreaseQuantityResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ProductDecreaseQuantityResponse)
+	err := c.cc.Invoke(ctx, ProductService_DecreaseQuantity_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *productServiceClient) Delete(ctx context.Context, in *ProductDeleteRequest, opts ...grpc.CallOption) (*ProductDeleteResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ProductDeleteResponse)
@@ -103,6 +115,7 @@ type ProductServiceServer interface {
 	Find(context.Context, *ProductFindRequest) (*ProductFindResponse, error)
 	FindAll(context.Context, *ProductFindAllRequest) (*ProductFindAllResponse, error)
 	Update(context.Context, *ProductUpdateRequest) (*ProductUpdateResponse, error)
+	DecreaseQuantity(context.Context, *ProductDecreaseQuantityRequest) (*ProductDecreaseQuantityResponse, error)
 	Delete(context.Context, *ProductDeleteRequest) (*ProductDeleteResponse, error)
 	mustEmbedUnimplementedProductServiceServer()
 }
@@ -126,6 +139,9 @@ func (UnimplementedProductServiceServer) FindAll(context.Context, *ProductFindAl
 func (UnimplementedProductServiceServer) Update(context.Context, *ProductUpdateRequest) (*ProductUpdateResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method Update not implemented")
 }
+func (UnimplementedProductServiceServer) DecreaseQuantity(context.Context, *ProductDecreaseQuantityRequest) (*ProductDecreaseQuantityResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method DecreaseQuantity not implemented")
+}
 func (UnimplementedProductServiceServer) Delete(context.Context, *ProductDeleteRequest) (*ProductDeleteResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method Delete not implemented")
 }
@@ -222,6 +238,24 @@ func _ProductService_Update_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ProductService_DecreaseQuantity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProductDecreaseQuantityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductServiceServer).DecreaseQuantity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductService_DecreaseQuantity_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductServiceServer).DecreaseQuantity(ctx, req.(*ProductDecreaseQuantityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _ProductService_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ProductDeleteRequest)
 	if err := dec(in); err != nil {
@@ -263,6 +297,10 @@ var ProductService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Update",
 			Handler:    _ProductService_Update_Handler,
 		},
+		{
+			MethodName: "DecreaseQuantity",
+			Handler:    _ProductService_DecreaseQuantity_Handler,
+		},
 		{
 			MethodName: "Delete",
 			Handler:    _ProductService_Delete_Handler,
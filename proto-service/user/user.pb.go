@@ -1638,6 +1638,147 @@ func (x *CheckIsPremiumResponse) GetExpiresAt() *timestamppb.Timestamp {
 	return nil
 }
 
+// ShortenSubscriptionRequest takes days off the current subscription of the
+// user, e.g. when its order is partially refunded. days must be positive; the
+// expiry moves back by that much and never past now, which ends the
+// subscription. It never extends or creates a subscription. reference
+// identifies the request, so a retried call shortens once.
+type ShortenSubscriptionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Days          int64                  `protobuf:"varint,2,opt,name=days,proto3" json:"days,omitempty"`
+	Reference     string                 `protobuf:"bytes,3,opt,name=reference,proto3" json:"reference,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShortenSubscriptionRequest) Reset() {
+	*x = ShortenSubscriptionRequest{}
+	mi := &file_user_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShortenSubscriptionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShortenSubscriptionRequest) ProtoMessage() {}
+
+func (x *ShortenSubscriptionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_user_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShortenSubscriptionRequest.ProtoReflect.Descriptor instead.
+func (*ShortenSubscriptionRequest) Descriptor() ([]byte, []int) {
+	return file_user_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ShortenSubscriptionRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ShortenSubscriptionRequest) GetDays() int64 {
+	if x != nil {
+		return x.Days
+	}
+	return 0
+}
+
+func (x *ShortenSubscriptionRequest) GetReference() string {
+	if x != nil {
+		return x.Reference
+	}
+	return ""
+}
+
+type ShortenSubscriptionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShortenSubscriptionResponse) Reset() {
+	*x = ShortenSubscriptionResponse{}
+	mi := &file_user_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShortenSubscriptionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShortenSubscriptionResponse) ProtoMessage() {}
+
+func (x *ShortenSubscriptionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_user_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShortenSubscriptionResponse.ProtoReflect.Descriptor instead.
+func (*ShortenSubscriptionResponse) Descriptor() ([]byte, []int) {
+	return file_user_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *ShortenSubscriptionResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ShortenSubscriptionResponse) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ShortenSubscriptionResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ShortenSubscriptionResponse) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *ShortenSubscriptionResponse) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
 type CancelSubscriptionRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
@@ -1647,7 +1788,7 @@ type CancelSubscriptionRequest struct {
 
 func (x *CancelSubscriptionRequest) Reset() {
 	*x = CancelSubscriptionRequest{}
-	mi := &file_user_proto_msgTypes[30]
+	mi := &file_user_proto_msgTypes[32]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1659,7 +1800,7 @@ func (x *CancelSubscriptionRequest) String() string {
 func (*CancelSubscriptionRequest) ProtoMessage() {}
 
 func (x *CancelSubscriptionRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_user_proto_msgTypes[30]
+	mi := &file_user_proto_msgTypes[32]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1672,7 +1813,7 @@ func (x *CancelSubscriptionRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CancelSubscriptionRequest.ProtoReflect.Descriptor instead.
 func (*CancelSubscriptionRequest) Descriptor() ([]byte, []int) {
-	return file_user_proto_rawDescGZIP(), []int{30}
+	return file_user_proto_rawDescGZIP(), []int{32}
 }
 
 func (x *CancelSubscriptionRequest) GetUserId() string {
@@ -1694,7 +1835,7 @@ type CancelSubscriptionResponse struct {
 
 func (x *CancelSubscriptionResponse) Reset() {
 	*x = CancelSubscriptionResponse{}
-	mi := &file_user_proto_msgTypes[31]
+	mi := &file_user_proto_msgTypes[33]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1706,7 +1847,7 @@ func (x *CancelSubscriptionResponse) String() string {
 func (*CancelSubscriptionResponse) ProtoMessage() {}
 
 func (x *CancelSubscriptionResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_user_proto_msgTypes[31]
+	mi := &file_user_proto_msgTypes[33]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1719,7 +1860,7 @@ func (x *CancelSubscriptionResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CancelSubscriptionResponse.ProtoReflect.Descriptor instead.
 func (*CancelSubscriptionResponse) Descriptor() ([]byte, []int) {
-	return file_user_proto_rawDescGZIP(), []int{31}
+	return file_user_proto_rawDescGZIP(), []int{33}
 }
 
 func (x *CancelSubscriptionResponse) GetSubscriptionId() string {
@@ -1860,7 +2001,19 @@ const file_user_proto_rawDesc = "" +
 	"\x16CheckIsPremiumResponse\x12\x16\n" +
 	"\x06active\x18\x01 \x01(\bR\x06active\x129\n" +
 	"\n" +
-	"expires_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\"4\n" +
+	"expires_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\"g\n" +
+	"\x1aShortenSubscriptionRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x12\n" +
+	"\x04days\x18\x02 \x01(\x03R\x04days\x12\x1c\n" +
+	"\treference\x18\x03 \x01(\tR\treference\"\xd4\x01\n" +
+	"\x1bShortenSubscriptionResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x129\n" +
+	"\n" +
+	"expires_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x129\n" +
+	"\n" +
+	"updated_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"4\n" +
 	"\x19CancelSubscriptionRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\"\xb1\x01\n" +
 	"\x1aCancelSubscriptionResponse\x12'\n" +
@@ -1881,10 +2034,11 @@ const file_user_proto_rawDesc = "" +
 	"\x06Delete\x12\x0e.DeleteRequest\x1a\x0f.DeleteResponse\x12>\n" +
 	"\rValidateToken\x12\x15.ValidateTokenRequest\x1a\x16.ValidateTokenResponse\x12>\n" +
 	"\rRefreshTokens\x12\x15.RefreshTokensRequest\x1a\x16.RefreshTokensResponse\x12>\n" +
-	"\rGetStatsConns\x12\x15.GetStatsConnsRequest\x1a\x16.GetStatsConnsResponse2\x82\x02\n" +
+	"\rGetStatsConns\x12\x15.GetStatsConnsRequest\x1a\x16.GetStatsConnsResponse2\xd4\x02\n" +
 	"\x13SubscriptionService\x12Y\n" +
 	"\x0eCreateOrExtend\x12\".CreateOrExtendSubscriptionRequest\x1a#.CreateOrExtendSubscriptionResponse\x12M\n" +
-	"\x12CancelSubscription\x12\x1a.CancelSubscriptionRequest\x1a\x1b.CancelSubscriptionResponse\x12A\n" +
+	"\x12CancelSubscription\x12\x1a.CancelSubscriptionRequest\x1a\x1b.CancelSubscriptionResponse\x12P\n" +
+	"\x13ShortenSubscription\x12\x1b.ShortenSubscriptionRequest\x1a\x1c.ShortenSubscriptionResponse\x12A\n" +
 	"\x0eCheckIsPremium\x12\x16.CheckIsPremiumRequest\x1a\x17.CheckIsPremiumResponseB!Z\x1fgithub.com/aclgo/grpc-jwt/protob\x06proto3"
 
 var (
@@ -1899,7 +2053,7 @@ func file_user_proto_rawDescGZIP() []byte {
 	return file_user_proto_rawDescData
 }
 
-var file_user_proto_msgTypes = make([]protoimpl.MessageInfo, 32)
+var file_user_proto_msgTypes = make([]protoimpl.MessageInfo, 34)
 var file_user_proto_goTypes = []any{
 	(*User)(nil),                               // 0: User
 	(*CreateUserRequest)(nil),                  // 1: CreateUserRequest
@@ -1931,13 +2085,15 @@ var file_user_proto_goTypes = []any{
 	(*CreateOrExtendSubscriptionResponse)(nil), // 27: CreateOrExtendSubscriptionResponse
 	(*CheckIsPremiumRequest)(nil),              // 28: CheckIsPremiumRequest
 	(*CheckIsPremiumResponse)(nil),             // 29: CheckIsPremiumResponse
-	(*CancelSubscriptionRequest)(nil),          // 30: CancelSubscriptionRequest
-	(*CancelSubscriptionResponse)(nil),         // 31: CancelSubscriptionResponse
-	(*timestamppb.Timestamp)(nil),              // 32: google.protobuf.Timestamp
+	(*ShortenSubscriptionRequest)(nil),         // 30: ShortenSubscriptionRequest
+	(*ShortenSubscriptionResponse)(nil),        // 31: ShortenSubscriptionResponse
+	(*CancelSubscriptionRequest)(nil),          // 32: CancelSubscriptionRequest
+	(*CancelSubscriptionResponse)(nil),         // 33: CancelSubscriptionResponse
+	(*timestamppb.Timestamp)(nil),              // 34: google.protobuf.Timestamp
 }
 var file_user_proto_depIdxs = []int32{
-	32, // 0: User.created_at:type_name -> google.protobuf.Timestamp
-	32, // 1: User.updated_at:type_name -> google.protobuf.Timestamp
+	34, // 0: User.created_at:type_name -> google.protobuf.Timestamp
+	34, // 1: User.updated_at:type_name -> google.protobuf.Timestamp
 	0,  // 2: CreatedUserResponse.user:type_name -> User
 	4,  // 3: UserLoginResponse.tokens:type_name -> Tokens
 	4,  // 4: UserLoginNoPassResponse.tokens:type_name -> Tokens
@@ -1945,47 +2101,51 @@ var file_user_proto_depIdxs = []int32{
 	0,  // 6: FindByIdResponse.user:type_name -> User
 	0,  // 7: FindByEmailResponse.user:type_name -> User
 	0,  // 8: UpdateResponse.user:type_name -> User
-	32, // 9: CreateOrExtendSubscriptionResponse.starts_at:type_name -> google.protobuf.Timestamp
-	32, // 10: CreateOrExtendSubscriptionResponse.expires_at:type_name -> google.protobuf.Timestamp
-	32, // 11: CreateOrExtendSubscriptionResponse.created_at:type_name -> google.protobuf.Timestamp
-	32, // 12: CreateOrExtendSubscriptionResponse.updated_at:type_name -> google.protobuf.Timestamp
-	32, // 13: CheckIsPremiumResponse.expires_at:type_name -> google.protobuf.Timestamp
-	32, // 14: CancelSubscriptionResponse.updated_at:type_name -> google.protobuf.Timestamp
-	1,  // 15: UserService.Register:input_type -> CreateUserRequest
-	3,  // 16: UserService.Login:input_type -> UserLoginRequest
-	8,  // 17: UserService.LoginNoPass:input_type -> UserLoginNoPassRequest
-	6,  // 18: UserService.Logout:input_type -> UserLogoutRequest
-	10, // 19: UserService.FindAll:input_type -> FindAllRequest
-	12, // 20: UserService.FindById:input_type -> FindByIdRequest
-	14, // 21: UserService.FindByEmail:input_type -> FindByEmailRequest
-	16, // 22: UserService.Update:input_type -> UpdateRequest
-	18, // 23: UserService.Delete:input_type -> DeleteRequest
-	20, // 24: UserService.ValidateToken:input_type -> ValidateTokenRequest
-	22, // 25: UserService.RefreshTokens:input_type -> RefreshTokensRequest
-	24, // 26: UserService.GetStatsConns:input_type -> GetStatsConnsRequest
-	26, // 27: SubscriptionService.CreateOrExtend:input_type -> CreateOrExtendSubscriptionRequest
-	30, // 28: SubscriptionService.CancelSubscription:input_type -> CancelSubscriptionRequest
-	28, // 29: SubscriptionService.CheckIsPremium:input_type -> CheckIsPremiumRequest
-	2,  // 30: UserService.Register:output_type -> CreatedUserResponse
-	5,  // 31: UserService.Login:output_type -> UserLoginResponse
-	9,  // 32: UserService.LoginNoPass:output_type -> UserLoginNoPassResponse
-	7,  // 33: UserService.Logout:output_type -> UserLogoutResponse
-	11, // 34: UserService.FindAll:output_type -> FindAllResponse
-	13, // 35: UserService.FindById:output_type -> FindByIdResponse
-	15, // 36: UserService.FindByEmail:output_type -> FindByEmailResponse
-	17, // 37: UserService.Update:output_type -> UpdateResponse
-	19, // 38: UserService.Delete:output_type -> DeleteResponse
-	21, // 39: UserService.ValidateToken:output_type -> ValidateTokenResponse
-	23, // 40: UserService.RefreshTokens:output_type -> RefreshTokensResponse
-	25, // 41: UserService.GetStatsConns:output_type -> GetStatsConnsResponse
-	27, // 42: SubscriptionService.CreateOrExtend:output_type -> CreateOrExtendSubscriptionResponse
-	31, // 43: SubscriptionService.CancelSubscription:output_type -> CancelSubscriptionResponse
-	29, // 44: SubscriptionService.CheckIsPremium:output_type -> CheckIsPremiumResponse
-	30, // [30:45] is the sub-list for method output_type
-	15, // [15:30] is the sub-list for method input_type
-	15, // [15:15] is the sub-list for extension type_name
-	15, // [15:15] is the sub-list for extension extendee
-	0,  // [0:15] is the sub-list for field type_name
+	34, // 9: CreateOrExtendSubscriptionResponse.starts_at:type_name -> google.protobuf.Timestamp
+	34, // 10: CreateOrExtendSubscriptionResponse.expires_at:type_name -> google.protobuf.Timestamp
+	34, // 11: CreateOrExtendSubscriptionResponse.created_at:type_name -> google.protobuf.Timestamp
+	34, // 12: CreateOrExtendSubscriptionResponse.updated_at:type_name -> google.protobuf.Timestamp
+	34, // 13: CheckIsPremiumResponse.expires_at:type_name -> google.protobuf.Timestamp
+	34, // 14: ShortenSubscriptionResponse.expires_at:type_name -> google.protobuf.Timestamp
+	34, // 15: ShortenSubscriptionResponse.updated_at:type_name -> google.protobuf.Timestamp
+	34, // 16: CancelSubscriptionResponse.updated_at:type_name -> google.protobuf.Timestamp
+	1,  // 17: UserService.Register:input_type -> CreateUserRequest
+	3,  // 18: UserService.Login:input_type -> UserLoginRequest
+	8,  // 19: UserService.LoginNoPass:input_type -> UserLoginNoPassRequest
+	6,  // 20: UserService.Logout:input_type -> UserLogoutRequest
+	10, // 21: UserService.FindAll:input_type -> FindAllRequest
+	12, // 22: UserService.FindById:input_type -> FindByIdRequest
+	14, // 23: UserService.FindByEmail:input_type -> FindByEmailRequest
+	16, // 24: UserService.Update:input_type -> UpdateRequest
+	18, // 25: UserService.Delete:input_type -> DeleteRequest
+	20, // 26: UserService.ValidateToken:input_type -> ValidateTokenRequest
+	22, // 27: UserService.RefreshTokens:input_type -> RefreshTokensRequest
+	24, // 28: UserService.GetStatsConns:input_type -> GetStatsConnsRequest
+	26, // 29: SubscriptionService.CreateOrExtend:input_type -> CreateOrExtendSubscriptionRequest
+	32, // 30: SubscriptionService.CancelSubscription:input_type -> CancelSubscriptionRequest
+	30, // 31: SubscriptionService.ShortenSubscription:input_type -> ShortenSubscriptionRequest
+	28, // 32: SubscriptionService.CheckIsPremium:input_type -> CheckIsPremiumRequest
+	2,  // 33: UserService.Register:output_type -> CreatedUserResponse
+	5,  // 34: UserService.Login:output_type -> UserLoginResponse
+	9,  // 35: UserService.LoginNoPass:output_type -> UserLoginNoPassResponse
+	7,  // 36: UserService.Logout:output_type -> UserLogoutResponse
+	11, // 37: UserService.FindAll:output_type -> FindAllResponse
+	13, // 38: UserService.FindById:output_type -> FindByIdResponse
+	15, // 39: UserService.FindByEmail:output_type -> FindByEmailResponse
+	17, // 40: UserService.Update:output_type -> UpdateResponse
+	19, // 41: UserService.Delete:output_type -> DeleteResponse
+	21, // 42: UserService.ValidateToken:output_type -> ValidateTokenResponse
+	23, // 43: UserService.RefreshTokens:output_type -> RefreshTokensResponse
+	25, // 44: UserService.GetStatsConns:output_type -> GetStatsConnsResponse
+	27, // 45: SubscriptionService.CreateOrExtend:output_type -> CreateOrExtendSubscriptionResponse
+	33, // 46: SubscriptionService.CancelSubscription:output_type -> CancelSubscriptionResponse
+	31, // 47: SubscriptionService.ShortenSubscription:output_type -> ShortenSubscriptionResponse
+	29, // 48: SubscriptionService.CheckIsPremium:output_type -> CheckIsPremiumResponse
+	33, // [33:49] is the sub-list for method output_type
+	17, // [17:33] is the sub-list for method input_type
+	17, // [17:17] is the sub-list for extension type_name
+	17, // [17:17] is the sub-list for extension extendee
+	0,  // [0:17] is the sub-list for field type_name
 }
 
 func init() { file_user_proto_init() }
@@ -1999,7 +2159,7 @@ func file_user_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_user_proto_rawDesc), len(file_user_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   32,
+			NumMessages:   34,
 			NumExtensions: 0,
 			NumServices:   2,
 		},
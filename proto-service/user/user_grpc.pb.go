@@ -539,9 +539,10 @@ var UserService_ServiceDesc = grpc.ServiceDesc{
 }
 
 const (
-	SubscriptionService_CreateOrExtend_FullMethodName     = "/SubscriptionService/CreateOrExtend"
-	SubscriptionService_CancelSubscription_FullMethodName = "/SubscriptionService/CancelSubscription"
-	SubscriptionService_CheckIsPremium_FullMethodName     = "/SubscriptionService/CheckIsPremium"
+	SubscriptionService_CreateOrExtend_FullMethodName      = "/SubscriptionService/CreateOrExtend"
+	SubscriptionService_CancelSubscription_FullMethodName  = "/SubscriptionService/CancelSubscription"
+	SubscriptionService_ShortenSubscription_FullMethodName = "/SubscriptionService/ShortenSubscription"
+	SubscriptionService_CheckIsPremium_FullMethodName      = "/SubscriptionService/CheckIsPremium"
 )
 
 // SubscriptionServiceClient is the client API for SubscriptionService service.
@@ -550,6 +551,7 @@ const (
 type SubscriptionServiceClient interface {
 	CreateOrExtend(ctx context.Context, in *CreateOrExtendSubscriptionRequest, opts ...grpc.CallOption) (*CreateOrExtendSubscriptionResponse, error)
 	CancelSubscription(ctx context.Context, in *CancelSubscriptionRequest, opts ...grpc.CallOption) (*CancelSubscriptionResponse, error)
+	ShortenSubscription(ctx context.Context, in *ShortenSubscriptionRequest, opts ...grpc.CallOption) (*ShortenSubscriptionResponse, error)
 	CheckIsPremium(ctx context.Context, in *CheckIsPremiumRequest, opts ...grpc.CallOption) (*CheckIsPremiumResponse, error)
 }
 
@@ -581,6 +583,16 @@ func (c *subscriptionServiceClient) CancelSubscription(ctx context.Context, in *
 	return out, nil
 }
 
+func (c *subscriptionServiceClient) ShortenSubscription(ctx context.Context, in *ShortenSubscriptionRequest, opts ...grpc.CallOption) (*ShortenSubscriptionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ShortenSubscriptionResponse)
+	err := c.cc.Invoke(ctx, SubscriptionService_ShortenSubscription_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *subscriptionServiceClient) CheckIsPremium(ctx context.Context, in *CheckIsPremiumRequest, opts ...grpc.CallOption) (*CheckIsPremiumResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(CheckIsPremiumResponse)
@@ -597,6 +609,7 @@ func (c *subscriptionServiceClient) CheckIsPremium(ctx context.Context, in *Chec
 type SubscriptionServiceServer interface {
 	CreateOrExtend(context.Context, *CreateOrExtendSubscriptionRequest) (*CreateOrExtendSubscriptionResponse, error)
 	CancelSubscription(context.Context, *CancelSubscriptionRequest) (*CancelSubscriptionResponse, error)
+	ShortenSubscription(context.Context, *ShortenSubscriptionRequest) (*ShortenSubscriptionResponse, error)
 	CheckIsPremium(context.Context, *CheckIsPremiumRequest) (*CheckIsPremiumResponse, error)
 	mustEmbedUnimplementedSubscriptionServiceServer()
 }
@@ -614,6 +627,9 @@ func (UnimplementedSubscriptionServiceServer) CreateOrExtend(context.Context, *C
 func (UnimplementedSubscriptionServiceServer) CancelSubscription(context.Context, *CancelSubscriptionRequest) (*CancelSubscriptionResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method CancelSubscription not implemented")
 }
+func (UnimplementedSubscriptionServiceServer) ShortenSubscription(context.Context, *ShortenSubscriptionRequest) (*ShortenSubscriptionResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ShortenSubscription not implemented")
+}
 func (UnimplementedSubscriptionServiceServer) CheckIsPremium(context.Context, *CheckIsPremiumRequest) (*CheckIsPremiumResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method CheckIsPremium not implemented")
 }
@@ -674,6 +690,24 @@ func _SubscriptionService_CancelSubscription_Handler(srv interface{}, ctx contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _SubscriptionService_ShortenSubscription_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShortenSubscriptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SubscriptionServiceServer).ShortenSubscription(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SubscriptionService_ShortenSubscription_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SubscriptionServiceServer).ShortenSubscription(ctx, req.(*ShortenSubscriptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _SubscriptionService_CheckIsPremium_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(CheckIsPremiumRequest)
 	if err := dec(in); err != nil {
@@ -707,6 +741,10 @@ var SubscriptionService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CancelSubscription",
 			Handler:    _SubscriptionService_CancelSubscription_Handler,
 		},
+		{
+			MethodName: "ShortenSubscription",
+			Handler:    _SubscriptionService_ShortenSubscription_Handler,
+		},
 		{
 			MethodName: "CheckIsPremium",
 			Handler:    _SubscriptionService_CheckIsPremium_Handler,